@@ -3,10 +3,15 @@ package main
 import (
 	"context"
 	"log"
+	"net"
 
 	"github.com/shenikar/subscription-service/internal/config"
 	"github.com/shenikar/subscription-service/internal/db"
+	"github.com/shenikar/subscription-service/internal/event"
+	"github.com/shenikar/subscription-service/internal/gql"
+	"github.com/shenikar/subscription-service/internal/grpcserver"
 	"github.com/shenikar/subscription-service/internal/handler"
+	"github.com/shenikar/subscription-service/internal/middleware"
 	"github.com/shenikar/subscription-service/internal/repository"
 	"github.com/shenikar/subscription-service/internal/router"
 	"github.com/shenikar/subscription-service/internal/service"
@@ -24,16 +29,52 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to connect db: %v", err)
 	}
-	defer conn.Close(context.Background())
+	defer conn.Close()
+
+	broker := event.NewBroker()
+
+	catalog := service.NewCatalogService(repository.NewServiceRepository(conn), cfg.ServiceAutoCreate)
+	serviceHandler := handler.NewServiceHandler(catalog)
 
 	repo := repository.NewSubscriptionRepository(conn)
-	svc := service.NewSubscriptionService(repo)
-	handl := handler.NewSubscriptionHandler(svc)
+	users := service.NewUserService(repository.NewUserRepository(conn), repo, cfg.UserAutoRegister, cfg.TimeZone)
+	budgets := service.NewBudgetService(repository.NewBudgetRepository(conn), repo, users, broker)
+	svc := service.NewSubscriptionService(repo, catalog, users, budgets, broker, cfg.OverlapMode)
+	handl := handler.NewSubscriptionHandler(svc, cfg)
+	userHandler := handler.NewUserHandler(users, svc)
+	budgetHandler := handler.NewBudgetHandler(budgets)
+	tagHandler := handler.NewTagHandler(service.NewTagService(repository.NewTagRepository(conn), repo, broker))
+	organisationHandler := handler.NewOrganisationHandler(service.NewOrganisationService(repository.NewOrganisationRepository(conn), users))
+	reportHandler := handler.NewReportHandler(service.NewReportService(repository.NewReportRepository(conn), repo, catalog, users))
+
+	executor, err := gql.NewExecutor(svc, cfg.GraphQLMaxDepth, cfg.GraphQLMaxComplexity)
+	if err != nil {
+		log.Fatalf("failed to build graphql schema: %v", err)
+	}
+	gqlHandler := handler.NewGraphQLHandler(executor)
 
-	router := router.SetupRouter(handl)
+	idempotencyRepo := repository.NewIdempotencyRepository(conn)
+	idempotency := middleware.Idempotency(idempotencyRepo, cfg.IdempotencyTTL)
+
+	router := router.SetupRouter(handl, serviceHandler, userHandler, reportHandler, budgetHandler, tagHandler, organisationHandler, gqlHandler, idempotency)
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	trials := service.NewTrialNotifier(repo, users, broker, cfg.TrialEndingDays, cfg.TrialCheckInterval)
+	go trials.Run(context.Background())
+
+	grpcServer := grpcserver.New(svc, broker)
+	lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		log.Fatalf("failed to listen grpc port: %v", err)
+	}
+	go func() {
+		log.Printf("grpc server is running at %s", lis.Addr())
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("failed to start grpc server: %v", err)
+		}
+	}()
+
 	addr := ":" + cfg.ServerPort
 	log.Printf("server is running at %s", addr)
 	if err := router.Run(addr); err != nil {
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"os"
+
+	"github.com/shenikar/subscription-service/internal/subctl"
+)
+
+func main() {
+	os.Exit(subctl.Execute())
+}
@@ -3,8 +3,25 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/shenikar/subscription-service/internal/dates"
+)
+
+const (
+	defaultGRPCPort       = "9090"
+	defaultBatchMaxSize   = 1000
+	defaultIdempotencyTTL = 24 * time.Hour
+
+	defaultGraphQLMaxDepth      = 8
+	defaultGraphQLMaxComplexity = 1000
+
+	defaultOverlapMode = "warn"
+
+	defaultTrialEndingDays    = 3
+	defaultTrialCheckInterval = time.Hour
 )
 
 type Config struct {
@@ -16,6 +33,32 @@ type Config struct {
 	DBSSLMode  string
 
 	ServerPort string
+	GRPCPort   string
+
+	BatchMaxSize   int
+	IdempotencyTTL time.Duration
+	RequireIfMatch bool
+
+	GraphQLMaxDepth      int
+	GraphQLMaxComplexity int
+
+	// ServiceAutoCreate добавляет в каталог сервисы с неизвестными названиями
+	// при создании подписок, иначе такие подписки отклоняются.
+	ServiceAutoCreate bool
+	// UserAutoRegister регистрирует пользователей с неизвестными user_id при создании
+	// подписок — режим для клиентов, которые ещё не заводят пользователей через /users.
+	UserAutoRegister bool
+	// OverlapMode — как обрабатывать подписку, период которой пересекается с другой
+	// подпиской того же пользователя на тот же сервис: reject, warn или allow.
+	OverlapMode string
+	// TimeZone — часовой пояс для пользователей, у которых он не задан: в нём
+	// определяются текущий месяц и месяц моментов времени RFC 3339.
+	TimeZone *time.Location
+	// TrialEndingDays — за сколько дней до окончания пробного периода отправлять
+	// событие subscription.trial_ending, 0 — в день перехода подписки в платную;
+	// TrialCheckInterval — как часто проверять.
+	TrialEndingDays    int
+	TrialCheckInterval time.Duration
 }
 
 func LoadConfig() Config {
@@ -32,5 +75,108 @@ func LoadConfig() Config {
 		DBSSLMode:  os.Getenv("DB_SSLMODE"),
 
 		ServerPort: os.Getenv("SERVER_PORT"),
+		GRPCPort:   getEnv("GRPC_PORT", defaultGRPCPort),
+
+		BatchMaxSize:   getEnvInt("BATCH_MAX_SIZE", defaultBatchMaxSize),
+		IdempotencyTTL: getEnvDuration("IDEMPOTENCY_TTL", defaultIdempotencyTTL),
+		RequireIfMatch: getEnvBool("REQUIRE_IF_MATCH", false),
+
+		GraphQLMaxDepth:      getEnvInt("GRAPHQL_MAX_DEPTH", defaultGraphQLMaxDepth),
+		GraphQLMaxComplexity: getEnvInt("GRAPHQL_MAX_COMPLEXITY", defaultGraphQLMaxComplexity),
+
+		ServiceAutoCreate: getEnvBool("SERVICE_AUTO_CREATE", true),
+		UserAutoRegister:  getEnvBool("USER_AUTO_REGISTER", false),
+		OverlapMode:       getEnvOneOf("OVERLAP_MODE", defaultOverlapMode, "reject", "warn", "allow"),
+		TimeZone:          getEnvLocation("TIME_ZONE", time.UTC),
+
+		TrialEndingDays:    getEnvNonNegativeInt("TRIAL_ENDING_DAYS", defaultTrialEndingDays),
+		TrialCheckInterval: getEnvDuration("TRIAL_CHECK_INTERVAL", defaultTrialCheckInterval),
+	}
+}
+
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func getEnvInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		log.Printf("invalid %s=%q, using default %d", key, v, def)
+		return def
+	}
+	return n
+}
+
+// getEnvNonNegativeInt разбирает целое число, для которого 0 — допустимое значение.
+func getEnvNonNegativeInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Printf("invalid %s=%q, must be a non-negative integer, using default %d", key, v, def)
+		return def
+	}
+	return n
+}
+
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("invalid %s=%q, using default %s", key, v, def)
+		return def
+	}
+	return d
+}
+
+func getEnvBool(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("invalid %s=%q, using default %t", key, v, def)
+		return def
+	}
+	return b
+}
+
+func getEnvOneOf(key, def string, allowed ...string) string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+	log.Printf("invalid %s=%q, using default %s", key, v, def)
+	return def
+}
+
+func getEnvLocation(key string, def *time.Location) *time.Location {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	loc, err := dates.LoadLocation(v)
+	if err != nil {
+		log.Printf("invalid %s=%q, using default %s", key, v, def)
+		return def
 	}
+	return loc
 }
@@ -0,0 +1,25 @@
+package config
+
+import "testing"
+
+func TestGetEnvNonNegativeInt(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  int
+	}{
+		{name: "unset", value: "", want: 3},
+		{name: "zero", value: "0", want: 0},
+		{name: "positive", value: "7", want: 7},
+		{name: "negative", value: "-1", want: 3},
+		{name: "not a number", value: "soon", want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRIAL_ENDING_DAYS", tt.value)
+			if got := getEnvNonNegativeInt("TRIAL_ENDING_DAYS", 3); got != tt.want {
+				t.Errorf("getEnvNonNegativeInt(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Package dates разбирает и форматирует даты подписок. Подписки оплачиваются помесячно,
+// поэтому даты начала и окончания хранятся первым числом месяца.
+package dates
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Форматы дат в ответах API. Формат выбирается параметром date_format или заголовком
+// X-Date-Format, по умолчанию — MonthYear.
+const (
+	MonthYear = "MM-YYYY"
+	YearMonth = "YYYY-MM"
+	Date      = "YYYY-MM-DD"
+	RFC3339   = "RFC3339"
+
+	Default = MonthYear
+)
+
+var layouts = map[string]string{
+	MonthYear: "01-2006",
+	YearMonth: "2006-01",
+	Date:      "2006-01-02",
+	RFC3339:   time.RFC3339,
+}
+
+// inputLayouts перечисляет принимаемые на входе форматы. DD-MM-YYYY оставлен для
+// совместимости с прежними параметрами from_date и to_date в /subscriptions/total.
+var inputLayouts = []string{"01-2006", "2006-01", "2006-01-02", time.RFC3339, "02-01-2006"}
+
+var ErrInvalidDate = errors.New("invalid date, expected MM-YYYY, YYYY-MM, YYYY-MM-DD or RFC 3339")
+
+// Parse разбирает дату в любом из принимаемых форматов. Время суток отбрасывается:
+// результат — полночь UTC того календарного дня, который указан в строке.
+func Parse(s string) (time.Time, error) {
+	for _, layout := range inputLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidDate, s)
+}
+
+// ParseMonth разбирает дату и возвращает первое число её месяца.
+func ParseMonth(s string) (time.Time, error) {
+	t, err := Parse(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+// Format форматирует дату в формате format; неизвестный формат заменяется Default.
+func Format(t time.Time, format string) string {
+	layout, ok := layouts[format]
+	if !ok {
+		layout = layouts[Default]
+	}
+	return t.Format(layout)
+}
+
+// FormatMonth форматирует месяц в формате MM-YYYY, которым отчёты обозначают месяцы.
+func FormatMonth(t time.Time) string {
+	return t.Format(layouts[MonthYear])
+}
+
+// ParseFormat возвращает формат ответа по названию без учёта регистра. Пустое название
+// означает Default.
+func ParseFormat(name string) (string, error) {
+	if name == "" {
+		return Default, nil
+	}
+	for format := range layouts {
+		if strings.EqualFold(name, format) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown date format %q, expected %s, %s, %s or %s", name, MonthYear, YearMonth, Date, RFC3339)
+}
@@ -0,0 +1,99 @@
+package dates
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{in: "07-2025", want: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{in: "2025-07", want: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{in: "2025-07-15", want: time.Date(2025, time.July, 15, 0, 0, 0, 0, time.UTC)},
+		{in: "15-07-2025", want: time.Date(2025, time.July, 15, 0, 0, 0, 0, time.UTC)},
+		// Время суток и часовой пояс отбрасываются, календарный день сохраняется.
+		{in: "2025-07-31T23:30:00+03:00", want: time.Date(2025, time.July, 31, 0, 0, 0, 0, time.UTC)},
+		{in: "2025-07-01T00:30:00-05:00", want: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.in, err)
+			}
+			if !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("Parse(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, in := range []string{"", "13-2025", "2025-13", "2025-02-30", "07/2025", "July 2025", "2025"} {
+		t.Run(in, func(t *testing.T) {
+			if got, err := Parse(in); !errors.Is(err, ErrInvalidDate) {
+				t.Errorf("Parse(%q) = %v, %v; want ErrInvalidDate", in, got, err)
+			}
+		})
+	}
+}
+
+func TestParseMonth(t *testing.T) {
+	want := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	for _, in := range []string{"07-2025", "2025-07", "2025-07-15", "2025-07-31T23:30:00Z"} {
+		if got, err := ParseMonth(in); err != nil || !got.Equal(want) {
+			t.Errorf("ParseMonth(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseMonth("2025-13"); !errors.Is(err, ErrInvalidDate) {
+		t.Errorf("ParseMonth(2025-13) error = %v, want ErrInvalidDate", err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	day := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: MonthYear, want: "07-2025"},
+		{format: YearMonth, want: "2025-07"},
+		{format: Date, want: "2025-07-01"},
+		{format: RFC3339, want: "2025-07-01T00:00:00Z"},
+		{format: "unknown", want: "07-2025"},
+	}
+	for _, tt := range tests {
+		if got := Format(day, tt.format); got != tt.want {
+			t.Errorf("Format(%s) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+	if got := FormatMonth(day); got != "07-2025" {
+		t.Errorf("FormatMonth() = %q, want 07-2025", got)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "", want: Default},
+		{name: "MM-YYYY", want: MonthYear},
+		{name: "yyyy-mm", want: YearMonth},
+		{name: "yyyy-mm-dd", want: Date},
+		{name: "rfc3339", want: RFC3339},
+		{name: "DD-MM-YYYY", wantErr: true},
+		{name: "iso", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package dates
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	// База часовых поясов встраивается в бинарник: в контейнере её может не быть.
+	_ "time/tzdata"
+)
+
+// Месяц подписки — календарная величина, но «сейчас» и моменты времени в формате
+// RFC 3339 переводятся в календарные даты в часовом поясе пользователя. Иначе у
+// пользователя в UTC+10 подписка, оформленная первого числа утром, начиналась бы
+// в предыдущем месяце.
+
+type locationKey struct{}
+
+// WithLocation сохраняет в контексте часовой пояс, явно заданный в запросе.
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+// LocationFrom возвращает часовой пояс, явно заданный в запросе.
+func LocationFrom(ctx context.Context) (*time.Location, bool) {
+	loc, ok := ctx.Value(locationKey{}).(*time.Location)
+	return loc, ok && loc != nil
+}
+
+// LoadLocation загружает часовой пояс по имени IANA, например Europe/Moscow.
+// Local не принимается: результат не должен зависеть от настроек сервера.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || strings.EqualFold(name, "Local") {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// ParseIn разбирает дату как Parse, но момент времени в формате RFC 3339 сначала
+// переводится в часовой пояс loc. Даты без времени от пояса не зависят.
+func ParseIn(s string, loc *time.Location) (time.Time, error) {
+	if loc != nil {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return Day(t, loc), nil
+		}
+	}
+	return Parse(s)
+}
+
+// ParseMonthIn разбирает дату как ParseIn и возвращает первое число её месяца.
+func ParseMonthIn(s string, loc *time.Location) (time.Time, error) {
+	t, err := ParseIn(s, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+// Day возвращает календарный день момента t в часовом поясе loc как полночь UTC.
+func Day(t time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Month возвращает первое число месяца момента t в часовом поясе loc как полночь UTC.
+func Month(t time.Time, loc *time.Location) time.Time {
+	d := Day(t, loc)
+	return time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Today возвращает текущий календарный день в часовом поясе loc.
+func Today(loc *time.Location) time.Time {
+	return Day(time.Now(), loc)
+}
+
+// CurrentMonth возвращает первое число текущего месяца в часовом поясе loc.
+func CurrentMonth(loc *time.Location) time.Time {
+	return Month(time.Now(), loc)
+}
@@ -0,0 +1,111 @@
+package dates
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Переход на летнее и зимнее время сдвигает полночь относительно UTC, но календарный
+// день по-прежнему определяется часами пользователя.
+func TestDayAcrossDSTTransitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		zone    string
+		instant string
+		want    time.Time
+	}{
+		{name: "Berlin before spring forward", zone: "Europe/Berlin", instant: "2025-03-29T22:59:00Z", want: day(2025, time.March, 29)},
+		{name: "Berlin spring forward midnight", zone: "Europe/Berlin", instant: "2025-03-29T23:00:00Z", want: day(2025, time.March, 30)},
+		{name: "Berlin spring forward last minute", zone: "Europe/Berlin", instant: "2025-03-30T21:59:00Z", want: day(2025, time.March, 30)},
+		{name: "Berlin after spring forward", zone: "Europe/Berlin", instant: "2025-03-30T22:00:00Z", want: day(2025, time.March, 31)},
+		{name: "Berlin fall back midnight", zone: "Europe/Berlin", instant: "2025-10-25T22:00:00Z", want: day(2025, time.October, 26)},
+		{name: "Berlin fall back last minute", zone: "Europe/Berlin", instant: "2025-10-26T22:59:00Z", want: day(2025, time.October, 26)},
+		{name: "Berlin after fall back", zone: "Europe/Berlin", instant: "2025-10-26T23:00:00Z", want: day(2025, time.October, 27)},
+		{name: "New York before spring forward", zone: "America/New_York", instant: "2025-03-09T04:59:00Z", want: day(2025, time.March, 8)},
+		{name: "New York spring forward midnight", zone: "America/New_York", instant: "2025-03-09T05:00:00Z", want: day(2025, time.March, 9)},
+		{name: "New York spring forward last minute", zone: "America/New_York", instant: "2025-03-10T03:59:00Z", want: day(2025, time.March, 9)},
+		{name: "New York after spring forward", zone: "America/New_York", instant: "2025-03-10T04:00:00Z", want: day(2025, time.March, 10)},
+		{name: "New York fall back midnight", zone: "America/New_York", instant: "2025-11-02T04:00:00Z", want: day(2025, time.November, 2)},
+		{name: "New York fall back last minute", zone: "America/New_York", instant: "2025-11-03T04:59:00Z", want: day(2025, time.November, 2)},
+		{name: "New York after fall back", zone: "America/New_York", instant: "2025-11-03T05:00:00Z", want: day(2025, time.November, 3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := mustLoad(t, tt.zone)
+			instant, err := time.Parse(time.RFC3339, tt.instant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Day(instant, loc); !got.Equal(tt.want) {
+				t.Errorf("Day(%s) = %s, want %s", tt.instant, got, tt.want)
+			}
+			got, err := ParseIn(tt.instant, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseIn(%s) = %s, want %s", tt.instant, got, tt.want)
+			}
+		})
+	}
+}
+
+// Момент на стыке лет относится к месяцу, который в этот момент идёт у пользователя.
+func TestParseMonthInAcrossYearBoundary(t *testing.T) {
+	tests := []struct {
+		name  string
+		zone  string
+		input string
+		want  time.Time
+	}{
+		{name: "December in UTC", zone: "UTC", input: "2024-12-31T23:30:00Z", want: day(2024, time.December, 1)},
+		{name: "January in Berlin", zone: "Europe/Berlin", input: "2024-12-31T23:30:00Z", want: day(2025, time.January, 1)},
+		{name: "January in UTC", zone: "UTC", input: "2025-01-01T03:00:00Z", want: day(2025, time.January, 1)},
+		{name: "December in New York", zone: "America/New_York", input: "2025-01-01T03:00:00Z", want: day(2024, time.December, 1)},
+		{name: "offset converted to zone", zone: "America/New_York", input: "2025-01-01T00:30:00+01:00", want: day(2024, time.December, 1)},
+		{name: "date without time ignores zone", zone: "America/New_York", input: "2025-01-01", want: day(2025, time.January, 1)},
+		{name: "month ignores zone", zone: "Europe/Berlin", input: "12-2024", want: day(2024, time.December, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMonthIn(tt.input, mustLoad(t, tt.zone))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseMonthIn(%s) = %s, want %s", tt.input, got, tt.want)
+			}
+			instant, err := time.Parse(time.RFC3339, tt.input)
+			if err != nil {
+				return
+			}
+			if got := Month(instant, mustLoad(t, tt.zone)); !got.Equal(tt.want) {
+				t.Errorf("Month(%s) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// Без пояса момент RFC 3339 относится к дню, указанному в строке.
+func TestParseInWithoutZoneKeepsWrittenDay(t *testing.T) {
+	got, err := ParseIn("2025-01-01T00:30:00+01:00", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := day(2025, time.January, 1); !got.Equal(want) {
+		t.Errorf("ParseIn = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,13 @@
+package dto
+
+import "github.com/shenikar/subscription-service/pkg/api"
+
+type BatchItem struct {
+	Index   int
+	Op      string
+	ID      int64
+	Version *int
+	Create  *api.CreateSubscriptionRequest
+	Update  *api.UpdateSubscriptionRequest
+	Errors  []string
+}
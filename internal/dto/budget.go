@@ -0,0 +1,12 @@
+package dto
+
+type ListBudgetsFilter struct {
+	UserID string `form:"user_id" binding:"omitempty,uuid"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+type BudgetStatusOptions struct {
+	// Month — любая дата месяца, по умолчанию текущий месяц.
+	Month string `form:"month" binding:"omitempty,date"`
+}
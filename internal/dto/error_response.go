@@ -1,5 +0,0 @@
-package dto
-
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
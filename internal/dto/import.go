@@ -0,0 +1,15 @@
+package dto
+
+import "github.com/shenikar/subscription-service/pkg/api"
+
+type ImportOptions struct {
+	DryRun  bool   `form:"dry_run"`
+	Mode    string `form:"mode" binding:"omitempty,oneof=partial all_or_nothing"`
+	Columns string `form:"columns"`
+}
+
+type ImportRow struct {
+	Row     int
+	Request api.CreateSubscriptionRequest
+	Errors  []string
+}
@@ -0,0 +1,14 @@
+package dto
+
+type ListOrganisationsFilter struct {
+	// UserID отбирает организации, в которых пользователь состоит.
+	UserID string `form:"user_id" binding:"omitempty,uuid"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+// OrgSpendingFilter — период отчёта о расходах организации, месяцы включительно.
+type OrgSpendingFilter struct {
+	From string `form:"from" binding:"required,date"`
+	To   string `form:"to" binding:"required,date"`
+}
@@ -0,0 +1,48 @@
+package dto
+
+// SpendingReportFilter — параметры отчёта о расходах. Фильтры совпадают со списком подписок,
+// период задаётся месяцами включительно, в любом формате, который принимает dates.Parse.
+type SpendingReportFilter struct {
+	UserID      string   `form:"user_id" binding:"omitempty,uuid"`
+	ServiceID   int64    `form:"service_id" binding:"omitempty,min=1"`
+	ServiceName string   `form:"service_name"`
+	Category    string   `form:"category" binding:"omitempty,max=100"`
+	Tags        []string `form:"tag" binding:"omitempty,max=20,dive,max=64"`
+	From        string   `form:"from" binding:"required,date"`
+	To          string   `form:"to" binding:"required,date"`
+	GroupBy     string   `form:"group_by" binding:"omitempty,oneof=month service user category tag"`
+}
+
+// ForecastFilter — параметры прогноза расходов на months месяцев вперёд.
+type ForecastFilter struct {
+	Months      int    `form:"months" binding:"omitempty,min=1,max=36"`
+	UserID      string `form:"user_id" binding:"omitempty,uuid"`
+	ServiceID   int64  `form:"service_id" binding:"omitempty,min=1"`
+	ServiceName string `form:"service_name"`
+	// IncludePriceChanges учитывает запланированные изменения цен, по умолчанию true.
+	IncludePriceChanges *bool `form:"include_price_changes"`
+}
+
+// DuplicatesFilter — параметры отчёта о пересекающихся подписках.
+type DuplicatesFilter struct {
+	UserID      string `form:"user_id" binding:"omitempty,uuid"`
+	ServiceID   int64  `form:"service_id" binding:"omitempty,min=1"`
+	ServiceName string `form:"service_name"`
+}
+
+// CancellationReportFilter — параметры отчёта об отменах: месяцы, в которые подписки
+// были отменены, включительно.
+type CancellationReportFilter struct {
+	UserID      string `form:"user_id" binding:"omitempty,uuid"`
+	ServiceID   int64  `form:"service_id" binding:"omitempty,min=1"`
+	ServiceName string `form:"service_name"`
+	From        string `form:"from" binding:"required,date"`
+	To          string `form:"to" binding:"required,date"`
+}
+
+// BalancesFilter — параметры взаиморасчётов по совместным подпискам за месяц,
+// по умолчанию текущий.
+type BalancesFilter struct {
+	UserID string `form:"user_id" binding:"omitempty,uuid"`
+	Month  string `form:"month" binding:"omitempty,date"`
+}
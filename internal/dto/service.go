@@ -0,0 +1,8 @@
+package dto
+
+type ListServicesFilter struct {
+	Query    string `form:"q"`
+	Category string `form:"category"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset   int    `form:"offset" binding:"omitempty,min=0"`
+}
@@ -1,28 +1,21 @@
 package dto
 
-import "github.com/google/uuid"
-
-type CreateSubscriptionRequest struct {
-	ServiceName string    `json:"service_name" binding:"required"`
-	Price       int       `json:"price" binding:"required,min=1"`
-	UserID      uuid.UUID `json:"user_id" binding:"required"`
-	StartDate   string    `json:"start_date" binding:"required,datetime=01-2006"`
-	EndDate     *string   `json:"end_date,omitempty" binding:"omitempty,datetime=01-2006"`
-}
-
-type UpdateSubscriptionRequest struct {
-	ServiceName *string    `json:"service_name" binding:"omitempty"`
-	Price       *int       `json:"price" binding:"omitempty,min=1"`
-	UserID      *uuid.UUID `json:"user_id" binding:"omitempty"`
-	StartDate   *string    `json:"start_date" binding:"omitempty,datetime=01-2006"`
-	EndDate     *string    `json:"end_date,omitempty" binding:"omitempty,datetime=01-2006"`
+type ListSubscriptionsFilter struct {
+	UserID      string `form:"user_id" binding:"omitempty,uuid"`
+	ServiceID   int64  `form:"service_id" binding:"omitempty,min=1"`
+	ServiceName string `form:"service_name"`
+	// Category — собственная категория подписки или категория её сервиса в каталоге.
+	Category string `form:"category" binding:"omitempty,max=100"`
+	// Tags отбирает подписки, у которых есть все эти метки.
+	Tags   []string `form:"tag" binding:"omitempty,max=20,dive,max=64"`
+	Limit  int      `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset int      `form:"offset" binding:"omitempty,min=0"`
 }
 
-type SubscriptionResponse struct {
-	ID          int64     `json:"id"`
-	ServiceName string    `json:"service_name"`
-	Price       int       `json:"price"`
-	UserID      uuid.UUID `json:"user_id"`
-	StartDate   string    `json:"start_date"`
-	EndDate     *string   `json:"end_date,omitempty"`
+type SearchSubscriptionsFilter struct {
+	// Query ищется в названии и псевдонимах сервиса, метках и заметках, допускает опечатки.
+	Query  string `form:"q" binding:"required,min=2,max=200"`
+	UserID string `form:"user_id" binding:"omitempty,uuid"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
 }
@@ -0,0 +1,7 @@
+package dto
+
+type ListTagsFilter struct {
+	Query  string `form:"q"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+}
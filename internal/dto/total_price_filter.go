@@ -1,12 +1,18 @@
 package dto
 
-import (
-	"time"
-)
-
+// TotalPriceFilterDTO задаёт период месяцами from_date и to_date включительно. Даты
+// принимаются в любом формате dates.Parse. Без from_date период начинается с начала
+// каждой подписки, без to_date — заканчивается с ней, а бессрочная подписка считается
+// по текущий месяц. С to_date бессрочная подписка начисляется по to_date, и месяцы
+// после текущего — прогноз. С group_by сумма дополнительно разбивается по сервисам,
+// категориям или меткам.
 type TotalPriceFilterDTO struct {
-	UserID      string    `form:"user_id"`
-	ServiceName string    `form:"service_name"`
-	FromDate    time.Time `form:"from_date" time_format:"02-01-2006"`
-	ToDate      time.Time `form:"to_date" time_format:"02-01-2006"`
+	UserID      string   `form:"user_id"`
+	ServiceID   int64    `form:"service_id" binding:"omitempty,min=1"`
+	ServiceName string   `form:"service_name"`
+	Category    string   `form:"category" binding:"omitempty,max=100"`
+	Tags        []string `form:"tag" binding:"omitempty,max=20,dive,max=64"`
+	FromDate    string   `form:"from_date" binding:"omitempty,date"`
+	ToDate      string   `form:"to_date" binding:"omitempty,date"`
+	GroupBy     string   `form:"group_by" binding:"omitempty,oneof=service category tag"`
 }
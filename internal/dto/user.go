@@ -0,0 +1,12 @@
+package dto
+
+type ListUsersFilter struct {
+	Query  string `form:"q"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+type UserSummaryOptions struct {
+	// WithinDays — горизонт ближайших продлений в днях.
+	WithinDays int `form:"within_days" binding:"omitempty,min=1,max=366"`
+}
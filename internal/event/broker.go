@@ -0,0 +1,77 @@
+package event
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shenikar/subscription-service/internal/model"
+)
+
+const (
+	SubscriptionCreated = "subscription.created"
+	SubscriptionUpdated = "subscription.updated"
+	SubscriptionDeleted = "subscription.deleted"
+	SubscriptionPaused  = "subscription.paused"
+	SubscriptionResumed = "subscription.resumed"
+	// SubscriptionCancelled отправляется при отмене подписки с указанием причины.
+	SubscriptionCancelled = "subscription.cancelled"
+	// SubscriptionTrialEnding отправляется за TRIAL_ENDING_DAYS дней до окончания пробного периода.
+	SubscriptionTrialEnding = "subscription.trial_ending"
+
+	BudgetThresholdReached = "budget.threshold_reached"
+)
+
+// Event — событие сервиса. Для событий подписок заполнено Subscription,
+// для уведомлений о бюджете — Budget.
+type Event struct {
+	Type           string
+	SubscriptionID int64
+	Subscription   *model.Subscription
+	Budget         *model.BudgetAlert
+	OccurredAt     time.Time
+}
+
+// Broker рассылает события подписчикам внутри процесса. Публикация не блокируется:
+// если буфер подписчика заполнен, событие для него отбрасывается.
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[chan Event]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[chan Event]struct{})}
+}
+
+func (b *Broker) Publish(e Event) {
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+func (b *Broker) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
@@ -0,0 +1,141 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/service"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+// Коды ошибок в extensions.code ответа.
+const (
+	CodeBadUserInput       = "BAD_USER_INPUT"
+	CodeNotFound           = "NOT_FOUND"
+	CodePreconditionFailed = "PRECONDITION_FAILED"
+	CodeConflict           = "CONFLICT"
+	CodeQueryTooComplex    = "QUERY_TOO_COMPLEX"
+	CodeInternal           = "INTERNAL_SERVER_ERROR"
+)
+
+type Executor struct {
+	service       *service.SubscriptionService
+	schema        graphql.Schema
+	maxDepth      int
+	maxComplexity int
+}
+
+func NewExecutor(svc *service.SubscriptionService, maxDepth, maxComplexity int) (*Executor, error) {
+	schema, err := newSchema(svc)
+	if err != nil {
+		return nil, fmt.Errorf("build graphql schema: %w", err)
+	}
+	return &Executor{service: svc, schema: schema, maxDepth: maxDepth, maxComplexity: maxComplexity}, nil
+}
+
+// Execute разбирает и валидирует запрос, проверяет ограничения глубины и сложности
+// и только после этого выполняет его с загрузчиками, общими для всего запроса.
+func (e *Executor) Execute(ctx context.Context, req api.GraphQLRequest) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	if res := graphql.ValidateDocument(&e.schema, doc, nil); !res.IsValid {
+		return &graphql.Result{Errors: res.Errors}
+	}
+
+	depth, complexity := measure(doc, req.Variables)
+	if depth > e.maxDepth {
+		return limitExceeded(fmt.Sprintf("query depth %d exceeds the limit of %d", depth, e.maxDepth))
+	}
+	if complexity > e.maxComplexity {
+		return limitExceeded(fmt.Sprintf("query complexity %d exceeds the limit of %d", complexity, e.maxComplexity))
+	}
+
+	res := graphql.Execute(graphql.ExecuteParams{
+		Schema:        e.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoaders(ctx, e.service),
+	})
+	for i := range res.Errors {
+		res.Errors[i] = classify(res.Errors[i])
+	}
+	return res
+}
+
+func limitExceeded(msg string) *graphql.Result {
+	err := gqlerrors.NewFormattedError(msg)
+	err.Extensions = map[string]any{"code": CodeQueryTooComplex}
+	return &graphql.Result{Errors: []gqlerrors.FormattedError{err}}
+}
+
+// classify проставляет код ошибкам резолверов. Ошибки разбора, валидации и
+// приведения переменных остаются без изменений.
+func classify(ferr gqlerrors.FormattedError) gqlerrors.FormattedError {
+	err := originalError(ferr)
+
+	var code string
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		code = CodeBadUserInput
+	case errors.Is(err, service.ErrNotFound):
+		code = CodeNotFound
+	case errors.Is(err, service.ErrPreconditionFailed):
+		code = CodePreconditionFailed
+	case errors.Is(err, service.ErrOverlap):
+		code = CodeConflict
+	case errors.Is(err, errInternal):
+		code = CodeInternal
+	default:
+		return ferr
+	}
+	ferr.Extensions = map[string]any{"code": code}
+	return ferr
+}
+
+// originalError снимает обёртки, которыми библиотека оборачивает ошибки резолверов
+// (в том числе ошибки отложенных значений загрузчиков).
+func originalError(err error) error {
+	for {
+		switch e := err.(type) {
+		case gqlerrors.FormattedError:
+			err = e.OriginalError()
+		case *gqlerrors.Error:
+			if e.OriginalError == nil {
+				return err
+			}
+			err = e.OriginalError
+		default:
+			return err
+		}
+	}
+}
+
+var errInternal = errors.New("internal error")
+
+func inputError(msg string) error {
+	return fmt.Errorf("%w: %s", service.ErrInvalidInput, msg)
+}
+
+// internalError пропускает доменные ошибки сервиса как есть, а остальные логирует
+// и заменяет на errInternal, чтобы детали работы с базой не уходили клиенту.
+func internalError(err error) error {
+	if errors.Is(err, service.ErrInvalidInput) || errors.Is(err, service.ErrNotFound) || errors.Is(err, service.ErrPreconditionFailed) ||
+		errors.Is(err, service.ErrOverlap) {
+		return err
+	}
+	logger.GetLogger().WithError(err).Error("GraphQL: internal error")
+	return errInternal
+}
@@ -0,0 +1,121 @@
+package gql
+
+import (
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// defaultListSize — оценка числа элементов для списков без явного limit
+// (вложенные subscriptions, services).
+const defaultListSize = 10
+
+var listFields = map[string]bool{
+	"subscriptions": true,
+	"users":         true,
+	"services":      true,
+}
+
+// queryCost обходит запрос до выполнения и считает его глубину и сложность.
+// Каждое поле стоит 1, стоимость вложенной выборки списка умножается на limit
+// (или длину userIds), чтобы запросы вида users { subscriptions { user { ... } } }
+// отсекались до обращения к базе.
+type queryCost struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+}
+
+func measure(doc *ast.Document, variables map[string]any) (depth, complexity int) {
+	qc := queryCost{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+	}
+	for _, def := range doc.Definitions {
+		if frag, ok := def.(*ast.FragmentDefinition); ok {
+			qc.fragments[frag.Name.Value] = frag
+		}
+	}
+
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		d, c := qc.selectionSet(op.SelectionSet, true)
+		depth = max(depth, d)
+		complexity += c
+	}
+	return depth, complexity
+}
+
+func (qc queryCost) selectionSet(set *ast.SelectionSet, root bool) (depth, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	for _, sel := range set.Selections {
+		var d, c int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			d, c = qc.field(sel, root)
+		case *ast.InlineFragment:
+			d, c = qc.selectionSet(sel.SelectionSet, root)
+		case *ast.FragmentSpread:
+			if frag, ok := qc.fragments[sel.Name.Value]; ok {
+				d, c = qc.selectionSet(frag.SelectionSet, root)
+			}
+		}
+		depth = max(depth, d)
+		complexity += c
+	}
+	return depth, complexity
+}
+
+func (qc queryCost) field(field *ast.Field, root bool) (depth, complexity int) {
+	d, c := qc.selectionSet(field.SelectionSet, false)
+	if field.SelectionSet != nil && listFields[field.Name.Value] {
+		c *= qc.listSize(field, root)
+	}
+	return d + 1, c + 1
+}
+
+func (qc queryCost) listSize(field *ast.Field, root bool) int {
+	for _, arg := range field.Arguments {
+		switch arg.Name.Value {
+		case "limit":
+			if n, ok := qc.intValue(arg.Value); ok && n > 0 {
+				return n
+			}
+			return defaultListLimit
+		case "userIds":
+			if list, ok := arg.Value.(*ast.ListValue); ok {
+				return max(len(list.Values), 1)
+			}
+			if v, ok := arg.Value.(*ast.Variable); ok {
+				if list, ok := qc.variables[v.Name.Value].([]any); ok {
+					return max(len(list), 1)
+				}
+			}
+		}
+	}
+	if root && field.Name.Value == "subscriptions" {
+		return defaultListLimit
+	}
+	return defaultListSize
+}
+
+func (qc queryCost) intValue(value ast.Value) (int, bool) {
+	switch v := value.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(v.Value)
+		return n, err == nil
+	case *ast.Variable:
+		switch n := qc.variables[v.Name.Value].(type) {
+		case int:
+			return n, true
+		case float64:
+			return int(n), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,86 @@
+package gql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/service"
+)
+
+type loadersKey struct{}
+
+// loader собирает ключи, запрошенные резолверами одного уровня запроса, и загружает
+// их одним обращением к базе при первом вычислении любого из отложенных значений.
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending map[K]struct{}
+	cache   map[K]V
+	errs    map[K]error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		pending: make(map[K]struct{}),
+		cache:   make(map[K]V),
+		errs:    make(map[K]error),
+	}
+}
+
+func (l *loader[K, V]) Load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	if _, ok := l.cache[key]; !ok {
+		l.pending[key] = struct{}{}
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if len(l.pending) > 0 {
+			keys := make([]K, 0, len(l.pending))
+			for k := range l.pending {
+				keys = append(keys, k)
+			}
+			clear(l.pending)
+
+			values, err := l.fetch(ctx, keys)
+			for _, k := range keys {
+				if err != nil {
+					l.errs[k] = err
+					continue
+				}
+				l.cache[k] = values[k]
+			}
+		}
+		return l.cache[key], l.errs[key]
+	}
+}
+
+type loaders struct {
+	byUser    *loader[uuid.UUID, []model.Subscription]
+	byService *loader[string, []model.Subscription]
+	// userMonth — текущий месяц в часовом поясе пользователя.
+	userMonth *loader[uuid.UUID, time.Time]
+	// month — текущий месяц в часовом поясе запроса для сводок по сервисам.
+	month time.Time
+}
+
+func withLoaders(ctx context.Context, svc *service.SubscriptionService) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		byUser:    newLoader(svc.ListByUsers),
+		byService: newLoader(svc.ListByServices),
+		userMonth: newLoader(svc.CurrentMonths),
+		month:     svc.CurrentMonth(ctx),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,440 @@
+package gql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	"github.com/shenikar/subscription-service/internal/dates"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/service"
+	"github.com/shenikar/subscription-service/internal/validation"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+type userSummary struct {
+	UserID uuid.UUID
+}
+
+type serviceSummary struct {
+	Name string
+}
+
+type resolver struct {
+	service *service.SubscriptionService
+}
+
+func newSchema(svc *service.SubscriptionService) (graphql.Schema, error) {
+	r := &resolver{service: svc}
+
+	var userSummaryType, serviceSummaryType *graphql.Object
+	subscriptionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":          {Type: graphql.NewNonNull(graphql.ID), Resolve: subscriptionField(func(s model.Subscription) any { return s.ID })},
+				"serviceId":   {Type: graphql.NewNonNull(graphql.ID), Resolve: subscriptionField(func(s model.Subscription) any { return s.ServiceID })},
+				"serviceName": {Type: graphql.NewNonNull(graphql.String), Resolve: subscriptionField(func(s model.Subscription) any { return s.ServiceName })},
+				"price":       {Type: graphql.NewNonNull(graphql.Int), Resolve: subscriptionField(func(s model.Subscription) any { return s.Price })},
+				"userId":      {Type: graphql.NewNonNull(graphql.String), Resolve: subscriptionField(func(s model.Subscription) any { return s.UserID.String() })},
+				"startDate":   {Type: graphql.NewNonNull(graphql.String), Resolve: subscriptionField(func(s model.Subscription) any { return dates.FormatMonth(s.StartDate) })},
+				"endDate": {Type: graphql.String, Resolve: subscriptionField(func(s model.Subscription) any {
+					if s.EndDate == nil {
+						return nil
+					}
+					return dates.FormatMonth(*s.EndDate)
+				})},
+				"version": {Type: graphql.NewNonNull(graphql.Int), Resolve: subscriptionField(func(s model.Subscription) any { return s.Version })},
+				"user": {Type: graphql.NewNonNull(userSummaryType), Resolve: subscriptionField(func(s model.Subscription) any {
+					return userSummary{UserID: s.UserID}
+				})},
+				"service": {Type: graphql.NewNonNull(serviceSummaryType), Resolve: subscriptionField(func(s model.Subscription) any {
+					return serviceSummary{Name: s.ServiceName}
+				})},
+			}
+		}),
+	})
+
+	userSummaryType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "UserSummary",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"userId": {Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(userSummary).UserID.String(), nil
+				}},
+				"subscriptionCount": {Type: graphql.NewNonNull(graphql.Int), Resolve: userAggregate(func(subs []model.Subscription, month time.Time) any { return len(subs) })},
+				"activeCount":       {Type: graphql.NewNonNull(graphql.Int), Resolve: userAggregate(func(subs []model.Subscription, month time.Time) any { return len(activeSubscriptions(subs, month)) })},
+				"monthlySpend":      {Type: graphql.NewNonNull(graphql.Int), Resolve: userAggregate(func(subs []model.Subscription, month time.Time) any { return monthlySpend(subs, month) })},
+				"subscriptions": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(subscriptionType))), Resolve: userAggregate(func(subs []model.Subscription, month time.Time) any {
+					return subs
+				})},
+			}
+		}),
+	})
+
+	serviceSummaryType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ServiceSummary",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"name": {Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(serviceSummary).Name, nil
+				}},
+				"subscriptionCount": {Type: graphql.NewNonNull(graphql.Int), Resolve: serviceAggregate(func(subs []model.Subscription, month time.Time) any { return len(subs) })},
+				"userCount":         {Type: graphql.NewNonNull(graphql.Int), Resolve: serviceAggregate(func(subs []model.Subscription, month time.Time) any { return userCount(subs) })},
+				"monthlySpend":      {Type: graphql.NewNonNull(graphql.Int), Resolve: serviceAggregate(func(subs []model.Subscription, month time.Time) any { return monthlySpend(subs, month) })},
+				"subscriptions": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(subscriptionType))), Resolve: serviceAggregate(func(subs []model.Subscription, month time.Time) any {
+					return subs
+				})},
+			}
+		}),
+	})
+
+	subscriptionInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "SubscriptionInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"serviceId":   {Type: graphql.ID, Description: "ID сервиса из каталога, вместо serviceName"},
+			"serviceName": {Type: graphql.String, Description: "Название или псевдоним сервиса из каталога"},
+			"price":       {Type: graphql.Int, Description: "По умолчанию цена сервиса из каталога"},
+			"userId":      {Type: graphql.NewNonNull(graphql.String)},
+			"startDate":   {Type: graphql.NewNonNull(graphql.String), Description: "Месяц начала: MM-YYYY, YYYY-MM, YYYY-MM-DD или RFC 3339"},
+			"endDate":     {Type: graphql.String, Description: "Месяц окончания, не раньше месяца начала"},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"subscription": {
+				Type:    subscriptionType,
+				Args:    graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: r.subscription,
+			},
+			"subscriptions": {
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(subscriptionType))),
+				Args: graphql.FieldConfigArgument{
+					"userId":      {Type: graphql.String},
+					"serviceName": {Type: graphql.String},
+					"limit":       {Type: graphql.Int, DefaultValue: defaultListLimit},
+					"offset":      {Type: graphql.Int, DefaultValue: 0},
+				},
+				Resolve: r.subscriptions,
+			},
+			"user": {
+				Type:    graphql.NewNonNull(userSummaryType),
+				Args:    graphql.FieldConfigArgument{"userId": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: r.user,
+			},
+			"users": {
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(userSummaryType))),
+				Args:    graphql.FieldConfigArgument{"userIds": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))}},
+				Resolve: r.users,
+			},
+			"service": {
+				Type:    graphql.NewNonNull(serviceSummaryType),
+				Args:    graphql.FieldConfigArgument{"name": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: r.serviceSummary,
+			},
+			"services": {
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(serviceSummaryType))),
+				Resolve: r.services,
+			},
+			"total": {
+				Type: graphql.NewNonNull(graphql.Int),
+				Args: graphql.FieldConfigArgument{
+					"userId":      {Type: graphql.NewNonNull(graphql.String)},
+					"serviceName": {Type: graphql.String},
+					"fromDate":    {Type: graphql.String, Description: "Начало периода: MM-YYYY, YYYY-MM, YYYY-MM-DD или RFC 3339"},
+					"toDate":      {Type: graphql.String, Description: "Конец периода: MM-YYYY, YYYY-MM, YYYY-MM-DD или RFC 3339"},
+				},
+				Resolve: r.total,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createSubscription": {
+				Type:    graphql.NewNonNull(subscriptionType),
+				Args:    graphql.FieldConfigArgument{"input": {Type: graphql.NewNonNull(subscriptionInput)}},
+				Resolve: r.createSubscription,
+			},
+			"updateSubscription": {
+				Type: graphql.NewNonNull(subscriptionType),
+				Args: graphql.FieldConfigArgument{
+					"id":              {Type: graphql.NewNonNull(graphql.ID)},
+					"input":           {Type: graphql.NewNonNull(subscriptionInput)},
+					"expectedVersion": {Type: graphql.Int},
+				},
+				Resolve: r.updateSubscription,
+			},
+			"deleteSubscription": {
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id":              {Type: graphql.NewNonNull(graphql.ID)},
+					"expectedVersion": {Type: graphql.Int},
+				},
+				Resolve: r.deleteSubscription,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+func subscriptionField(get func(model.Subscription) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		return get(p.Source.(model.Subscription)), nil
+	}
+}
+
+// userAggregate и serviceAggregate не ходят в базу сами, а ставят ключ в очередь
+// загрузчика: подписки всех пользователей (сервисов) одного уровня выбираются одним запросом.
+// userAggregate вычисляет поле сводки по подпискам пользователя; month — текущий месяц
+// в часовом поясе пользователя.
+func userAggregate(compute func([]model.Subscription, time.Time) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		l := loadersFrom(p.Context)
+		userID := p.Source.(userSummary).UserID
+		thunk := l.byUser.Load(p.Context, userID)
+		monthThunk := l.userMonth.Load(p.Context, userID)
+		return func() (any, error) {
+			subs, err := thunk()
+			if err != nil {
+				return nil, internalError(err)
+			}
+			month, err := monthThunk()
+			if err != nil {
+				return nil, internalError(err)
+			}
+			return compute(subs, month), nil
+		}, nil
+	}
+}
+
+// serviceAggregate вычисляет поле сводки по подпискам сервиса; month — текущий месяц
+// в часовом поясе запроса.
+func serviceAggregate(compute func([]model.Subscription, time.Time) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		l := loadersFrom(p.Context)
+		thunk := l.byService.Load(p.Context, p.Source.(serviceSummary).Name)
+		return func() (any, error) {
+			subs, err := thunk()
+			if err != nil {
+				return nil, internalError(err)
+			}
+			return compute(subs, l.month), nil
+		}, nil
+	}
+}
+
+func (r *resolver) subscription(p graphql.ResolveParams) (any, error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	sub, err := r.service.GetByID(p.Context, id)
+	if err != nil || sub == nil {
+		return nil, internalError(err)
+	}
+	return *sub, nil
+}
+
+func (r *resolver) subscriptions(p graphql.ResolveParams) (any, error) {
+	filter := dto.ListSubscriptionsFilter{
+		Limit:  p.Args["limit"].(int),
+		Offset: p.Args["offset"].(int),
+	}
+	filter.UserID, _ = p.Args["userId"].(string)
+	filter.ServiceName, _ = p.Args["serviceName"].(string)
+	if filter.Limit < 1 || filter.Limit > maxListLimit {
+		return nil, inputError(fmt.Sprintf("limit must be between 1 and %d", maxListLimit))
+	}
+	if filter.Offset < 0 {
+		return nil, inputError("offset must not be negative")
+	}
+
+	subs, err := r.service.List(p.Context, filter)
+	if err != nil {
+		return nil, internalError(err)
+	}
+	if subs == nil {
+		subs = []model.Subscription{}
+	}
+	return subs, nil
+}
+
+func (r *resolver) user(p graphql.ResolveParams) (any, error) {
+	userID, err := uuid.Parse(p.Args["userId"].(string))
+	if err != nil {
+		return nil, inputError("invalid userId")
+	}
+	return userSummary{UserID: userID}, nil
+}
+
+func (r *resolver) users(p graphql.ResolveParams) (any, error) {
+	ids := p.Args["userIds"].([]any)
+	if len(ids) > maxListLimit {
+		return nil, inputError(fmt.Sprintf("at most %d userIds are allowed", maxListLimit))
+	}
+
+	res := make([]userSummary, 0, len(ids))
+	for _, raw := range ids {
+		userID, err := uuid.Parse(raw.(string))
+		if err != nil {
+			return nil, inputError(fmt.Sprintf("invalid userId %q", raw))
+		}
+		res = append(res, userSummary{UserID: userID})
+	}
+	return res, nil
+}
+
+func (r *resolver) serviceSummary(p graphql.ResolveParams) (any, error) {
+	return serviceSummary{Name: p.Args["name"].(string)}, nil
+}
+
+func (r *resolver) services(p graphql.ResolveParams) (any, error) {
+	names, err := r.service.ServiceNames(p.Context)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	res := make([]serviceSummary, 0, len(names))
+	for _, name := range names {
+		res = append(res, serviceSummary{Name: name})
+	}
+	return res, nil
+}
+
+func (r *resolver) total(p graphql.ResolveParams) (any, error) {
+	filter := dto.TotalPriceFilterDTO{UserID: p.Args["userId"].(string)}
+	filter.ServiceName, _ = p.Args["serviceName"].(string)
+	filter.FromDate, _ = p.Args["fromDate"].(string)
+	filter.ToDate, _ = p.Args["toDate"].(string)
+
+	total, err := r.service.TotalPrice(p.Context, filter)
+	if err != nil {
+		return nil, internalError(err)
+	}
+	return total, nil
+}
+
+func (r *resolver) createSubscription(p graphql.ResolveParams) (any, error) {
+	req, err := parseInput(p.Args["input"].(map[string]any))
+	if err != nil {
+		return nil, err
+	}
+	sub, err := r.service.Create(p.Context, req)
+	if err != nil {
+		return nil, internalError(err)
+	}
+	return sub, nil
+}
+
+func (r *resolver) updateSubscription(p graphql.ResolveParams) (any, error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	req, err := parseInput(p.Args["input"].(map[string]any))
+	if err != nil {
+		return nil, err
+	}
+	sub, err := r.service.Update(p.Context, id, api.UpdateSubscriptionRequest(req), expectedVersion(p.Args["expectedVersion"]))
+	if err != nil {
+		return nil, internalError(err)
+	}
+	return sub, nil
+}
+
+func (r *resolver) deleteSubscription(p graphql.ResolveParams) (any, error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	if err := r.service.Delete(p.Context, id, expectedVersion(p.Args["expectedVersion"])); err != nil {
+		return nil, internalError(err)
+	}
+	return true, nil
+}
+
+func parseInput(input map[string]any) (api.CreateSubscriptionRequest, error) {
+	userID, err := uuid.Parse(input["userId"].(string))
+	if err != nil {
+		return api.CreateSubscriptionRequest{}, inputError("invalid userId")
+	}
+
+	req := api.CreateSubscriptionRequest{
+		UserID:    userID,
+		StartDate: input["startDate"].(string),
+	}
+	req.ServiceName, _ = input["serviceName"].(string)
+	req.Price, _ = input["price"].(int)
+	if v, ok := input["serviceId"]; ok && v != nil {
+		serviceID, err := parseID(v)
+		if err != nil {
+			return api.CreateSubscriptionRequest{}, inputError("invalid serviceId")
+		}
+		req.ServiceID = &serviceID
+	}
+	if endDate, ok := input["endDate"].(string); ok {
+		req.EndDate = &endDate
+	}
+	if errs := validation.Struct(&req); len(errs) > 0 {
+		return api.CreateSubscriptionRequest{}, inputError(errs[0])
+	}
+	return req, nil
+}
+
+func parseID(v any) (int64, error) {
+	var id int64
+	if _, err := fmt.Sscan(fmt.Sprint(v), &id); err != nil || id <= 0 {
+		return 0, inputError("invalid id")
+	}
+	return id, nil
+}
+
+func expectedVersion(v any) *int {
+	version, ok := v.(int)
+	if !ok {
+		return nil
+	}
+	return &version
+}
+
+// activeSubscriptions возвращает подписки, действующие в месяце month.
+func activeSubscriptions(subs []model.Subscription, month time.Time) []model.Subscription {
+	var res []model.Subscription
+	for _, sub := range subs {
+		if sub.ActiveIn(month) {
+			res = append(res, sub)
+		}
+	}
+	return res
+}
+
+// monthlySpend суммирует начисления за месяц month; приостановленные подписки не оплачиваются.
+func monthlySpend(subs []model.Subscription, month time.Time) int {
+	var sum int
+	for _, sub := range subs {
+		if price, ok := sub.ChargeIn(month); ok {
+			sum += price
+		}
+	}
+	return sum
+}
+
+func userCount(subs []model.Subscription) int {
+	users := make(map[uuid.UUID]struct{})
+	for _, sub := range subs {
+		users[sub.UserID] = struct{}{}
+	}
+	return len(users)
+}
@@ -0,0 +1,421 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	subscriptionv1 "github.com/shenikar/subscription-service/api/subscription/v1"
+	"github.com/shenikar/subscription-service/internal/dates"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/event"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/mapper"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/service"
+	"github.com/shenikar/subscription-service/internal/validation"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+	watchBuffer     = 64
+)
+
+type Server struct {
+	subscriptionv1.UnimplementedSubscriptionServiceServer
+
+	service *service.SubscriptionService
+	broker  *event.Broker
+}
+
+// New создаёт gRPC-сервер с SubscriptionService, стандартным health-сервисом и reflection.
+func New(svc *service.SubscriptionService, broker *event.Broker) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(loggingUnaryInterceptor, timeZoneUnaryInterceptor),
+		grpc.ChainStreamInterceptor(loggingStreamInterceptor, timeZoneStreamInterceptor),
+	)
+
+	subscriptionv1.RegisterSubscriptionServiceServer(srv, &Server{service: svc, broker: broker})
+
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthSrv.SetServingStatus(subscriptionv1.SubscriptionService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, healthSrv)
+
+	reflection.Register(srv)
+
+	return srv
+}
+
+func (s *Server) Create(ctx context.Context, req *subscriptionv1.CreateRequest) (*subscriptionv1.Subscription, error) {
+	userID, err := parseUserID(req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	createReq := api.CreateSubscriptionRequest{
+		ServiceID:   req.ServiceId,
+		ServiceName: req.GetServiceName(),
+		Price:       int(req.GetPrice()),
+		UserID:      userID,
+		StartDate:   req.GetStartDate(),
+		EndDate:     req.EndDate,
+	}
+	if err := validate(&createReq); err != nil {
+		return nil, err
+	}
+
+	sub, err := s.service.Create(ctx, createReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(sub), nil
+}
+
+func (s *Server) Get(ctx context.Context, req *subscriptionv1.GetRequest) (*subscriptionv1.Subscription, error) {
+	sub, err := s.service.GetByID(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if sub == nil {
+		return nil, status.Error(codes.NotFound, service.ErrNotFound.Error())
+	}
+	return toProto(*sub), nil
+}
+
+func (s *Server) List(ctx context.Context, req *subscriptionv1.ListRequest) (*subscriptionv1.ListResponse, error) {
+	pageSize := int(req.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+
+	offset, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+
+	subs, err := s.service.List(ctx, dto.ListSubscriptionsFilter{
+		UserID:      req.GetUserId(),
+		ServiceID:   req.GetServiceId(),
+		ServiceName: req.GetServiceName(),
+		Limit:       pageSize + 1,
+		Offset:      offset,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &subscriptionv1.ListResponse{}
+	if len(subs) > pageSize {
+		subs = subs[:pageSize]
+		resp.NextPageToken = encodePageToken(offset + pageSize)
+	}
+	for _, sub := range subs {
+		resp.Subscriptions = append(resp.Subscriptions, toProto(sub))
+	}
+	return resp, nil
+}
+
+func (s *Server) Update(ctx context.Context, req *subscriptionv1.UpdateRequest) (*subscriptionv1.Subscription, error) {
+	userID, err := parseUserID(req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	updateReq := api.UpdateSubscriptionRequest{
+		ServiceID:   req.ServiceId,
+		ServiceName: req.GetServiceName(),
+		Price:       int(req.GetPrice()),
+		UserID:      userID,
+		StartDate:   req.GetStartDate(),
+		EndDate:     req.EndDate,
+	}
+	if err := validate(&updateReq); err != nil {
+		return nil, err
+	}
+
+	sub, err := s.service.Update(ctx, req.GetId(), updateReq, expectedVersion(req.ExpectedVersion))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(sub), nil
+}
+
+func (s *Server) Delete(ctx context.Context, req *subscriptionv1.DeleteRequest) (*subscriptionv1.DeleteResponse, error) {
+	if err := s.service.Delete(ctx, req.GetId(), expectedVersion(req.ExpectedVersion)); err != nil {
+		return nil, toStatus(err)
+	}
+	return &subscriptionv1.DeleteResponse{}, nil
+}
+
+func (s *Server) Total(ctx context.Context, req *subscriptionv1.TotalRequest) (*subscriptionv1.TotalResponse, error) {
+	filter := dto.TotalPriceFilterDTO{
+		UserID:      req.GetUserId(),
+		ServiceID:   req.GetServiceId(),
+		ServiceName: req.GetServiceName(),
+		FromDate:    req.GetFromDate(),
+		ToDate:      req.GetToDate(),
+	}
+
+	total, err := s.service.TotalPrice(ctx, filter)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &subscriptionv1.TotalResponse{Total: int64(total)}, nil
+}
+
+func (s *Server) Watch(req *subscriptionv1.WatchRequest, stream grpc.ServerStreamingServer[subscriptionv1.Event]) error {
+	var userID *uuid.UUID
+	if req.GetUserId() != "" {
+		id, err := parseUserID(req.GetUserId())
+		if err != nil {
+			return err
+		}
+		userID = &id
+	}
+
+	events, unsubscribe := s.broker.Subscribe(watchBuffer)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			msg, owner, ok := toProtoEvent(e)
+			if !ok || (userID != nil && owner != *userID) {
+				continue
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// toProtoEvent переводит событие брокера в событие Watch и возвращает пользователя,
+// к которому оно относится. Прочие события в поток не попадают.
+func toProtoEvent(e event.Event) (*subscriptionv1.Event, uuid.UUID, bool) {
+	msg := &subscriptionv1.Event{Type: e.Type, OccurredAt: timestamppb.New(e.OccurredAt)}
+	switch e.Type {
+	case event.SubscriptionCreated, event.SubscriptionUpdated, event.SubscriptionDeleted,
+		event.SubscriptionPaused, event.SubscriptionResumed, event.SubscriptionCancelled,
+		event.SubscriptionTrialEnding:
+		if e.Subscription == nil {
+			return nil, uuid.Nil, false
+		}
+		msg.SubscriptionId = e.SubscriptionID
+		msg.Subscription = toProto(*e.Subscription)
+		return msg, e.Subscription.UserID, true
+	case event.BudgetThresholdReached:
+		if e.Budget == nil {
+			return nil, uuid.Nil, false
+		}
+		msg.Budget = &subscriptionv1.BudgetAlert{
+			BudgetId:  e.Budget.BudgetID,
+			UserId:    e.Budget.UserID.String(),
+			Month:     dates.FormatMonth(e.Budget.Month),
+			Threshold: int32(e.Budget.Threshold),
+			Spent:     int64(e.Budget.Spent),
+			Limit:     int64(e.Budget.Limit),
+		}
+		return msg, e.Budget.UserID, true
+	default:
+		return nil, uuid.Nil, false
+	}
+}
+
+func toProto(sub model.Subscription) *subscriptionv1.Subscription {
+	res := mapper.ToResponseDTO(sub, dates.Default)
+	return &subscriptionv1.Subscription{
+		Id:          res.ID,
+		ServiceId:   res.ServiceID,
+		ServiceName: res.ServiceName,
+		Price:       int64(res.Price),
+		UserId:      res.UserID.String(),
+		StartDate:   res.StartDate,
+		EndDate:     res.EndDate,
+		Version:     int32(res.Version),
+	}
+}
+
+// statusCodes сопоставляет доменные ошибки сервиса с кодами gRPC. Ошибки, которых нет
+// в таблице, возвращаются клиенту как codes.Internal.
+var statusCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{service.ErrNotFound, codes.NotFound},
+	{service.ErrPreconditionFailed, codes.Aborted},
+	{service.ErrOverlap, codes.AlreadyExists},
+	{service.ErrAlreadyPaused, codes.FailedPrecondition},
+	{service.ErrNotPaused, codes.FailedPrecondition},
+	{service.ErrAlreadyEnded, codes.FailedPrecondition},
+	{service.ErrNotStarted, codes.FailedPrecondition},
+	{service.ErrInvalidInput, codes.InvalidArgument},
+	{service.ErrServiceNotFound, codes.NotFound},
+	{service.ErrServiceExists, codes.AlreadyExists},
+	{service.ErrServiceInUse, codes.FailedPrecondition},
+	{service.ErrUserNotFound, codes.NotFound},
+	{service.ErrUserExists, codes.AlreadyExists},
+	{service.ErrUserInUse, codes.FailedPrecondition},
+	{service.ErrTagNotFound, codes.NotFound},
+	{service.ErrTagExists, codes.AlreadyExists},
+	{service.ErrMemberNotFound, codes.NotFound},
+	{service.ErrUserIsMember, codes.FailedPrecondition},
+	{service.ErrUserInOrganisation, codes.FailedPrecondition},
+	{service.ErrOrganisationNotFound, codes.NotFound},
+	{service.ErrOrganisationExists, codes.AlreadyExists},
+	{service.ErrOrganisationInUse, codes.FailedPrecondition},
+	{service.ErrUnitNotFound, codes.NotFound},
+	{service.ErrUnitExists, codes.AlreadyExists},
+	{service.ErrUnitInUse, codes.FailedPrecondition},
+	{service.ErrCostCentreNotFound, codes.NotFound},
+	{service.ErrCostCentreExists, codes.AlreadyExists},
+	{service.ErrCostCentreInUse, codes.FailedPrecondition},
+	{service.ErrOrgMemberNotFound, codes.NotFound},
+	{service.ErrLastOwner, codes.FailedPrecondition},
+	{context.Canceled, codes.Canceled},
+	{context.DeadlineExceeded, codes.DeadlineExceeded},
+}
+
+// toStatus переводит доменные ошибки сервиса в коды gRPC.
+func toStatus(err error) error {
+	for _, sc := range statusCodes {
+		if errors.Is(err, sc.err) {
+			return status.Error(sc.code, err.Error())
+		}
+	}
+	logger.GetLogger().WithError(err).Error("gRPC: internal error")
+	return status.Error(codes.Internal, "internal error")
+}
+
+func validate(obj any) error {
+	if errs := validation.Struct(obj); len(errs) > 0 {
+		return status.Error(codes.InvalidArgument, errs[0])
+	}
+	return nil
+}
+
+func parseUserID(s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "invalid user_id")
+	}
+	return id, nil
+}
+
+func expectedVersion(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+	version := int(*v)
+	return &version
+}
+
+func encodePageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodePageToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, errors.New("invalid offset")
+	}
+	return offset, nil
+}
+
+func loggingUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logCall(info.FullMethod, start, err)
+	return resp, err
+}
+
+// timeZoneMetadata — ключ метаданных с часовым поясом запроса, аналог заголовка X-Time-Zone.
+const timeZoneMetadata = "x-time-zone"
+
+// timeZoneUnaryInterceptor сохраняет в контексте часовой пояс из метаданных x-time-zone.
+func timeZoneUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := withTimeZone(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// timeZoneStreamInterceptor — то же для потоковых вызовов: контекст потока содержит
+// часовой пояс из метаданных x-time-zone.
+func timeZoneStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := withTimeZone(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// withTimeZone добавляет в ctx часовой пояс из метаданных x-time-zone, если он задан.
+func withTimeZone(ctx context.Context) (context.Context, error) {
+	if values := metadata.ValueFromIncomingContext(ctx, timeZoneMetadata); len(values) > 0 && values[0] != "" {
+		loc, err := dates.LoadLocation(values[0])
+		if err != nil {
+			return ctx, status.Error(codes.InvalidArgument, err.Error())
+		}
+		ctx = dates.WithLocation(ctx, loc)
+	}
+	return ctx, nil
+}
+
+// contextStream подменяет контекст потока.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func loggingStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	logCall(info.FullMethod, start, err)
+	return err
+}
+
+func logCall(method string, start time.Time, err error) {
+	logger.GetLogger().WithFields(logrus.Fields{
+		"method":  method,
+		"code":    status.Code(err).String(),
+		"latency": time.Since(start),
+	}).Info("grpc call completed")
+}
@@ -0,0 +1,66 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/shenikar/subscription-service/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{err: service.ErrNotFound, code: codes.NotFound},
+		{err: service.ErrPreconditionFailed, code: codes.Aborted},
+		{err: fmt.Errorf("%w: overlapping subscriptions 1", service.ErrOverlap), code: codes.AlreadyExists},
+		{err: service.ErrAlreadyPaused, code: codes.FailedPrecondition},
+		{err: service.ErrNotPaused, code: codes.FailedPrecondition},
+		{err: service.ErrAlreadyEnded, code: codes.FailedPrecondition},
+		{err: service.ErrNotStarted, code: codes.FailedPrecondition},
+		{err: fmt.Errorf("%w: price is required", service.ErrInvalidInput), code: codes.InvalidArgument},
+		{err: service.ErrServiceNotFound, code: codes.NotFound},
+		{err: fmt.Errorf("%w: \"netflix\" is used by service 1 (Netflix)", service.ErrServiceExists), code: codes.AlreadyExists},
+		{err: service.ErrServiceInUse, code: codes.FailedPrecondition},
+		{err: service.ErrUserNotFound, code: codes.NotFound},
+		{err: service.ErrUserExists, code: codes.AlreadyExists},
+		{err: service.ErrUserInUse, code: codes.FailedPrecondition},
+		{err: service.ErrTagNotFound, code: codes.NotFound},
+		{err: service.ErrTagExists, code: codes.AlreadyExists},
+		{err: service.ErrMemberNotFound, code: codes.NotFound},
+		{err: service.ErrUserIsMember, code: codes.FailedPrecondition},
+		{err: service.ErrUserInOrganisation, code: codes.FailedPrecondition},
+		{err: service.ErrOrganisationNotFound, code: codes.NotFound},
+		{err: service.ErrOrganisationExists, code: codes.AlreadyExists},
+		{err: service.ErrOrganisationInUse, code: codes.FailedPrecondition},
+		{err: service.ErrUnitNotFound, code: codes.NotFound},
+		{err: service.ErrUnitExists, code: codes.AlreadyExists},
+		{err: service.ErrUnitInUse, code: codes.FailedPrecondition},
+		{err: service.ErrCostCentreNotFound, code: codes.NotFound},
+		{err: service.ErrCostCentreExists, code: codes.AlreadyExists},
+		{err: service.ErrCostCentreInUse, code: codes.FailedPrecondition},
+		{err: service.ErrOrgMemberNotFound, code: codes.NotFound},
+		{err: service.ErrLastOwner, code: codes.FailedPrecondition},
+		{err: context.Canceled, code: codes.Canceled},
+		{err: fmt.Errorf("list failed: %w", context.DeadlineExceeded), code: codes.DeadlineExceeded},
+		{err: errors.New("connection refused"), code: codes.Internal},
+	}
+	for _, tt := range tests {
+		st, ok := status.FromError(toStatus(tt.err))
+		if !ok {
+			t.Fatalf("toStatus(%v) is not a gRPC status", tt.err)
+		}
+		if st.Code() != tt.code {
+			t.Errorf("toStatus(%v) code = %v, want %v", tt.err, st.Code(), tt.code)
+		}
+	}
+
+	if st, _ := status.FromError(toStatus(errors.New("connection refused"))); st.Message() != "internal error" {
+		t.Errorf("internal error message = %q, want it hidden", st.Message())
+	}
+}
@@ -0,0 +1,76 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shenikar/subscription-service/internal/dates"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s fakeStream) Context() context.Context { return s.ctx }
+
+// TestTimeZoneInterceptors проверяет, что унарные и потоковые вызовы одинаково получают
+// часовой пояс из метаданных x-time-zone.
+func TestTimeZoneInterceptors(t *testing.T) {
+	tests := []struct {
+		name     string
+		zone     string
+		wantZone string
+		wantCode codes.Code
+	}{
+		{name: "no metadata", wantZone: ""},
+		{name: "berlin", zone: "Europe/Berlin", wantZone: "Europe/Berlin"},
+		{name: "new york", zone: "America/New_York", wantZone: "America/New_York"},
+		{name: "unknown zone", zone: "Mars/Olympus", wantCode: codes.InvalidArgument},
+		{name: "local is rejected", zone: "Local", wantCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.zone != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(timeZoneMetadata, tt.zone))
+		}
+		zoneOf := func(ctx context.Context) string {
+			if loc, ok := dates.LocationFrom(ctx); ok {
+				return loc.String()
+			}
+			return ""
+		}
+
+		t.Run(tt.name+"/unary", func(t *testing.T) {
+			var got string
+			_, err := timeZoneUnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+				got = zoneOf(ctx)
+				return nil, nil
+			})
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code = %s, want %s", code, tt.wantCode)
+			}
+			if err == nil && got != tt.wantZone {
+				t.Errorf("zone = %q, want %q", got, tt.wantZone)
+			}
+		})
+
+		t.Run(tt.name+"/stream", func(t *testing.T) {
+			var got string
+			err := timeZoneStreamInterceptor(nil, fakeStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(_ any, ss grpc.ServerStream) error {
+				got = zoneOf(ss.Context())
+				return nil
+			})
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code = %s, want %s", code, tt.wantCode)
+			}
+			if err == nil && got != tt.wantZone {
+				t.Errorf("zone = %q, want %q", got, tt.wantZone)
+			}
+		})
+	}
+}
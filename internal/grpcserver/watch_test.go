@@ -0,0 +1,65 @@
+package grpcserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/event"
+	"github.com/shenikar/subscription-service/internal/model"
+)
+
+// TestToProtoEvent проверяет, какие события брокера попадают в Watch и к какому
+// пользователю они относятся.
+func TestToProtoEvent(t *testing.T) {
+	userID := uuid.New()
+	month := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	sub := &model.Subscription{ID: 7, UserID: userID, ServiceName: "Netflix", Price: 400, StartDate: month}
+	alert := &model.BudgetAlert{BudgetID: 3, UserID: userID, Month: month, Threshold: 80, Spent: 1200, Limit: 1500}
+
+	tests := []struct {
+		name   string
+		event  event.Event
+		want   bool
+		budget bool
+	}{
+		{name: "subscription", event: event.Event{Type: event.SubscriptionUpdated, SubscriptionID: 7, Subscription: sub}, want: true},
+		{name: "trial ending", event: event.Event{Type: event.SubscriptionTrialEnding, SubscriptionID: 7, Subscription: sub}, want: true},
+		{name: "cancelled", event: event.Event{Type: event.SubscriptionCancelled, SubscriptionID: 7, Subscription: sub}, want: true},
+		{name: "subscription without state", event: event.Event{Type: event.SubscriptionDeleted, SubscriptionID: 7}},
+		{name: "budget threshold", event: event.Event{Type: event.BudgetThresholdReached, Budget: alert}, want: true, budget: true},
+		{name: "budget without alert", event: event.Event{Type: event.BudgetThresholdReached}},
+		{name: "unknown", event: event.Event{Type: "tag.merged"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, owner, ok := toProtoEvent(tt.event)
+			if ok != tt.want {
+				t.Fatalf("ok = %v, want %v", ok, tt.want)
+			}
+			if !ok {
+				return
+			}
+			if owner != userID {
+				t.Errorf("owner = %s, want %s", owner, userID)
+			}
+			if msg.GetType() != tt.event.Type {
+				t.Errorf("type = %q, want %q", msg.GetType(), tt.event.Type)
+			}
+			if !tt.budget {
+				if msg.GetSubscription().GetId() != sub.ID || msg.GetBudget() != nil {
+					t.Errorf("event = %v, want subscription %d without budget", msg, sub.ID)
+				}
+				return
+			}
+			b := msg.GetBudget()
+			if b.GetBudgetId() != 3 || b.GetUserId() != userID.String() || b.GetMonth() != "07-2025" ||
+				b.GetThreshold() != 80 || b.GetSpent() != 1200 || b.GetLimit() != 1500 {
+				t.Errorf("budget = %v", b)
+			}
+			if msg.GetSubscription() != nil {
+				t.Errorf("budget event has subscription %v", msg.GetSubscription())
+			}
+		})
+	}
+}
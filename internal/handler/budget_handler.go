@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/mapper"
+	"github.com/shenikar/subscription-service/internal/service"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+type BudgetHandler struct {
+	budgets *service.BudgetService
+}
+
+func NewBudgetHandler(budgets *service.BudgetService) *BudgetHandler {
+	return &BudgetHandler{budgets: budgets}
+}
+
+// Create godoc
+// @Summary Создать бюджет
+// @Description Создать месячный бюджет пользователя на все подписки, один сервис или категорию. При достижении 80% и 100% бюджета отправляется событие budget.threshold_reached
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param budget body api.BudgetRequest true "Данные бюджета"
+// @Success 201 {object} api.BudgetResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /budgets [post]
+func (h *BudgetHandler) Create(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var req api.BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("CreateBudget: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	budget, err := h.budgets.Create(c.Request.Context(), req)
+	if err != nil {
+		writeBudgetError(c, "CreateBudget", err)
+		return
+	}
+
+	log.WithField("id", budget.ID).Info("CreateBudget: budget created")
+	c.JSON(http.StatusCreated, mapper.ToBudgetResponse(budget))
+}
+
+// GetByID godoc
+// @Summary Получить бюджет по ID
+// @Tags budgets
+// @Produce json
+// @Param id path int true "ID бюджета"
+// @Success 200 {object} api.BudgetResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /budgets/{id} [get]
+func (h *BudgetHandler) GetByID(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn("GetBudget: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	budget, err := h.budgets.GetByID(c.Request.Context(), id)
+	if err != nil {
+		log.WithError(err).WithField("id", id).Error("GetBudget: failed to get budget")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get budget"})
+		return
+	}
+	if budget == nil {
+		log.WithField("id", id).Warn("GetBudget: budget not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "budget not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, mapper.ToBudgetResponse(*budget))
+}
+
+// GetAll godoc
+// @Summary Получить список бюджетов
+// @Tags budgets
+// @Produce json
+// @Param user_id query string false "UUID пользователя"
+// @Param limit query int false "Количество записей"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} api.BudgetResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /budgets [get]
+func (h *BudgetHandler) GetAll(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var filter dto.ListBudgetsFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		log.WithError(err).Warn("ListBudgets: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	budgets, err := h.budgets.List(c.Request.Context(), filter)
+	if err != nil {
+		writeBudgetError(c, "ListBudgets", err)
+		return
+	}
+
+	res := make([]api.BudgetResponse, 0, len(budgets))
+	for _, budget := range budgets {
+		res = append(res, mapper.ToBudgetResponse(budget))
+	}
+
+	log.WithField("count", len(res)).Info("ListBudgets: budgets listed")
+	c.JSON(http.StatusOK, res)
+}
+
+// Update godoc
+// @Summary Заменить бюджет
+// @Description Полностью заменить бюджет. Если новый лимит уже достигнут, отправляется событие budget.threshold_reached
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path int true "ID бюджета"
+// @Param budget body api.BudgetRequest true "Данные бюджета"
+// @Success 200 {object} api.BudgetResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /budgets/{id} [put]
+func (h *BudgetHandler) Update(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn("UpdateBudget: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req api.BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("UpdateBudget: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	budget, err := h.budgets.Update(c.Request.Context(), id, req)
+	if err != nil {
+		writeBudgetError(c, "UpdateBudget", err)
+		return
+	}
+
+	log.WithField("id", id).Info("UpdateBudget: budget updated")
+	c.JSON(http.StatusOK, mapper.ToBudgetResponse(budget))
+}
+
+// Delete godoc
+// @Summary Удалить бюджет
+// @Tags budgets
+// @Param id path int true "ID бюджета"
+// @Success 204
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /budgets/{id} [delete]
+func (h *BudgetHandler) Delete(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn("DeleteBudget: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.budgets.Delete(c.Request.Context(), id); err != nil {
+		writeBudgetError(c, "DeleteBudget", err)
+		return
+	}
+
+	log.WithField("id", id).Info("DeleteBudget: budget deleted")
+	c.Status(http.StatusNoContent)
+}
+
+// Status godoc
+// @Summary Использование бюджета
+// @Description Расходы за месяц по той же логике, что и /subscriptions/total, остаток, процент использования и отправленные за месяц уведомления
+// @Tags budgets
+// @Produce json
+// @Param id path int true "ID бюджета"
+// @Param month query string false "Любая дата месяца (MM-YYYY, YYYY-MM, YYYY-MM-DD или RFC 3339), по умолчанию текущий месяц"
+// @Param tz query string false "Часовой пояс IANA, например Europe/Moscow; по умолчанию часовой пояс пользователя или сервиса"
+// @Param X-Time-Zone header string false "Часовой пояс IANA, если не задан tz"
+// @Success 200 {object} api.BudgetStatusResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /budgets/{id}/status [get]
+func (h *BudgetHandler) Status(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn("BudgetStatus: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var opts dto.BudgetStatusOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		log.WithError(err).Warn("BudgetStatus: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := h.budgets.Status(c.Request.Context(), id, opts.Month)
+	if err != nil {
+		writeBudgetError(c, "BudgetStatus", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+func writeBudgetError(c *gin.Context, op string, err error) {
+	log := logger.GetLogger()
+
+	switch {
+	case errors.Is(err, service.ErrBudgetNotFound):
+		log.Warn(op + ": budget not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "budget not found"})
+	case errors.Is(err, service.ErrInvalidInput):
+		log.WithError(err).Warn(op + ": invalid budget data")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.WithError(err).Error(op + ": failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process budget"})
+	}
+}
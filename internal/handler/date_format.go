@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/subscription-service/internal/dates"
+)
+
+// dateFormatHeader выбирает формат дат в ответе, если не задан параметр date_format.
+const dateFormatHeader = "X-Date-Format"
+
+// dateFormat возвращает формат дат подписок в ответе: параметр date_format важнее
+// заголовка X-Date-Format, без обоих используется dates.Default.
+func dateFormat(c *gin.Context) (string, error) {
+	name := c.Query("date_format")
+	if name == "" {
+		name = c.GetHeader(dateFormatHeader)
+	}
+	return dates.ParseFormat(name)
+}
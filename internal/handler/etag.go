@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errIfMatchRequired = errors.New("If-Match header is required")
+	errInvalidIfMatch  = errors.New("invalid If-Match header")
+	errWeakIfMatch     = errors.New("If-Match requires a strong ETag")
+)
+
+func etag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", etag(version))
+}
+
+// ifMatchVersion возвращает версию из заголовка If-Match. Для "*" и отсутствующего
+// заголовка возвращается nil, если заголовок не обязателен. If-Match сравнивает ETag
+// строго (RFC 9110, 13.1.1), поэтому слабый ETag W/"n" не совпадает ни с одной версией.
+func (h *SubscriptionHandler) ifMatchVersion(c *gin.Context) (*int, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		if h.requireIfMatch {
+			return nil, errIfMatchRequired
+		}
+		return nil, nil
+	}
+	if header == "*" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(header, "W/") {
+		return nil, errWeakIfMatch
+	}
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil {
+		return nil, errInvalidIfMatch
+	}
+	return &version, nil
+}
+
+func ifMatchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errIfMatchRequired):
+		return http.StatusPreconditionRequired
+	case errors.Is(err, errWeakIfMatch):
+		return http.StatusPreconditionFailed
+	}
+	return http.StatusBadRequest
+}
+
+// noneMatch сообщает, совпадает ли версия с заголовком If-None-Match. If-None-Match
+// сравнивает ETag слабо, поэтому W/"n" совпадает с версией n.
+func noneMatch(c *gin.Context, version int) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
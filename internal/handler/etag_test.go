@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/config"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/testutil"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+func testContext(header, value string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	if value != "" {
+		c.Request.Header.Set(header, value)
+	}
+	return c
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		header  string
+		require bool
+		want    *int
+		err     error
+	}{
+		{header: ""},
+		{header: "", require: true, err: errIfMatchRequired},
+		{header: "*"},
+		{header: `"3"`, want: intPtr(3)},
+		{header: ` "3" `, want: intPtr(3)},
+		{header: `W/"3"`, err: errWeakIfMatch},
+		{header: `"abc"`, err: errInvalidIfMatch},
+	}
+	for _, tt := range tests {
+		h := &SubscriptionHandler{requireIfMatch: tt.require}
+		got, err := h.ifMatchVersion(testContext("If-Match", tt.header))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ifMatchVersion(%q) error = %v, want %v", tt.header, err, tt.err)
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+			t.Errorf("ifMatchVersion(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestNoneMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: false},
+		{header: `"3"`, want: true},
+		{header: `W/"3"`, want: true},
+		{header: `"2", W/"3"`, want: true},
+		{header: "*", want: true},
+		{header: `"2"`, want: false},
+	}
+	for _, tt := range tests {
+		if got := noneMatch(testContext("If-None-Match", tt.header), 3); got != tt.want {
+			t.Errorf("noneMatch(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+// Проверка версии выполняется после проверки существования: If-Match для
+// отсутствующей подписки даёт 404, а не 412.
+func TestIfMatchStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	stores := testutil.NewStores(model.Subscription{
+		ServiceName: "Netflix",
+		Price:       400,
+		UserID:      uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+		StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+	})
+	h := NewSubscriptionHandler(stores.SubscriptionService(), config.Config{})
+	r := gin.New()
+	r.PUT("/subscriptions/:id", h.Update)
+	r.PATCH("/subscriptions/:id", h.Patch)
+	r.DELETE("/subscriptions/:id", h.Delete)
+
+	put := `{"service_name": "Netflix", "price": 500, "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba", "start_date": "07-2025"}`
+	patch := `{"price": 600}`
+	tests := []struct {
+		method, path, body, ifMatch string
+		status                      int
+	}{
+		{method: http.MethodPut, path: "/subscriptions/42", body: put, ifMatch: `"1"`, status: http.StatusNotFound},
+		{method: http.MethodPatch, path: "/subscriptions/42", body: patch, ifMatch: `"1"`, status: http.StatusNotFound},
+		{method: http.MethodDelete, path: "/subscriptions/42", ifMatch: `"1"`, status: http.StatusNotFound},
+		{method: http.MethodPut, path: "/subscriptions/1", body: put, ifMatch: `"2"`, status: http.StatusPreconditionFailed},
+		{method: http.MethodPatch, path: "/subscriptions/1", body: patch, ifMatch: `"2"`, status: http.StatusPreconditionFailed},
+		{method: http.MethodDelete, path: "/subscriptions/1", ifMatch: `"2"`, status: http.StatusPreconditionFailed},
+		{method: http.MethodPut, path: "/subscriptions/1", body: put, ifMatch: `W/"1"`, status: http.StatusPreconditionFailed},
+		{method: http.MethodPatch, path: "/subscriptions/1", body: patch, ifMatch: `W/"1"`, status: http.StatusPreconditionFailed},
+		{method: http.MethodDelete, path: "/subscriptions/1", ifMatch: `W/"1"`, status: http.StatusPreconditionFailed},
+		{method: http.MethodPut, path: "/subscriptions/1", body: put, ifMatch: `"1"`, status: http.StatusOK},
+		{method: http.MethodPatch, path: "/subscriptions/1", body: patch, ifMatch: `"2"`, status: http.StatusOK},
+		{method: http.MethodDelete, path: "/subscriptions/1", ifMatch: `"3"`, status: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.method == http.MethodPatch {
+			req.Header.Set("Content-Type", api.PatchTypeMerge)
+		}
+		req.Header.Set("If-Match", tt.ifMatch)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s %s with If-Match %s: status = %d, want %d; body %s", tt.method, tt.path, tt.ifMatch, w.Code, tt.status, w.Body)
+		}
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
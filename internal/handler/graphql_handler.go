@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/subscription-service/internal/gql"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+type GraphQLHandler struct {
+	executor *gql.Executor
+}
+
+func NewGraphQLHandler(executor *gql.Executor) *GraphQLHandler {
+	return &GraphQLHandler{executor: executor}
+}
+
+// Query godoc
+// @Summary GraphQL-запрос
+// @Description Выполнить GraphQL-запрос или мутацию. Схема содержит типы Subscription, UserSummary и ServiceSummary;
+// @Description глубина и сложность запроса ограничены настройками GRAPHQL_MAX_DEPTH и GRAPHQL_MAX_COMPLEXITY.
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Param request body api.GraphQLRequest true "GraphQL-запрос"
+// @Success 200 {object} api.GraphQLResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Router /graphql [post]
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var req api.GraphQLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("GraphQL: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := h.executor.Execute(c.Request.Context(), req)
+	if res.HasErrors() {
+		log.WithFields(logrus.Fields{
+			"operation": req.OperationName,
+			"errors":    len(res.Errors),
+		}).Warn("GraphQL: request completed with errors")
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/mapper"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/service"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+// Members godoc
+// @Summary Участники совместной подписки
+// @Description Правило разделения стоимости и доли владельца и участников в цене подписки за текущий месяц владельца
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {object} api.SharingResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /subscriptions/{id}/members [get]
+func (h *SubscriptionHandler) Members(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn("Members: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	sub, month, err := h.service.Members(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			log.WithField("id", id).Warn("Members: subscription not found")
+			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+			return
+		}
+		log.WithError(err).WithField("id", id).Error("Members: failed to get subscription")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get subscription"})
+		return
+	}
+
+	setETag(c, sub.Version)
+	c.JSON(http.StatusOK, mapper.ToSharingResponse(sub, month))
+}
+
+// SetMembers godoc
+// @Summary Задать участников совместной подписки
+// @Description Заменить правило разделения стоимости и всех участников. Подписку оплачивает владелец, участники должны ему свои доли: поровну (equal), в процентах (percentage, в сумме не больше 100) или фиксированными суммами (fixed, в сумме не больше цены). Пустой members делает подписку обычной
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param members body api.SetMembersRequest true "Правило разделения и участники"
+// @Param If-Match header string false "ETag версии, которую клиент изменяет"
+// @Success 200 {object} api.SharingResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 412 {object} api.ErrorResponse
+// @Failure 428 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /subscriptions/{id}/members [put]
+func (h *SubscriptionHandler) SetMembers(c *gin.Context) {
+	var req api.SetMembersRequest
+	h.changeMembers(c, "SetMembers", &req, func(ctx context.Context, id int64, ifMatch *int) (model.Subscription, time.Time, error) {
+		return h.service.SetMembers(ctx, id, req, ifMatch)
+	})
+}
+
+// AddMember godoc
+// @Summary Добавить участника совместной подписки
+// @Description Добавить участника по текущему правилу разделения или изменить условия существующего участника
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param member body api.MemberRequest true "Участник"
+// @Param If-Match header string false "ETag версии, которую клиент изменяет"
+// @Success 200 {object} api.SharingResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 412 {object} api.ErrorResponse
+// @Failure 428 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /subscriptions/{id}/members [post]
+func (h *SubscriptionHandler) AddMember(c *gin.Context) {
+	var req api.MemberRequest
+	h.changeMembers(c, "AddMember", &req, func(ctx context.Context, id int64, ifMatch *int) (model.Subscription, time.Time, error) {
+		return h.service.AddMember(ctx, id, req, ifMatch)
+	})
+}
+
+// RemoveMember godoc
+// @Summary Исключить участника совместной подписки
+// @Description Исключить участника: его доля снова приходится на владельца
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param user_id path string true "UUID участника"
+// @Param If-Match header string false "ETag версии, которую клиент изменяет"
+// @Success 200 {object} api.SharingResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 412 {object} api.ErrorResponse
+// @Failure 428 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /subscriptions/{id}/members/{user_id} [delete]
+func (h *SubscriptionHandler) RemoveMember(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		logger.GetLogger().WithError(err).Warn("RemoveMember: invalid user_id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		return
+	}
+	h.changeMembers(c, "RemoveMember", nil, func(ctx context.Context, id int64, ifMatch *int) (model.Subscription, time.Time, error) {
+		return h.service.RemoveMember(ctx, id, userID, ifMatch)
+	})
+}
+
+// changeMembers разбирает тело запроса в req, если он задан, и выполняет apply.
+func (h *SubscriptionHandler) changeMembers(c *gin.Context, op string, req any, apply func(context.Context, int64, *int) (model.Subscription, time.Time, error)) {
+	log := logger.GetLogger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn(op + ": invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ifMatch, err := h.ifMatchVersion(c)
+	if err != nil {
+		log.WithError(err).Warn(op + ": invalid If-Match header")
+		c.JSON(ifMatchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if req != nil {
+		if err := c.ShouldBindJSON(req); err != nil {
+			log.WithError(err).Warn(op + ": invalid request payload")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	sub, month, err := apply(c.Request.Context(), id, ifMatch)
+	if err != nil {
+		writeUpdateError(c, op, id, err)
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"id":      id,
+		"members": len(sub.Members),
+		"version": sub.Version,
+	}).Info(op + ": subscription members changed")
+	setETag(c, sub.Version)
+	c.JSON(http.StatusOK, mapper.ToSharingResponse(sub, month))
+}
@@ -0,0 +1,665 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/mapper"
+	"github.com/shenikar/subscription-service/internal/service"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+type OrganisationHandler struct {
+	orgs *service.OrganisationService
+}
+
+func NewOrganisationHandler(orgs *service.OrganisationService) *OrganisationHandler {
+	return &OrganisationHandler{orgs: orgs}
+}
+
+// Create godoc
+// @Summary Создать организацию
+// @Description Создать организацию; owner_id становится её владельцем
+// @Tags organisations
+// @Accept json
+// @Produce json
+// @Param organisation body api.CreateOrganisationRequest true "Название и владелец"
+// @Success 201 {object} api.OrganisationResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations [post]
+func (h *OrganisationHandler) Create(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var req api.CreateOrganisationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("CreateOrganisation: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.orgs.Create(c.Request.Context(), req)
+	if err != nil {
+		writeOrganisationError(c, "CreateOrganisation", err)
+		return
+	}
+
+	log.WithField("id", org.ID).Info("CreateOrganisation: organisation created")
+	c.JSON(http.StatusCreated, mapper.ToOrganisationResponse(org))
+}
+
+// GetAll godoc
+// @Summary Получить организации
+// @Tags organisations
+// @Produce json
+// @Param user_id query string false "UUID пользователя: только организации, в которых он состоит"
+// @Param limit query int false "Количество записей"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} api.OrganisationResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations [get]
+func (h *OrganisationHandler) GetAll(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var filter dto.ListOrganisationsFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		log.WithError(err).Warn("ListOrganisations: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orgs, err := h.orgs.List(c.Request.Context(), filter)
+	if err != nil {
+		writeOrganisationError(c, "ListOrganisations", err)
+		return
+	}
+
+	res := make([]api.OrganisationResponse, 0, len(orgs))
+	for _, org := range orgs {
+		res = append(res, mapper.ToOrganisationResponse(org))
+	}
+
+	log.WithField("count", len(res)).Info("ListOrganisations: organisations listed")
+	c.JSON(http.StatusOK, res)
+}
+
+// GetByID godoc
+// @Summary Получить организацию по ID
+// @Tags organisations
+// @Produce json
+// @Param id path int true "ID организации"
+// @Success 200 {object} api.OrganisationResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations/{id} [get]
+func (h *OrganisationHandler) GetByID(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, ok := pathID(c, "GetOrganisation", "id")
+	if !ok {
+		return
+	}
+
+	org, err := h.orgs.GetByID(c.Request.Context(), id)
+	if err != nil {
+		log.WithError(err).WithField("id", id).Error("GetOrganisation: failed to get organisation")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get organisation"})
+		return
+	}
+	if org == nil {
+		log.WithField("id", id).Warn("GetOrganisation: organisation not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "organisation not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, mapper.ToOrganisationResponse(*org))
+}
+
+// Update godoc
+// @Summary Переименовать организацию
+// @Tags organisations
+// @Accept json
+// @Produce json
+// @Param id path int true "ID организации"
+// @Param organisation body api.OrganisationRequest true "Новое название"
+// @Success 200 {object} api.OrganisationResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations/{id} [put]
+func (h *OrganisationHandler) Update(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, ok := pathID(c, "UpdateOrganisation", "id")
+	if !ok {
+		return
+	}
+
+	var req api.OrganisationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("UpdateOrganisation: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.orgs.Update(c.Request.Context(), id, req)
+	if err != nil {
+		writeOrganisationError(c, "UpdateOrganisation", err)
+		return
+	}
+
+	log.WithField("id", id).Info("UpdateOrganisation: organisation updated")
+	c.JSON(http.StatusOK, mapper.ToOrganisationResponse(org))
+}
+
+// Delete godoc
+// @Summary Удалить организацию
+// @Description Удалить организацию с подразделениями, центрами затрат и участниками. Если к её центрам затрат относили подписки, возвращается 409
+// @Tags organisations
+// @Param id path int true "ID организации"
+// @Success 204
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations/{id} [delete]
+func (h *OrganisationHandler) Delete(c *gin.Context) {
+	id, ok := pathID(c, "DeleteOrganisation", "id")
+	if !ok {
+		return
+	}
+
+	if err := h.orgs.Delete(c.Request.Context(), id); err != nil {
+		writeOrganisationError(c, "DeleteOrganisation", err)
+		return
+	}
+
+	logger.GetLogger().WithField("id", id).Info("DeleteOrganisation: organisation deleted")
+	c.Status(http.StatusNoContent)
+}
+
+// Units godoc
+// @Summary Подразделения организации
+// @Description Отделы и команды организации: отделы перед командами, по названию
+// @Tags organisations
+// @Produce json
+// @Param id path int true "ID организации"
+// @Success 200 {array} api.OrgUnitResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations/{id}/units [get]
+func (h *OrganisationHandler) Units(c *gin.Context) {
+	orgID, ok := pathID(c, "ListUnits", "id")
+	if !ok {
+		return
+	}
+
+	units, err := h.orgs.Units(c.Request.Context(), orgID)
+	if err != nil {
+		writeOrganisationError(c, "ListUnits", err)
+		return
+	}
+
+	res := make([]api.OrgUnitResponse, 0, len(units))
+	for _, unit := range units {
+		res = append(res, mapper.ToUnitResponse(unit))
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// CreateUnit godoc
+// @Summary Создать подразделение
+// @Description Создать отдел (department) или команду (team). Команда входит в отдел parent_id или, без него, в организацию
+// @Tags organisations
+// @Accept json
+// @Produce json
+// @Param id path int true "ID организации"
+// @Param unit body api.OrgUnitRequest true "Подразделение"
+// @Success 201 {object} api.OrgUnitResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations/{id}/units [post]
+func (h *OrganisationHandler) CreateUnit(c *gin.Context) {
+	log := logger.GetLogger()
+
+	orgID, ok := pathID(c, "CreateUnit", "id")
+	if !ok {
+		return
+	}
+
+	var req api.OrgUnitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("CreateUnit: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	unit, err := h.orgs.CreateUnit(c.Request.Context(), mapper.ToModelUnit(orgID, 0, req))
+	if err != nil {
+		writeOrganisationError(c, "CreateUnit", err)
+		return
+	}
+
+	log.WithField("id", unit.ID).Info("CreateUnit: unit created")
+	c.JSON(http.StatusCreated, mapper.ToUnitResponse(unit))
+}
+
+// UpdateUnit godoc
+// @Summary Изменить подразделение
+// @Description Полностью заменить подразделение. Отдел с командами нельзя превратить в команду
+// @Tags organisations
+// @Accept json
+// @Produce json
+// @Param id path int true "ID организации"
+// @Param unit_id path int true "ID подразделения"
+// @Param unit body api.OrgUnitRequest true "Подразделение"
+// @Success 200 {object} api.OrgUnitResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations/{id}/units/{unit_id} [put]
+func (h *OrganisationHandler) UpdateUnit(c *gin.Context) {
+	log := logger.GetLogger()
+
+	orgID, ok := pathID(c, "UpdateUnit", "id")
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "UpdateUnit", "unit_id")
+	if !ok {
+		return
+	}
+
+	var req api.OrgUnitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("UpdateUnit: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	unit, err := h.orgs.UpdateUnit(c.Request.Context(), mapper.ToModelUnit(orgID, id, req))
+	if err != nil {
+		writeOrganisationError(c, "UpdateUnit", err)
+		return
+	}
+
+	log.WithField("id", id).Info("UpdateUnit: unit updated")
+	c.JSON(http.StatusOK, mapper.ToUnitResponse(unit))
+}
+
+// DeleteUnit godoc
+// @Summary Удалить подразделение
+// @Description Удалить подразделение без команд и центров затрат. Его участники остаются в организации без подразделения
+// @Tags organisations
+// @Param id path int true "ID организации"
+// @Param unit_id path int true "ID подразделения"
+// @Success 204
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations/{id}/units/{unit_id} [delete]
+func (h *OrganisationHandler) DeleteUnit(c *gin.Context) {
+	orgID, ok := pathID(c, "DeleteUnit", "id")
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "DeleteUnit", "unit_id")
+	if !ok {
+		return
+	}
+
+	if err := h.orgs.DeleteUnit(c.Request.Context(), orgID, id); err != nil {
+		writeOrganisationError(c, "DeleteUnit", err)
+		return
+	}
+
+	logger.GetLogger().WithField("id", id).Info("DeleteUnit: unit deleted")
+	c.Status(http.StatusNoContent)
+}
+
+// CostCentres godoc
+// @Summary Центры затрат организации
+// @Tags organisations
+// @Produce json
+// @Param id path int true "ID организации"
+// @Success 200 {array} api.CostCentreResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations/{id}/cost-centres [get]
+func (h *OrganisationHandler) CostCentres(c *gin.Context) {
+	orgID, ok := pathID(c, "ListCostCentres", "id")
+	if !ok {
+		return
+	}
+
+	centres, err := h.orgs.CostCentres(c.Request.Context(), orgID)
+	if err != nil {
+		writeOrganisationError(c, "ListCostCentres", err)
+		return
+	}
+
+	res := make([]api.CostCentreResponse, 0, len(centres))
+	for _, cc := range centres {
+		res = append(res, mapper.ToCostCentreResponse(cc))
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// CreateCostCentre godoc
+// @Summary Создать центр затрат
+// @Description Создать центр затрат подразделения unit_id или, без него, организации целиком. Код уникален в организации
+// @Tags organisations
+// @Accept json
+// @Produce json
+// @Param id path int true "ID организации"
+// @Param cost_centre body api.CostCentreRequest true "Центр затрат"
+// @Success 201 {object} api.CostCentreResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations/{id}/cost-centres [post]
+func (h *OrganisationHandler) CreateCostCentre(c *gin.Context) {
+	log := logger.GetLogger()
+
+	orgID, ok := pathID(c, "CreateCostCentre", "id")
+	if !ok {
+		return
+	}
+
+	var req api.CostCentreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("CreateCostCentre: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cc, err := h.orgs.CreateCostCentre(c.Request.Context(), mapper.ToModelCostCentre(orgID, 0, req))
+	if err != nil {
+		writeOrganisationError(c, "CreateCostCentre", err)
+		return
+	}
+
+	log.WithField("id", cc.ID).Info("CreateCostCentre: cost centre created")
+	c.JSON(http.StatusCreated, mapper.ToCostCentreResponse(cc))
+}
+
+// UpdateCostCentre godoc
+// @Summary Изменить центр затрат
+// @Description Полностью заменить центр затрат. Перенос в другое подразделение меняет и прошлые отчёты: расходы центра затрат всегда сворачиваются по текущей иерархии
+// @Tags organisations
+// @Accept json
+// @Produce json
+// @Param id path int true "ID организации"
+// @Param cc_id path int true "ID центра затрат"
+// @Param cost_centre body api.CostCentreRequest true "Центр затрат"
+// @Success 200 {object} api.CostCentreResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations/{id}/cost-centres/{cc_id} [put]
+func (h *OrganisationHandler) UpdateCostCentre(c *gin.Context) {
+	log := logger.GetLogger()
+
+	orgID, ok := pathID(c, "UpdateCostCentre", "id")
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "UpdateCostCentre", "cc_id")
+	if !ok {
+		return
+	}
+
+	var req api.CostCentreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("UpdateCostCentre: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cc, err := h.orgs.UpdateCostCentre(c.Request.Context(), mapper.ToModelCostCentre(orgID, id, req))
+	if err != nil {
+		writeOrganisationError(c, "UpdateCostCentre", err)
+		return
+	}
+
+	log.WithField("id", id).Info("UpdateCostCentre: cost centre updated")
+	c.JSON(http.StatusOK, mapper.ToCostCentreResponse(cc))
+}
+
+// DeleteCostCentre godoc
+// @Summary Удалить центр затрат
+// @Description Удалить центр затрат, к которому никогда не относили подписки: история распределения должна сохраниться
+// @Tags organisations
+// @Param id path int true "ID организации"
+// @Param cc_id path int true "ID центра затрат"
+// @Success 204
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations/{id}/cost-centres/{cc_id} [delete]
+func (h *OrganisationHandler) DeleteCostCentre(c *gin.Context) {
+	orgID, ok := pathID(c, "DeleteCostCentre", "id")
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "DeleteCostCentre", "cc_id")
+	if !ok {
+		return
+	}
+
+	if err := h.orgs.DeleteCostCentre(c.Request.Context(), orgID, id); err != nil {
+		writeOrganisationError(c, "DeleteCostCentre", err)
+		return
+	}
+
+	logger.GetLogger().WithField("id", id).Info("DeleteCostCentre: cost centre deleted")
+	c.Status(http.StatusNoContent)
+}
+
+// Members godoc
+// @Summary Участники организации
+// @Description Участники организации с ролями и подразделениями в порядке добавления
+// @Tags organisations
+// @Produce json
+// @Param id path int true "ID организации"
+// @Success 200 {array} api.OrgMemberResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations/{id}/members [get]
+func (h *OrganisationHandler) Members(c *gin.Context) {
+	orgID, ok := pathID(c, "ListOrgMembers", "id")
+	if !ok {
+		return
+	}
+
+	members, err := h.orgs.Members(c.Request.Context(), orgID)
+	if err != nil {
+		writeOrganisationError(c, "ListOrgMembers", err)
+		return
+	}
+
+	res := make([]api.OrgMemberResponse, 0, len(members))
+	for _, m := range members {
+		res = append(res, mapper.ToOrgMemberResponse(m))
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// SetMember godoc
+// @Summary Добавить участника организации
+// @Description Добавить пользователя в организацию или изменить его роль (owner, admin, finance, member) и подразделение. У организации всегда остаётся хотя бы один владелец
+// @Tags organisations
+// @Accept json
+// @Produce json
+// @Param id path int true "ID организации"
+// @Param user_id path string true "UUID пользователя"
+// @Param member body api.OrgMemberRequest true "Роль и подразделение"
+// @Success 200 {object} api.OrgMemberResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations/{id}/members/{user_id} [put]
+func (h *OrganisationHandler) SetMember(c *gin.Context) {
+	log := logger.GetLogger()
+
+	orgID, ok := pathID(c, "SetOrgMember", "id")
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		log.WithError(err).Warn("SetOrgMember: invalid user_id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		return
+	}
+
+	var req api.OrgMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("SetOrgMember: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.orgs.SetMember(c.Request.Context(), orgID, userID, req)
+	if err != nil {
+		writeOrganisationError(c, "SetOrgMember", err)
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"organisation_id": orgID,
+		"user_id":         userID,
+	}).Info("SetOrgMember: organisation member saved")
+	c.JSON(http.StatusOK, mapper.ToOrgMemberResponse(member))
+}
+
+// RemoveMember godoc
+// @Summary Исключить участника организации
+// @Description Исключить пользователя из организации. Последнего владельца исключить нельзя
+// @Tags organisations
+// @Param id path int true "ID организации"
+// @Param user_id path string true "UUID пользователя"
+// @Success 204
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations/{id}/members/{user_id} [delete]
+func (h *OrganisationHandler) RemoveMember(c *gin.Context) {
+	log := logger.GetLogger()
+
+	orgID, ok := pathID(c, "RemoveOrgMember", "id")
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		log.WithError(err).Warn("RemoveOrgMember: invalid user_id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		return
+	}
+
+	if err := h.orgs.RemoveMember(c.Request.Context(), orgID, userID); err != nil {
+		writeOrganisationError(c, "RemoveOrgMember", err)
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"organisation_id": orgID,
+		"user_id":         userID,
+	}).Info("RemoveOrgMember: organisation member removed")
+	c.Status(http.StatusNoContent)
+}
+
+// Spending godoc
+// @Summary Расходы организации
+// @Description Начисления подписок за месяцы периода по центрам затрат, свёрнутые вверх по иерархии: центр затрат → команда → отдел → организация. Каждый месяц начисление относится к центру затрат, действующему в этом месяце
+// @Tags organisations
+// @Produce json
+// @Param id path int true "ID организации"
+// @Param from query string true "Первый месяц периода (MM-YYYY, YYYY-MM, YYYY-MM-DD или RFC 3339)"
+// @Param to query string true "Последний месяц периода включительно"
+// @Param tz query string false "Часовой пояс IANA, например Europe/Moscow; по умолчанию часовой пояс сервиса"
+// @Param X-Time-Zone header string false "Часовой пояс IANA, если не задан tz"
+// @Success 200 {object} api.OrgSpendingResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /organisations/{id}/spending [get]
+func (h *OrganisationHandler) Spending(c *gin.Context) {
+	log := logger.GetLogger()
+
+	orgID, ok := pathID(c, "OrgSpending", "id")
+	if !ok {
+		return
+	}
+
+	var filter dto.OrgSpendingFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		log.WithError(err).Warn("OrgSpending: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.orgs.Spending(c.Request.Context(), orgID, filter)
+	if err != nil {
+		writeOrganisationError(c, "OrgSpending", err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// pathID разбирает числовой параметр пути name и отвечает 400, если он некорректен.
+func pathID(c *gin.Context, op, name string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil {
+		logger.GetLogger().WithError(err).Warnf("%s: invalid %s param", op, name)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+		return 0, false
+	}
+	return id, true
+}
+
+func writeOrganisationError(c *gin.Context, op string, err error) {
+	log := logger.GetLogger()
+
+	switch {
+	case errors.Is(err, service.ErrOrganisationNotFound), errors.Is(err, service.ErrUnitNotFound),
+		errors.Is(err, service.ErrCostCentreNotFound), errors.Is(err, service.ErrOrgMemberNotFound):
+		log.WithError(err).Warn(op + ": not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrOrganisationExists), errors.Is(err, service.ErrUnitExists),
+		errors.Is(err, service.ErrCostCentreExists), errors.Is(err, service.ErrOrganisationInUse),
+		errors.Is(err, service.ErrUnitInUse), errors.Is(err, service.ErrCostCentreInUse),
+		errors.Is(err, service.ErrLastOwner):
+		log.WithError(err).Warn(op + ": conflict")
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidInput):
+		log.WithError(err).Warn(op + ": invalid input")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.WithError(err).Error(op + ": failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process organisation"})
+	}
+}
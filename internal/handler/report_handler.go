@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/service"
+)
+
+type ReportHandler struct {
+	reports *service.ReportService
+}
+
+func NewReportHandler(reports *service.ReportService) *ReportHandler {
+	return &ReportHandler{reports: reports}
+}
+
+// Spending godoc
+// @Summary Отчёт о расходах
+// @Description Помесячные начисления за период, сгруппированные по месяцам, сервисам, пользователям, категориям или меткам. При группировке по месяцам месяцы без начислений возвращаются с нулевой суммой
+// @Tags reports
+// @Produce json
+// @Param from query string true "Первый месяц периода: MM-YYYY, YYYY-MM, YYYY-MM-DD или RFC 3339"
+// @Param to query string true "Последний месяц периода в том же наборе форматов"
+// @Param group_by query string false "Группировка: month (по умолчанию), service, user, category, tag"
+// @Param user_id query string false "UUID пользователя"
+// @Param service_id query int false "ID сервиса из каталога"
+// @Param service_name query string false "Название или псевдоним сервиса из каталога, без учёта регистра"
+// @Param category query string false "Категория подписки или её сервиса"
+// @Param tag query []string false "Метки: подписка должна иметь все" collectionFormat(multi)
+// @Param tz query string false "Часовой пояс IANA, например Europe/Moscow; по умолчанию часовой пояс пользователя или сервиса"
+// @Param X-Time-Zone header string false "Часовой пояс IANA, если не задан tz"
+// @Success 200 {object} api.SpendingReportResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /reports/spending [get]
+func (h *ReportHandler) Spending(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var filter dto.SpendingReportFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		log.WithError(err).Warn("SpendingReport: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.reports.Spending(c.Request.Context(), filter)
+	if err != nil {
+		writeReportError(c, "SpendingReport", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Forecast godoc
+// @Summary Прогноз расходов
+// @Description Помесячный прогноз начислений начиная со следующего месяца с учётом end_date подписок и, по умолчанию, запланированных изменений цен. Для каждого месяца перечислены подписки, из которых складывается сумма
+// @Tags reports
+// @Produce json
+// @Param months query int false "Количество месяцев, по умолчанию 12, не больше 36"
+// @Param user_id query string false "UUID пользователя"
+// @Param service_id query int false "ID сервиса из каталога"
+// @Param service_name query string false "Название или псевдоним сервиса из каталога, без учёта регистра"
+// @Param include_price_changes query bool false "Учитывать запланированные изменения цен, по умолчанию true"
+// @Param tz query string false "Часовой пояс IANA, например Europe/Moscow; по умолчанию часовой пояс пользователя или сервиса"
+// @Param X-Time-Zone header string false "Часовой пояс IANA, если не задан tz"
+// @Success 200 {object} api.ForecastResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /reports/forecast [get]
+func (h *ReportHandler) Forecast(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var filter dto.ForecastFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		log.WithError(err).Warn("Forecast: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	forecast, err := h.reports.Forecast(c.Request.Context(), filter)
+	if err != nil {
+		writeReportError(c, "Forecast", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, forecast)
+}
+
+// Duplicates godoc
+// @Summary Пересекающиеся подписки
+// @Description Пары подписок одного пользователя на один сервис, периоды которых пересекаются, с месяцами пересечения
+// @Tags reports
+// @Produce json
+// @Param user_id query string false "UUID пользователя"
+// @Param service_id query int false "ID сервиса из каталога"
+// @Param service_name query string false "Название или псевдоним сервиса из каталога, без учёта регистра"
+// @Success 200 {object} api.DuplicatesReportResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /reports/duplicates [get]
+func (h *ReportHandler) Duplicates(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var filter dto.DuplicatesFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		log.WithError(err).Warn("Duplicates: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.reports.Duplicates(c.Request.Context(), filter)
+	if err != nil {
+		writeReportError(c, "Duplicates", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Cancellations godoc
+// @Summary Отчёт об отменах
+// @Description Отмены подписок за месяцы периода, сгруппированные по причинам и по сервисам, с суммой цен отменённых подписок в их последнем месяце
+// @Tags reports
+// @Produce json
+// @Param from query string true "Первый месяц периода: MM-YYYY, YYYY-MM, YYYY-MM-DD или RFC 3339"
+// @Param to query string true "Последний месяц периода в том же наборе форматов"
+// @Param user_id query string false "UUID пользователя"
+// @Param service_id query int false "ID сервиса из каталога"
+// @Param service_name query string false "Название или псевдоним сервиса из каталога, без учёта регистра"
+// @Param tz query string false "Часовой пояс IANA, например Europe/Moscow; по умолчанию часовой пояс пользователя или сервиса"
+// @Param X-Time-Zone header string false "Часовой пояс IANA, если не задан tz"
+// @Success 200 {object} api.CancellationReportResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /reports/cancellations [get]
+func (h *ReportHandler) Cancellations(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var filter dto.CancellationReportFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		log.WithError(err).Warn("Cancellations: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.reports.Cancellations(c.Request.Context(), filter)
+	if err != nil {
+		writeReportError(c, "Cancellations", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Balances godoc
+// @Summary Взаиморасчёты по совместным подпискам
+// @Description Кто кому должен за совместные подписки в месяце: участники должны владельцам свои доли, встречные долги двух пользователей взаимно погашаются
+// @Tags reports
+// @Produce json
+// @Param month query string false "Месяц: MM-YYYY, YYYY-MM, YYYY-MM-DD или RFC 3339; по умолчанию текущий"
+// @Param user_id query string false "UUID пользователя: только его долги и долги ему"
+// @Param tz query string false "Часовой пояс IANA, например Europe/Moscow; по умолчанию часовой пояс пользователя или сервиса"
+// @Param X-Time-Zone header string false "Часовой пояс IANA, если не задан tz"
+// @Success 200 {object} api.BalancesResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /reports/balances [get]
+func (h *ReportHandler) Balances(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var filter dto.BalancesFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		log.WithError(err).Warn("Balances: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.reports.Balances(c.Request.Context(), filter)
+	if err != nil {
+		writeReportError(c, "Balances", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func writeReportError(c *gin.Context, op string, err error) {
+	log := logger.GetLogger()
+
+	if errors.Is(err, service.ErrInvalidInput) {
+		log.WithError(err).Warn(op + ": invalid parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.WithError(err).Error(op + ": failed")
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build report"})
+}
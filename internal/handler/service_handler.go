@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/mapper"
+	"github.com/shenikar/subscription-service/internal/service"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+type ServiceHandler struct {
+	catalog *service.CatalogService
+}
+
+func NewServiceHandler(catalog *service.CatalogService) *ServiceHandler {
+	return &ServiceHandler{catalog: catalog}
+}
+
+// Create godoc
+// @Summary Добавить сервис в каталог
+// @Description Создать запись каталога сервисов с псевдонимами, по которым сопоставляются названия в подписках
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param service body api.ServiceRequest true "Данные сервиса"
+// @Success 201 {object} api.ServiceResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /services [post]
+func (h *ServiceHandler) Create(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var req api.ServiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("CreateService: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	svc, err := h.catalog.Create(c.Request.Context(), req)
+	if err != nil {
+		writeServiceError(c, "CreateService", err)
+		return
+	}
+
+	log.WithField("id", svc.ID).Info("CreateService: service created")
+	c.JSON(http.StatusCreated, mapper.ToServiceResponse(svc))
+}
+
+// GetByID godoc
+// @Summary Получить сервис по ID
+// @Tags services
+// @Produce json
+// @Param id path int true "ID сервиса"
+// @Success 200 {object} api.ServiceResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /services/{id} [get]
+func (h *ServiceHandler) GetByID(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn("GetService: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	svc, err := h.catalog.GetByID(c.Request.Context(), id)
+	if err != nil {
+		log.WithError(err).WithField("id", id).Error("GetService: failed to get service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get service"})
+		return
+	}
+	if svc == nil {
+		log.WithField("id", id).Warn("GetService: service not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "service not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, mapper.ToServiceResponse(*svc))
+}
+
+// GetAll godoc
+// @Summary Получить каталог сервисов
+// @Description Список сервисов с поиском по названию и псевдонимам
+// @Tags services
+// @Produce json
+// @Param q query string false "Подстрока названия или псевдонима"
+// @Param category query string false "Категория"
+// @Param limit query int false "Количество записей"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} api.ServiceResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /services [get]
+func (h *ServiceHandler) GetAll(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var filter dto.ListServicesFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		log.WithError(err).Warn("ListServices: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	services, err := h.catalog.List(c.Request.Context(), filter)
+	if err != nil {
+		log.WithError(err).Error("ListServices: failed to list services")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list services"})
+		return
+	}
+
+	res := make([]api.ServiceResponse, 0, len(services))
+	for _, svc := range services {
+		res = append(res, mapper.ToServiceResponse(svc))
+	}
+
+	log.WithField("count", len(res)).Info("ListServices: services listed")
+	c.JSON(http.StatusOK, res)
+}
+
+// Update godoc
+// @Summary Заменить сервис
+// @Description Полностью заменить запись каталога, включая псевдонимы. Новое название сразу видно во всех подписках сервиса
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path int true "ID сервиса"
+// @Param service body api.ServiceRequest true "Данные сервиса"
+// @Success 200 {object} api.ServiceResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /services/{id} [put]
+func (h *ServiceHandler) Update(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn("UpdateService: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req api.ServiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("UpdateService: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	svc, err := h.catalog.Update(c.Request.Context(), id, req)
+	if err != nil {
+		writeServiceError(c, "UpdateService", err)
+		return
+	}
+
+	log.WithField("id", id).Info("UpdateService: service updated")
+	c.JSON(http.StatusOK, mapper.ToServiceResponse(svc))
+}
+
+// Delete godoc
+// @Summary Удалить сервис
+// @Description Удалить сервис из каталога. Сервис, на который ссылаются подписки, удалить нельзя
+// @Tags services
+// @Param id path int true "ID сервиса"
+// @Success 204
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /services/{id} [delete]
+func (h *ServiceHandler) Delete(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn("DeleteService: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.catalog.Delete(c.Request.Context(), id); err != nil {
+		writeServiceError(c, "DeleteService", err)
+		return
+	}
+
+	log.WithField("id", id).Info("DeleteService: service deleted")
+	c.Status(http.StatusNoContent)
+}
+
+func writeServiceError(c *gin.Context, op string, err error) {
+	log := logger.GetLogger()
+
+	switch {
+	case errors.Is(err, service.ErrServiceNotFound):
+		log.Warn(op + ": service not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "service not found"})
+	case errors.Is(err, service.ErrServiceExists), errors.Is(err, service.ErrServiceInUse):
+		log.WithError(err).Warn(op + ": conflict")
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.WithError(err).Error(op + ": failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process service"})
+	}
+}
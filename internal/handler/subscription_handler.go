@@ -1,23 +1,39 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shenikar/subscription-service/internal/config"
 	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/importer"
 	"github.com/shenikar/subscription-service/internal/logger"
 	"github.com/shenikar/subscription-service/internal/mapper"
+	"github.com/shenikar/subscription-service/internal/model"
 	"github.com/shenikar/subscription-service/internal/service"
+	"github.com/shenikar/subscription-service/internal/validation"
+	"github.com/shenikar/subscription-service/pkg/api"
 	"github.com/sirupsen/logrus"
 )
 
 type SubscriptionHandler struct {
-	service *service.SubscriptionService
+	service        *service.SubscriptionService
+	batchMaxSize   int
+	requireIfMatch bool
 }
 
-func NewSubscriptionHandler(service *service.SubscriptionService) *SubscriptionHandler {
-	return &SubscriptionHandler{service: service}
+func NewSubscriptionHandler(service *service.SubscriptionService, cfg config.Config) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		service:        service,
+		batchMaxSize:   cfg.BatchMaxSize,
+		requireIfMatch: cfg.RequireIfMatch,
+	}
 }
 
 // Create godoc
@@ -26,14 +42,29 @@ func NewSubscriptionHandler(service *service.SubscriptionService) *SubscriptionH
 // @Tags subscriptions
 // @Accept json
 // @Produce json
-// @Param subscription body dto.CreateSubscriptionRequest true "Данные подписки"
-// @Success 201 {object} dto.SubscriptionResponse
-// @Failure 400 {object} dto.ErrorResponse
-// @Failure 500 {object} dto.ErrorResponse
+// @Param subscription body api.CreateSubscriptionRequest true "Данные подписки"
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасных повторов"
+// @Param date_format query string false "Формат дат в ответе: MM-YYYY (по умолчанию), YYYY-MM, YYYY-MM-DD или RFC3339"
+// @Param X-Date-Format header string false "Формат дат в ответе, если не задан date_format"
+// @Param tz query string false "Часовой пояс IANA, например Europe/Moscow; по умолчанию часовой пояс пользователя или сервиса"
+// @Param X-Time-Zone header string false "Часовой пояс IANA, если не задан tz"
+// @Success 201 {object} api.SubscriptionResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 422 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
 // @Router /subscriptions [post]
 func (h *SubscriptionHandler) Create(c *gin.Context) {
 	log := logger.GetLogger()
-	var req dto.CreateSubscriptionRequest
+
+	format, err := dateFormat(c)
+	if err != nil {
+		log.WithError(err).Warn("Create: invalid date format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req api.CreateSubscriptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.WithError(err).Warn("Create: invalid request payload")
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -42,6 +73,16 @@ func (h *SubscriptionHandler) Create(c *gin.Context) {
 
 	sub, err := h.service.Create(c.Request.Context(), req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			log.WithError(err).Warn("Create: invalid subscription data")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrOverlap) {
+			log.WithError(err).Warn("Create: subscription overlaps")
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		log.WithError(err).Error("Create: failed to create subscription")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create subscription"})
 		return
@@ -53,7 +94,184 @@ func (h *SubscriptionHandler) Create(c *gin.Context) {
 		"userID":      sub.UserID,
 	}).Info("Subscription created")
 
-	c.JSON(http.StatusCreated, mapper.ToResponseDTO(sub))
+	setETag(c, sub.Version)
+	c.JSON(http.StatusCreated, mapper.ToResponseDTO(sub, format))
+}
+
+// Import godoc
+// @Summary Импортировать подписки
+// @Description Массовый импорт подписок из CSV или JSON-массива с отчётом по строкам. Валидные строки сохраняются в одной транзакции
+// @Tags subscriptions
+// @Accept json
+// @Accept text/csv
+// @Produce json
+// @Param subscriptions body []api.CreateSubscriptionRequest true "Подписки для импорта"
+// @Param dry_run query bool false "Только проверить данные, без записи"
+// @Param mode query string false "Режим импорта: partial (по умолчанию) или all_or_nothing"
+// @Param columns query string false "Маппинг колонок, например Сервис:service_name,Цена:price"
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасных повторов"
+// @Success 200 {object} api.ImportReport
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 422 {object} api.ImportReport
+// @Failure 500 {object} api.ErrorResponse
+// @Router /subscriptions/import [post]
+func (h *SubscriptionHandler) Import(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var opts dto.ImportOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		log.WithError(err).Warn("Import: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	columns, err := importer.ParseColumns(opts.Columns)
+	if err != nil {
+		log.WithError(err).Warn("Import: invalid column mapping")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rows []dto.ImportRow
+	switch c.ContentType() {
+	case "text/csv", "application/csv":
+		rows, err = importer.ParseCSV(c.Request.Body, columns)
+	case "application/json", "":
+		rows, err = importer.ParseJSON(c.Request.Body, columns)
+	default:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "unsupported content type, expected text/csv or application/json"})
+		return
+	}
+	if err != nil {
+		log.WithError(err).Warn("Import: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.service.Import(c.Request.Context(), rows, opts)
+	if errors.Is(err, service.ErrOverlap) {
+		log.WithError(err).Warn("Import: subscriptions overlap")
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("Import: failed to import subscriptions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import subscriptions"})
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"total":    report.Total,
+		"imported": report.Imported,
+		"invalid":  report.Invalid,
+		"dryRun":   report.DryRun,
+	}).Info("Import: subscriptions processed")
+
+	if report.Mode == api.ImportModeAllOrNothing && report.Invalid > 0 {
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// Batch godoc
+// @Summary Пакетные операции с подписками
+// @Description Выполнить список операций create/update/delete в одной транзакции с результатом по каждой операции. При atomic=true первая ошибка откатывает весь пакет
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param batch body api.BatchRequest true "Операции"
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасных повторов"
+// @Param date_format query string false "Формат дат в ответе: MM-YYYY (по умолчанию), YYYY-MM, YYYY-MM-DD или RFC3339"
+// @Param X-Date-Format header string false "Формат дат в ответе, если не задан date_format"
+// @Success 200 {object} api.BatchResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 413 {object} api.ErrorResponse
+// @Failure 422 {object} api.BatchResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /subscriptions:batch [post]
+func (h *SubscriptionHandler) Batch(c *gin.Context) {
+	log := logger.GetLogger()
+
+	format, err := dateFormat(c)
+	if err != nil {
+		log.WithError(err).Warn("Batch: invalid date format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req api.BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("Batch: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Operations) > h.batchMaxSize {
+		log.WithField("size", len(req.Operations)).Warn("Batch: batch size exceeds limit")
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("batch size exceeds maximum of %d operations", h.batchMaxSize)})
+		return
+	}
+
+	items := make([]dto.BatchItem, len(req.Operations))
+	for i, op := range req.Operations {
+		items[i] = decodeBatchOperation(i, op)
+	}
+
+	resp, err := h.service.Batch(c.Request.Context(), items, req.Atomic, format)
+	if err != nil {
+		log.WithError(err).Error("Batch: failed to execute batch")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to execute batch"})
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"atomic":    resp.Atomic,
+		"succeeded": resp.Succeeded,
+		"failed":    resp.Failed,
+	}).Info("Batch: batch executed")
+
+	if resp.Atomic && resp.Failed > 0 {
+		c.JSON(http.StatusUnprocessableEntity, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func decodeBatchOperation(index int, op api.BatchOperation) dto.BatchItem {
+	item := dto.BatchItem{Index: index, Op: op.Op, Version: op.Version}
+
+	if op.Op != api.BatchOpCreate {
+		if op.ID == nil {
+			item.Errors = append(item.Errors, "id is required")
+			return item
+		}
+		item.ID = *op.ID
+	}
+
+	switch op.Op {
+	case api.BatchOpCreate:
+		item.Create = &api.CreateSubscriptionRequest{}
+		item.Errors = decodeBatchData(op.Data, item.Create)
+	case api.BatchOpUpdate:
+		item.Update = &api.UpdateSubscriptionRequest{}
+		item.Errors = decodeBatchData(op.Data, item.Update)
+	case api.BatchOpDelete:
+	default:
+		item.Errors = append(item.Errors, fmt.Sprintf("unknown op %q", op.Op))
+	}
+	return item
+}
+
+func decodeBatchData(data json.RawMessage, obj any) []string {
+	if len(data) == 0 {
+		return []string{"data is required"}
+	}
+	if err := json.Unmarshal(data, obj); err != nil {
+		return []string{err.Error()}
+	}
+	return validation.Struct(obj)
 }
 
 // GetByID godoc
@@ -62,13 +280,25 @@ func (h *SubscriptionHandler) Create(c *gin.Context) {
 // @Tags subscriptions
 // @Produce json
 // @Param id path int true "ID подписки"
-// @Success 200 {object} dto.SubscriptionResponse
-// @Failure 400 {object} dto.ErrorResponse
-// @Failure 404 {object} dto.ErrorResponse
+// @Param If-None-Match header string false "ETag известной клиенту версии"
+// @Param date_format query string false "Формат дат в ответе: MM-YYYY (по умолчанию), YYYY-MM, YYYY-MM-DD или RFC3339"
+// @Param X-Date-Format header string false "Формат дат в ответе, если не задан date_format"
+// @Success 200 {object} api.SubscriptionResponse
+// @Success 304
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
 // @Router /subscriptions/{id} [get]
 func (h *SubscriptionHandler) GetByID(c *gin.Context) {
 	log := logger.GetLogger()
 
+	format, err := dateFormat(c)
+	if err != nil {
+		log.WithError(err).Warn("GetByID: invalid date format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		log.WithError(err).Warn("GetByID: invalid id param")
@@ -78,37 +308,78 @@ func (h *SubscriptionHandler) GetByID(c *gin.Context) {
 
 	sub, err := h.service.GetByID(c.Request.Context(), id)
 	if err != nil {
+		log.WithError(err).WithField("id", id).Error("GetByID: failed to get subscription")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get subscription"})
+		return
+	}
+	if sub == nil {
 		log.WithField("id", id).Warn("GetByID: subscription not found")
 		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
 		return
 	}
 
+	setETag(c, sub.Version)
+	if noneMatch(c, sub.Version) {
+		log.WithField("id", id).Info("GetByID: subscription not modified")
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	log.WithField("id", id).Info("GetByID: subscription fetched")
 
-	c.JSON(http.StatusOK, mapper.ToResponseDTO(*sub))
+	c.JSON(http.StatusOK, mapper.ToResponseDTO(*sub, format))
 }
 
 // GetAll godoc
 // @Summary Получить все подписки
-// @Description Получить список всех подписок
+// @Description Получить список подписок с фильтрацией и постраничным выводом
 // @Tags subscriptions
 // @Produce json
-// @Success 200 {array} dto.SubscriptionResponse
-// @Failure 500 {object} dto.ErrorResponse
+// @Param user_id query string false "UUID пользователя"
+// @Param service_id query int false "ID сервиса из каталога"
+// @Param service_name query string false "Название или псевдоним сервиса из каталога, без учёта регистра"
+// @Param category query string false "Категория подписки или её сервиса"
+// @Param tag query []string false "Метки: подписка должна иметь все" collectionFormat(multi)
+// @Param limit query int false "Количество записей"
+// @Param offset query int false "Смещение"
+// @Param date_format query string false "Формат дат в ответе: MM-YYYY (по умолчанию), YYYY-MM, YYYY-MM-DD или RFC3339"
+// @Param X-Date-Format header string false "Формат дат в ответе, если не задан date_format"
+// @Success 200 {array} api.SubscriptionResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
 // @Router /subscriptions [get]
 func (h *SubscriptionHandler) GetAll(c *gin.Context) {
 	log := logger.GetLogger()
 
-	subs, err := h.service.GelAll(c.Request.Context())
+	format, err := dateFormat(c)
+	if err != nil {
+		log.WithError(err).Warn("List: invalid date format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var filter dto.ListSubscriptionsFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		log.WithError(err).Warn("List: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subs, err := h.service.List(c.Request.Context(), filter)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			log.WithError(err).Warn("List: invalid filter")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		log.WithError(err).Error("List: failed to list subscriptions")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list subscriptions"})
 		return
 	}
 
-	var res []dto.SubscriptionResponse
+	var res []api.SubscriptionResponse
 	for _, sub := range subs {
-		res = append(res, mapper.ToResponseDTO(sub))
+		res = append(res, mapper.ToResponseDTO(sub, format))
 	}
 
 	log.WithField("count", len(res)).Info("List: subscriptions listed")
@@ -116,21 +387,92 @@ func (h *SubscriptionHandler) GetAll(c *gin.Context) {
 	c.JSON(http.StatusOK, res)
 }
 
+// Search godoc
+// @Summary Искать подписки
+// @Description Полнотекстовый и нечёткий поиск по названию и псевдонимам сервиса, меткам и заметкам.
+// @Description Результаты отсортированы по релевантности, совпавшие фрагменты обрамлены маркерами <mark>.
+// @Tags subscriptions
+// @Produce json
+// @Param q query string true "Поисковый запрос, от 2 до 200 символов; допускает опечатки"
+// @Param user_id query string false "UUID пользователя"
+// @Param limit query int false "Количество результатов, по умолчанию 20, не больше 100"
+// @Param offset query int false "Смещение"
+// @Param date_format query string false "Формат дат в ответе: MM-YYYY (по умолчанию), YYYY-MM, YYYY-MM-DD или RFC3339"
+// @Param X-Date-Format header string false "Формат дат в ответе, если не задан date_format"
+// @Success 200 {array} api.SearchResult
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /subscriptions/search [get]
+func (h *SubscriptionHandler) Search(c *gin.Context) {
+	log := logger.GetLogger()
+
+	format, err := dateFormat(c)
+	if err != nil {
+		log.WithError(err).Warn("Search: invalid date format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var filter dto.SearchSubscriptionsFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		log.WithError(err).Warn("Search: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hits, err := h.service.Search(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			log.WithError(err).Warn("Search: invalid query")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.WithError(err).Error("Search: failed to search subscriptions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search subscriptions"})
+		return
+	}
+
+	res := make([]api.SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		res = append(res, mapper.ToSearchResult(hit, format))
+	}
+
+	log.WithField("count", len(res)).Info("Search: subscriptions found")
+
+	c.JSON(http.StatusOK, res)
+}
+
 // Update godoc
-// @Summary Обновить подписку
-// @Description Обновить запись подписки по ID
+// @Summary Заменить подписку
+// @Description Полностью заменить запись подписки по ID, все поля кроме end_date обязательны
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param id path int true "ID подписки"
-// @Param subscription body dto.UpdateSubscriptionRequest true "Обновленные данные подписки"
-// @Success 200 {object} dto.SubscriptionResponse
-// @Failure 400 {object} dto.ErrorResponse
-// @Failure 500 {object} dto.ErrorResponse
+// @Param subscription body api.UpdateSubscriptionRequest true "Обновленные данные подписки"
+// @Param If-Match header string false "ETag версии, которую клиент изменяет"
+// @Param date_format query string false "Формат дат в ответе: MM-YYYY (по умолчанию), YYYY-MM, YYYY-MM-DD или RFC3339"
+// @Param X-Date-Format header string false "Формат дат в ответе, если не задан date_format"
+// @Param tz query string false "Часовой пояс IANA, например Europe/Moscow; по умолчанию часовой пояс пользователя или сервиса"
+// @Param X-Time-Zone header string false "Часовой пояс IANA, если не задан tz"
+// @Success 200 {object} api.SubscriptionResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 412 {object} api.ErrorResponse
+// @Failure 428 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
 // @Router /subscriptions/{id} [put]
 func (h *SubscriptionHandler) Update(c *gin.Context) {
 	log := logger.GetLogger()
 
+	format, err := dateFormat(c)
+	if err != nil {
+		log.WithError(err).Warn("Update: invalid date format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		log.WithError(err).Warn("Update: invalid id param")
@@ -138,25 +480,440 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 		return
 	}
 
-	var req dto.UpdateSubscriptionRequest
+	ifMatch, err := h.ifMatchVersion(c)
+	if err != nil {
+		log.WithError(err).Warn("Update: invalid If-Match header")
+		c.JSON(ifMatchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var req api.UpdateSubscriptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.WithError(err).Warn("Update: invalid request payload")
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	sub, err := h.service.Update(c.Request.Context(), id, req)
+	sub, err := h.service.Update(c.Request.Context(), id, req, ifMatch)
 	if err != nil {
-		log.WithFields(logrus.Fields{
-			"id":  id,
-			"err": err,
-		}).Error("Update: failed to update subscription")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update subscription"})
+		writeUpdateError(c, "Update", id, err)
 		return
 	}
 
 	log.WithField("id", id).Info("Update: subscription updated")
-	c.JSON(http.StatusOK, mapper.ToResponseDTO(sub))
+	setETag(c, sub.Version)
+	c.JSON(http.StatusOK, mapper.ToResponseDTO(sub, format))
+}
+
+// Patch godoc
+// @Summary Частично обновить подписку
+// @Description Применить к подписке JSON Merge Patch (null очищает поле) или JSON Patch
+// @Tags subscriptions
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param patch body object true "Merge Patch или JSON Patch документ"
+// @Param If-Match header string false "ETag версии, которую клиент изменяет"
+// @Param date_format query string false "Формат дат в ответе: MM-YYYY (по умолчанию), YYYY-MM, YYYY-MM-DD или RFC3339"
+// @Param X-Date-Format header string false "Формат дат в ответе, если не задан date_format"
+// @Success 200 {object} api.SubscriptionResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 412 {object} api.ErrorResponse
+// @Failure 415 {object} api.ErrorResponse
+// @Failure 422 {object} api.ErrorResponse
+// @Failure 428 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /subscriptions/{id} [patch]
+func (h *SubscriptionHandler) Patch(c *gin.Context) {
+	log := logger.GetLogger()
+
+	format, err := dateFormat(c)
+	if err != nil {
+		log.WithError(err).Warn("Patch: invalid date format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn("Patch: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	patchType := c.ContentType()
+	if patchType != api.PatchTypeMerge && patchType != api.PatchTypeJSON {
+		log.WithField("contentType", patchType).Warn("Patch: unsupported content type")
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "unsupported content type, expected " + api.PatchTypeMerge + " or " + api.PatchTypeJSON})
+		return
+	}
+
+	ifMatch, err := h.ifMatchVersion(c)
+	if err != nil {
+		log.WithError(err).Warn("Patch: invalid If-Match header")
+		c.JSON(ifMatchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		log.WithError(err).Warn("Patch: failed to read request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+
+	sub, err := h.service.Patch(c.Request.Context(), id, patchType, patch, ifMatch)
+	if err != nil {
+		writeUpdateError(c, "Patch", id, err)
+		return
+	}
+
+	log.WithField("id", id).Info("Patch: subscription patched")
+	setETag(c, sub.Version)
+	c.JSON(http.StatusOK, mapper.ToResponseDTO(sub, format))
+}
+
+// SchedulePrice godoc
+// @Summary Запланировать изменение цены
+// @Description Задать новую цену подписки с первого числа месяца effective_from. Прошлые месяцы считаются по прежней цене, повторное изменение на тот же месяц заменяет цену
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param price body api.SchedulePriceRequest true "Новая цена и месяц, с которого она действует"
+// @Param If-Match header string false "ETag версии, которую клиент изменяет"
+// @Param date_format query string false "Формат дат в ответе: MM-YYYY (по умолчанию), YYYY-MM, YYYY-MM-DD или RFC3339"
+// @Param X-Date-Format header string false "Формат дат в ответе, если не задан date_format"
+// @Param tz query string false "Часовой пояс IANA, например Europe/Moscow; по умолчанию часовой пояс пользователя или сервиса"
+// @Param X-Time-Zone header string false "Часовой пояс IANA, если не задан tz"
+// @Success 200 {object} api.SubscriptionResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 412 {object} api.ErrorResponse
+// @Failure 428 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /subscriptions/{id}/prices [post]
+func (h *SubscriptionHandler) SchedulePrice(c *gin.Context) {
+	log := logger.GetLogger()
+
+	format, err := dateFormat(c)
+	if err != nil {
+		log.WithError(err).Warn("SchedulePrice: invalid date format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn("SchedulePrice: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ifMatch, err := h.ifMatchVersion(c)
+	if err != nil {
+		log.WithError(err).Warn("SchedulePrice: invalid If-Match header")
+		c.JSON(ifMatchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var req api.SchedulePriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("SchedulePrice: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := h.service.SchedulePrice(c.Request.Context(), id, req, ifMatch)
+	if err != nil {
+		writeUpdateError(c, "SchedulePrice", id, err)
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"id":             id,
+		"price":          req.Price,
+		"effective_from": req.EffectiveFrom,
+	}).Info("SchedulePrice: price change scheduled")
+	setETag(c, sub.Version)
+	c.JSON(http.StatusOK, mapper.ToResponseDTO(sub, format))
+}
+
+// Allocate godoc
+// @Summary Отнести подписку к центру затрат
+// @Description Отнести подписку к центру затрат организации с месяца from. Прошлые месяцы остаются за прежним центром затрат, повторное распределение с того же месяца заменяет центр затрат. Без cost_centre_id подписка с этого месяца не относится ни к одному центру затрат
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param allocation body api.AllocateRequest true "Центр затрат и месяц, с которого он действует"
+// @Param If-Match header string false "ETag версии, которую клиент изменяет"
+// @Param date_format query string false "Формат дат в ответе: MM-YYYY (по умолчанию), YYYY-MM, YYYY-MM-DD или RFC3339"
+// @Param X-Date-Format header string false "Формат дат в ответе, если не задан date_format"
+// @Param tz query string false "Часовой пояс IANA, например Europe/Moscow; по умолчанию часовой пояс пользователя или сервиса"
+// @Param X-Time-Zone header string false "Часовой пояс IANA, если не задан tz"
+// @Success 200 {object} api.SubscriptionResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 412 {object} api.ErrorResponse
+// @Failure 428 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /subscriptions/{id}/allocations [post]
+func (h *SubscriptionHandler) Allocate(c *gin.Context) {
+	log := logger.GetLogger()
+
+	format, err := dateFormat(c)
+	if err != nil {
+		log.WithError(err).Warn("Allocate: invalid date format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn("Allocate: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ifMatch, err := h.ifMatchVersion(c)
+	if err != nil {
+		log.WithError(err).Warn("Allocate: invalid If-Match header")
+		c.JSON(ifMatchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var req api.AllocateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("Allocate: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := h.service.Allocate(c.Request.Context(), id, req, ifMatch)
+	if err != nil {
+		writeUpdateError(c, "Allocate", id, err)
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"id":   id,
+		"from": req.From,
+	}).Info("Allocate: subscription allocated")
+	setETag(c, sub.Version)
+	c.JSON(http.StatusOK, mapper.ToResponseDTO(sub, format))
+}
+
+// Pause godoc
+// @Summary Приостановить подписку
+// @Description Приостановить оплату подписки с месяца from (по умолчанию следующего) по месяц to включительно или до возобновления. Месяцы приостановки не входят в итоги, отчёты и прогноз
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param pause body api.PauseRequest false "Период приостановки"
+// @Param If-Match header string false "ETag версии, которую клиент изменяет"
+// @Param date_format query string false "Формат дат в ответе: MM-YYYY (по умолчанию), YYYY-MM, YYYY-MM-DD или RFC3339"
+// @Param X-Date-Format header string false "Формат дат в ответе, если не задан date_format"
+// @Param tz query string false "Часовой пояс IANA, например Europe/Moscow; по умолчанию часовой пояс пользователя или сервиса"
+// @Param X-Time-Zone header string false "Часовой пояс IANA, если не задан tz"
+// @Success 200 {object} api.SubscriptionResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 412 {object} api.ErrorResponse
+// @Failure 428 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /subscriptions/{id}/pause [post]
+func (h *SubscriptionHandler) Pause(c *gin.Context) {
+	var req api.PauseRequest
+	h.changePause(c, "Pause", &req, func(ctx context.Context, id int64, ifMatch *int) (model.Subscription, error) {
+		return h.service.Pause(ctx, id, req, ifMatch)
+	})
+}
+
+// Resume godoc
+// @Summary Возобновить подписку
+// @Description Возобновить оплату приостановленной подписки с месяца from, по умолчанию следующего. Приостановки, запланированные позже, отменяются
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param resume body api.ResumeRequest false "Месяц возобновления"
+// @Param If-Match header string false "ETag версии, которую клиент изменяет"
+// @Param date_format query string false "Формат дат в ответе: MM-YYYY (по умолчанию), YYYY-MM, YYYY-MM-DD или RFC3339"
+// @Param X-Date-Format header string false "Формат дат в ответе, если не задан date_format"
+// @Param tz query string false "Часовой пояс IANA, например Europe/Moscow; по умолчанию часовой пояс пользователя или сервиса"
+// @Param X-Time-Zone header string false "Часовой пояс IANA, если не задан tz"
+// @Success 200 {object} api.SubscriptionResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 412 {object} api.ErrorResponse
+// @Failure 428 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /subscriptions/{id}/resume [post]
+func (h *SubscriptionHandler) Resume(c *gin.Context) {
+	var req api.ResumeRequest
+	h.changePause(c, "Resume", &req, func(ctx context.Context, id int64, ifMatch *int) (model.Subscription, error) {
+		return h.service.Resume(ctx, id, req, ifMatch)
+	})
+}
+
+// changePause разбирает запрос приостановки или возобновления в req и выполняет apply.
+// Тело запроса необязательно: без него используются значения по умолчанию.
+func (h *SubscriptionHandler) changePause(c *gin.Context, op string, req any, apply func(context.Context, int64, *int) (model.Subscription, error)) {
+	log := logger.GetLogger()
+
+	format, err := dateFormat(c)
+	if err != nil {
+		log.WithError(err).Warn(op + ": invalid date format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn(op + ": invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ifMatch, err := h.ifMatchVersion(c)
+	if err != nil {
+		log.WithError(err).Warn(op + ": invalid If-Match header")
+		c.JSON(ifMatchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.ShouldBindJSON(req); err != nil && !errors.Is(err, io.EOF) {
+		log.WithError(err).Warn(op + ": invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := apply(c.Request.Context(), id, ifMatch)
+	if err != nil {
+		writeUpdateError(c, op, id, err)
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"id":      id,
+		"version": sub.Version,
+	}).Info(op + ": subscription pauses changed")
+	setETag(c, sub.Version)
+	c.JSON(http.StatusOK, mapper.ToResponseDTO(sub, format))
+}
+
+// Cancel godoc
+// @Summary Отменить подписку
+// @Description Отменить подписку с причиной: немедленно (immediately) подписка заканчивается текущим месяцем, в конце периода (end_of_period, по умолчанию) — текущим месяцем или последним месяцем пробного периода. Более ранний end_date сохраняется. Отмена попадает в отчёт /reports/cancellations
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param cancel body api.CancelRequest true "Причина и режим отмены"
+// @Param If-Match header string false "ETag версии, которую клиент изменяет"
+// @Param date_format query string false "Формат дат в ответе: MM-YYYY (по умолчанию), YYYY-MM, YYYY-MM-DD или RFC3339"
+// @Param X-Date-Format header string false "Формат дат в ответе, если не задан date_format"
+// @Param tz query string false "Часовой пояс IANA, например Europe/Moscow; по умолчанию часовой пояс пользователя или сервиса"
+// @Param X-Time-Zone header string false "Часовой пояс IANA, если не задан tz"
+// @Success 200 {object} api.CancellationResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 412 {object} api.ErrorResponse
+// @Failure 428 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /subscriptions/{id}/cancel [post]
+func (h *SubscriptionHandler) Cancel(c *gin.Context) {
+	log := logger.GetLogger()
+
+	format, err := dateFormat(c)
+	if err != nil {
+		log.WithError(err).Warn("Cancel: invalid date format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn("Cancel: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ifMatch, err := h.ifMatchVersion(c)
+	if err != nil {
+		log.WithError(err).Warn("Cancel: invalid If-Match header")
+		c.JSON(ifMatchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var req api.CancelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("Cancel: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cancellation, sub, err := h.service.Cancel(c.Request.Context(), id, req, ifMatch)
+	if err != nil {
+		writeUpdateError(c, "Cancel", id, err)
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"id":      id,
+		"reason":  cancellation.Reason,
+		"version": sub.Version,
+	}).Info("Cancel: subscription cancelled")
+	setETag(c, sub.Version)
+	c.JSON(http.StatusOK, mapper.ToCancellationResponse(cancellation, sub, format))
+}
+
+func writeUpdateError(c *gin.Context, op string, id int64, err error) {
+	log := logger.GetLogger().WithField("id", id)
+
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		log.Warn(op + ": subscription not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+	case errors.Is(err, service.ErrMemberNotFound):
+		log.Warn(op + ": member not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPreconditionFailed):
+		log.Warn(op + ": version mismatch")
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidPatch):
+		log.WithError(err).Warn(op + ": invalid patch")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPatchConflict):
+		log.WithError(err).Warn(op + ": patch cannot be applied")
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrOverlap):
+		log.WithError(err).Warn(op + ": subscription overlaps")
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAlreadyPaused), errors.Is(err, service.ErrNotPaused):
+		log.WithError(err).Warn(op + ": pause conflict")
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAlreadyEnded), errors.Is(err, service.ErrNotStarted):
+		log.WithError(err).Warn(op + ": subscription cannot be cancelled")
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPatchResultInvalid):
+		log.WithError(err).Warn(op + ": patched subscription is invalid")
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidInput):
+		log.WithError(err).Warn(op + ": invalid subscription data")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.WithError(err).Error(op + ": failed to update subscription")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update subscription"})
+	}
 }
 
 // Delete godoc
@@ -164,9 +921,13 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 // @Description Удалить подписку по ID
 // @Tags subscriptions
 // @Param id path int true "ID подписки"
+// @Param If-Match header string false "ETag версии, которую клиент удаляет"
 // @Success 204
-// @Failure 400 {object} dto.ErrorResponse
-// @Failure 500 {object} dto.ErrorResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 412 {object} api.ErrorResponse
+// @Failure 428 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
 // @Router /subscriptions/{id} [delete]
 func (h *SubscriptionHandler) Delete(c *gin.Context) {
 	log := logger.GetLogger()
@@ -178,7 +939,24 @@ func (h *SubscriptionHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+	ifMatch, err := h.ifMatchVersion(c)
+	if err != nil {
+		log.WithError(err).Warn("Delete: invalid If-Match header")
+		c.JSON(ifMatchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id, ifMatch); err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotFound):
+			log.WithField("id", id).Warn("Delete: subscription not found")
+			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+			return
+		case errors.Is(err, service.ErrPreconditionFailed):
+			log.WithField("id", id).Warn("Delete: version mismatch")
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+			return
+		}
 		log.WithError(err).Error("Delete: failed to delete subscription")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete subscription"})
 		return
@@ -190,16 +968,22 @@ func (h *SubscriptionHandler) Delete(c *gin.Context) {
 
 // TotalPrice godoc
 // @Summary Получить суммарную стоимость подписок
-// @Description Подсчитывает общую стоимость подписок за период с фильтрацией по user_id и service_name
+// @Description Суммирует помесячные начисления за месяцы периода по цене, действующей в каждом месяце, с фильтрацией по user_id, сервису, категории и меткам и разбивкой по group_by. Бессрочные подписки начисляются по to_date, будущие месяцы входят в итог как прогноз
 // @Tags subscriptions
 // @Produce json
 // @Param user_id query string true "UUID пользователя"
-// @Param service_name query string false "Название сервиса"
-// @Param from query string false "Дата начала периода (dd-MM-YYYY)"
-// @Param to query string false "Дата конца периода (dd-MM-YYYY)"
-// @Success 200 {object} map[string]int
-// @Failure 400 {object} dto.ErrorResponse
-// @Failure 500 {object} dto.ErrorResponse
+// @Param service_id query int false "ID сервиса из каталога"
+// @Param service_name query string false "Название или псевдоним сервиса из каталога, без учёта регистра"
+// @Param category query string false "Категория подписки или её сервиса"
+// @Param tag query []string false "Метки: подписка должна иметь все" collectionFormat(multi)
+// @Param from_date query string false "Начало периода: MM-YYYY, YYYY-MM, YYYY-MM-DD или RFC 3339"
+// @Param to_date query string false "Конец периода в том же наборе форматов; без него бессрочные подписки считаются по текущий месяц"
+// @Param group_by query string false "Разбивка суммы: service, category или tag"
+// @Param tz query string false "Часовой пояс IANA, например Europe/Moscow; по умолчанию часовой пояс пользователя или сервиса"
+// @Param X-Time-Zone header string false "Часовой пояс IANA, если не задан tz"
+// @Success 200 {object} api.TotalPriceResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
 // @Router /subscriptions/total [get]
 func (h *SubscriptionHandler) TotalPrice(c *gin.Context) {
 	log := logger.GetLogger()
@@ -211,8 +995,20 @@ func (h *SubscriptionHandler) TotalPrice(c *gin.Context) {
 		return
 	}
 
-	total, err := h.service.TotalPrice(c.Request.Context(), filter)
+	var res api.TotalPriceResponse
+	var groups []model.SpendingBucket
+	var err error
+	if filter.GroupBy != "" {
+		res.Total, groups, err = h.service.TotalByGroup(c.Request.Context(), filter)
+	} else {
+		res.Total, err = h.service.TotalPrice(c.Request.Context(), filter)
+	}
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			log.WithError(err).Warn("TotalPrice: invalid filter")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		log.WithError(err).Error("TotalPrice: failed to calculate total price")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to calculate total price"})
 		return
@@ -223,8 +1019,11 @@ func (h *SubscriptionHandler) TotalPrice(c *gin.Context) {
 		"service_name": filter.ServiceName,
 		"from":         filter.FromDate,
 		"to":           filter.ToDate,
-		"total":        total,
+		"total":        res.Total,
 	}).Info("TotalPrice: total calculated")
 
-	c.JSON(http.StatusOK, gin.H{"total": total})
+	for _, g := range groups {
+		res.Groups = append(res.Groups, mapper.ToSpendingBucketResponse(g))
+	}
+	c.JSON(http.StatusOK, res)
 }
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/mapper"
+	"github.com/shenikar/subscription-service/internal/service"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+type TagHandler struct {
+	tags *service.TagService
+}
+
+func NewTagHandler(tags *service.TagService) *TagHandler {
+	return &TagHandler{tags: tags}
+}
+
+// GetAll godoc
+// @Summary Получить метки подписок
+// @Description Список меток с числом подписок, по убыванию числа подписок. Метки создаются при сохранении подписок
+// @Tags tags
+// @Produce json
+// @Param q query string false "Подстрока названия метки"
+// @Param limit query int false "Количество записей"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} api.TagResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /tags [get]
+func (h *TagHandler) GetAll(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var filter dto.ListTagsFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		log.WithError(err).Warn("ListTags: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tags, err := h.tags.List(c.Request.Context(), filter)
+	if err != nil {
+		log.WithError(err).Error("ListTags: failed to list tags")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list tags"})
+		return
+	}
+
+	res := make([]api.TagResponse, 0, len(tags))
+	for _, tag := range tags {
+		res = append(res, mapper.ToTagResponse(tag))
+	}
+
+	log.WithField("count", len(res)).Info("ListTags: tags listed")
+	c.JSON(http.StatusOK, res)
+}
+
+// GetByID godoc
+// @Summary Получить метку по ID
+// @Tags tags
+// @Produce json
+// @Param id path int true "ID метки"
+// @Success 200 {object} api.TagResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /tags/{id} [get]
+func (h *TagHandler) GetByID(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn("GetTag: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	tag, err := h.tags.GetByID(c.Request.Context(), id)
+	if err != nil {
+		log.WithError(err).WithField("id", id).Error("GetTag: failed to get tag")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get tag"})
+		return
+	}
+	if tag == nil {
+		log.WithField("id", id).Warn("GetTag: tag not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, mapper.ToTagResponse(*tag))
+}
+
+// Rename godoc
+// @Summary Переименовать метку
+// @Description Переименовать метку во всех подписках. Если название занято другой меткой, их нужно объединить через /tags/merge
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "ID метки"
+// @Param tag body api.RenameTagRequest true "Новое название"
+// @Success 200 {object} api.TagResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /tags/{id} [put]
+func (h *TagHandler) Rename(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithError(err).Warn("RenameTag: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req api.RenameTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("RenameTag: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := h.tags.Rename(c.Request.Context(), id, req)
+	if err != nil {
+		writeTagError(c, "RenameTag", err)
+		return
+	}
+
+	log.WithField("id", id).Info("RenameTag: tag renamed")
+	c.JSON(http.StatusOK, mapper.ToTagResponse(tag))
+}
+
+// Merge godoc
+// @Summary Объединить метки
+// @Description Перенести подписки меток source_ids на метку target_id и удалить исходные метки
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param merge body api.MergeTagsRequest true "Исходные метки и метка, в которую они объединяются"
+// @Success 200 {object} api.TagResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /tags/merge [post]
+func (h *TagHandler) Merge(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var req api.MergeTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("MergeTags: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := h.tags.Merge(c.Request.Context(), req)
+	if err != nil {
+		writeTagError(c, "MergeTags", err)
+		return
+	}
+
+	log.WithField("target_id", req.TargetID).Info("MergeTags: tags merged")
+	c.JSON(http.StatusOK, mapper.ToTagResponse(tag))
+}
+
+func writeTagError(c *gin.Context, op string, err error) {
+	log := logger.GetLogger()
+
+	switch {
+	case errors.Is(err, service.ErrTagNotFound):
+		log.Warn(op + ": tag not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+	case errors.Is(err, service.ErrTagExists):
+		log.WithError(err).Warn(op + ": conflict")
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidInput):
+		log.WithError(err).Warn(op + ": invalid input")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.WithError(err).Error(op + ": failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process tag"})
+	}
+}
@@ -0,0 +1,329 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/mapper"
+	"github.com/shenikar/subscription-service/internal/service"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+type UserHandler struct {
+	users         *service.UserService
+	subscriptions *service.SubscriptionService
+}
+
+func NewUserHandler(users *service.UserService, subscriptions *service.SubscriptionService) *UserHandler {
+	return &UserHandler{
+		users:         users,
+		subscriptions: subscriptions,
+	}
+}
+
+// Create godoc
+// @Summary Создать пользователя
+// @Description Создать пользователя. Если id не передан, он генерируется
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body api.UserRequest true "Данные пользователя"
+// @Success 201 {object} api.UserResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /users [post]
+func (h *UserHandler) Create(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var req api.UserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("CreateUser: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.users.Create(c.Request.Context(), req)
+	if err != nil {
+		writeUserError(c, "CreateUser", err)
+		return
+	}
+
+	log.WithField("id", user.ID).Info("CreateUser: user created")
+	c.JSON(http.StatusCreated, mapper.ToUserResponse(user))
+}
+
+// GetByID godoc
+// @Summary Получить пользователя по ID
+// @Tags users
+// @Produce json
+// @Param id path string true "UUID пользователя"
+// @Success 200 {object} api.UserResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /users/{id} [get]
+func (h *UserHandler) GetByID(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.WithError(err).Warn("GetUser: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	user, err := h.users.GetByID(c.Request.Context(), id)
+	if err != nil {
+		log.WithError(err).WithField("id", id).Error("GetUser: failed to get user")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
+		return
+	}
+	if user == nil {
+		log.WithField("id", id).Warn("GetUser: user not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, mapper.ToUserResponse(*user))
+}
+
+// GetAll godoc
+// @Summary Получить список пользователей
+// @Description Список пользователей с поиском по email и имени
+// @Tags users
+// @Produce json
+// @Param q query string false "Подстрока email или имени"
+// @Param limit query int false "Количество записей"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} api.UserResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /users [get]
+func (h *UserHandler) GetAll(c *gin.Context) {
+	log := logger.GetLogger()
+
+	var filter dto.ListUsersFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		log.WithError(err).Warn("ListUsers: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, err := h.users.List(c.Request.Context(), filter)
+	if err != nil {
+		log.WithError(err).Error("ListUsers: failed to list users")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list users"})
+		return
+	}
+
+	res := make([]api.UserResponse, 0, len(users))
+	for _, user := range users {
+		res = append(res, mapper.ToUserResponse(user))
+	}
+
+	log.WithField("count", len(res)).Info("ListUsers: users listed")
+	c.JSON(http.StatusOK, res)
+}
+
+// Update godoc
+// @Summary Заменить профиль пользователя
+// @Description Полностью заменить профиль пользователя, поле id в теле не учитывается
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID пользователя"
+// @Param user body api.UserRequest true "Данные пользователя"
+// @Success 200 {object} api.UserResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /users/{id} [put]
+func (h *UserHandler) Update(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.WithError(err).Warn("UpdateUser: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req api.UserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.WithError(err).Warn("UpdateUser: invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.users.Update(c.Request.Context(), id, req)
+	if err != nil {
+		writeUserError(c, "UpdateUser", err)
+		return
+	}
+
+	log.WithField("id", id).Info("UpdateUser: user updated")
+	c.JSON(http.StatusOK, mapper.ToUserResponse(user))
+}
+
+// Delete godoc
+// @Summary Удалить пользователя
+// @Description Удалить пользователя. Пользователя с подписками, участника совместных подписок или организаций удалить нельзя
+// @Tags users
+// @Param id path string true "UUID пользователя"
+// @Success 204
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /users/{id} [delete]
+func (h *UserHandler) Delete(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.WithError(err).Warn("DeleteUser: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.users.Delete(c.Request.Context(), id); err != nil {
+		writeUserError(c, "DeleteUser", err)
+		return
+	}
+
+	log.WithField("id", id).Info("DeleteUser: user deleted")
+	c.Status(http.StatusNoContent)
+}
+
+// Subscriptions godoc
+// @Summary Подписки пользователя
+// @Description Получить подписки пользователя с фильтрацией по сервису и постраничным выводом
+// @Tags users
+// @Produce json
+// @Param id path string true "UUID пользователя"
+// @Param service_id query int false "ID сервиса из каталога"
+// @Param service_name query string false "Название или псевдоним сервиса из каталога, без учёта регистра"
+// @Param limit query int false "Количество записей"
+// @Param offset query int false "Смещение"
+// @Param date_format query string false "Формат дат в ответе: MM-YYYY (по умолчанию), YYYY-MM, YYYY-MM-DD или RFC3339"
+// @Param X-Date-Format header string false "Формат дат в ответе, если не задан date_format"
+// @Success 200 {array} api.SubscriptionResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /users/{id}/subscriptions [get]
+func (h *UserHandler) Subscriptions(c *gin.Context) {
+	log := logger.GetLogger()
+
+	format, err := dateFormat(c)
+	if err != nil {
+		log.WithError(err).Warn("UserSubscriptions: invalid date format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.WithError(err).Warn("UserSubscriptions: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var filter dto.ListSubscriptionsFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		log.WithError(err).Warn("UserSubscriptions: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.UserID = id.String()
+
+	user, err := h.users.GetByID(c.Request.Context(), id)
+	if err != nil {
+		log.WithError(err).WithField("id", id).Error("UserSubscriptions: failed to get user")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
+		return
+	}
+	if user == nil {
+		log.WithField("id", id).Warn("UserSubscriptions: user not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	subs, err := h.subscriptions.List(c.Request.Context(), filter)
+	if err != nil {
+		log.WithError(err).Error("UserSubscriptions: failed to list subscriptions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list subscriptions"})
+		return
+	}
+
+	res := make([]api.SubscriptionResponse, 0, len(subs))
+	for _, sub := range subs {
+		res = append(res, mapper.ToResponseDTO(sub, format))
+	}
+
+	log.WithField("count", len(res)).Info("UserSubscriptions: subscriptions listed")
+	c.JSON(http.StatusOK, res)
+}
+
+// Summary godoc
+// @Summary Сводка по пользователю
+// @Description Количество подписок, расходы за текущий месяц и ближайшие продления. Подписки продлеваются первого числа месяца
+// @Tags users
+// @Produce json
+// @Param id path string true "UUID пользователя"
+// @Param within_days query int false "Горизонт продлений в днях, по умолчанию 30"
+// @Param tz query string false "Часовой пояс IANA, например Europe/Moscow; по умолчанию часовой пояс пользователя или сервиса"
+// @Param X-Time-Zone header string false "Часовой пояс IANA, если не задан tz"
+// @Success 200 {object} api.UserSummaryResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Router /users/{id}/summary [get]
+func (h *UserHandler) Summary(c *gin.Context) {
+	log := logger.GetLogger()
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.WithError(err).Warn("UserSummary: invalid id param")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var opts dto.UserSummaryOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		log.WithError(err).Warn("UserSummary: invalid query parameters")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary, err := h.users.Summary(c.Request.Context(), id, opts.WithinDays)
+	if err != nil {
+		writeUserError(c, "UserSummary", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+func writeUserError(c *gin.Context, op string, err error) {
+	log := logger.GetLogger()
+
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		log.Warn(op + ": user not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	case errors.Is(err, service.ErrUserExists), errors.Is(err, service.ErrUserInUse),
+		errors.Is(err, service.ErrUserIsMember), errors.Is(err, service.ErrUserInOrganisation):
+		log.WithError(err).Warn(op + ": conflict")
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.WithError(err).Error(op + ": failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process user"})
+	}
+}
@@ -0,0 +1,205 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/validation"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+const (
+	fieldServiceID   = "service_id"
+	fieldServiceName = "service_name"
+	fieldPrice       = "price"
+	fieldUserID      = "user_id"
+	fieldStartDate   = "start_date"
+	fieldEndDate     = "end_date"
+)
+
+var knownFields = map[string]bool{
+	fieldServiceID:   true,
+	fieldServiceName: true,
+	fieldPrice:       true,
+	fieldUserID:      true,
+	fieldStartDate:   true,
+	fieldEndDate:     true,
+}
+
+// Сервис задаётся колонкой service_name или service_id, цена может браться из каталога.
+var requiredFields = []string{fieldUserID, fieldStartDate}
+
+// ParseColumns разбирает маппинг колонок вида "Сервис:service_name,Цена:price".
+func ParseColumns(s string) (map[string]string, error) {
+	columns := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return columns, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		source, target, ok := strings.Cut(pair, ":")
+		source, target = strings.TrimSpace(source), strings.TrimSpace(target)
+		if !ok || source == "" || target == "" {
+			return nil, fmt.Errorf("invalid column mapping %q, expected source:target", pair)
+		}
+		if !knownFields[target] {
+			return nil, fmt.Errorf("unknown target column %q", target)
+		}
+		columns[strings.ToLower(source)] = target
+	}
+	return columns, nil
+}
+
+func resolveColumn(name string, columns map[string]string) string {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if target, ok := columns[key]; ok {
+		return target
+	}
+	if knownFields[key] {
+		return key
+	}
+	return ""
+}
+
+func ParseCSV(r io.Reader, columns map[string]string) ([]dto.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("empty csv")
+		}
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	// Excel с русской локалью сохраняет CSV через точку с запятой.
+	if len(header) == 1 && strings.Contains(header[0], ";") {
+		header = strings.Split(header[0], ";")
+		reader.Comma = ';'
+	}
+
+	fields := make([]string, len(header))
+	present := make(map[string]bool)
+	for i, name := range header {
+		fields[i] = resolveColumn(strings.TrimPrefix(name, "\ufeff"), columns)
+		present[fields[i]] = true
+	}
+	for _, f := range requiredFields {
+		if !present[f] {
+			return nil, fmt.Errorf("missing required column %q", f)
+		}
+	}
+	if !present[fieldServiceName] && !present[fieldServiceID] {
+		return nil, fmt.Errorf("missing required column %q or %q", fieldServiceName, fieldServiceID)
+	}
+
+	var rows []dto.ImportRow
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv row %d: %w", n, err)
+		}
+
+		row := dto.ImportRow{Row: n}
+		for i, value := range record {
+			if i >= len(fields) || fields[i] == "" {
+				continue
+			}
+			if err := setField(&row.Request, fields[i], strings.TrimSpace(value)); err != nil {
+				row.Errors = append(row.Errors, err.Error())
+			}
+		}
+		if len(row.Errors) == 0 {
+			validate(&row)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func setField(req *api.CreateSubscriptionRequest, field, value string) error {
+	switch field {
+	case fieldServiceID:
+		if value == "" {
+			return nil
+		}
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("service_id: invalid integer %q", value)
+		}
+		req.ServiceID = &id
+	case fieldServiceName:
+		req.ServiceName = value
+	case fieldPrice:
+		if value == "" {
+			return nil
+		}
+		price, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("price: invalid integer %q", value)
+		}
+		req.Price = price
+	case fieldUserID:
+		if value == "" {
+			return nil
+		}
+		userID, err := uuid.Parse(value)
+		if err != nil {
+			return fmt.Errorf("user_id: invalid uuid %q", value)
+		}
+		req.UserID = userID
+	case fieldStartDate:
+		req.StartDate = value
+	case fieldEndDate:
+		if value != "" {
+			req.EndDate = &value
+		}
+	}
+	return nil
+}
+
+func ParseJSON(r io.Reader, columns map[string]string) ([]dto.ImportRow, error) {
+	var records []map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("invalid json array: %w", err)
+	}
+
+	rows := make([]dto.ImportRow, 0, len(records))
+	for i, record := range records {
+		row := dto.ImportRow{Row: i + 1}
+
+		mapped := make(map[string]json.RawMessage, len(record))
+		for key, value := range record {
+			if field := resolveColumn(key, columns); field != "" {
+				mapped[field] = value
+			}
+		}
+
+		data, err := json.Marshal(mapped)
+		if err == nil {
+			err = json.Unmarshal(data, &row.Request)
+		}
+		if err != nil {
+			row.Errors = append(row.Errors, err.Error())
+		} else {
+			validate(&row)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func validate(row *dto.ImportRow) {
+	row.Errors = append(row.Errors, validation.Struct(&row.Request)...)
+}
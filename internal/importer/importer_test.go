@@ -0,0 +1,127 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseColumns(t *testing.T) {
+	columns, err := ParseColumns("Сервис:service_name, Цена : price")
+	if err != nil {
+		t.Fatalf("ParseColumns() error = %v", err)
+	}
+	if columns["сервис"] != fieldServiceName || columns["цена"] != fieldPrice {
+		t.Errorf("ParseColumns() = %v", columns)
+	}
+
+	for _, s := range []string{"Сервис", "Сервис:", "Сервис:name"} {
+		if _, err := ParseColumns(s); err == nil {
+			t.Errorf("ParseColumns(%q) error = nil, want error", s)
+		}
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		columns map[string]string
+		// errs — число ошибок в каждой строке.
+		errs []int
+	}{
+		{
+			name: "valid rows",
+			input: "service_name,price,user_id,start_date,end_date\n" +
+				"Netflix,400,60601fee-2bf1-4721-ae6f-7636e79a0cba,07-2025,\n" +
+				"Spotify,200,60601fee-2bf1-4721-ae6f-7636e79a0cba,08-2025,12-2025\n",
+			errs: []int{0, 0},
+		},
+		{
+			name: "semicolon separator and byte order mark",
+			input: "\ufeffservice_name;price;user_id;start_date\n" +
+				"Netflix;400;60601fee-2bf1-4721-ae6f-7636e79a0cba;07-2025\n",
+			errs: []int{0},
+		},
+		{
+			name: "column mapping",
+			input: "Сервис,Цена,Пользователь,Начало\n" +
+				"Netflix,400,60601fee-2bf1-4721-ae6f-7636e79a0cba,07-2025\n",
+			columns: map[string]string{"сервис": fieldServiceName, "цена": fieldPrice, "пользователь": fieldUserID, "начало": fieldStartDate},
+			errs:    []int{0},
+		},
+		{
+			name: "invalid rows are reported, valid rows are kept",
+			input: "service_name,price,user_id,start_date\n" +
+				"Netflix,abc,not-a-uuid,07-2025\n" +
+				"Spotify,200,60601fee-2bf1-4721-ae6f-7636e79a0cba,2025/07\n" +
+				"Yandex Plus,300,60601fee-2bf1-4721-ae6f-7636e79a0cba,07-2025\n",
+			errs: []int{2, 1, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ParseCSV(strings.NewReader(tt.input), tt.columns)
+			if err != nil {
+				t.Fatalf("ParseCSV() error = %v", err)
+			}
+			if len(rows) != len(tt.errs) {
+				t.Fatalf("ParseCSV() returned %d rows, want %d", len(rows), len(tt.errs))
+			}
+			for i, row := range rows {
+				if row.Row != i+1 {
+					t.Errorf("rows[%d].Row = %d, want %d", i, row.Row, i+1)
+				}
+				if len(row.Errors) != tt.errs[i] {
+					t.Errorf("rows[%d].Errors = %v, want %d errors", i, row.Errors, tt.errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseCSVRejectsFile(t *testing.T) {
+	for name, input := range map[string]string{
+		"empty":                   "",
+		"missing required column": "service_name,price,user_id\nNetflix,400,60601fee-2bf1-4721-ae6f-7636e79a0cba\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseCSV(strings.NewReader(input), nil); err == nil {
+				t.Error("ParseCSV() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	input := `[
+		{"service_name": "Netflix", "price": 400, "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba", "start_date": "07-2025"},
+		{"service_name": "Spotify", "price": "200", "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba", "start_date": "07-2025"},
+		{"service_name": "Yandex Plus", "price": 300, "start_date": "07-2025"},
+		{"Сервис": "Kinopoisk", "price": 300, "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba", "start_date": "07-2025"}
+	]`
+	rows, err := ParseJSON(strings.NewReader(input), map[string]string{"сервис": fieldServiceName})
+	if err != nil {
+		t.Fatalf("ParseJSON() error = %v", err)
+	}
+	// Цена строкой — ошибка разбора, без user_id — ошибка проверки, а колонка
+	// из маппинга подставляется в service_name.
+	want := []bool{false, true, true, false}
+	if len(rows) != len(want) {
+		t.Fatalf("ParseJSON() returned %d rows, want %d", len(rows), len(want))
+	}
+	for i, row := range rows {
+		if row.Row != i+1 {
+			t.Errorf("rows[%d].Row = %d, want %d", i, row.Row, i+1)
+		}
+		if got := len(row.Errors) > 0; got != want[i] {
+			t.Errorf("rows[%d].Errors = %v, want errors: %v", i, row.Errors, want[i])
+		}
+	}
+	if rows[3].Request.ServiceName != "Kinopoisk" {
+		t.Errorf("rows[3].Request.ServiceName = %q, want Kinopoisk", rows[3].Request.ServiceName)
+	}
+
+	if _, err := ParseJSON(strings.NewReader(`{"service_name": "Netflix"}`), nil); err == nil {
+		t.Error("ParseJSON() of an object error = nil, want error")
+	}
+}
@@ -0,0 +1,36 @@
+package mapper
+
+import (
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+func ToModelBudget(id int64, req api.BudgetRequest) model.Budget {
+	return model.Budget{
+		ID:           id,
+		UserID:       req.UserID,
+		ServiceID:    req.ServiceID,
+		Category:     req.Category,
+		MonthlyLimit: req.MonthlyLimit,
+	}
+}
+
+func ToBudgetResponse(budget model.Budget) api.BudgetResponse {
+	return api.BudgetResponse{
+		ID:           budget.ID,
+		UserID:       budget.UserID,
+		ServiceID:    budget.ServiceID,
+		Category:     budget.Category,
+		MonthlyLimit: budget.MonthlyLimit,
+		CreatedAt:    budget.CreatedAt,
+		UpdatedAt:    budget.UpdatedAt,
+	}
+}
+
+func ToBudgetAlertResponse(alert model.BudgetAlert) api.BudgetAlertResponse {
+	return api.BudgetAlertResponse{
+		Threshold: alert.Threshold,
+		Spent:     alert.Spent,
+		CreatedAt: alert.CreatedAt,
+	}
+}
@@ -0,0 +1,74 @@
+package mapper
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/dates"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+func ToModelMember(req api.MemberRequest) model.Member {
+	return model.Member{
+		UserID:  req.UserID,
+		Percent: req.Percent,
+		Amount:  req.Amount,
+	}
+}
+
+func ToMemberResponses(members []model.Member) []api.MemberResponse {
+	var res []api.MemberResponse
+	for _, m := range members {
+		res = append(res, api.MemberResponse{
+			UserID:  m.UserID,
+			Percent: m.Percent,
+			Amount:  m.Amount,
+		})
+	}
+	return res
+}
+
+// split возвращает правило разделения только для совместной подписки.
+func split(sub model.Subscription) string {
+	if len(sub.Members) == 0 {
+		return ""
+	}
+	return sub.Split
+}
+
+// ToSharingResponse рассчитывает доли владельца и участников в цене подписки за месяц month.
+func ToSharingResponse(sub model.Subscription, month time.Time) api.SharingResponse {
+	members := make(map[uuid.UUID]model.Member, len(sub.Members))
+	for _, m := range sub.Members {
+		members[m.UserID] = m
+	}
+
+	price := sub.PriceIn(month)
+	res := api.SharingResponse{
+		SubscriptionID: sub.ID,
+		Split:          sub.Split,
+		Month:          dates.FormatMonth(month),
+		Price:          price,
+	}
+	for _, share := range sub.Shares(price) {
+		m := members[share.UserID]
+		res.Shares = append(res.Shares, api.ShareResponse{
+			UserID:  share.UserID,
+			Owner:   share.Owner,
+			Percent: m.Percent,
+			Amount:  m.Amount,
+			Share:   share.Amount,
+		})
+	}
+	return res
+}
+
+func ToBalanceResponse(b model.Balance) api.BalanceResponse {
+	return api.BalanceResponse{
+		FromUserID:    b.From,
+		ToUserID:      b.To,
+		Amount:        b.Amount,
+		Subscriptions: b.SubscriptionIDs,
+	}
+}
@@ -0,0 +1,83 @@
+package mapper
+
+import (
+	"github.com/shenikar/subscription-service/internal/dates"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+func ToOrganisationResponse(org model.Organisation) api.OrganisationResponse {
+	return api.OrganisationResponse{
+		ID:        org.ID,
+		Name:      org.Name,
+		CreatedAt: org.CreatedAt,
+	}
+}
+
+func ToModelUnit(orgID, id int64, req api.OrgUnitRequest) model.OrgUnit {
+	return model.OrgUnit{
+		ID:             id,
+		OrganisationID: orgID,
+		ParentID:       req.ParentID,
+		Kind:           req.Kind,
+		Name:           req.Name,
+	}
+}
+
+func ToUnitResponse(unit model.OrgUnit) api.OrgUnitResponse {
+	return api.OrgUnitResponse{
+		ID:             unit.ID,
+		OrganisationID: unit.OrganisationID,
+		ParentID:       unit.ParentID,
+		Kind:           unit.Kind,
+		Name:           unit.Name,
+		CreatedAt:      unit.CreatedAt,
+	}
+}
+
+func ToModelCostCentre(orgID, id int64, req api.CostCentreRequest) model.CostCentre {
+	return model.CostCentre{
+		ID:             id,
+		OrganisationID: orgID,
+		UnitID:         req.UnitID,
+		Code:           req.Code,
+		Name:           req.Name,
+	}
+}
+
+func ToCostCentreResponse(cc model.CostCentre) api.CostCentreResponse {
+	return api.CostCentreResponse{
+		ID:             cc.ID,
+		OrganisationID: cc.OrganisationID,
+		UnitID:         cc.UnitID,
+		Code:           cc.Code,
+		Name:           cc.Name,
+		CreatedAt:      cc.CreatedAt,
+	}
+}
+
+func ToOrgMemberResponse(m model.OrgMember) api.OrgMemberResponse {
+	return api.OrgMemberResponse{
+		UserID:    m.UserID,
+		Role:      m.Role,
+		UnitID:    m.UnitID,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+// ToAllocationPeriods строит периоды распределения подписки: каждое распределение
+// действует до месяца перед следующим.
+func ToAllocationPeriods(sub model.Subscription, dateFormat string) []api.AllocationPeriod {
+	var res []api.AllocationPeriod
+	for _, a := range sub.Allocations {
+		if len(res) > 0 {
+			to := dates.Format(a.EffectiveFrom.AddDate(0, -1, 0), dateFormat)
+			res[len(res)-1].To = &to
+		}
+		res = append(res, api.AllocationPeriod{
+			From:         dates.Format(a.EffectiveFrom, dateFormat),
+			CostCentreID: a.CostCentreID,
+		})
+	}
+	return res
+}
@@ -0,0 +1,42 @@
+package mapper
+
+import (
+	"github.com/shenikar/subscription-service/internal/dates"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+func ToSpendingBucketResponse(b model.SpendingBucket) api.SpendingBucket {
+	return api.SpendingBucket{
+		Key:           b.Key,
+		Label:         b.Label,
+		Total:         b.Total,
+		Subscriptions: b.Subscriptions,
+	}
+}
+
+func ToDuplicatePairResponse(p model.DuplicatePair) api.DuplicatePair {
+	var to *string
+	if p.OverlapTo != nil {
+		s := dates.FormatMonth(*p.OverlapTo)
+		to = &s
+	}
+	return api.DuplicatePair{
+		UserID:      p.UserID,
+		ServiceID:   p.ServiceID,
+		ServiceName: p.ServiceName,
+		FirstID:     p.FirstID,
+		SecondID:    p.SecondID,
+		OverlapFrom: dates.FormatMonth(p.OverlapFrom),
+		OverlapTo:   to,
+	}
+}
+
+func ToCancellationBucketResponse(b model.CancellationBucket) api.CancellationBucket {
+	return api.CancellationBucket{
+		Key:         b.Key,
+		Label:       b.Label,
+		Count:       b.Count,
+		LostRevenue: b.LostRevenue,
+	}
+}
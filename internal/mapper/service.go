@@ -0,0 +1,62 @@
+package mapper
+
+import (
+	"strings"
+
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+const defaultCurrency = "RUB"
+
+// NormalizeServiceName убирает лишние пробелы в названии сервиса. Регистр сохраняется:
+// каталог сравнивает названия без учёта регистра.
+func NormalizeServiceName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func ToModelService(id int64, req api.ServiceRequest) model.Service {
+	aliases := make([]string, 0, len(req.Aliases))
+	seen := map[string]bool{strings.ToLower(NormalizeServiceName(req.Name)): true}
+	for _, alias := range req.Aliases {
+		alias = NormalizeServiceName(alias)
+		key := strings.ToLower(alias)
+		if alias == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		aliases = append(aliases, alias)
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	return model.Service{
+		ID:           id,
+		Name:         NormalizeServiceName(req.Name),
+		Aliases:      aliases,
+		Category:     req.Category,
+		Website:      req.Website,
+		DefaultPrice: req.DefaultPrice,
+		Currency:     currency,
+	}
+}
+
+func ToServiceResponse(svc model.Service) api.ServiceResponse {
+	aliases := svc.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+	return api.ServiceResponse{
+		ID:           svc.ID,
+		Name:         svc.Name,
+		Aliases:      aliases,
+		Category:     svc.Category,
+		Website:      svc.Website,
+		DefaultPrice: svc.DefaultPrice,
+		Currency:     svc.Currency,
+		CreatedAt:    svc.CreatedAt,
+		UpdatedAt:    svc.UpdatedAt,
+	}
+}
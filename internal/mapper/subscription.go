@@ -2,90 +2,212 @@ package mapper
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
-	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/dates"
 	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/pkg/api"
 )
 
-func ParseMonthYear(s string) (time.Time, error) {
-	return time.Parse("01-2006", s)
-}
-
-func FormatMonthYear(t time.Time) string {
-	return t.Format("01-2006")
-}
-
-func ToModelSubscription(dto dto.CreateSubscriptionRequest) (model.Subscription, error) {
-	startDate, err := ParseMonthYear(dto.StartDate)
+// ToModelSubscription разбирает даты подписки до первого числа месяца и проверяет,
+// что end_date не раньше start_date. Моменты времени RFC 3339 относятся к месяцу
+// в часовом поясе пользователя loc. Пробный период задаётся днём окончания или
+// длиной в днях от start_date и должен заканчиваться позже start_date.
+func ToModelSubscription(dto api.CreateSubscriptionRequest, loc *time.Location) (model.Subscription, error) {
+	startDate, err := dates.ParseMonthIn(dto.StartDate, loc)
 	if err != nil {
 		return model.Subscription{}, fmt.Errorf("invalid start_date format: %w", err)
 	}
 
 	var endDate *time.Time
 	if dto.EndDate != nil {
-		ed, err := ParseMonthYear(*dto.EndDate)
+		ed, err := dates.ParseMonthIn(*dto.EndDate, loc)
 		if err != nil {
 			return model.Subscription{}, fmt.Errorf("invalid end_date format: %w", err)
 		}
+		if ed.Before(startDate) {
+			return model.Subscription{}, fmt.Errorf("end_date %s must not be before start_date %s",
+				dates.FormatMonth(ed), dates.FormatMonth(startDate))
+		}
 		endDate = &ed
 	}
+	var trialEndsAt *time.Time
+	switch {
+	case dto.TrialEndsAt != nil:
+		t, err := dates.ParseIn(*dto.TrialEndsAt, loc)
+		if err != nil {
+			return model.Subscription{}, fmt.Errorf("invalid trial_ends_at format: %w", err)
+		}
+		trialEndsAt = &t
+	case dto.TrialDays != nil:
+		t := startDate.AddDate(0, 0, *dto.TrialDays)
+		trialEndsAt = &t
+	}
+	if trialEndsAt != nil && !trialEndsAt.After(startDate) {
+		return model.Subscription{}, fmt.Errorf("trial_ends_at %s must be after start_date %s",
+			dates.Format(*trialEndsAt, dates.Date), dates.Format(startDate, dates.Date))
+	}
+	var serviceID int64
+	if dto.ServiceID != nil {
+		serviceID = *dto.ServiceID
+	}
 	return model.Subscription{
+		ServiceID:   serviceID,
 		ServiceName: dto.ServiceName,
 		Price:       dto.Price,
 		UserID:      dto.UserID,
 		StartDate:   startDate,
 		EndDate:     endDate,
+		TrialEndsAt: trialEndsAt,
+		Category:    nonBlank(dto.Category),
+		Notes:       nonBlank(dto.Notes),
+		Tags:        model.NormalizeTags(dto.Tags),
 	}, nil
 }
 
-func ToResponseDTO(sub model.Subscription) dto.SubscriptionResponse {
+// nonBlank убирает пробелы по краям строки и возвращает nil для пустой строки.
+func nonBlank(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// ToResponseDTO форматирует даты подписки в формате dateFormat (одна из констант dates).
+func ToResponseDTO(sub model.Subscription, dateFormat string) api.SubscriptionResponse {
 	var endDateSrt *string
 	if sub.EndDate != nil {
-		s := FormatMonthYear(*sub.EndDate)
+		s := dates.Format(*sub.EndDate, dateFormat)
 		endDateSrt = &s
 	}
-	return dto.SubscriptionResponse{
+	return api.SubscriptionResponse{
 		ID:          sub.ID,
+		ServiceID:   sub.ServiceID,
 		ServiceName: sub.ServiceName,
 		Price:       sub.Price,
 		UserID:      sub.UserID,
-		StartDate:   FormatMonthYear(sub.StartDate),
+		StartDate:   dates.Format(sub.StartDate, dateFormat),
 		EndDate:     endDateSrt,
+		Version:     sub.Version,
+
+		PriceTimeline: ToPriceTimeline(sub, dateFormat),
+		OverlapsWith:  sub.OverlapsWith,
+		Paused:        sub.PausedIn(model.MonthStart(time.Now())),
+		Pauses:        ToPausePeriods(sub, dateFormat),
+		TrialEndsAt:   formatTrialEnd(sub),
+		InTrial:       sub.InTrialOn(dates.Today(time.UTC)),
+		Category:      sub.Category,
+		Notes:         sub.Notes,
+		Tags:          append([]string{}, sub.Tags...),
+		Split:         split(sub),
+		Members:       ToMemberResponses(sub.Members),
+		CostCentreID:  sub.CostCentreIn(model.MonthStart(time.Now())),
+		Allocations:   ToAllocationPeriods(sub, dateFormat),
 	}
 }
 
-func ToModelSubscriptionFromUpdate(id int64, dto dto.UpdateSubscriptionRequest, current model.Subscription) (model.Subscription, error) {
-	sub := current
-	sub.ID = id
+// ToPausePeriods форматирует приостановки подписки в формате dateFormat.
+func ToPausePeriods(sub model.Subscription, dateFormat string) []api.PausePeriod {
+	res := make([]api.PausePeriod, 0, len(sub.Pauses))
+	for _, p := range sub.Pauses {
+		period := api.PausePeriod{From: dates.Format(p.From, dateFormat)}
+		if p.To != nil {
+			to := dates.Format(*p.To, dateFormat)
+			period.To = &to
+		}
+		res = append(res, period)
+	}
+	return res
+}
+
+// ToPriceTimeline строит периоды действия цен подписки: начальная цена действует с месяца
+// начала, каждое изменение — до месяца перед следующим. Изменения после окончания
+// подписки не попадают в график.
+func ToPriceTimeline(sub model.Subscription, dateFormat string) []api.PricePeriod {
+	timeline := []api.PricePeriod{{From: dates.Format(sub.StartDate, dateFormat), Price: sub.Price}}
+	for _, change := range sub.PriceChanges() {
+		if sub.EndDate != nil && change.EffectiveFrom.After(*sub.EndDate) {
+			break
+		}
+		to := dates.Format(change.EffectiveFrom.AddDate(0, -1, 0), dateFormat)
+		timeline[len(timeline)-1].To = &to
+		timeline = append(timeline, api.PricePeriod{From: dates.Format(change.EffectiveFrom, dateFormat), Price: change.Price})
+	}
+	if sub.EndDate != nil {
+		end := dates.Format(*sub.EndDate, dateFormat)
+		timeline[len(timeline)-1].To = &end
+	}
+	return timeline
+}
 
-	if dto.ServiceName != nil {
-		sub.ServiceName = *dto.ServiceName
+func ToModelSubscriptionFromUpdate(id int64, req api.UpdateSubscriptionRequest, loc *time.Location) (model.Subscription, error) {
+	sub, err := ToModelSubscription(api.CreateSubscriptionRequest(req), loc)
+	if err != nil {
+		return model.Subscription{}, err
 	}
-	if dto.Price != nil {
-		sub.Price = *dto.Price
+	sub.ID = id
+	return sub, nil
+}
+
+// ToUpdateRequest возвращает текущее состояние подписки в формате запроса PUT,
+// к которому применяются PATCH-документы. Сервис передаётся только названием,
+// чтобы патч мог сменить его как через service_name, так и через service_id.
+func ToUpdateRequest(sub model.Subscription) api.UpdateSubscriptionRequest {
+	var endDate *string
+	if sub.EndDate != nil {
+		s := dates.FormatMonth(*sub.EndDate)
+		endDate = &s
 	}
-	if dto.UserID != nil {
-		sub.UserID = *dto.UserID
+	return api.UpdateSubscriptionRequest{
+		ServiceName: sub.ServiceName,
+		Price:       sub.Price,
+		UserID:      sub.UserID,
+		StartDate:   dates.FormatMonth(sub.StartDate),
+		EndDate:     endDate,
+		TrialEndsAt: formatTrialEnd(sub),
+		Category:    sub.Category,
+		Notes:       sub.Notes,
+		Tags:        sub.Tags,
 	}
-	if dto.StartDate != nil {
-		startDate, err := ParseMonthYear(*dto.StartDate)
-		if err != nil {
-			return model.Subscription{}, fmt.Errorf("invalid start_date format: %w", err)
-		}
-		sub.StartDate = startDate
+}
+
+// formatTrialEnd форматирует день окончания пробного периода как YYYY-MM-DD: в отличие
+// от дат подписки он не совпадает с первым числом месяца.
+func formatTrialEnd(sub model.Subscription) *string {
+	if sub.TrialEndsAt == nil {
+		return nil
 	}
-	if dto.EndDate != nil {
-		if *dto.EndDate != "" {
-			endDate, err := ParseMonthYear(*dto.EndDate)
-			if err != nil {
-				return model.Subscription{}, fmt.Errorf("invalid end_date format: %w", err)
-			}
-			sub.EndDate = &endDate
-		} else {
-			sub.EndDate = nil
-		}
+	s := dates.Format(*sub.TrialEndsAt, dates.Date)
+	return &s
+}
+
+// ToCancellationResponse форматирует отмену и отменённую подписку в формате dateFormat.
+func ToCancellationResponse(c model.Cancellation, sub model.Subscription, dateFormat string) api.CancellationResponse {
+	return api.CancellationResponse{
+		ID:             c.ID,
+		SubscriptionID: c.SubscriptionID,
+		Reason:         c.Reason,
+		Note:           c.Note,
+		Mode:           c.Mode,
+		EndDate:        dates.Format(c.EndDate, dateFormat),
+		CancelledAt:    c.CancelledAt,
+		Subscription:   ToResponseDTO(sub, dateFormat),
 	}
+}
 
-	return sub, nil
+func ToSearchResult(hit model.SearchHit, dateFormat string) api.SearchResult {
+	return api.SearchResult{
+		Subscription: ToResponseDTO(hit.Subscription, dateFormat),
+		Rank:         hit.Rank,
+		Highlights: api.SearchHighlights{
+			ServiceName: hit.Highlights.ServiceName,
+			Tags:        hit.Highlights.Tags,
+			Notes:       hit.Highlights.Notes,
+		},
+	}
 }
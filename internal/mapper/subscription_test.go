@@ -0,0 +1,59 @@
+package mapper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+func TestToModelSubscriptionPeriod(t *testing.T) {
+	str := func(s string) *string { return &s }
+	vladivostok, err := time.LoadLocation("Asia/Vladivostok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		start string
+		end   *string
+		loc   *time.Location
+		want  time.Time
+		err   string
+	}{
+		{name: "open-ended", start: "2025-07-15", want: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "same month", start: "07-2025", end: str("2025-07-31"), want: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "later end", start: "07-2025", end: str("2026-01")},
+		// Утро первого июля во Владивостоке — ещё 30 июня в UTC.
+		{name: "moment in user zone", start: "2025-06-30T22:00:00Z", loc: vladivostok, want: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "moment without zone", start: "2025-06-30T22:00:00Z", want: time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "end before start in user zone", start: "2025-07-01T01:00:00+10:00", end: str("2025-06-30T12:00:00Z"), loc: vladivostok, err: "end_date 06-2025 must not be before start_date 07-2025"},
+		{name: "end before start", start: "07-2025", end: str("06-2025"), err: "end_date 06-2025 must not be before start_date 07-2025"},
+		{name: "invalid start", start: "2025/07", err: "invalid start_date format"},
+		{name: "invalid end", start: "07-2025", end: str("2025-13"), err: "invalid end_date format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := ToModelSubscription(api.CreateSubscriptionRequest{
+				ServiceName: "Netflix", Price: 400, UserID: uuid.New(), StartDate: tt.start, EndDate: tt.end,
+			}, tt.loc)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("ToModelSubscription() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ToModelSubscription() error = %v", err)
+			}
+			if !tt.want.IsZero() && !sub.StartDate.Equal(tt.want) {
+				t.Errorf("StartDate = %v, want %v", sub.StartDate, tt.want)
+			}
+			if sub.EndDate != nil && sub.EndDate.Day() != 1 {
+				t.Errorf("EndDate = %v, want first day of month", *sub.EndDate)
+			}
+		})
+	}
+}
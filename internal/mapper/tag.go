@@ -0,0 +1,15 @@
+package mapper
+
+import (
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+func ToTagResponse(tag model.Tag) api.TagResponse {
+	return api.TagResponse{
+		ID:            tag.ID,
+		Name:          tag.Name,
+		Subscriptions: tag.Subscriptions,
+		CreatedAt:     tag.CreatedAt,
+	}
+}
@@ -0,0 +1,41 @@
+package mapper
+
+import (
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+const defaultReminderDays = 3
+
+func ToModelUser(id uuid.UUID, req api.UserRequest) model.User {
+	currency := req.Currency
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	reminderDays := defaultReminderDays
+	if req.ReminderDays != nil {
+		reminderDays = *req.ReminderDays
+	}
+	return model.User{
+		ID:           id,
+		Email:        req.Email,
+		Name:         req.Name,
+		Currency:     currency,
+		ReminderDays: reminderDays,
+		TimeZone:     req.TimeZone,
+	}
+}
+
+func ToUserResponse(user model.User) api.UserResponse {
+	return api.UserResponse{
+		ID:           user.ID,
+		Email:        user.Email,
+		Name:         user.Name,
+		Currency:     user.Currency,
+		ReminderDays: user.ReminderDays,
+		TimeZone:     user.TimeZone,
+		CreatedAt:    user.CreatedAt,
+		UpdatedAt:    user.UpdatedAt,
+	}
+}
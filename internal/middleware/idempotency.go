@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyStore хранит ключи идемпотентности; в сервисе это
+// repository.IdempotencyRepository.
+type IdempotencyStore interface {
+	// Reserve резервирует ключ и возвращает nil или уже сохранённый ключ.
+	Reserve(ctx context.Context, key model.IdempotencyKey) (*model.IdempotencyKey, error)
+	Complete(ctx context.Context, key model.IdempotencyKey) error
+	Release(ctx context.Context, key model.IdempotencyKey) error
+}
+
+// Idempotency сохраняет ответ на запрос с заголовком Idempotency-Key и повторяет
+// его для повторных запросов с тем же ключом и телом.
+func Idempotency(repo IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	log := logger.GetLogger()
+
+	return func(c *gin.Context) {
+		idemKey := c.GetHeader(IdempotencyKeyHeader)
+		if idemKey == "" {
+			c.Next()
+			return
+		}
+		if len(idemKey) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			log.WithError(err).Warn("Idempotency: failed to read request body")
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := model.IdempotencyKey{
+			Key:         idemKey,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			Fingerprint: fingerprint(c, body),
+			ExpiresAt:   time.Now().Add(ttl),
+		}
+		fields := logrus.Fields{"key": key.Key, "method": key.Method, "path": key.Path}
+
+		existing, err := repo.Reserve(c.Request.Context(), key)
+		if err != nil {
+			log.WithError(err).WithFields(fields).Error("Idempotency: failed to reserve key")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to process idempotency key"})
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.Fingerprint != key.Fingerprint:
+				log.WithFields(fields).Warn("Idempotency: key reused with different request")
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+			case existing.StatusCode == nil:
+				log.WithFields(fields).Warn("Idempotency: request with the same key is in progress")
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "request with this Idempotency-Key is still in progress"})
+			default:
+				log.WithFields(fields).Info("Idempotency: replaying stored response")
+				contentType := ""
+				if existing.ContentType != nil {
+					contentType = *existing.ContentType
+				}
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(*existing.StatusCode, contentType, existing.ResponseBody)
+				c.Abort()
+			}
+			return
+		}
+
+		// Если обработчик паникует, ответ 500 формирует gin.Recovery снаружи этого
+		// middleware. Ключ освобождается до этого, иначе повторы с тем же ключом
+		// получали бы 409 до истечения TTL.
+		defer func() {
+			if p := recover(); p != nil {
+				if err := repo.Release(context.WithoutCancel(c.Request.Context()), key); err != nil {
+					log.WithError(err).WithFields(fields).Error("Idempotency: failed to release key after panic")
+				}
+				panic(p)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := repo.Release(c.Request.Context(), key); err != nil {
+				log.WithError(err).WithFields(fields).Error("Idempotency: failed to release key")
+			}
+			return
+		}
+
+		contentType := recorder.Header().Get("Content-Type")
+		key.StatusCode = &status
+		key.ContentType = &contentType
+		key.ResponseBody = recorder.body.Bytes()
+		if err := repo.Complete(c.Request.Context(), key); err != nil {
+			log.WithError(err).WithFields(fields).Error("Idempotency: failed to store response")
+		}
+	}
+}
+
+func fingerprint(c *gin.Context, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + "\n" + c.Request.URL.RequestURI() + "\n" + c.ContentType() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/subscription-service/internal/testutil"
+)
+
+func TestIdempotencyReleasesKeyOnPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	calls := 0
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.POST("/subscriptions", Idempotency(testutil.NewIdempotencyKeys(), time.Hour), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("handler failed")
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(`{"price":100}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name     string
+		status   int
+		replayed bool
+	}{
+		{name: "panic", status: http.StatusInternalServerError},
+		{name: "retry after panic", status: http.StatusCreated},
+		{name: "replay", status: http.StatusCreated, replayed: true},
+	}
+	for _, tt := range tests {
+		w := send()
+		if w.Code != tt.status {
+			t.Fatalf("%s: status = %d, want %d (body %s)", tt.name, w.Code, tt.status, w.Body.String())
+		}
+		if got := w.Header().Get(IdempotentReplayedHeader) == "true"; got != tt.replayed {
+			t.Fatalf("%s: replayed = %v, want %v", tt.name, got, tt.replayed)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("handler calls = %d, want 2", calls)
+	}
+}
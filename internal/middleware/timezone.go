@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/subscription-service/internal/dates"
+)
+
+// TimeZoneHeader задаёт часовой пояс запроса, если не задан параметр tz.
+const TimeZoneHeader = "X-Time-Zone"
+
+// TimeZone сохраняет в контексте запроса часовой пояс из параметра tz или заголовка
+// X-Time-Zone. Без них используется часовой пояс пользователя или сервиса.
+func TimeZone() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Query("tz")
+		if name == "" {
+			name = c.GetHeader(TimeZoneHeader)
+		}
+		if name == "" {
+			c.Next()
+			return
+		}
+
+		loc, err := dates.LoadLocation(name)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Request = c.Request.WithContext(dates.WithLocation(c.Request.Context(), loc))
+		c.Next()
+	}
+}
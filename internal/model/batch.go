@@ -0,0 +1,14 @@
+package model
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+type BatchOp struct {
+	Kind            string
+	Subscription    *Subscription
+	ID              int64
+	ExpectedVersion *int
+}
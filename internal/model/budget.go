@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Пороги использования бюджета в процентах, при достижении которых отправляется уведомление.
+const (
+	BudgetWarningThreshold  = 80
+	BudgetExceededThreshold = 100
+)
+
+var BudgetThresholds = []int{BudgetWarningThreshold, BudgetExceededThreshold}
+
+// Budget — месячный лимит расходов пользователя. Если задан ServiceID или Category,
+// учитываются только подписки этого сервиса или этой категории.
+type Budget struct {
+	ID           int64     `db:"id"`
+	UserID       uuid.UUID `db:"user_id"`
+	ServiceID    *int64    `db:"service_id"`
+	Category     *string   `db:"category"`
+	MonthlyLimit int       `db:"monthly_limit"`
+	CreatedAt    time.Time `db:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+// BudgetAlert — уведомление о достижении порога бюджета в месяце Month.
+type BudgetAlert struct {
+	BudgetID  int64
+	UserID    uuid.UUID
+	Month     time.Time
+	Threshold int
+	Spent     int
+	Limit     int
+	CreatedAt time.Time
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Режимы отмены подписки.
+const (
+	// CancelImmediately прекращает подписку сразу: начислений после текущего месяца нет,
+	// а пробный период заканчивается без перехода в платную подписку.
+	CancelImmediately = "immediately"
+	// CancelEndOfPeriod оставляет подписку до конца оплаченного периода: текущего месяца
+	// или последнего месяца пробного периода.
+	CancelEndOfPeriod = "end_of_period"
+)
+
+// Причины отмены подписки.
+const (
+	CancelReasonTooExpensive    = "too_expensive"
+	CancelReasonNotUsing        = "not_using"
+	CancelReasonSwitched        = "switched_service"
+	CancelReasonMissingFeatures = "missing_features"
+	CancelReasonTechnical       = "technical_issues"
+	CancelReasonTemporary       = "temporary"
+	CancelReasonOther           = "other"
+)
+
+// Cancellation — отмена подписки. EndDate — последний месяц подписки, Price — цена
+// в этом месяце, по ней отчёт об оттоке оценивает потерянные ежемесячные расходы.
+type Cancellation struct {
+	ID             int64
+	SubscriptionID int64
+	UserID         uuid.UUID
+	ServiceID      int64
+	Reason         string
+	Note           *string
+	Mode           string
+	EndDate        time.Time
+	Price          int
+	CancelledAt    time.Time
+}
+
+// CancellationBucket — отмены одной группы отчёта об оттоке.
+type CancellationBucket struct {
+	Key         string
+	Label       string
+	Count       int
+	LostRevenue int
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type IdempotencyKey struct {
+	Key          string    `db:"key"`
+	Method       string    `db:"method"`
+	Path         string    `db:"path"`
+	Fingerprint  string    `db:"fingerprint"`
+	StatusCode   *int      `db:"status_code"`
+	ContentType  *string   `db:"content_type"`
+	ResponseBody []byte    `db:"response_body"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Виды подразделений: отделы входят в организацию, команды — в отделы или в организацию.
+const (
+	UnitDepartment = "department"
+	UnitTeam       = "team"
+)
+
+// Роли участников организации.
+const (
+	// RoleOwner управляет организацией; у организации всегда есть хотя бы один владелец.
+	RoleOwner   = "owner"
+	RoleAdmin   = "admin"
+	RoleFinance = "finance"
+	RoleMember  = "member"
+)
+
+// Organisation — компания, расходы которой распределяются по центрам затрат.
+type Organisation struct {
+	ID        int64     `db:"id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// OrgUnit — подразделение организации: отдел или команда. ParentID команды — её отдел.
+type OrgUnit struct {
+	ID             int64     `db:"id"`
+	OrganisationID int64     `db:"organisation_id"`
+	ParentID       *int64    `db:"parent_id"`
+	Kind           string    `db:"kind"`
+	Name           string    `db:"name"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+// CostCentre — центр затрат организации. Без UnitID он относится к организации целиком.
+type CostCentre struct {
+	ID             int64     `db:"id"`
+	OrganisationID int64     `db:"organisation_id"`
+	UnitID         *int64    `db:"unit_id"`
+	Code           string    `db:"code"`
+	Name           string    `db:"name"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+// OrgMember — участник организации с ролью и, возможно, подразделением.
+type OrgMember struct {
+	OrganisationID int64     `db:"organisation_id"`
+	UserID         uuid.UUID `db:"user_id"`
+	Role           string    `db:"role"`
+	UnitID         *int64    `db:"unit_id"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+// Allocation относит подписку к центру затрат с месяца EffectiveFrom до следующего
+// перераспределения. CostCentreID равен nil, если подписку сняли с центра затрат.
+type Allocation struct {
+	EffectiveFrom time.Time
+	CostCentreID  *int64
+}
+
+// CostCentreSpending — начисления подписок, отнесённых к центру затрат, за период.
+type CostCentreSpending struct {
+	CostCentreID  int64
+	Total         int
+	Subscriptions int
+}
+
+// CostCentreIn возвращает центр затрат подписки в месяце month или nil.
+func (s Subscription) CostCentreIn(month time.Time) *int64 {
+	var res *int64
+	for _, a := range s.Allocations {
+		if a.EffectiveFrom.After(month) {
+			break
+		}
+		res = a.CostCentreID
+	}
+	return res
+}
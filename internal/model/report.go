@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Группировки отчёта о расходах.
+const (
+	GroupByMonth    = "month"
+	GroupByService  = "service"
+	GroupByUser     = "user"
+	GroupByCategory = "category"
+	GroupByTag      = "tag"
+)
+
+// SpendingBucket — сумма начислений группы отчёта и число подписок, по которым они были.
+type SpendingBucket struct {
+	Key           string
+	Label         string
+	Total         int
+	Subscriptions int
+}
+
+// DuplicatePair — две подписки одного пользователя на один сервис с пересекающимися
+// периодами. OverlapTo равен nil, если обе подписки бессрочные.
+type DuplicatePair struct {
+	UserID      uuid.UUID
+	ServiceID   int64
+	ServiceName string
+	FirstID     int64
+	SecondID    int64
+	OverlapFrom time.Time
+	OverlapTo   *time.Time
+}
@@ -0,0 +1,17 @@
+package model
+
+// SearchHit — подписка, найденная поиском, с релевантностью и совпавшими фрагментами.
+type SearchHit struct {
+	Subscription Subscription
+	// Rank — релевантность: чем больше, тем выше подписка в выдаче.
+	Rank       float64
+	Highlights SearchHighlights
+}
+
+// SearchHighlights — совпавшие поля подписки, где найденные фрагменты обрамлены
+// маркерами <mark> и </mark>. Пустые поля не совпали с запросом.
+type SearchHighlights struct {
+	ServiceName string
+	Tags        []string
+	Notes       string
+}
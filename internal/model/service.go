@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Service — запись каталога сервисов. Подписки ссылаются на неё по ID.
+type Service struct {
+	ID           int64     `db:"id"`
+	Name         string    `db:"name"`
+	Aliases      []string  `db:"aliases"`
+	Category     *string   `db:"category"`
+	Website      *string   `db:"website"`
+	DefaultPrice *int      `db:"default_price"`
+	Currency     string    `db:"currency"`
+	CreatedAt    time.Time `db:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
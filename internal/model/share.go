@@ -0,0 +1,91 @@
+package model
+
+import "github.com/google/uuid"
+
+// Правила разделения стоимости совместной подписки между владельцем и участниками.
+const (
+	// SplitEqual делит начисление поровну между владельцем и участниками.
+	SplitEqual = "equal"
+	// SplitPercentage начисляет участнику заданный процент, владельцу — остаток.
+	SplitPercentage = "percentage"
+	// SplitFixed начисляет участнику фиксированную сумму в месяц, владельцу — остаток.
+	// Если начисление меньше суммы долей, доли уменьшаются пропорционально.
+	SplitFixed = "fixed"
+)
+
+// MaxMembers — наибольшее число участников совместной подписки.
+const MaxMembers = 20
+
+// Member — участник совместной подписки. Percent задаётся для правила percentage,
+// Amount — для правила fixed.
+type Member struct {
+	UserID  uuid.UUID
+	Percent *int
+	Amount  *int
+}
+
+// Share — доля пользователя в начислении подписки за месяц.
+type Share struct {
+	UserID uuid.UUID
+	Owner  bool
+	Amount int
+}
+
+// Balance — сумма, которую пользователь From должен пользователю To за совместные подписки.
+type Balance struct {
+	From            uuid.UUID
+	To              uuid.UUID
+	Amount          int
+	SubscriptionIDs []int64
+}
+
+// UserIDs возвращает владельца и участников подписки.
+func (s Subscription) UserIDs() []uuid.UUID {
+	ids := []uuid.UUID{s.UserID}
+	for _, m := range s.Members {
+		ids = append(ids, m.UserID)
+	}
+	return ids
+}
+
+// Shares делит начисление amount между владельцем и участниками по правилу Split.
+// Доли участников округляются вниз, остаток платит владелец, он идёт первым.
+// Так же начисления делит chargesQuery.
+func (s Subscription) Shares(amount int) []Share {
+	shares := []Share{{UserID: s.UserID, Owner: true, Amount: amount}}
+	fixed := 0
+	for _, m := range s.Members {
+		if m.Amount != nil {
+			fixed += *m.Amount
+		}
+	}
+	for _, m := range s.Members {
+		var share int
+		switch s.Split {
+		case SplitPercentage:
+			if m.Percent != nil {
+				share = amount * *m.Percent / 100
+			}
+		case SplitFixed:
+			if m.Amount != nil {
+				share = amount * *m.Amount / max(fixed, amount)
+			}
+		default:
+			share = amount / (len(s.Members) + 1)
+		}
+		shares[0].Amount -= share
+		shares = append(shares, Share{UserID: m.UserID, Amount: share})
+	}
+	return shares
+}
+
+// ShareOf возвращает долю пользователя userID в начислении amount или 0, если он
+// не владелец и не участник подписки.
+func (s Subscription) ShareOf(userID uuid.UUID, amount int) int {
+	for _, share := range s.Shares(amount) {
+		if share.UserID == userID {
+			return share.Amount
+		}
+	}
+	return 0
+}
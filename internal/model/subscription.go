@@ -8,9 +8,136 @@ import (
 
 type Subscription struct {
 	ID          int64      `db:"id"`
+	ServiceID   int64      `db:"service_id"`
 	ServiceName string     `db:"service_name"`
 	Price       int        `db:"price"`
 	UserID      uuid.UUID  `db:"user_id"`
 	StartDate   time.Time  `db:"start_date"`
 	EndDate     *time.Time `db:"end_date"`
+	Version     int        `db:"version"`
+	// Prices — запланированные изменения цены по возрастанию EffectiveFrom.
+	// До первого изменения действует Price.
+	Prices []PriceChange `db:"-"`
+	// AllowOverlap допускает пересечение периода с другими подписками того же
+	// пользователя на тот же сервис.
+	AllowOverlap bool `db:"allow_overlap"`
+	// OverlapsWith — ID пересекающихся подписок, найденных при последнем сохранении.
+	OverlapsWith []int64 `db:"-"`
+	// Pauses — приостановки оплаты по возрастанию From.
+	Pauses []Pause `db:"-"`
+	// TrialEndsAt — день окончания пробного периода, с которого подписка платная.
+	TrialEndsAt *time.Time `db:"trial_ends_at"`
+	// Category заменяет категорию сервиса из каталога.
+	Category *string `db:"category"`
+	Notes    *string `db:"notes"`
+	// Tags — метки подписки по алфавиту.
+	Tags []string `db:"-"`
+	// Split — правило разделения стоимости с участниками Members; платит владелец UserID.
+	Split   string   `db:"split_rule"`
+	Members []Member `db:"-"`
+	// Allocations — распределение по центрам затрат по возрастанию EffectiveFrom.
+	Allocations []Allocation `db:"-"`
+}
+
+// Режимы обработки пересекающихся подписок одного пользователя на один сервис.
+const (
+	OverlapReject = "reject"
+	OverlapWarn   = "warn"
+	OverlapAllow  = "allow"
+)
+
+// PriceChange задаёт цену подписки, действующую с первого числа месяца EffectiveFrom.
+type PriceChange struct {
+	EffectiveFrom time.Time
+	Price         int
+}
+
+// Pause приостанавливает оплату подписки с месяца From по месяц To включительно.
+// To равен nil, пока подписку не возобновили.
+type Pause struct {
+	From time.Time
+	To   *time.Time
+}
+
+// Covers сообщает, приостановлена ли оплата в месяце month.
+func (p Pause) Covers(month time.Time) bool {
+	return !p.From.After(month) && (p.To == nil || !p.To.Before(month))
+}
+
+// MonthStart возвращает первое число месяца даты t в UTC: подписки оплачиваются помесячно.
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ActiveIn сообщает, действует ли подписка в месяце, начинающемся с month.
+func (s Subscription) ActiveIn(month time.Time) bool {
+	if s.StartDate.After(month) {
+		return false
+	}
+	return s.EndDate == nil || !s.EndDate.Before(month)
+}
+
+// PriceIn возвращает цену, действующую в месяце month. Изменения, запланированные
+// не позже месяца начала подписки, не учитываются: начальную цену задаёт Price.
+func (s Subscription) PriceIn(month time.Time) int {
+	price := s.Price
+	for _, change := range s.PriceChanges() {
+		if change.EffectiveFrom.After(month) {
+			break
+		}
+		price = change.Price
+	}
+	return price
+}
+
+// PausedIn сообщает, приостановлена ли оплата подписки в месяце month.
+func (s Subscription) PausedIn(month time.Time) bool {
+	for _, p := range s.Pauses {
+		if p.Covers(month) {
+			return true
+		}
+	}
+	return false
+}
+
+// InTrialOn сообщает, идёт ли в день day пробный период. Месяц не оплачивается, если
+// пробный период идёт в его первое число.
+func (s Subscription) InTrialOn(day time.Time) bool {
+	return s.TrialEndsAt != nil && day.Before(*s.TrialEndsAt)
+}
+
+// ChargeIn возвращает начисление по подписке за месяц month и false, если в этом
+// месяце подписка не действует, приостановлена или ещё в пробном периоде.
+func (s Subscription) ChargeIn(month time.Time) (int, bool) {
+	if !s.ActiveIn(month) || s.PausedIn(month) || s.InTrialOn(month) {
+		return 0, false
+	}
+	return s.PriceIn(month), true
+}
+
+// PriceChanges возвращает изменения цены, которые действуют после месяца начала подписки.
+func (s Subscription) PriceChanges() []PriceChange {
+	start := MonthStart(s.StartDate)
+	var res []PriceChange
+	for _, change := range s.Prices {
+		if change.EffectiveFrom.After(start) {
+			res = append(res, change)
+		}
+	}
+	return res
+}
+
+// Overlaps сообщает, пересекаются ли периоды подписок одного пользователя на один сервис.
+func (s Subscription) Overlaps(o Subscription) bool {
+	if s.UserID != o.UserID || s.ServiceID != o.ServiceID {
+		return false
+	}
+	if s.EndDate != nil && MonthStart(*s.EndDate).Before(MonthStart(o.StartDate)) {
+		return false
+	}
+	if o.EndDate != nil && MonthStart(*o.EndDate).Before(MonthStart(s.StartDate)) {
+		return false
+	}
+	return true
 }
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tag — метка подписок. Названия меток сравниваются без учёта регистра.
+type Tag struct {
+	ID        int64     `db:"id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+	// Subscriptions — число подписок с этой меткой.
+	Subscriptions int `db:"-"`
+}
+
+// NormalizeTags убирает пробелы по краям названий, пустые названия и повторы без учёта
+// регистра и сортирует метки по алфавиту.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var res []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		res = append(res, tag)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return strings.ToLower(res[i]) < strings.ToLower(res[j])
+	})
+	return res
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type User struct {
+	ID    uuid.UUID `db:"id"`
+	Email *string   `db:"email"`
+	Name  *string   `db:"name"`
+	// Currency — предпочитаемая валюта пользователя, код ISO 4217.
+	Currency string `db:"currency"`
+	// ReminderDays — за сколько дней напоминать о продлении подписки.
+	ReminderDays int `db:"reminder_days"`
+	// TimeZone — часовой пояс пользователя (имя IANA), nil — часовой пояс сервиса.
+	TimeZone  *string   `db:"time_zone"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
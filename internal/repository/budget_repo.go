@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shenikar/subscription-service/internal/model"
+)
+
+var (
+	ErrBudgetNotFound = errors.New("budget not found")
+	// ErrBudgetReference — пользователь или сервис бюджета не существует.
+	ErrBudgetReference = errors.New("budget references unknown user or service")
+)
+
+const budgetColumns = `id, user_id, service_id, category, monthly_limit, created_at, updated_at`
+
+func scanBudget(row pgx.Row, budget *model.Budget) error {
+	return row.Scan(&budget.ID, &budget.UserID, &budget.ServiceID, &budget.Category, &budget.MonthlyLimit,
+		&budget.CreatedAt, &budget.UpdatedAt)
+}
+
+type BudgetRepository struct {
+	conn *pgxpool.Pool
+}
+
+func NewBudgetRepository(conn *pgxpool.Pool) *BudgetRepository {
+	return &BudgetRepository{conn: conn}
+}
+
+// db возвращает транзакцию из ctx, открытую InTx, или пул.
+func (r *BudgetRepository) db(ctx context.Context) querier {
+	return connFrom(ctx, r.conn)
+}
+
+func (r *BudgetRepository) Create(ctx context.Context, budget *model.Budget) error {
+	query := `INSERT INTO budgets (user_id, service_id, category, monthly_limit)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+	err := r.db(ctx).QueryRow(ctx, query, budget.UserID, budget.ServiceID, budget.Category, budget.MonthlyLimit).
+		Scan(&budget.ID, &budget.CreatedAt, &budget.UpdatedAt)
+	if err != nil {
+		return budgetWriteError(err, "failed insert budget")
+	}
+	return nil
+}
+
+func (r *BudgetRepository) GetByID(ctx context.Context, id int64) (*model.Budget, error) {
+	query := `SELECT ` + budgetColumns + ` FROM budgets WHERE id = $1`
+
+	var budget model.Budget
+	if err := scanBudget(r.db(ctx).QueryRow(ctx, query, id), &budget); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get budget: %w", err)
+	}
+	return &budget, nil
+}
+
+func (r *BudgetRepository) List(ctx context.Context, userID *uuid.UUID, limit, offset int) ([]*model.Budget, error) {
+	query := `SELECT ` + budgetColumns + ` FROM budgets WHERE 1 = 1`
+
+	var args []interface{}
+	argNum := 1
+	if userID != nil {
+		query += fmt.Sprintf(" AND user_id = $%d", argNum)
+		args = append(args, *userID)
+		argNum++
+	}
+
+	query += " ORDER BY id"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, limit)
+		argNum++
+	}
+	if offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, offset)
+	}
+
+	rows, err := r.db(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+	defer rows.Close()
+
+	var budgets []*model.Budget
+	for rows.Next() {
+		var budget model.Budget
+		if err := scanBudget(rows, &budget); err != nil {
+			return nil, fmt.Errorf("failed to scan budget: %w", err)
+		}
+		budgets = append(budgets, &budget)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+	return budgets, nil
+}
+
+func (r *BudgetRepository) Update(ctx context.Context, budget *model.Budget) error {
+	query := `UPDATE budgets SET user_id = $1, service_id = $2, category = $3, monthly_limit = $4, updated_at = now()
+		WHERE id = $5
+		RETURNING created_at, updated_at
+	`
+	err := r.db(ctx).QueryRow(ctx, query, budget.UserID, budget.ServiceID, budget.Category, budget.MonthlyLimit, budget.ID).
+		Scan(&budget.CreatedAt, &budget.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrBudgetNotFound
+		}
+		return budgetWriteError(err, "failed to update budget")
+	}
+	return nil
+}
+
+func (r *BudgetRepository) Delete(ctx context.Context, id int64) error {
+	tag, err := r.db(ctx).Exec(ctx, `DELETE FROM budgets WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete budget: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
+
+// RecordAlert сохраняет уведомление о пороге бюджета и возвращает false, если
+// уведомление об этом пороге в этом месяце уже было.
+func (r *BudgetRepository) RecordAlert(ctx context.Context, alert *model.BudgetAlert) (bool, error) {
+	query := `INSERT INTO budget_alerts (budget_id, month, threshold, spent)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (budget_id, month, threshold) DO NOTHING
+		RETURNING created_at
+	`
+	err := r.db(ctx).QueryRow(ctx, query, alert.BudgetID, alert.Month, alert.Threshold, alert.Spent).Scan(&alert.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to record budget alert: %w", err)
+	}
+	return true, nil
+}
+
+// Alerts возвращает уведомления бюджета за месяц по возрастанию порога.
+func (r *BudgetRepository) Alerts(ctx context.Context, budgetID int64, month time.Time) ([]model.BudgetAlert, error) {
+	query := `SELECT threshold, spent, created_at FROM budget_alerts
+		WHERE budget_id = $1 AND month = $2
+		ORDER BY threshold
+	`
+	rows, err := r.db(ctx).Query(ctx, query, budgetID, month)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budget alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []model.BudgetAlert
+	for rows.Next() {
+		alert := model.BudgetAlert{BudgetID: budgetID, Month: month}
+		if err := rows.Scan(&alert.Threshold, &alert.Spent, &alert.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan budget alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get budget alerts: %w", err)
+	}
+	return alerts, nil
+}
+
+func budgetWriteError(err error, msg string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+		return ErrBudgetReference
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
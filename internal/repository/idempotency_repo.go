@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shenikar/subscription-service/internal/model"
+)
+
+type IdempotencyRepository struct {
+	conn *pgxpool.Pool
+}
+
+func NewIdempotencyRepository(conn *pgxpool.Pool) *IdempotencyRepository {
+	return &IdempotencyRepository{conn: conn}
+}
+
+// Reserve сохраняет ключ до выполнения запроса. Если ключ уже есть, запись не
+// создаётся и возвращается существующая.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, key model.IdempotencyKey) (*model.IdempotencyKey, error) {
+	if _, err := r.conn.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= now()`); err != nil {
+		return nil, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	query := `INSERT INTO idempotency_keys (key, method, path, fingerprint, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (key, method, path) DO NOTHING
+	`
+	tag, err := r.conn.Exec(ctx, query, key.Key, key.Method, key.Path, key.Fingerprint, key.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		return nil, nil
+	}
+
+	query = `SELECT key, method, path, fingerprint, status_code, content_type, response_body, expires_at
+		FROM idempotency_keys WHERE key = $1 AND method = $2 AND path = $3
+	`
+	var existing model.IdempotencyKey
+	err = r.conn.QueryRow(ctx, query, key.Key, key.Method, key.Path).Scan(
+		&existing.Key, &existing.Method, &existing.Path, &existing.Fingerprint,
+		&existing.StatusCode, &existing.ContentType, &existing.ResponseBody, &existing.ExpiresAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return &existing, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key model.IdempotencyKey) error {
+	query := `UPDATE idempotency_keys SET status_code = $1, content_type = $2, response_body = $3
+		WHERE key = $4 AND method = $5 AND path = $6
+	`
+	_, err := r.conn.Exec(ctx, query, key.StatusCode, key.ContentType, key.ResponseBody, key.Key, key.Method, key.Path)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, key model.IdempotencyKey) error {
+	query := `DELETE FROM idempotency_keys WHERE key = $1 AND method = $2 AND path = $3`
+
+	_, err := r.conn.Exec(ctx, query, key.Key, key.Method, key.Path)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,450 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shenikar/subscription-service/internal/model"
+)
+
+var (
+	ErrOrganisationNotFound = errors.New("organisation not found")
+	ErrOrganisationExists   = errors.New("organisation name already exists")
+	ErrOrganisationInUse    = errors.New("organisation has cost centres with allocated subscriptions")
+	ErrUnitNotFound         = errors.New("unit not found")
+	ErrUnitExists           = errors.New("unit name already exists in the organisation")
+	ErrUnitInUse            = errors.New("unit has child units or cost centres")
+	ErrCostCentreNotFound   = errors.New("cost centre not found")
+	ErrCostCentreExists     = errors.New("cost centre code already exists in the organisation")
+	ErrCostCentreInUse      = errors.New("cost centre has allocated subscriptions")
+	ErrOrgMemberNotFound    = errors.New("user is not a member of the organisation")
+	ErrLastOwner            = errors.New("organisation must keep at least one owner")
+	// ErrOrgReference — пользователь, подразделение или организация, на которые ссылается
+	// запись, не существуют.
+	ErrOrgReference = errors.New("organisation data references unknown user or unit")
+)
+
+const (
+	organisationColumns = `o.id, o.name, o.created_at`
+	unitColumns         = `u.id, u.organisation_id, u.parent_id, u.kind, u.name, u.created_at`
+	costCentreColumns   = `cc.id, cc.organisation_id, cc.unit_id, cc.code, cc.name, cc.created_at`
+	orgMemberColumns    = `m.organisation_id, m.user_id, m.role, m.unit_id, m.created_at`
+)
+
+func scanOrganisation(row pgx.Row, org *model.Organisation) error {
+	return row.Scan(&org.ID, &org.Name, &org.CreatedAt)
+}
+
+func scanUnit(row pgx.Row, unit *model.OrgUnit) error {
+	return row.Scan(&unit.ID, &unit.OrganisationID, &unit.ParentID, &unit.Kind, &unit.Name, &unit.CreatedAt)
+}
+
+func scanCostCentre(row pgx.Row, cc *model.CostCentre) error {
+	return row.Scan(&cc.ID, &cc.OrganisationID, &cc.UnitID, &cc.Code, &cc.Name, &cc.CreatedAt)
+}
+
+func scanOrgMember(row pgx.Row, m *model.OrgMember) error {
+	return row.Scan(&m.OrganisationID, &m.UserID, &m.Role, &m.UnitID, &m.CreatedAt)
+}
+
+type OrganisationRepository struct {
+	conn *pgxpool.Pool
+}
+
+func NewOrganisationRepository(conn *pgxpool.Pool) *OrganisationRepository {
+	return &OrganisationRepository{conn: conn}
+}
+
+// Create создаёт организацию и делает пользователя owner её владельцем.
+func (r *OrganisationRepository) Create(ctx context.Context, org *model.Organisation, owner uuid.UUID) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `INSERT INTO organisations (name) VALUES ($1) RETURNING id, created_at`, org.Name).
+		Scan(&org.ID, &org.CreatedAt)
+	if err != nil {
+		return orgWriteError(err, ErrOrganisationExists, "failed to insert organisation")
+	}
+	_, err = tx.Exec(ctx, `INSERT INTO organisation_members (organisation_id, user_id, role) VALUES ($1, $2, $3)`,
+		org.ID, owner, model.RoleOwner)
+	if err != nil {
+		return orgWriteError(err, nil, "failed to insert organisation owner")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *OrganisationRepository) GetByID(ctx context.Context, id int64) (*model.Organisation, error) {
+	query := `SELECT ` + organisationColumns + ` FROM organisations o WHERE o.id = $1`
+
+	var org model.Organisation
+	if err := scanOrganisation(r.conn.QueryRow(ctx, query, id), &org); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get organisation: %w", err)
+	}
+	return &org, nil
+}
+
+// List возвращает организации по названию. userID отбирает организации, в которых
+// пользователь состоит.
+func (r *OrganisationRepository) List(ctx context.Context, userID *uuid.UUID, limit, offset int) ([]*model.Organisation, error) {
+	query := `SELECT ` + organisationColumns + ` FROM organisations o WHERE 1 = 1`
+
+	var args []interface{}
+	argNum := 1
+	if userID != nil {
+		query += fmt.Sprintf(` AND EXISTS (
+			SELECT 1 FROM organisation_members m WHERE m.organisation_id = o.id AND m.user_id = $%d)`, argNum)
+		args = append(args, *userID)
+		argNum++
+	}
+
+	query += " ORDER BY lower(o.name), o.id"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, limit)
+		argNum++
+	}
+	if offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, offset)
+	}
+
+	rows, err := r.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organisations: %w", err)
+	}
+	defer rows.Close()
+
+	var orgs []*model.Organisation
+	for rows.Next() {
+		var org model.Organisation
+		if err := scanOrganisation(rows, &org); err != nil {
+			return nil, fmt.Errorf("failed to scan organisation: %w", err)
+		}
+		orgs = append(orgs, &org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get organisations: %w", err)
+	}
+	return orgs, nil
+}
+
+func (r *OrganisationRepository) Update(ctx context.Context, org *model.Organisation) error {
+	err := r.conn.QueryRow(ctx, `UPDATE organisations SET name = $1 WHERE id = $2 RETURNING created_at`, org.Name, org.ID).
+		Scan(&org.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrOrganisationNotFound
+		}
+		return orgWriteError(err, ErrOrganisationExists, "failed to update organisation")
+	}
+	return nil
+}
+
+// Delete удаляет организацию с подразделениями, центрами затрат и участниками. Если
+// к её центрам затрат отнесены подписки, возвращается ErrOrganisationInUse.
+func (r *OrganisationRepository) Delete(ctx context.Context, id int64) error {
+	tag, err := r.conn.Exec(ctx, `DELETE FROM organisations WHERE id = $1`, id)
+	if err != nil {
+		return orgDeleteError(err, ErrOrganisationInUse, "failed to delete organisation")
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrOrganisationNotFound
+	}
+	return nil
+}
+
+func (r *OrganisationRepository) CreateUnit(ctx context.Context, unit *model.OrgUnit) error {
+	query := `INSERT INTO org_units (organisation_id, parent_id, kind, name)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	err := r.conn.QueryRow(ctx, query, unit.OrganisationID, unit.ParentID, unit.Kind, unit.Name).Scan(&unit.ID, &unit.CreatedAt)
+	if err != nil {
+		return orgWriteError(err, ErrUnitExists, "failed to insert unit")
+	}
+	return nil
+}
+
+// Units возвращает подразделения организации: отделы перед командами, по названию.
+func (r *OrganisationRepository) Units(ctx context.Context, orgID int64) ([]*model.OrgUnit, error) {
+	query := `SELECT ` + unitColumns + ` FROM org_units u WHERE u.organisation_id = $1
+		ORDER BY u.kind = 'team', lower(u.name), u.id`
+
+	rows, err := r.conn.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get units: %w", err)
+	}
+	defer rows.Close()
+
+	var units []*model.OrgUnit
+	for rows.Next() {
+		var unit model.OrgUnit
+		if err := scanUnit(rows, &unit); err != nil {
+			return nil, fmt.Errorf("failed to scan unit: %w", err)
+		}
+		units = append(units, &unit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get units: %w", err)
+	}
+	return units, nil
+}
+
+func (r *OrganisationRepository) UpdateUnit(ctx context.Context, unit *model.OrgUnit) error {
+	query := `UPDATE org_units SET parent_id = $1, kind = $2, name = $3
+		WHERE id = $4 AND organisation_id = $5
+		RETURNING created_at`
+	err := r.conn.QueryRow(ctx, query, unit.ParentID, unit.Kind, unit.Name, unit.ID, unit.OrganisationID).Scan(&unit.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrUnitNotFound
+		}
+		return orgWriteError(err, ErrUnitExists, "failed to update unit")
+	}
+	return nil
+}
+
+// DeleteUnit удаляет подразделение без дочерних подразделений и центров затрат, иначе
+// возвращает ErrUnitInUse. Участники подразделения остаются в организации.
+func (r *OrganisationRepository) DeleteUnit(ctx context.Context, orgID, id int64) error {
+	tag, err := r.conn.Exec(ctx, `DELETE FROM org_units WHERE id = $1 AND organisation_id = $2`, id, orgID)
+	if err != nil {
+		return orgDeleteError(err, ErrUnitInUse, "failed to delete unit")
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUnitNotFound
+	}
+	return nil
+}
+
+func (r *OrganisationRepository) CreateCostCentre(ctx context.Context, cc *model.CostCentre) error {
+	query := `INSERT INTO cost_centres (organisation_id, unit_id, code, name)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	err := r.conn.QueryRow(ctx, query, cc.OrganisationID, cc.UnitID, cc.Code, cc.Name).Scan(&cc.ID, &cc.CreatedAt)
+	if err != nil {
+		return orgWriteError(err, ErrCostCentreExists, "failed to insert cost centre")
+	}
+	return nil
+}
+
+// CostCentres возвращает центры затрат организации по коду.
+func (r *OrganisationRepository) CostCentres(ctx context.Context, orgID int64) ([]*model.CostCentre, error) {
+	query := `SELECT ` + costCentreColumns + ` FROM cost_centres cc WHERE cc.organisation_id = $1
+		ORDER BY lower(cc.code), cc.id`
+
+	rows, err := r.conn.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cost centres: %w", err)
+	}
+	defer rows.Close()
+
+	var centres []*model.CostCentre
+	for rows.Next() {
+		var cc model.CostCentre
+		if err := scanCostCentre(rows, &cc); err != nil {
+			return nil, fmt.Errorf("failed to scan cost centre: %w", err)
+		}
+		centres = append(centres, &cc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get cost centres: %w", err)
+	}
+	return centres, nil
+}
+
+func (r *OrganisationRepository) UpdateCostCentre(ctx context.Context, cc *model.CostCentre) error {
+	query := `UPDATE cost_centres SET unit_id = $1, code = $2, name = $3
+		WHERE id = $4 AND organisation_id = $5
+		RETURNING created_at`
+	err := r.conn.QueryRow(ctx, query, cc.UnitID, cc.Code, cc.Name, cc.ID, cc.OrganisationID).Scan(&cc.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrCostCentreNotFound
+		}
+		return orgWriteError(err, ErrCostCentreExists, "failed to update cost centre")
+	}
+	return nil
+}
+
+// DeleteCostCentre удаляет центр затрат, к которому никогда не относили подписки, иначе
+// возвращает ErrCostCentreInUse: история распределения должна сохраниться.
+func (r *OrganisationRepository) DeleteCostCentre(ctx context.Context, orgID, id int64) error {
+	tag, err := r.conn.Exec(ctx, `DELETE FROM cost_centres WHERE id = $1 AND organisation_id = $2`, id, orgID)
+	if err != nil {
+		return orgDeleteError(err, ErrCostCentreInUse, "failed to delete cost centre")
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCostCentreNotFound
+	}
+	return nil
+}
+
+// Members возвращает участников организации в порядке добавления.
+func (r *OrganisationRepository) Members(ctx context.Context, orgID int64) ([]*model.OrgMember, error) {
+	query := `SELECT ` + orgMemberColumns + ` FROM organisation_members m WHERE m.organisation_id = $1
+		ORDER BY m.created_at, m.user_id`
+
+	rows, err := r.conn.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organisation members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*model.OrgMember
+	for rows.Next() {
+		var m model.OrgMember
+		if err := scanOrgMember(rows, &m); err != nil {
+			return nil, fmt.Errorf("failed to scan organisation member: %w", err)
+		}
+		members = append(members, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get organisation members: %w", err)
+	}
+	return members, nil
+}
+
+// SetMember добавляет участника организации или меняет его роль и подразделение.
+// Если у организации не остаётся владельцев, возвращается ErrLastOwner.
+func (r *OrganisationRepository) SetMember(ctx context.Context, m *model.OrgMember) error {
+	return r.withOwners(ctx, m.OrganisationID, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `INSERT INTO organisation_members (organisation_id, user_id, role, unit_id)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (organisation_id, user_id) DO UPDATE SET role = EXCLUDED.role, unit_id = EXCLUDED.unit_id
+			RETURNING created_at`, m.OrganisationID, m.UserID, m.Role, m.UnitID).Scan(&m.CreatedAt)
+		if err != nil {
+			return orgWriteError(err, nil, "failed to save organisation member")
+		}
+		return nil
+	})
+}
+
+// RemoveMember исключает участника организации. Последнего владельца исключить нельзя.
+func (r *OrganisationRepository) RemoveMember(ctx context.Context, orgID int64, userID uuid.UUID) error {
+	return r.withOwners(ctx, orgID, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `DELETE FROM organisation_members WHERE organisation_id = $1 AND user_id = $2`, orgID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to delete organisation member: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrOrgMemberNotFound
+		}
+		return nil
+	})
+}
+
+// withOwners выполняет fn в транзакции, заблокировав организацию, и откатывает изменение,
+// если после него у организации не осталось владельцев.
+func (r *OrganisationRepository) withOwners(ctx context.Context, orgID int64, fn func(pgx.Tx) error) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var id int64
+	if err := tx.QueryRow(ctx, `SELECT id FROM organisations WHERE id = $1 FOR UPDATE`, orgID).Scan(&id); err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrOrganisationNotFound
+		}
+		return fmt.Errorf("failed to lock organisation: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	var owners int
+	err = tx.QueryRow(ctx, `SELECT COUNT(*) FROM organisation_members WHERE organisation_id = $1 AND role = $2`,
+		orgID, model.RoleOwner).Scan(&owners)
+	if err != nil {
+		return fmt.Errorf("failed to count organisation owners: %w", err)
+	}
+	if owners == 0 {
+		return ErrLastOwner
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Spending суммирует начисления подписок по центрам затрат организации за месяцы с from
+// по to. Каждый месяц начисления относится к центру затрат, действующему в этом месяце.
+func (r *OrganisationRepository) Spending(ctx context.Context, orgID int64, from, to time.Time) ([]model.CostCentreSpending, error) {
+	where, shares, args := ChargeFilter{}.where(from, to)
+	where += fmt.Sprintf(` AND EXISTS (
+		SELECT 1 FROM subscription_allocations sa JOIN cost_centres cc ON cc.id = sa.cost_centre_id
+		WHERE sa.subscription_id = s.id AND cc.organisation_id = $%d)`, len(args)+1)
+	query := chargesQuery(where, shares) + fmt.Sprintf(`SELECT a.cost_centre_id, SUM(c.amount), COUNT(DISTINCT c.subscription_id)
+		FROM charges c
+		CROSS JOIN LATERAL (
+			SELECT sa.cost_centre_id FROM subscription_allocations sa
+			WHERE sa.subscription_id = c.subscription_id AND sa.effective_from <= c.month
+			ORDER BY sa.effective_from DESC LIMIT 1
+		) AS a
+		JOIN cost_centres cc ON cc.id = a.cost_centre_id AND cc.organisation_id = $%d
+		GROUP BY a.cost_centre_id`, len(args)+1)
+	args = append(args, orgID)
+
+	rows, err := r.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cost centre spending: %w", err)
+	}
+	defer rows.Close()
+
+	var res []model.CostCentreSpending
+	for rows.Next() {
+		var s model.CostCentreSpending
+		if err := rows.Scan(&s.CostCentreID, &s.Total, &s.Subscriptions); err != nil {
+			return nil, fmt.Errorf("failed to scan cost centre spending: %w", err)
+		}
+		res = append(res, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get cost centre spending: %w", err)
+	}
+	return res, nil
+}
+
+// orgWriteError переводит нарушение уникальности в exists, а ссылку на несуществующую
+// запись — в ErrOrgReference.
+func orgWriteError(err, exists error, msg string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == pgUniqueViolation && exists != nil:
+			return exists
+		case pgErr.Code == pgForeignKeyViolation:
+			return ErrOrgReference
+		}
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// orgDeleteError возвращает inUse, если удалению мешает ограничение RESTRICT: дочерние
+// подразделения, центры затрат или распределённые на них подписки.
+func orgDeleteError(err, inUse error, msg string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+		return inUse
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
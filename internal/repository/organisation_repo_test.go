@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Ограничения RESTRICT из миграции 000017 превращаются в ошибки «используется», которые
+// обработчики отдают как 409.
+func TestOrgDeleteError(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint string
+		inUse      error
+	}{
+		{name: "organisation with allocated cost centres", constraint: "fk_subscription_allocations_cost_centre_id", inUse: ErrOrganisationInUse},
+		{name: "department with teams", constraint: "fk_org_units_parent_id", inUse: ErrUnitInUse},
+		{name: "unit with cost centres", constraint: "fk_cost_centres_unit_id", inUse: ErrUnitInUse},
+		{name: "cost centre with allocations", constraint: "fk_subscription_allocations_cost_centre_id", inUse: ErrCostCentreInUse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &pgconn.PgError{Code: pgForeignKeyViolation, ConstraintName: tt.constraint}
+			if got := orgDeleteError(err, tt.inUse, "failed to delete"); !errors.Is(got, tt.inUse) {
+				t.Errorf("orgDeleteError = %v, want %v", got, tt.inUse)
+			}
+		})
+	}
+
+	other := errors.New("connection refused")
+	if got := orgDeleteError(other, ErrUnitInUse, "failed to delete unit"); !errors.Is(got, other) || errors.Is(got, ErrUnitInUse) {
+		t.Errorf("orgDeleteError(other) = %v, want the wrapped error", got)
+	}
+}
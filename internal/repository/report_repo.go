@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shenikar/subscription-service/internal/model"
+)
+
+// spendingQueries группируют начисления charges. При группировке по месяцам пустые месяцы
+// периода возвращаются с нулевой суммой.
+var spendingQueries = map[string]string{
+	model.GroupByMonth: `SELECT to_char(m.month, 'MM-YYYY'), '', COALESCE(SUM(c.amount), 0), COUNT(DISTINCT c.subscription_id)
+		FROM generate_series(date_trunc('month', $1::timestamp), date_trunc('month', $2::timestamp), interval '1 month') AS m(month)
+		LEFT JOIN charges c ON c.month = m.month::date
+		GROUP BY m.month
+		ORDER BY m.month`,
+	model.GroupByService: `SELECT c.service_id::text, sv.name, SUM(c.amount), COUNT(DISTINCT c.subscription_id)
+		FROM charges c JOIN services sv ON sv.id = c.service_id
+		GROUP BY c.service_id, sv.name
+		ORDER BY SUM(c.amount) DESC, sv.name`,
+	model.GroupByUser: `SELECT c.user_id::text, COALESCE(u.name, u.email, ''), SUM(c.amount), COUNT(DISTINCT c.subscription_id)
+		FROM charges c JOIN users u ON u.id = c.user_id
+		GROUP BY c.user_id, u.name, u.email
+		ORDER BY SUM(c.amount) DESC, c.user_id`,
+	model.GroupByCategory: `SELECT COALESCE(c.category, ''), '', SUM(c.amount), COUNT(DISTINCT c.subscription_id)
+		FROM charges c
+		GROUP BY COALESCE(c.category, '')
+		ORDER BY SUM(c.amount) DESC, 1`,
+	// Подписка с несколькими метками входит в группу каждой из них.
+	model.GroupByTag: `SELECT COALESCE(t.name, ''), '', SUM(c.amount), COUNT(DISTINCT c.subscription_id)
+		FROM charges c
+		LEFT JOIN subscription_tags st ON st.subscription_id = c.subscription_id
+		LEFT JOIN tags t ON t.id = st.tag_id
+		GROUP BY t.id, t.name
+		ORDER BY SUM(c.amount) DESC, 1`,
+}
+
+type ReportRepository struct {
+	conn *pgxpool.Pool
+}
+
+func NewReportRepository(conn *pgxpool.Pool) *ReportRepository {
+	return &ReportRepository{conn: conn}
+}
+
+// Spending группирует помесячные начисления за месяцы с from по to включительно.
+func (r *ReportRepository) Spending(ctx context.Context, filter ChargeFilter, from, to time.Time, groupBy string) ([]model.SpendingBucket, error) {
+	return spending(ctx, r.conn, filter, from, to, groupBy)
+}
+
+func spending(ctx context.Context, conn *pgxpool.Pool, filter ChargeFilter, from, to time.Time, groupBy string) ([]model.SpendingBucket, error) {
+	grouping, ok := spendingQueries[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown grouping %q", groupBy)
+	}
+	where, shares, args := filter.where(from, to)
+
+	rows, err := conn.Query(ctx, chargesQuery(where, shares)+grouping, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spending report: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []model.SpendingBucket
+	for rows.Next() {
+		var b model.SpendingBucket
+		if err := rows.Scan(&b.Key, &b.Label, &b.Total, &b.Subscriptions); err != nil {
+			return nil, fmt.Errorf("failed to scan spending bucket: %w", err)
+		}
+		buckets = append(buckets, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get spending report: %w", err)
+	}
+	return buckets, nil
+}
+
+// Balances возвращает долги участников совместных подписок владельцам за месяц month:
+// строку на каждую пару участник — владелец с суммой долей участника. Для userID
+// возвращаются только пары, где он участник или владелец.
+func (r *ReportRepository) Balances(ctx context.Context, month time.Time, userID *uuid.UUID) ([]model.Balance, error) {
+	where, _, args := ChargeFilter{UserID: userID}.where(month, month)
+	query := chargesQuery(where, "TRUE") + `SELECT c.user_id, c.owner_id, SUM(c.amount),
+			ARRAY_AGG(DISTINCT c.subscription_id ORDER BY c.subscription_id)
+		FROM charges c
+		WHERE c.user_id <> c.owner_id`
+	if userID != nil {
+		query += ` AND $3 IN (c.user_id, c.owner_id)`
+	}
+	query += ` GROUP BY c.user_id, c.owner_id HAVING SUM(c.amount) > 0`
+
+	rows, err := r.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+	defer rows.Close()
+
+	var balances []model.Balance
+	for rows.Next() {
+		var b model.Balance
+		if err := rows.Scan(&b.From, &b.To, &b.Amount, &b.SubscriptionIDs); err != nil {
+			return nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		balances = append(balances, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+	return balances, nil
+}
+
+// Duplicates возвращает пары подписок одного пользователя на один сервис с пересекающимися
+// периодами. Пересечение считается помесячно: подписка действует до конца месяца end_date.
+func (r *ReportRepository) Duplicates(ctx context.Context, userID *uuid.UUID, serviceID *int64) ([]model.DuplicatePair, error) {
+	query := `SELECT a.user_id, a.service_id, sv.name, a.id, b.id,
+			GREATEST(a.start_date, b.start_date), LEAST(a.end_date, b.end_date)
+		FROM subscriptions a
+		JOIN subscriptions b ON b.user_id = a.user_id AND b.service_id = a.service_id AND b.id > a.id
+		JOIN services sv ON sv.id = a.service_id
+		WHERE daterange(a.start_date, (a.end_date + interval '1 month')::date)
+				&& daterange(b.start_date, (b.end_date + interval '1 month')::date)
+			AND ($1::uuid IS NULL OR a.user_id = $1)
+			AND ($2::bigint IS NULL OR a.service_id = $2)
+		ORDER BY a.user_id, sv.name, a.id, b.id`
+
+	rows, err := r.conn.Query(ctx, query, userID, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duplicate subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var pairs []model.DuplicatePair
+	for rows.Next() {
+		var p model.DuplicatePair
+		if err := rows.Scan(&p.UserID, &p.ServiceID, &p.ServiceName, &p.FirstID, &p.SecondID, &p.OverlapFrom, &p.OverlapTo); err != nil {
+			return nil, fmt.Errorf("failed to scan duplicate subscriptions: %w", err)
+		}
+		pairs = append(pairs, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get duplicate subscriptions: %w", err)
+	}
+	return pairs, nil
+}
+
+// Cancellations группирует отмены подписок за период [from, to) по причинам и по сервисам.
+func (r *ReportRepository) Cancellations(ctx context.Context, from, to time.Time, userID *uuid.UUID, serviceID *int64) (byReason, byService []model.CancellationBucket, err error) {
+	query := `SELECT GROUPING(c.reason) = 0, COALESCE(c.reason, c.service_id::text), COALESCE(sv.name, ''),
+			COUNT(*), SUM(c.price)
+		FROM subscription_cancellations c
+		JOIN services sv ON sv.id = c.service_id
+		WHERE c.cancelled_at >= $1 AND c.cancelled_at < $2
+			AND ($3::uuid IS NULL OR c.user_id = $3)
+			AND ($4::bigint IS NULL OR c.service_id = $4)
+		GROUP BY GROUPING SETS ((c.reason), (c.service_id, sv.name))
+		ORDER BY COUNT(*) DESC, 2`
+
+	rows, err := r.conn.Query(ctx, query, from, to, userID, serviceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get cancellations report: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var isReason bool
+		var b model.CancellationBucket
+		if err := rows.Scan(&isReason, &b.Key, &b.Label, &b.Count, &b.LostRevenue); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan cancellations bucket: %w", err)
+		}
+		if isReason {
+			b.Label = ""
+			byReason = append(byReason, b)
+		} else {
+			byService = append(byService, b)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to get cancellations report: %w", err)
+	}
+	return byReason, byService, nil
+}
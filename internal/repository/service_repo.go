@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shenikar/subscription-service/internal/model"
+)
+
+var (
+	ErrServiceNotFound = errors.New("service not found")
+	ErrServiceExists   = errors.New("service name or alias already exists")
+	ErrServiceInUse    = errors.New("service is referenced by subscriptions")
+)
+
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+)
+
+const serviceColumns = `sv.id, sv.name,
+	ARRAY(SELECT a.alias FROM service_aliases a WHERE a.service_id = sv.id ORDER BY a.alias),
+	sv.category, sv.website, sv.default_price, sv.currency, sv.created_at, sv.updated_at`
+
+func scanService(row pgx.Row, svc *model.Service) error {
+	return row.Scan(&svc.ID, &svc.Name, &svc.Aliases, &svc.Category, &svc.Website,
+		&svc.DefaultPrice, &svc.Currency, &svc.CreatedAt, &svc.UpdatedAt)
+}
+
+type ServiceRepository struct {
+	conn *pgxpool.Pool
+}
+
+func NewServiceRepository(conn *pgxpool.Pool) *ServiceRepository {
+	return &ServiceRepository{conn: conn}
+}
+
+// db возвращает транзакцию из ctx, открытую InTx, или пул.
+func (r *ServiceRepository) db(ctx context.Context) querier {
+	return connFrom(ctx, r.conn)
+}
+
+func (r *ServiceRepository) Create(ctx context.Context, svc *model.Service) error {
+	tx, err := r.db(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO services (name, category, website, default_price, currency)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query, svc.Name, svc.Category, svc.Website, svc.DefaultPrice, svc.Currency).
+		Scan(&svc.ID, &svc.CreatedAt, &svc.UpdatedAt)
+	if err != nil {
+		return serviceWriteError(err, "failed insert service")
+	}
+	if err := insertAliases(ctx, tx, svc.ID, svc.Aliases); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *ServiceRepository) GetByID(ctx context.Context, id int64) (*model.Service, error) {
+	query := `SELECT ` + serviceColumns + ` FROM services sv WHERE sv.id = $1`
+
+	var svc model.Service
+	if err := scanService(r.db(ctx).QueryRow(ctx, query, id), &svc); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	return &svc, nil
+}
+
+// FindByName ищет сервис по названию или псевдониму без учёта регистра.
+func (r *ServiceRepository) FindByName(ctx context.Context, name string) (*model.Service, error) {
+	query := `SELECT ` + serviceColumns + ` FROM services sv
+		WHERE lower(sv.name) = lower($1)
+			OR sv.id = (SELECT a.service_id FROM service_aliases a WHERE lower(a.alias) = lower($1))
+	`
+
+	var svc model.Service
+	if err := scanService(r.db(ctx).QueryRow(ctx, query, name), &svc); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find service: %w", err)
+	}
+	return &svc, nil
+}
+
+// List возвращает сервисы каталога. query ищет подстроку в названии и псевдонимах.
+func (r *ServiceRepository) List(ctx context.Context, query, category *string, limit, offset int) ([]*model.Service, error) {
+	sql := `SELECT ` + serviceColumns + ` FROM services sv WHERE 1 = 1`
+
+	var args []interface{}
+	argNum := 1
+	if query != nil {
+		sql += fmt.Sprintf(` AND (sv.name ILIKE $%d OR EXISTS (
+			SELECT 1 FROM service_aliases a WHERE a.service_id = sv.id AND a.alias ILIKE $%d))`, argNum, argNum)
+		args = append(args, "%"+*query+"%")
+		argNum++
+	}
+	if category != nil {
+		sql += fmt.Sprintf(" AND lower(sv.category) = lower($%d)", argNum)
+		args = append(args, *category)
+		argNum++
+	}
+
+	sql += " ORDER BY sv.name"
+	if limit > 0 {
+		sql += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, limit)
+		argNum++
+	}
+	if offset > 0 {
+		sql += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, offset)
+	}
+
+	rows, err := r.db(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+	defer rows.Close()
+
+	var services []*model.Service
+	for rows.Next() {
+		var svc model.Service
+		if err := scanService(rows, &svc); err != nil {
+			return nil, fmt.Errorf("failed to scan service: %w", err)
+		}
+		services = append(services, &svc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+	return services, nil
+}
+
+// Update заменяет данные сервиса вместе со списком псевдонимов.
+func (r *ServiceRepository) Update(ctx context.Context, svc *model.Service) error {
+	tx, err := r.db(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE services SET name = $1, category = $2, website = $3, default_price = $4, currency = $5,
+			updated_at = now()
+		WHERE id = $6
+		RETURNING created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query, svc.Name, svc.Category, svc.Website, svc.DefaultPrice, svc.Currency, svc.ID).
+		Scan(&svc.CreatedAt, &svc.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrServiceNotFound
+		}
+		return serviceWriteError(err, "failed to update service")
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM service_aliases WHERE service_id = $1`, svc.ID); err != nil {
+		return fmt.Errorf("failed to delete service aliases: %w", err)
+	}
+	if err := insertAliases(ctx, tx, svc.ID, svc.Aliases); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Delete удаляет сервис. Сервис, на который ссылаются подписки, не удаляется: возвращается ErrServiceInUse.
+func (r *ServiceRepository) Delete(ctx context.Context, id int64) error {
+	tag, err := r.db(ctx).Exec(ctx, `DELETE FROM services WHERE id = $1`, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+			return ErrServiceInUse
+		}
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrServiceNotFound
+	}
+	return nil
+}
+
+func insertAliases(ctx context.Context, tx pgx.Tx, serviceID int64, aliases []string) error {
+	for _, alias := range aliases {
+		_, err := tx.Exec(ctx, `INSERT INTO service_aliases (alias, service_id) VALUES ($1, $2)`, alias, serviceID)
+		if err != nil {
+			return serviceWriteError(err, "failed insert service alias")
+		}
+	}
+	return nil
+}
+
+func serviceWriteError(err error, msg string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return ErrServiceExists
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
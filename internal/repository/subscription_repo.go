@@ -2,41 +2,345 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shenikar/subscription-service/internal/model"
 )
 
+var (
+	ErrNotFound        = errors.New("subscription not found")
+	ErrVersionConflict = errors.New("subscription version mismatch")
+	ErrRolledBack      = errors.New("rolled back")
+	ErrOverlap         = errors.New("subscription overlaps another subscription of the user to the service")
+	ErrPauseOverlap    = errors.New("subscription is already paused in this period")
+	ErrNotPaused       = errors.New("subscription is not paused")
+	// ErrUnknownCostCentre — центр затрат, к которому относят подписку, не существует.
+	ErrUnknownCostCentre = errors.New("unknown cost centre")
+)
+
+const pgExclusionViolation = "23P01"
+
+// Название сервиса берётся из каталога, поэтому запросы читают подписки вместе с services.
+// Изменения цены, приостановки и распределение по центрам затрат читаются парами массивов,
+// упорядоченных по месяцу,
+// участники совместной подписки — массивами в порядке добавления.
+const (
+	subscriptionColumns = `s.id, s.service_id, sv.name, s.price, s.user_id, s.start_date, s.end_date, s.version, s.allow_overlap,
+		s.trial_ends_at, s.category, s.notes,
+		ARRAY(SELECT t.name FROM subscription_tags st JOIN tags t ON t.id = st.tag_id
+			WHERE st.subscription_id = s.id ORDER BY lower(t.name)),
+		ARRAY(SELECT p.effective_from FROM subscription_prices p WHERE p.subscription_id = s.id ORDER BY p.effective_from),
+		ARRAY(SELECT p.price FROM subscription_prices p WHERE p.subscription_id = s.id ORDER BY p.effective_from),
+		ARRAY(SELECT ps.paused_from FROM subscription_pauses ps WHERE ps.subscription_id = s.id ORDER BY ps.paused_from),
+		ARRAY(SELECT ps.paused_to FROM subscription_pauses ps WHERE ps.subscription_id = s.id ORDER BY ps.paused_from),
+		s.split_rule,
+		ARRAY(SELECT m.user_id FROM subscription_members m WHERE m.subscription_id = s.id ORDER BY m.created_at, m.user_id),
+		ARRAY(SELECT m.percent FROM subscription_members m WHERE m.subscription_id = s.id ORDER BY m.created_at, m.user_id),
+		ARRAY(SELECT m.amount FROM subscription_members m WHERE m.subscription_id = s.id ORDER BY m.created_at, m.user_id),
+		ARRAY(SELECT a.effective_from FROM subscription_allocations a WHERE a.subscription_id = s.id ORDER BY a.effective_from),
+		ARRAY(SELECT a.cost_centre_id FROM subscription_allocations a WHERE a.subscription_id = s.id ORDER BY a.effective_from)`
+	subscriptionTables = ` FROM subscriptions s JOIN services sv ON sv.id = s.service_id`
+)
+
+func scanSubscription(row pgx.Row, sub *model.Subscription) error {
+	var months []time.Time
+	var prices []int
+	var pausedFrom []time.Time
+	var pausedTo []*time.Time
+	var memberIDs []uuid.UUID
+	var percents, amounts []*int
+	var allocatedFrom []time.Time
+	var costCentres []*int64
+	err := row.Scan(&sub.ID, &sub.ServiceID, &sub.ServiceName, &sub.Price, &sub.UserID, &sub.StartDate, &sub.EndDate, &sub.Version,
+		&sub.AllowOverlap, &sub.TrialEndsAt, &sub.Category, &sub.Notes, &sub.Tags, &months, &prices, &pausedFrom, &pausedTo,
+		&sub.Split, &memberIDs, &percents, &amounts, &allocatedFrom, &costCentres)
+	if err != nil {
+		return err
+	}
+
+	sub.Prices = nil
+	for i := range months {
+		sub.Prices = append(sub.Prices, model.PriceChange{EffectiveFrom: months[i], Price: prices[i]})
+	}
+	sub.Pauses = nil
+	for i := range pausedFrom {
+		sub.Pauses = append(sub.Pauses, model.Pause{From: pausedFrom[i], To: pausedTo[i]})
+	}
+	sub.Members = nil
+	for i := range memberIDs {
+		sub.Members = append(sub.Members, model.Member{UserID: memberIDs[i], Percent: percents[i], Amount: amounts[i]})
+	}
+	sub.Allocations = nil
+	for i := range allocatedFrom {
+		sub.Allocations = append(sub.Allocations, model.Allocation{EffectiveFrom: allocatedFrom[i], CostCentreID: costCentres[i]})
+	}
+	return nil
+}
+
+// ListActiveBetween возвращает подписки, действующие хотя бы в одном месяце с from по to.
+func (r *SubscriptionRepository) ListActiveBetween(ctx context.Context, filter ChargeFilter, from, to time.Time) ([]*model.Subscription, error) {
+	where, _, args := filter.where(from, to)
+	query := `SELECT ` + subscriptionColumns + subscriptionTables + ` WHERE ` + where + `
+		AND ($2::timestamp IS NULL OR date_trunc('month', s.start_date::timestamp) <= date_trunc('month', $2::timestamp))
+		AND ($1::timestamp IS NULL OR s.end_date IS NULL
+			OR date_trunc('month', s.end_date::timestamp) >= date_trunc('month', $1::timestamp))
+		ORDER BY s.id`
+	return r.query(ctx, query, args...)
+}
+
+// ChargeFilter отбирает подписки для списка и помесячных начислений.
+type ChargeFilter struct {
+	UserID    *uuid.UUID
+	ServiceID *int64
+	// Category отбирает подписки этой категории: собственной или, если она не задана,
+	// категории сервиса из каталога.
+	Category *string
+	// Tags отбирает подписки, у которых есть все эти метки.
+	Tags []string
+}
+
+// where возвращает условия на подписки и на доли начислений для chargesQuery и все
+// параметры запроса, начиная с периода. Нулевая граница периода передаётся как NULL и не
+// ограничивает его. Пользователь UserID отбирает и свои подписки, и совместные, где он
+// участник, а из начислений по ним — только свою долю.
+func (f ChargeFilter) where(from, to time.Time) (where, shares string, args []interface{}) {
+	args = []interface{}{periodBound(from), periodBound(to)}
+	shares = "TRUE"
+	rest := f
+	if f.UserID != nil {
+		args = append(args, *f.UserID)
+		shares = "user_id = $3"
+		rest.UserID = nil
+	}
+	where, restArgs := rest.conditions(len(args) + 1)
+	if f.UserID != nil {
+		where += ` AND (s.user_id = $3 OR EXISTS (
+			SELECT 1 FROM subscription_members sm WHERE sm.subscription_id = s.id AND sm.user_id = $3))`
+	}
+	return where, shares, append(args, restArgs...)
+}
+
+// conditions возвращает условие на подписки s с сервисами sv и его параметры,
+// пронумерованные с $argNum.
+func (f ChargeFilter) conditions(argNum int) (string, []interface{}) {
+	where := "TRUE"
+	var args []interface{}
+	if f.UserID != nil {
+		where += fmt.Sprintf(" AND s.user_id = $%d", argNum)
+		args = append(args, *f.UserID)
+		argNum++
+	}
+	if f.ServiceID != nil {
+		where += fmt.Sprintf(" AND s.service_id = $%d", argNum)
+		args = append(args, *f.ServiceID)
+		argNum++
+	}
+	if f.Category != nil {
+		where += fmt.Sprintf(" AND lower(COALESCE(s.category, sv.category)) = lower($%d)", argNum)
+		args = append(args, *f.Category)
+		argNum++
+	}
+	if len(f.Tags) > 0 {
+		where += fmt.Sprintf(" AND %s", tagsCondition(argNum))
+		args = append(args, f.Tags)
+	}
+	return where, args
+}
+
+// periodBound возвращает границу периода для запроса; нулевая дата становится NULL.
+func periodBound(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// tagsCondition отбирает подписки s, у которых есть все метки из параметра $argNum.
+func tagsCondition(argNum int) string {
+	return fmt.Sprintf(`(SELECT COUNT(*) FROM subscription_tags st JOIN tags t ON t.id = st.tag_id
+		WHERE st.subscription_id = s.id AND lower(t.name) IN (SELECT lower(n) FROM unnest($%d::text[]) AS n))
+		= (SELECT COUNT(DISTINCT lower(n)) FROM unnest($%d::text[]) AS n)`, argNum, argNum)
+}
+
+// chargesQuery возвращает CTE charges с помесячными начислениями по подпискам за период
+// с месяца даты $1 по месяц даты $2: строка на каждый месяц действия подписки с ценой,
+// действующей в этом месяце. Без $1 период начинается с начала подписки, без $2 —
+// заканчивается с подпиской, а бессрочная считается по текущий месяц. С $2 бессрочная
+// подписка начисляется по месяц $2, поэтому месяцы после текущего — прогноз. Месяцы
+// пробного периода и приостановки не начисляются. Начисление совместной подписки делится
+// на доли владельца owner_id и участников так же, как model.Subscription.Shares, и user_id
+// в charges — тот, кому начислена доля. where фильтрует подписки s с сервисами sv, его
+// параметры начинаются с $3, shares — доли.
+func chargesQuery(where, shares string) string {
+	return `WITH subscription_charges AS (
+		SELECT s.id AS subscription_id, s.user_id AS owner_id, s.split_rule, s.service_id,
+			COALESCE(s.category, sv.category) AS category,
+			m.month::date AS month,
+			COALESCE((
+				SELECT p.price FROM subscription_prices p
+				WHERE p.subscription_id = s.id AND p.effective_from <= m.month
+					AND p.effective_from > date_trunc('month', s.start_date::timestamp)
+				ORDER BY p.effective_from DESC LIMIT 1
+			), s.price) AS amount
+		FROM subscriptions s
+		JOIN services sv ON sv.id = s.service_id
+		CROSS JOIN LATERAL generate_series(
+			GREATEST(date_trunc('month', s.start_date::timestamp), date_trunc('month', $1::timestamp)),
+			LEAST(date_trunc('month', COALESCE(s.end_date, $2::date, CURRENT_DATE)::timestamp), date_trunc('month', $2::timestamp)),
+			interval '1 month'
+		) AS m(month)
+		WHERE ` + where + `
+			AND (s.trial_ends_at IS NULL OR s.trial_ends_at <= m.month)
+			AND NOT EXISTS (
+				SELECT 1 FROM subscription_pauses ps
+				WHERE ps.subscription_id = s.id AND ps.paused_from <= m.month
+					AND (ps.paused_to IS NULL OR ps.paused_to >= m.month)
+			)
+	),
+	member_charges AS (
+		SELECT c.subscription_id, m.user_id, c.owner_id, c.service_id, c.category, c.month,
+			(CASE c.split_rule
+				WHEN 'percentage' THEN c.amount::bigint * COALESCE(m.percent, 0) / 100
+				WHEN 'fixed' THEN c.amount::bigint * COALESCE(m.amount, 0) / GREATEST(f.amount, c.amount, 1)
+				ELSE c.amount / (f.members + 1)
+			END)::int AS amount
+		FROM subscription_charges c
+		JOIN subscription_members m ON m.subscription_id = c.subscription_id
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS members, COALESCE(SUM(fm.amount), 0) AS amount
+			FROM subscription_members fm WHERE fm.subscription_id = c.subscription_id
+		) AS f
+	),
+	shares AS (
+		SELECT subscription_id, user_id, owner_id, service_id, category, month, amount FROM member_charges
+		UNION ALL
+		SELECT c.subscription_id, c.owner_id, c.owner_id, c.service_id, c.category, c.month,
+			c.amount - COALESCE((
+				SELECT SUM(mc.amount) FROM member_charges mc
+				WHERE mc.subscription_id = c.subscription_id AND mc.month = c.month
+			), 0)::int
+		FROM subscription_charges c
+	),
+	charges AS (
+		SELECT * FROM shares WHERE ` + shares + `
+	)
+	`
+}
+
 type SubscriptionRepository struct {
-	conn *pgx.Conn
+	conn *pgxpool.Pool
 }
 
-func NewSubscriptionRepository(conn *pgx.Conn) *SubscriptionRepository {
+func NewSubscriptionRepository(conn *pgxpool.Pool) *SubscriptionRepository {
 	return &SubscriptionRepository{conn: conn}
 }
 
+// db возвращает транзакцию из ctx, открытую InTx, или пул.
+func (r *SubscriptionRepository) db(ctx context.Context) querier {
+	return connFrom(ctx, r.conn)
+}
+
+// InTx выполняет fn в одной транзакции: все репозитории, которым передан ctx из fn,
+// работают в ней, а ошибка fn откатывает все их изменения.
+func (r *SubscriptionRepository) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return inTx(ctx, r.conn, fn)
+}
+
+// Метки подписки сохраняются тем же запросом, что и подписка: новые метки добавляются
+// в tags, существующие сопоставляются по названию без учёта регистра.
+const (
+	insertSubscriptionQuery = `WITH s AS (
+			INSERT INTO subscriptions (service_id, price, user_id, start_date, end_date, allow_overlap, trial_ends_at,
+				category, notes)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING id, version
+		), ` + upsertTagsQuery + `,
+		linked AS (
+			INSERT INTO subscription_tags (subscription_id, tag_id) SELECT s.id, t.id FROM s, t
+		)
+		SELECT id, version FROM s`
+	updateSubscriptionQuery = `WITH s AS (
+			UPDATE subscriptions SET service_id = $1, price = $2, user_id = $3, start_date = $4, end_date = $5,
+				allow_overlap = $6, trial_ends_at = $7, category = $8, notes = $9, version = version + 1
+			WHERE id = $11 AND ($12::int IS NULL OR version = $12)
+			RETURNING id, version
+		), ` + upsertTagsQuery + `,
+		unlinked AS (
+			DELETE FROM subscription_tags WHERE subscription_id = $11 AND EXISTS (SELECT 1 FROM s)
+				AND tag_id NOT IN (SELECT id FROM t)
+		),
+		linked AS (
+			INSERT INTO subscription_tags (subscription_id, tag_id) SELECT s.id, t.id FROM s, t
+			ON CONFLICT DO NOTHING
+		)
+		SELECT version FROM s`
+	// upsertTagsQuery возвращает CTE t с ID меток из $10, если подписка s сохранена.
+	// DO UPDATE нужен, чтобы RETURNING вернул и уже существующие метки.
+	upsertTagsQuery = `t AS (
+			INSERT INTO tags (name)
+			SELECT DISTINCT ON (lower(n)) n FROM unnest($10::text[]) AS n WHERE EXISTS (SELECT 1 FROM s)
+			ON CONFLICT (lower(name)) DO UPDATE SET name = tags.name
+			RETURNING id
+		)`
+)
+
+func insertArgs(sub *model.Subscription) []interface{} {
+	return []interface{}{sub.ServiceID, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.AllowOverlap, sub.TrialEndsAt,
+		sub.Category, sub.Notes, sub.Tags}
+}
+
+func updateArgs(sub *model.Subscription, expectedVersion *int) []interface{} {
+	return append(insertArgs(sub), sub.ID, expectedVersion)
+}
+
 func (r *SubscriptionRepository) Create(ctx context.Context, sub *model.Subscription) error {
-	query := `INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date)
-			VALUES ($1, $2, $3, $4, $5)
-			RETURNING id;		
-	`
-	var id int64
-	err := r.conn.QueryRow(ctx, query, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate).Scan(&id)
+	err := r.db(ctx).QueryRow(ctx, insertSubscriptionQuery, insertArgs(sub)...).Scan(&sub.ID, &sub.Version)
+	if err != nil {
+		return writeError(err, "failed insert subscription")
+	}
+	return nil
+}
+
+func (r *SubscriptionRepository) CreateMany(ctx context.Context, subs []*model.Subscription) error {
+	tx, err := r.db(ctx).Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed insert subscription: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	for _, sub := range subs {
+		batch.Queue(insertSubscriptionQuery, insertArgs(sub)...)
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	for i, sub := range subs {
+		if err := results.QueryRow().Scan(&sub.ID, &sub.Version); err != nil {
+			results.Close()
+			return writeError(err, fmt.Sprintf("failed insert subscription #%d", i+1))
+		}
+	}
+	if err := results.Close(); err != nil {
+		return fmt.Errorf("failed to close batch: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
-	sub.ID = id
 	return nil
 }
 
 func (r *SubscriptionRepository) GetByID(ctx context.Context, id int64) (*model.Subscription, error) {
-	query := `SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions WHERE id = $1`
+	query := `SELECT ` + subscriptionColumns + subscriptionTables + ` WHERE s.id = $1`
 
 	var sub model.Subscription
-	err := r.conn.QueryRow(ctx, query, id).Scan(&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID, &sub.StartDate, &sub.EndDate)
+	err := scanSubscription(r.db(ctx).QueryRow(ctx, query, id), &sub)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -46,10 +350,23 @@ func (r *SubscriptionRepository) GetByID(ctx context.Context, id int64) (*model.
 	return &sub, nil
 }
 
-func (r *SubscriptionRepository) GelAll(ctx context.Context) ([]*model.Subscription, error) {
-	query := `SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions`
+func (r *SubscriptionRepository) List(ctx context.Context, filter ChargeFilter, limit, offset int) ([]*model.Subscription, error) {
+	where, args := filter.conditions(1)
+	query := `SELECT ` + subscriptionColumns + subscriptionTables + ` WHERE ` + where
+	argNum := len(args) + 1
 
-	rows, err := r.conn.Query(ctx, query)
+	query += " ORDER BY s.id"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, limit)
+		argNum++
+	}
+	if offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, offset)
+	}
+
+	rows, err := r.db(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all subscription: %w", err)
 	}
@@ -58,7 +375,7 @@ func (r *SubscriptionRepository) GelAll(ctx context.Context) ([]*model.Subscript
 	var subs []*model.Subscription
 	for rows.Next() {
 		var sub model.Subscription
-		err := rows.Scan(&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID, &sub.StartDate, &sub.EndDate)
+		err := scanSubscription(rows, &sub)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan subscription: %w", err)
 		}
@@ -67,50 +384,619 @@ func (r *SubscriptionRepository) GelAll(ctx context.Context) ([]*model.Subscript
 	return subs, nil
 }
 
-func (r *SubscriptionRepository) Update(ctx context.Context, sub *model.Subscription) error {
-	query := `UPDATE subscriptions SET service_name = $1, price = $2, user_id = $3, start_date = $4, end_date = $5
-		WHERE id = $6	
-	`
-	_, err := r.conn.Exec(ctx, query, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.ID)
+// searchQuery ищет подписки по названию и псевдонимам сервиса, меткам и заметкам.
+// Названия и метки сравниваются по сходству триграмм с любым словом ($1 <% ...), заметки —
+// полнотекстовым поиском и, для опечаток, по сходству триграмм. Каждый источник отбирается
+// по своему индексу, а релевантность складывается из лучших оценок по каждому полю.
+const searchQuery = `WITH q AS (
+		SELECT websearch_to_tsquery('russian', $1) AS ts
+	),
+	service_hits AS (
+		SELECT id AS service_id, word_similarity($1, name) AS score FROM services WHERE $1 <% name
+		UNION ALL
+		SELECT service_id, word_similarity($1, alias) FROM service_aliases WHERE $1 <% alias
+	),
+	tag_hits AS (
+		SELECT id AS tag_id, name, word_similarity($1, name) AS score FROM tags WHERE $1 <% name
+	),
+	hits AS (
+		SELECT s.id AS subscription_id, h.score AS service_score, 0::real AS tag_score,
+			NULL::text AS tag, 0::real AS notes_score
+		FROM service_hits h JOIN subscriptions s ON s.service_id = h.service_id
+		UNION ALL
+		SELECT st.subscription_id, 0, h.score, h.name, 0
+		FROM tag_hits h JOIN subscription_tags st ON st.tag_id = h.tag_id
+		UNION ALL
+		SELECT s.id, 0, 0, NULL,
+			GREATEST(ts_rank(to_tsvector('russian', COALESCE(s.notes, '')), q.ts, 32), word_similarity($1, s.notes) / 2)
+		FROM subscriptions s, q
+		WHERE to_tsvector('russian', COALESCE(s.notes, '')) @@ q.ts OR $1 <% s.notes
+	)
+	SELECT h.subscription_id,
+		(MAX(h.service_score) + MAX(h.tag_score) * 0.8 + MAX(h.notes_score) * 0.6)::float8 AS rank,
+		MAX(h.service_score) > 0,
+		ARRAY_REMOVE(ARRAY_AGG(DISTINCT h.tag), NULL),
+		CASE WHEN MAX(h.notes_score) > 0 THEN ts_headline('russian', s.notes, q.ts,
+			'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5') END
+	FROM hits h JOIN subscriptions s ON s.id = h.subscription_id, q
+	WHERE $2::uuid IS NULL OR s.user_id = $2
+	GROUP BY h.subscription_id, s.notes, q.ts
+	ORDER BY rank DESC, h.subscription_id
+	LIMIT $3 OFFSET $4`
+
+// Search возвращает подписки, подходящие под запрос, по убыванию релевантности.
+// В подсветке заполнены совпавшие метки и фрагменты заметок, а название сервиса
+// указывается целиком, если оно совпало: отметить в нём найденное — дело сервисного слоя.
+func (r *SubscriptionRepository) Search(ctx context.Context, q string, userID *uuid.UUID, limit, offset int) ([]model.SearchHit, error) {
+	rows, err := r.db(ctx).Query(ctx, searchQuery, q, userID, limit, offset)
 	if err != nil {
-		return fmt.Errorf("failed to update subscription: %w", err)
+		return nil, fmt.Errorf("failed to search subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		hits           []model.SearchHit
+		serviceMatched []bool
+		ids            []int64
+	)
+	for rows.Next() {
+		var (
+			hit     model.SearchHit
+			matched bool
+			notes   *string
+		)
+		if err := rows.Scan(&hit.Subscription.ID, &hit.Rank, &matched, &hit.Highlights.Tags, &notes); err != nil {
+			return nil, fmt.Errorf("failed to scan search hit: %w", err)
+		}
+		if notes != nil {
+			hit.Highlights.Notes = *notes
+		}
+		hits = append(hits, hit)
+		serviceMatched = append(serviceMatched, matched)
+		ids = append(ids, hit.Subscription.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search subscriptions: %w", err)
+	}
+	if len(hits) == 0 {
+		return nil, nil
+	}
+
+	subs, err := r.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	// Подписки, удалённые между запросами, в выдачу не попадают.
+	res := hits[:0]
+	for i, hit := range hits {
+		sub, ok := subs[hit.Subscription.ID]
+		if !ok {
+			continue
+		}
+		hit.Subscription = *sub
+		if serviceMatched[i] {
+			hit.Highlights.ServiceName = sub.ServiceName
+		}
+		res = append(res, hit)
+	}
+	return res, nil
+}
+
+func (r *SubscriptionRepository) ListByUserIDs(ctx context.Context, userIDs []uuid.UUID) ([]*model.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + subscriptionTables + ` WHERE s.user_id = ANY($1) ORDER BY s.id`
+	return r.query(ctx, query, userIDs)
+}
+
+func (r *SubscriptionRepository) ListByServiceNames(ctx context.Context, names []string) ([]*model.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + subscriptionTables + ` WHERE sv.name = ANY($1) ORDER BY s.id`
+	return r.query(ctx, query, names)
+}
+
+func (r *SubscriptionRepository) ServiceNames(ctx context.Context) ([]string, error) {
+	query := `SELECT sv.name FROM services sv
+		WHERE EXISTS (SELECT 1 FROM subscriptions s WHERE s.service_id = sv.id)
+		ORDER BY sv.name`
+
+	rows, err := r.db(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service names: %w", err)
+	}
+	defer rows.Close()
+
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan service name: %w", err)
+	}
+	return names, nil
+}
+
+func (r *SubscriptionRepository) query(ctx context.Context, query string, args ...interface{}) ([]*model.Subscription, error) {
+	rows, err := r.db(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []*model.Subscription
+	for rows.Next() {
+		var sub model.Subscription
+		if err := scanSubscription(rows, &sub); err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		subs = append(subs, &sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// Update обновляет подписку и увеличивает её версию. Если expectedVersion задан,
+// запись обновляется только при совпадении версии, иначе возвращается ErrVersionConflict.
+func (r *SubscriptionRepository) Update(ctx context.Context, sub *model.Subscription, expectedVersion *int) error {
+	err := r.db(ctx).QueryRow(ctx, updateSubscriptionQuery, updateArgs(sub, expectedVersion)...).Scan(&sub.Version)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return r.notAffectedError(ctx, sub.ID, expectedVersion)
+		}
+		return writeError(err, "failed to update subscription")
 	}
 	return nil
 }
 
-func (r *SubscriptionRepository) Delete(ctx context.Context, id int64) error {
-	query := `DELETE FROM subscriptions WHERE id = $1`
+// Delete удаляет подписку и возвращает её состояние до удаления.
+func (r *SubscriptionRepository) Delete(ctx context.Context, id int64, expectedVersion *int) (*model.Subscription, error) {
+	query := `WITH s AS (
+			DELETE FROM subscriptions WHERE id = $1 AND ($2::int IS NULL OR version = $2) RETURNING *
+		)
+		SELECT ` + subscriptionColumns + ` FROM s JOIN services sv ON sv.id = s.service_id`
+
+	var sub model.Subscription
+	err := scanSubscription(r.db(ctx).QueryRow(ctx, query, id, expectedVersion), &sub)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, r.notAffectedError(ctx, id, expectedVersion)
+		}
+		return nil, fmt.Errorf("failed to delete subscription: %w", err)
+	}
+	return &sub, nil
+}
+
+// SchedulePrice сохраняет изменение цены подписки и увеличивает её версию. Изменение
+// на уже запланированный месяц заменяет прежнюю цену.
+func (r *SubscriptionRepository) SchedulePrice(ctx context.Context, id int64, change model.PriceChange, expectedVersion *int) error {
+	return r.withVersion(ctx, id, expectedVersion, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `INSERT INTO subscription_prices (subscription_id, effective_from, price)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (subscription_id, effective_from) DO UPDATE SET price = EXCLUDED.price, created_at = now()`,
+			id, change.EffectiveFrom, change.Price)
+		if err != nil {
+			return fmt.Errorf("failed to schedule price: %w", err)
+		}
+		return nil
+	})
+}
+
+// Allocate относит подписку к центру затрат с месяца allocation.EffectiveFrom. Повторное
+// распределение с того же месяца заменяет центр затрат. Для неизвестного центра затрат
+// возвращается ErrUnknownCostCentre.
+func (r *SubscriptionRepository) Allocate(ctx context.Context, id int64, allocation model.Allocation, expectedVersion *int) error {
+	return r.withVersion(ctx, id, expectedVersion, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `INSERT INTO subscription_allocations (subscription_id, effective_from, cost_centre_id)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (subscription_id, effective_from) DO UPDATE SET cost_centre_id = EXCLUDED.cost_centre_id, created_at = now()`,
+			id, allocation.EffectiveFrom, allocation.CostCentreID)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+				return ErrUnknownCostCentre
+			}
+			return fmt.Errorf("failed to allocate subscription: %w", err)
+		}
+		return nil
+	})
+}
 
-	_, err := r.conn.Exec(ctx, query, id)
+// Pause приостанавливает оплату подписки. Если приостановка пересекается с другой
+// приостановкой подписки, возвращается ErrPauseOverlap.
+func (r *SubscriptionRepository) Pause(ctx context.Context, id int64, pause model.Pause, expectedVersion *int) error {
+	return r.withVersion(ctx, id, expectedVersion, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `INSERT INTO subscription_pauses (subscription_id, paused_from, paused_to)
+			VALUES ($1, $2, $3)`, id, pause.From, pause.To)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && (pgErr.Code == pgExclusionViolation || pgErr.Code == pgUniqueViolation) {
+				return ErrPauseOverlap
+			}
+			return fmt.Errorf("failed to pause subscription: %w", err)
+		}
+		return nil
+	})
+}
+
+// Resume возобновляет оплату подписки с месяца month: приостановка, действующая в этом
+// месяце, заканчивается месяцем раньше, а запланированные позже — удаляются. Если с месяца
+// month подписка не приостановлена, возвращается ErrNotPaused.
+func (r *SubscriptionRepository) Resume(ctx context.Context, id int64, month time.Time, expectedVersion *int) error {
+	return r.withVersion(ctx, id, expectedVersion, func(tx pgx.Tx) error {
+		deleted, err := tx.Exec(ctx, `DELETE FROM subscription_pauses WHERE subscription_id = $1 AND paused_from >= $2`, id, month)
+		if err != nil {
+			return fmt.Errorf("failed to delete subscription pauses: %w", err)
+		}
+		updated, err := tx.Exec(ctx, `UPDATE subscription_pauses SET paused_to = ($2::date - interval '1 month')::date
+			WHERE subscription_id = $1 AND paused_from < $2 AND (paused_to IS NULL OR paused_to >= $2)`, id, month)
+		if err != nil {
+			return fmt.Errorf("failed to resume subscription: %w", err)
+		}
+		if deleted.RowsAffected()+updated.RowsAffected() == 0 {
+			return ErrNotPaused
+		}
+		return nil
+	})
+}
+
+// SetMembers заменяет правило разделения стоимости и участников совместной подписки.
+// У оставшихся участников сохраняется порядок добавления.
+func (r *SubscriptionRepository) SetMembers(ctx context.Context, id int64, split string, members []model.Member, expectedVersion *int) error {
+	userIDs := make([]uuid.UUID, 0, len(members))
+	percents := make([]*int, 0, len(members))
+	amounts := make([]*int, 0, len(members))
+	for _, m := range members {
+		userIDs = append(userIDs, m.UserID)
+		percents = append(percents, m.Percent)
+		amounts = append(amounts, m.Amount)
+	}
+
+	return r.withVersion(ctx, id, expectedVersion, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `UPDATE subscriptions SET split_rule = $2 WHERE id = $1`, id, split); err != nil {
+			return fmt.Errorf("failed to update split rule: %w", err)
+		}
+		_, err := tx.Exec(ctx, `DELETE FROM subscription_members WHERE subscription_id = $1 AND user_id <> ALL($2)`, id, userIDs)
+		if err != nil {
+			return fmt.Errorf("failed to delete subscription members: %w", err)
+		}
+		_, err = tx.Exec(ctx, `INSERT INTO subscription_members (subscription_id, user_id, percent, amount)
+			SELECT $1, m.user_id, m.percent, m.amount FROM unnest($2::uuid[], $3::int[], $4::int[]) AS m(user_id, percent, amount)
+			ON CONFLICT (subscription_id, user_id) DO UPDATE SET percent = EXCLUDED.percent, amount = EXCLUDED.amount`,
+			id, userIDs, percents, amounts)
+		if err != nil {
+			return fmt.Errorf("failed to save subscription members: %w", err)
+		}
+		return nil
+	})
+}
+
+// withVersion выполняет fn в транзакции, увеличив версию подписки. Если expectedVersion
+// задан, подписка изменяется только при совпадении версии.
+func (r *SubscriptionRepository) withVersion(ctx context.Context, id int64, expectedVersion *int, fn func(pgx.Tx) error) error {
+	tx, err := r.db(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var version int
+	err = tx.QueryRow(ctx, `UPDATE subscriptions SET version = version + 1
+		WHERE id = $1 AND ($2::int IS NULL OR version = $2)
+		RETURNING version`, id, expectedVersion).Scan(&version)
 	if err != nil {
-		return fmt.Errorf("failed to delete subscription: %w", err)
+		if err == pgx.ErrNoRows {
+			return r.notAffectedError(ctx, id, expectedVersion)
+		}
+		return fmt.Errorf("failed to update subscription version: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	return nil
 }
 
-func (r *SubscriptionRepository) TotalSumSubscription(ctx context.Context, userID *uuid.UUID, serviceName *string, from, to time.Time) (int, error) {
-	query := `SELECT COALESCE(SUM(price), 0)
-		FROM subscriptions WHERE start_date >= $1 AND start_date <= $2
-	`
+// Cancel устанавливает end_date подписки и сохраняет отмену, заполняя её ID и время.
+func (r *SubscriptionRepository) Cancel(ctx context.Context, c *model.Cancellation, expectedVersion *int) error {
+	return r.withVersion(ctx, c.SubscriptionID, expectedVersion, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `UPDATE subscriptions SET end_date = $2 WHERE id = $1`, c.SubscriptionID, c.EndDate); err != nil {
+			return fmt.Errorf("failed to set subscription end date: %w", err)
+		}
+		err := tx.QueryRow(ctx, `INSERT INTO subscription_cancellations
+				(subscription_id, user_id, service_id, reason, note, mode, end_date, price)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id, cancelled_at`,
+			c.SubscriptionID, c.UserID, c.ServiceID, c.Reason, c.Note, c.Mode, c.EndDate, c.Price).Scan(&c.ID, &c.CancelledAt)
+		if err != nil {
+			return fmt.Errorf("failed to record cancellation: %w", err)
+		}
+		return nil
+	})
+}
 
-	args := []interface{}{from, to}
-	argNum := 3
-	if userID != nil {
-		query += fmt.Sprintf(" AND user_id = $%d", argNum)
-		args = append(args, *userID)
-		argNum++
+// TrialsEnding возвращает подписки, пробный период которых заканчивается с from по to включительно.
+func (r *SubscriptionRepository) TrialsEnding(ctx context.Context, from, to time.Time) ([]*model.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + subscriptionTables + `
+		WHERE s.trial_ends_at BETWEEN $1 AND $2
+		ORDER BY s.trial_ends_at, s.id`
+	return r.query(ctx, query, from, to)
+}
+
+// RecordTrialNotice сохраняет уведомление об окончании пробного периода подписки днём
+// trialEndsAt и возвращает false, если о нём уже уведомляли.
+func (r *SubscriptionRepository) RecordTrialNotice(ctx context.Context, id int64, trialEndsAt time.Time) (bool, error) {
+	tag, err := r.db(ctx).Exec(ctx, `INSERT INTO trial_notifications (subscription_id, trial_ends_at)
+		VALUES ($1, $2)
+		ON CONFLICT (subscription_id, trial_ends_at) DO NOTHING`, id, trialEndsAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to record trial notification: %w", err)
 	}
+	return tag.RowsAffected() > 0, nil
+}
 
-	if serviceName != nil {
-		query += fmt.Sprintf(" AND service_name ILIKE $%d", argNum)
-		args = append(args, "%"+*serviceName+"%")
-		argNum++
+// writeError возвращает ErrOverlap, если запись нарушила ограничение на
+// пересечение подписок, иначе оборачивает err сообщением msg.
+func writeError(err error, msg string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgExclusionViolation {
+		return ErrOverlap
 	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// notAffectedError возвращает ошибку запроса, который не изменил подписку id: ErrNotFound,
+// если подписки нет, и ErrVersionConflict, если не совпала версия expectedVersion.
+func (r *SubscriptionRepository) notAffectedError(ctx context.Context, id int64, expectedVersion *int) error {
+	if expectedVersion == nil {
+		return ErrNotFound
+	}
+
+	var exists bool
+	err := r.db(ctx).QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM subscriptions WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check subscription: %w", err)
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrVersionConflict
+}
+
+// batchNotAffectedError — notAffectedError для операции пакета: результаты пакета читаются
+// в открытой транзакции, поэтому существование подписки проверяет сервис до выполнения пакета.
+func batchNotAffectedError(expectedVersion *int) error {
+	if expectedVersion != nil {
+		return ErrVersionConflict
+	}
+	return ErrNotFound
+}
+
+// ChargesSum считает сумму помесячных начислений подписок по фильтру за месяцы с from по to.
+// Нулевая граница не ограничивает период.
+// Нулевая граница не ограничивает период.
+func (r *SubscriptionRepository) ChargesSum(ctx context.Context, filter ChargeFilter, from, to time.Time) (int, error) {
+	where, shares, args := filter.where(from, to)
+	query := chargesQuery(where, shares) + `SELECT COALESCE(SUM(amount), 0) FROM charges`
 
 	var sum int
-	err := r.conn.QueryRow(ctx, query, args...).Scan(&sum)
+	err := r.db(ctx).QueryRow(ctx, query, args...).Scan(&sum)
 	if err != nil {
 		return 0, fmt.Errorf("failed to calculate total: %w", err)
 	}
 	return sum, nil
 }
+
+// ChargesByGroup группирует помесячные начисления подписок по фильтру за месяцы с from по to
+// так же, как отчёт о расходах.
+func (r *SubscriptionRepository) ChargesByGroup(ctx context.Context, filter ChargeFilter, from, to time.Time, groupBy string) ([]model.SpendingBucket, error) {
+	return spending(ctx, r.conn, filter, from, to, groupBy)
+}
+
+// Overlapping возвращает для каждой подписки из subs (по её индексу) сохранённые подписки
+// того же пользователя на тот же сервис, период которых пересекается с её периодом.
+// Сама подписка, если она уже сохранена, не учитывается.
+func (r *SubscriptionRepository) Overlapping(ctx context.Context, subs []*model.Subscription) (map[int][]*model.Subscription, error) {
+	query := `SELECT c.idx - 1, s.id
+		FROM unnest($1::bigint[], $2::uuid[], $3::bigint[], $4::date[], $5::date[])
+			WITH ORDINALITY AS c(id, user_id, service_id, start_date, end_date, idx)
+		JOIN subscriptions s ON s.user_id = c.user_id AND s.service_id = c.service_id AND s.id <> c.id
+		WHERE daterange(s.start_date, (s.end_date + interval '1 month')::date)
+			&& daterange(c.start_date, (c.end_date + interval '1 month')::date)
+		ORDER BY c.idx, s.id`
+
+	ids := make([]int64, len(subs))
+	userIDs := make([]uuid.UUID, len(subs))
+	serviceIDs := make([]int64, len(subs))
+	starts := make([]time.Time, len(subs))
+	ends := make([]*time.Time, len(subs))
+	for i, sub := range subs {
+		ids[i], userIDs[i], serviceIDs[i], starts[i], ends[i] = sub.ID, sub.UserID, sub.ServiceID, sub.StartDate, sub.EndDate
+	}
+
+	rows, err := r.db(ctx).Query(ctx, query, ids, userIDs, serviceIDs, starts, ends)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find overlapping subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	matches := map[int][]int64{}
+	var found []int64
+	for rows.Next() {
+		var idx int
+		var id int64
+		if err := rows.Scan(&idx, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan overlapping subscription: %w", err)
+		}
+		matches[idx] = append(matches[idx], id)
+		found = append(found, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find overlapping subscriptions: %w", err)
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+
+	byID, err := r.GetByIDs(ctx, found)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[int][]*model.Subscription, len(matches))
+	for idx, matched := range matches {
+		for _, id := range matched {
+			if sub, ok := byID[id]; ok {
+				res[idx] = append(res[idx], sub)
+			}
+		}
+	}
+	return res, nil
+}
+
+func (r *SubscriptionRepository) GetByIDs(ctx context.Context, ids []int64) (map[int64]*model.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + subscriptionTables + ` WHERE s.id = ANY($1)`
+
+	rows, err := r.db(ctx).Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := make(map[int64]*model.Subscription, len(ids))
+	for rows.Next() {
+		var sub model.Subscription
+		err := scanSubscription(rows, &sub)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		subs[sub.ID] = &sub
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+const batchDeleteQuery = `WITH s AS (
+		DELETE FROM subscriptions WHERE id = $1 AND ($2::int IS NULL OR version = $2) RETURNING *
+	)
+	SELECT ` + subscriptionColumns + ` FROM s JOIN services sv ON sv.id = s.service_id`
+
+// ApplyBatch выполняет операции в одной транзакции и возвращает ошибку для каждой операции.
+// В атомарном режиме первая ошибка откатывает всю транзакцию, иначе каждая операция
+// выполняется в своей точке сохранения и её ошибка не влияет на остальные.
+func (r *SubscriptionRepository) ApplyBatch(ctx context.Context, ops []model.BatchOp, atomic bool) ([]error, error) {
+	tx, err := r.db(ctx).Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var errs []error
+	if atomic {
+		errs, err = applyBatchAtomic(ctx, tx, ops)
+	} else {
+		errs, err = applyBatchPartial(ctx, tx, ops)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, opErr := range errs {
+		if opErr != nil && atomic {
+			return errs, nil
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return errs, nil
+}
+
+func applyBatchAtomic(ctx context.Context, tx pgx.Tx, ops []model.BatchOp) ([]error, error) {
+	batch := &pgx.Batch{}
+	for _, op := range ops {
+		queueBatchOp(batch, op)
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	defer results.Close()
+
+	errs := make([]error, len(ops))
+	for i, op := range ops {
+		if err := readBatchOp(results, op); err != nil {
+			for j := range errs {
+				errs[j] = ErrRolledBack
+			}
+			errs[i] = err
+			return errs, nil
+		}
+	}
+	return errs, nil
+}
+
+func applyBatchPartial(ctx context.Context, tx pgx.Tx, ops []model.BatchOp) ([]error, error) {
+	errs := make([]error, len(ops))
+	for i, op := range ops {
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		batch := &pgx.Batch{}
+		queueBatchOp(batch, op)
+		results := savepoint.SendBatch(ctx, batch)
+		opErr := readBatchOp(results, op)
+		if err := results.Close(); err != nil && opErr == nil {
+			opErr = err
+		}
+
+		if opErr != nil {
+			errs[i] = opErr
+			if err := savepoint.Rollback(ctx); err != nil {
+				return nil, fmt.Errorf("failed to rollback savepoint: %w", err)
+			}
+			continue
+		}
+		if err := savepoint.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+	}
+	return errs, nil
+}
+
+func queueBatchOp(batch *pgx.Batch, op model.BatchOp) {
+	switch op.Kind {
+	case model.BatchCreate:
+		sub := op.Subscription
+		batch.Queue(insertSubscriptionQuery, insertArgs(sub)...)
+	case model.BatchUpdate:
+		sub := op.Subscription
+		batch.Queue(updateSubscriptionQuery, updateArgs(sub, op.ExpectedVersion)...)
+	case model.BatchDelete:
+		batch.Queue(batchDeleteQuery, op.ID, op.ExpectedVersion)
+	}
+}
+
+func readBatchOp(results pgx.BatchResults, op model.BatchOp) error {
+	switch op.Kind {
+	case model.BatchCreate:
+		if err := results.QueryRow().Scan(&op.Subscription.ID, &op.Subscription.Version); err != nil {
+			return writeError(err, "failed insert subscription")
+		}
+	case model.BatchUpdate:
+		if err := results.QueryRow().Scan(&op.Subscription.Version); err != nil {
+			if err == pgx.ErrNoRows {
+				return batchNotAffectedError(op.ExpectedVersion)
+			}
+			return writeError(err, "failed to update subscription")
+		}
+	case model.BatchDelete:
+		if err := scanSubscription(results.QueryRow(), op.Subscription); err != nil {
+			if err == pgx.ErrNoRows {
+				return batchNotAffectedError(op.ExpectedVersion)
+			}
+			return fmt.Errorf("failed to delete subscription: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestChargeFilterWherePeriodBounds(t *testing.T) {
+	month := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []interface{}
+	}{
+		{name: "closed", from: month, to: month, want: []interface{}{month, month}},
+		{name: "no from", to: month, want: []interface{}{nil, month}},
+		{name: "no to", from: month, want: []interface{}{month, nil}},
+		{name: "open", want: []interface{}{nil, nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, args := ChargeFilter{}.where(tt.from, tt.to)
+			if len(args) != 2 {
+				t.Fatalf("args = %v, want 2 period bounds", args)
+			}
+			for i, want := range tt.want {
+				if args[i] != want {
+					t.Errorf("args[%d] = %v, want %v", i, args[i], want)
+				}
+			}
+		})
+	}
+}
+
+// Пользователь фильтра занимает $3 и в условии на подписки, и в условии на доли;
+// остальные условия нумеруются после него.
+func TestChargeFilterWhereUserShares(t *testing.T) {
+	month := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+	serviceID := int64(7)
+
+	where, shares, args := ChargeFilter{UserID: &userID, ServiceID: &serviceID}.where(month, month)
+	if shares != "user_id = $3" {
+		t.Errorf("shares = %q, want %q", shares, "user_id = $3")
+	}
+	if !strings.Contains(where, "s.service_id = $4") || !strings.Contains(where, "sm.user_id = $3") {
+		t.Errorf("where = %q, want service at $4 and membership by $3", where)
+	}
+	if want := []interface{}{month, month, userID, serviceID}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+
+	where, shares, args = ChargeFilter{ServiceID: &serviceID}.where(month, month)
+	if shares != "TRUE" || !strings.Contains(where, "s.service_id = $3") || len(args) != 3 {
+		t.Errorf("where = %q, shares = %q, args = %v, want all shares and service at $3", where, shares, args)
+	}
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shenikar/subscription-service/internal/model"
+)
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag name already exists")
+)
+
+// Число подписок с меткой считается при чтении: отдельного счётчика у меток нет.
+const (
+	tagColumns = `t.id, t.name, t.created_at,
+		(SELECT COUNT(*) FROM subscription_tags st WHERE st.tag_id = t.id)`
+	tagTables = ` FROM tags t`
+)
+
+func scanTag(row pgx.Row, tag *model.Tag) error {
+	return row.Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &tag.Subscriptions)
+}
+
+type TagRepository struct {
+	conn *pgxpool.Pool
+}
+
+func NewTagRepository(conn *pgxpool.Pool) *TagRepository {
+	return &TagRepository{conn: conn}
+}
+
+func (r *TagRepository) GetByID(ctx context.Context, id int64) (*model.Tag, error) {
+	query := `SELECT ` + tagColumns + tagTables + ` WHERE t.id = $1`
+
+	var tag model.Tag
+	if err := scanTag(r.conn.QueryRow(ctx, query, id), &tag); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+	return &tag, nil
+}
+
+// List возвращает метки по убыванию числа подписок. query отбирает метки, название
+// которых содержит подстроку без учёта регистра.
+func (r *TagRepository) List(ctx context.Context, query *string, limit, offset int) ([]*model.Tag, error) {
+	sql := `SELECT ` + tagColumns + tagTables + ` WHERE 1 = 1`
+
+	var args []interface{}
+	argNum := 1
+	if query != nil {
+		sql += fmt.Sprintf(" AND t.name ILIKE $%d", argNum)
+		args = append(args, "%"+*query+"%")
+		argNum++
+	}
+
+	sql += " ORDER BY 4 DESC, lower(t.name)"
+	if limit > 0 {
+		sql += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, limit)
+		argNum++
+	}
+	if offset > 0 {
+		sql += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, offset)
+	}
+
+	rows, err := r.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []*model.Tag
+	for rows.Next() {
+		var tag model.Tag
+		if err := scanTag(rows, &tag); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, &tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	return tags, nil
+}
+
+// Rename переименовывает метку и увеличивает версию подписок с ней. Возвращает ID этих
+// подписок; если метка с таким названием уже есть, возвращается ErrTagExists.
+func (r *TagRepository) Rename(ctx context.Context, id int64, name string) ([]int64, error) {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	res, err := tx.Exec(ctx, `UPDATE tags SET name = $2 WHERE id = $1`, id, name)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return nil, ErrTagExists
+		}
+		return nil, fmt.Errorf("failed to rename tag: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return nil, ErrTagNotFound
+	}
+
+	ids, err := touchTagged(ctx, tx, []int64{id})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return ids, nil
+}
+
+// Merge переносит подписки меток sourceIDs на метку targetID и удаляет исходные метки.
+// Возвращает ID изменённых подписок; если какой-то метки нет, возвращается ErrTagNotFound.
+func (r *TagRepository) Merge(ctx context.Context, sourceIDs []int64, targetID int64) ([]int64, error) {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var found int
+	err = tx.QueryRow(ctx, `SELECT COUNT(*) FROM (
+			SELECT id FROM tags WHERE id = ANY($1) OR id = $2 FOR UPDATE
+		) AS locked`, sourceIDs, targetID).Scan(&found)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock tags: %w", err)
+	}
+	if found != len(sourceIDs)+1 {
+		return nil, ErrTagNotFound
+	}
+
+	ids, err := touchTagged(ctx, tx, sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(ctx, `INSERT INTO subscription_tags (subscription_id, tag_id)
+		SELECT subscription_id, $2 FROM subscription_tags WHERE tag_id = ANY($1)
+		ON CONFLICT DO NOTHING`, sourceIDs, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge tags: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM tags WHERE id = ANY($1)`, sourceIDs); err != nil {
+		return nil, fmt.Errorf("failed to delete merged tags: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return ids, nil
+}
+
+// touchTagged увеличивает версию подписок с метками tagIDs: метки входят в представление
+// подписки, поэтому их изменение меняет ETag.
+func touchTagged(ctx context.Context, tx pgx.Tx, tagIDs []int64) ([]int64, error) {
+	rows, err := tx.Query(ctx, `UPDATE subscriptions SET version = version + 1
+		WHERE id IN (SELECT subscription_id FROM subscription_tags WHERE tag_id = ANY($1))
+		RETURNING id`, tagIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update tagged subscriptions: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, fmt.Errorf("failed to update tagged subscriptions: %w", err)
+	}
+	return ids, nil
+}
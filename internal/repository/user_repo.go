@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shenikar/subscription-service/internal/model"
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrUserExists         = errors.New("user id or email already exists")
+	ErrUserInUse          = errors.New("user has subscriptions")
+	ErrUserIsMember       = errors.New("user is a member of shared subscriptions")
+	ErrUserInOrganisation = errors.New("user is a member of organisations")
+)
+
+// Ограничения, которые не дают удалить участника совместных подписок и организаций.
+const (
+	fkSubscriptionMembersUser = "fk_subscription_members_user_id"
+	fkOrganisationMembersUser = "fk_organisation_members_user_id"
+)
+
+const userColumns = `id, email, name, currency, reminder_days, time_zone, created_at, updated_at`
+
+func scanUser(row pgx.Row, user *model.User) error {
+	return row.Scan(&user.ID, &user.Email, &user.Name, &user.Currency, &user.ReminderDays, &user.TimeZone, &user.CreatedAt, &user.UpdatedAt)
+}
+
+type UserRepository struct {
+	conn *pgxpool.Pool
+}
+
+func NewUserRepository(conn *pgxpool.Pool) *UserRepository {
+	return &UserRepository{conn: conn}
+}
+
+// db возвращает транзакцию из ctx, открытую InTx, или пул.
+func (r *UserRepository) db(ctx context.Context) querier {
+	return connFrom(ctx, r.conn)
+}
+
+// Create сохраняет пользователя. Если ID не задан, он генерируется базой.
+func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
+	query := `INSERT INTO users (id, email, name, currency, reminder_days, time_zone)
+		VALUES (COALESCE($1, uuid_generate_v4()), $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+	var id *uuid.UUID
+	if user.ID != uuid.Nil {
+		id = &user.ID
+	}
+	err := r.db(ctx).QueryRow(ctx, query, id, user.Email, user.Name, user.Currency, user.ReminderDays, user.TimeZone).
+		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return ErrUserExists
+		}
+		return fmt.Errorf("failed insert user: %w", err)
+	}
+	return nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+
+	var user model.User
+	if err := scanUser(r.db(ctx).QueryRow(ctx, query, id), &user); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &user, nil
+}
+
+// List возвращает пользователей. query ищет подстроку в email и имени.
+func (r *UserRepository) List(ctx context.Context, query *string, limit, offset int) ([]*model.User, error) {
+	sql := `SELECT ` + userColumns + ` FROM users WHERE 1 = 1`
+
+	var args []interface{}
+	argNum := 1
+	if query != nil {
+		sql += fmt.Sprintf(" AND (email ILIKE $%d OR name ILIKE $%d)", argNum, argNum)
+		args = append(args, "%"+*query+"%")
+		argNum++
+	}
+
+	sql += " ORDER BY created_at, id"
+	if limit > 0 {
+		sql += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, limit)
+		argNum++
+	}
+	if offset > 0 {
+		sql += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, offset)
+	}
+
+	rows, err := r.db(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*model.User
+	for rows.Next() {
+		var user model.User
+		if err := scanUser(rows, &user); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, &user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	return users, nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, user *model.User) error {
+	query := `UPDATE users SET email = $1, name = $2, currency = $3, reminder_days = $4, time_zone = $5, updated_at = now()
+		WHERE id = $6
+		RETURNING created_at, updated_at
+	`
+	err := r.db(ctx).QueryRow(ctx, query, user.Email, user.Name, user.Currency, user.ReminderDays, user.TimeZone, user.ID).
+		Scan(&user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrUserNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return ErrUserExists
+		}
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// Delete удаляет пользователя без подписок, участия в совместных подписках и организациях.
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.db(ctx).Exec(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return userDeleteError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// userDeleteError объясняет, почему пользователя нельзя удалить: ErrUserIsMember для
+// участника совместных подписок, ErrUserInOrganisation для участника организаций и
+// ErrUserInUse для владельца подписок.
+func userDeleteError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgForeignKeyViolation {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	switch pgErr.ConstraintName {
+	case fkSubscriptionMembersUser:
+		return ErrUserIsMember
+	case fkOrganisationMembersUser:
+		return ErrUserInOrganisation
+	default:
+		return ErrUserInUse
+	}
+}
+
+// Missing возвращает ID из списка, для которых нет пользователей.
+func (r *UserRepository) Missing(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	query := `SELECT id FROM unnest($1::uuid[]) AS t(id)
+		WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.id = t.id)
+	`
+	rows, err := r.db(ctx).Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check users: %w", err)
+	}
+	defer rows.Close()
+
+	missing, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan user id: %w", err)
+	}
+	return missing, nil
+}
+
+// Register создаёт пользователей без профиля для ID, которых ещё нет.
+func (r *UserRepository) Register(ctx context.Context, ids []uuid.UUID) error {
+	query := `INSERT INTO users (id) SELECT DISTINCT unnest($1::uuid[]) ON CONFLICT (id) DO NOTHING`
+	if _, err := r.db(ctx).Exec(ctx, query, ids); err != nil {
+		return fmt.Errorf("failed to register users: %w", err)
+	}
+	return nil
+}
+
+// TimeZones возвращает часовые пояса пользователей из списка, у которых они заданы.
+func (r *UserRepository) TimeZones(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	query := `SELECT id, time_zone FROM users WHERE id = ANY($1) AND time_zone IS NOT NULL`
+
+	rows, err := r.db(ctx).Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user time zones: %w", err)
+	}
+	defer rows.Close()
+
+	zones := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var id uuid.UUID
+		var zone string
+		if err := rows.Scan(&id, &zone); err != nil {
+			return nil, fmt.Errorf("failed to scan user time zone: %w", err)
+		}
+		zones[id] = zone
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get user time zones: %w", err)
+	}
+	return zones, nil
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestUserDeleteError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "owns subscriptions", err: &pgconn.PgError{Code: pgForeignKeyViolation, ConstraintName: "fk_subscriptions_user_id"}, want: ErrUserInUse},
+		{name: "shared subscription member", err: &pgconn.PgError{Code: pgForeignKeyViolation, ConstraintName: fkSubscriptionMembersUser}, want: ErrUserIsMember},
+		{name: "organisation member", err: &pgconn.PgError{Code: pgForeignKeyViolation, ConstraintName: fkOrganisationMembersUser}, want: ErrUserInOrganisation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := userDeleteError(tt.err); !errors.Is(got, tt.want) {
+				t.Errorf("userDeleteError = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	other := errors.New("connection refused")
+	if got := userDeleteError(other); !errors.Is(got, other) || errors.Is(got, ErrUserInUse) {
+		t.Errorf("userDeleteError(other) = %v, want the wrapped error", got)
+	}
+}
@@ -1,29 +1,128 @@
 package router
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/shenikar/subscription-service/internal/handler"
 	"github.com/shenikar/subscription-service/internal/middleware"
 )
 
-func SetupRouter(h *handler.SubscriptionHandler) *gin.Engine {
+func SetupRouter(h *handler.SubscriptionHandler, services *handler.ServiceHandler, users *handler.UserHandler, reports *handler.ReportHandler, budgets *handler.BudgetHandler, tags *handler.TagHandler, organisations *handler.OrganisationHandler, gql *handler.GraphQLHandler, idempotency gin.HandlerFunc) *gin.Engine {
 	r := gin.New()
 
 	r.Use(gin.Recovery())
 	r.Use(middleware.LoggerMiddleware())
 
 	api := r.Group("/api/v1")
+	api.Use(middleware.TimeZone())
 	{
 		sub := api.Group("/subscriptions")
 		{
-			sub.POST("/", h.Create)
+			sub.POST("/", idempotency, h.Create)
+			sub.POST("/import", idempotency, h.Import)
 			sub.GET("/", h.GetAll)
+			sub.GET("/search", h.Search)
 			sub.GET("/:id", h.GetByID)
 			sub.PUT("/:id", h.Update)
+			sub.PATCH("/:id", h.Patch)
 			sub.DELETE("/:id", h.Delete)
+			sub.POST("/:id/prices", h.SchedulePrice)
+			sub.POST("/:id/pause", h.Pause)
+			sub.POST("/:id/resume", h.Resume)
+			sub.POST("/:id/cancel", h.Cancel)
+			sub.GET("/:id/members", h.Members)
+			sub.PUT("/:id/members", h.SetMembers)
+			sub.POST("/:id/members", h.AddMember)
+			sub.DELETE("/:id/members/:user_id", h.RemoveMember)
+			sub.POST("/:id/allocations", h.Allocate)
 			sub.GET("/total", h.TotalPrice)
 		}
+		api.POST("/subscriptions:method", customMethod("batch"), idempotency, h.Batch)
+
+		svc := api.Group("/services")
+		{
+			svc.POST("/", services.Create)
+			svc.GET("/", services.GetAll)
+			svc.GET("/:id", services.GetByID)
+			svc.PUT("/:id", services.Update)
+			svc.DELETE("/:id", services.Delete)
+		}
+
+		usr := api.Group("/users")
+		{
+			usr.POST("/", users.Create)
+			usr.GET("/", users.GetAll)
+			usr.GET("/:id", users.GetByID)
+			usr.PUT("/:id", users.Update)
+			usr.DELETE("/:id", users.Delete)
+			usr.GET("/:id/subscriptions", users.Subscriptions)
+			usr.GET("/:id/summary", users.Summary)
+		}
+
+		bud := api.Group("/budgets")
+		{
+			bud.POST("/", budgets.Create)
+			bud.GET("/", budgets.GetAll)
+			bud.GET("/:id", budgets.GetByID)
+			bud.PUT("/:id", budgets.Update)
+			bud.DELETE("/:id", budgets.Delete)
+			bud.GET("/:id/status", budgets.Status)
+		}
+
+		tag := api.Group("/tags")
+		{
+			tag.GET("/", tags.GetAll)
+			tag.GET("/:id", tags.GetByID)
+			tag.PUT("/:id", tags.Rename)
+			tag.POST("/merge", tags.Merge)
+		}
+
+		org := api.Group("/organisations")
+		{
+			org.POST("/", organisations.Create)
+			org.GET("/", organisations.GetAll)
+			org.GET("/:id", organisations.GetByID)
+			org.PUT("/:id", organisations.Update)
+			org.DELETE("/:id", organisations.Delete)
+			org.GET("/:id/units", organisations.Units)
+			org.POST("/:id/units", organisations.CreateUnit)
+			org.PUT("/:id/units/:unit_id", organisations.UpdateUnit)
+			org.DELETE("/:id/units/:unit_id", organisations.DeleteUnit)
+			org.GET("/:id/cost-centres", organisations.CostCentres)
+			org.POST("/:id/cost-centres", organisations.CreateCostCentre)
+			org.PUT("/:id/cost-centres/:cc_id", organisations.UpdateCostCentre)
+			org.DELETE("/:id/cost-centres/:cc_id", organisations.DeleteCostCentre)
+			org.GET("/:id/members", organisations.Members)
+			org.PUT("/:id/members/:user_id", organisations.SetMember)
+			org.DELETE("/:id/members/:user_id", organisations.RemoveMember)
+			org.GET("/:id/spending", organisations.Spending)
+		}
+
+		rep := api.Group("/reports")
+		{
+			rep.GET("/spending", reports.Spending)
+			rep.GET("/forecast", reports.Forecast)
+			rep.GET("/duplicates", reports.Duplicates)
+			rep.GET("/cancellations", reports.Cancellations)
+			rep.GET("/balances", reports.Balances)
+		}
+		api.POST("/graphql", gql.Query)
 	}
 
 	return r
 }
+
+// customMethod пропускает только запросы к пользовательскому методу ресурса вида
+// /subscriptions:name. gin считает двоеточие началом параметра, а экранированный путь
+// разворачивает лишь в Engine.Run, поэтому маршрут регистрируется с параметром method,
+// который захватывает остаток сегмента вместе с двоеточием.
+func customMethod(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Param("method") != ":"+name {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.Next()
+	}
+}
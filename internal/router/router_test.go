@@ -0,0 +1,74 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/subscription-service/internal/config"
+	"github.com/shenikar/subscription-service/internal/gql"
+	"github.com/shenikar/subscription-service/internal/handler"
+	"github.com/shenikar/subscription-service/internal/middleware"
+	"github.com/shenikar/subscription-service/internal/testutil"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	stores := testutil.NewStores()
+	svc := stores.SubscriptionService()
+	h := handler.NewSubscriptionHandler(svc, config.Config{BatchMaxSize: 10})
+	executor, err := gql.NewExecutor(svc, 10, 1000)
+	if err != nil {
+		t.Fatalf("gql.NewExecutor: %v", err)
+	}
+	return SetupRouter(h, handler.NewServiceHandler(stores.Catalog()), handler.NewUserHandler(stores.UserService(), svc), handler.NewReportHandler(stores.ReportService()), handler.NewBudgetHandler(stores.BudgetService()), handler.NewTagHandler(stores.TagService()), handler.NewOrganisationHandler(stores.OrganisationService()), handler.NewGraphQLHandler(executor), middleware.Idempotency(testutil.NewIdempotencyKeys(), time.Hour))
+}
+
+// Маршрут пакета проверяется через ServeHTTP, а не Engine.Run: так его вызывают
+// httptest-серверы и обработчики, встроенные в другие серверы.
+func TestBatchRoute(t *testing.T) {
+	r := newTestRouter(t)
+
+	body := `{"operations": [{"op": "create", "data": {"service_name": "Netflix", "price": 400, "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba", "start_date": "07-2025"}}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions:batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST /api/v1/subscriptions:batch status = %d, want %d; body %s", w.Code, http.StatusOK, w.Body)
+	}
+	var resp api.BatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Succeeded != 1 || len(resp.Results) != 1 || resp.Results[0].Status != http.StatusCreated || resp.Results[0].ID != 1 {
+		t.Errorf("POST /api/v1/subscriptions:batch response = %+v, want one created subscription", resp)
+	}
+}
+
+func TestCustomMethodRejectsOtherMethods(t *testing.T) {
+	r := newTestRouter(t)
+
+	for _, path := range []string{
+		"/api/v1/subscriptions:batchx",
+		"/api/v1/subscriptions:bat",
+		"/api/v1/subscriptions:import",
+		"/api/v1/subscriptions:",
+	} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"operations": []}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("POST %s status = %d, want %d", path, w.Code, http.StatusNotFound)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/shenikar/subscription-service/internal/dates"
+	"github.com/shenikar/subscription-service/internal/event"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+// Allocate относит подписку к центру затрат с месяца req.From. Начисления прошлых месяцев
+// остаются за прежним центром затрат, поэтому отчёты за них не меняются.
+func (s *SubscriptionService) Allocate(ctx context.Context, id int64, req api.AllocateRequest, ifMatch *int) (model.Subscription, error) {
+	log := logger.GetLogger()
+
+	current, err := s.getForUpdate(ctx, id, ifMatch)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+
+	allocation := model.Allocation{CostCentreID: req.CostCentreID}
+	if allocation.EffectiveFrom, err = dates.ParseMonthIn(req.From, s.users.userLocation(ctx, current.UserID)); err != nil {
+		return model.Subscription{}, fmt.Errorf("%w: invalid from: %v", ErrInvalidInput, err)
+	}
+	if allocation.EffectiveFrom.Before(model.MonthStart(current.StartDate)) {
+		return model.Subscription{}, fmt.Errorf("%w: from must not be before start_date %s",
+			ErrInvalidInput, dates.FormatMonth(current.StartDate))
+	}
+
+	if err := s.repo.Allocate(ctx, id, allocation, &current.Version); err != nil {
+		if errors.Is(err, repository.ErrUnknownCostCentre) {
+			return model.Subscription{}, fmt.Errorf("%w: unknown cost_centre_id", ErrInvalidInput)
+		}
+		log.WithError(err).Errorf("failed to allocate subscription: %d", id)
+		return model.Subscription{}, repositoryError(err, "allocate failed")
+	}
+
+	updated, err := s.getAfterChange(ctx, id)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	log.WithFields(logrus.Fields{
+		"id":      id,
+		"from":    dates.FormatMonth(allocation.EffectiveFrom),
+		"version": updated.Version,
+	}).Info("subscription allocated")
+
+	s.publish(event.SubscriptionUpdated, updated)
+	return updated, nil
+}
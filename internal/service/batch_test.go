@@ -0,0 +1,60 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/shenikar/subscription-service/internal/dates"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/testutil"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+// Сервисы каталога и пользователи, созданные для операций пакета, сохраняются
+// вместе с подписками: откат атомарного пакета удаляет и их.
+func TestBatchCatalog(t *testing.T) {
+	tests := []struct {
+		name     string
+		atomic   bool
+		statuses []int
+		services []string
+		users    int
+	}{
+		{name: "atomic", atomic: true, statuses: []int{http.StatusFailedDependency, http.StatusNotFound}},
+		{name: "non-atomic", statuses: []int{http.StatusCreated, http.StatusNotFound}, services: []string{"Kinopoisk"}, users: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := testutil.NewStores()
+			svc := stores.SubscriptionService()
+
+			items := []dto.BatchItem{
+				{Index: 0, Op: api.BatchOpCreate, Create: &api.CreateSubscriptionRequest{ServiceName: "Kinopoisk", Price: 300, UserID: importUser, StartDate: "07-2025"}},
+				{Index: 1, Op: api.BatchOpDelete, ID: 42},
+			}
+			resp, err := svc.Batch(context.Background(), items, tt.atomic, dates.Default)
+			if err != nil {
+				t.Fatalf("Batch() error = %v", err)
+			}
+			for i, want := range tt.statuses {
+				if got := resp.Results[i].Status; got != want {
+					t.Errorf("Results[%d].Status = %d, want %d", i, got, want)
+				}
+			}
+
+			services := stores.Services.All()
+			if len(services) != len(tt.services) {
+				t.Fatalf("catalog = %+v, want %v", services, tt.services)
+			}
+			for i, name := range tt.services {
+				if services[i].Name != name {
+					t.Errorf("services[%d].Name = %q, want %q", i, services[i].Name, name)
+				}
+			}
+			if users := stores.Users.All(); len(users) != tt.users {
+				t.Errorf("registered %d users, want %d", len(users), tt.users)
+			}
+		})
+	}
+}
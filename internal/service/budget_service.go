@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/dates"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/event"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/mapper"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+// BudgetService управляет месячными бюджетами и уведомляет о достижении порогов.
+// Расходы считаются так же, как в /subscriptions/total: сумма начислений за месяц.
+type BudgetService struct {
+	repo     BudgetStore
+	subsRepo SubscriptionStore
+	users    *UserService
+	broker   *event.Broker
+}
+
+func NewBudgetService(repo BudgetStore, subsRepo SubscriptionStore, users *UserService, broker *event.Broker) *BudgetService {
+	return &BudgetService{
+		repo:     repo,
+		subsRepo: subsRepo,
+		users:    users,
+		broker:   broker,
+	}
+}
+
+func (s *BudgetService) Create(ctx context.Context, req api.BudgetRequest) (model.Budget, error) {
+	log := logger.GetLogger()
+
+	budget := mapper.ToModelBudget(0, req)
+	if err := s.repo.Create(ctx, &budget); err != nil {
+		if errors.Is(err, repository.ErrBudgetReference) {
+			return model.Budget{}, fmt.Errorf("%w: unknown user_id or service_id", ErrInvalidInput)
+		}
+		log.WithError(err).Error("failed to create budget in repository")
+		return model.Budget{}, fmt.Errorf("could not create budget: %w", err)
+	}
+
+	log.WithFields(logrus.Fields{
+		"id":      budget.ID,
+		"user_id": budget.UserID,
+		"limit":   budget.MonthlyLimit,
+	}).Info("budget created successfully")
+
+	s.checkBudget(ctx, budget, s.currentMonth(ctx, budget.UserID))
+	return budget, nil
+}
+
+func (s *BudgetService) GetByID(ctx context.Context, id int64) (*model.Budget, error) {
+	log := logger.GetLogger()
+	budget, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		log.WithError(err).Errorf("failed to get budget by ID: %d", id)
+		return nil, fmt.Errorf("get budget failed: %w", err)
+	}
+	if budget == nil {
+		log.Warnf("budget not found: %d", id)
+		return nil, nil
+	}
+	return budget, nil
+}
+
+func (s *BudgetService) List(ctx context.Context, filter dto.ListBudgetsFilter) ([]model.Budget, error) {
+	var userID *uuid.UUID
+	if filter.UserID != "" {
+		id, err := uuid.Parse(filter.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid user_id", ErrInvalidInput)
+		}
+		userID = &id
+	}
+
+	budgets, err := s.repo.List(ctx, userID, filter.Limit, filter.Offset)
+	if err != nil {
+		logger.GetLogger().WithError(err).Error("failed to get budgets")
+		return nil, fmt.Errorf("budgets failed: %w", err)
+	}
+
+	res := make([]model.Budget, 0, len(budgets))
+	for _, budget := range budgets {
+		res = append(res, *budget)
+	}
+	return res, nil
+}
+
+func (s *BudgetService) Update(ctx context.Context, id int64, req api.BudgetRequest) (model.Budget, error) {
+	log := logger.GetLogger()
+
+	budget := mapper.ToModelBudget(id, req)
+	if err := s.repo.Update(ctx, &budget); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrBudgetNotFound):
+			log.Warnf("budget to update not found: %d", id)
+			return model.Budget{}, ErrBudgetNotFound
+		case errors.Is(err, repository.ErrBudgetReference):
+			return model.Budget{}, fmt.Errorf("%w: unknown user_id or service_id", ErrInvalidInput)
+		}
+		log.WithError(err).Errorf("failed to update budget: %d", id)
+		return model.Budget{}, fmt.Errorf("update budget failed: %w", err)
+	}
+
+	log.WithField("id", id).Info("budget updated")
+
+	s.checkBudget(ctx, budget, s.currentMonth(ctx, budget.UserID))
+	return budget, nil
+}
+
+func (s *BudgetService) Delete(ctx context.Context, id int64) error {
+	log := logger.GetLogger()
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrBudgetNotFound) {
+			log.WithField("id", id).Info("budget to delete not found")
+			return ErrBudgetNotFound
+		}
+		log.WithError(err).Errorf("failed to delete budget: %d", id)
+		return fmt.Errorf("delete budget failed: %w", err)
+	}
+
+	log.WithField("id", id).Info("budget deleted")
+	return nil
+}
+
+// Status возвращает использование бюджета за месяц даты month, по умолчанию текущий.
+// Месяц определяется в часовом поясе владельца бюджета.
+func (s *BudgetService) Status(ctx context.Context, id int64, month string) (api.BudgetStatusResponse, error) {
+	log := logger.GetLogger()
+
+	budget, err := s.GetByID(ctx, id)
+	if err != nil {
+		return api.BudgetStatusResponse{}, err
+	}
+	if budget == nil {
+		return api.BudgetStatusResponse{}, ErrBudgetNotFound
+	}
+
+	loc := s.users.userLocation(ctx, budget.UserID)
+	start := dates.CurrentMonth(loc)
+	if month != "" {
+		if start, err = dates.ParseMonthIn(month, loc); err != nil {
+			return api.BudgetStatusResponse{}, fmt.Errorf("%w: invalid month: %v", ErrInvalidInput, err)
+		}
+	}
+
+	spent, err := s.spent(ctx, *budget, start)
+	if err != nil {
+		return api.BudgetStatusResponse{}, err
+	}
+	alerts, err := s.repo.Alerts(ctx, id, start)
+	if err != nil {
+		log.WithError(err).Errorf("failed to get alerts of budget: %d", id)
+		return api.BudgetStatusResponse{}, fmt.Errorf("budget status failed: %w", err)
+	}
+
+	res := api.BudgetStatusResponse{
+		BudgetID:     id,
+		Month:        dates.FormatMonth(start),
+		MonthlyLimit: budget.MonthlyLimit,
+		Spent:        spent,
+		Remaining:    max(budget.MonthlyLimit-spent, 0),
+		Utilization:  math.Round(float64(spent)*1000/float64(budget.MonthlyLimit)) / 10,
+		Status:       api.BudgetStatusOK,
+		Alerts:       []api.BudgetAlertResponse{},
+	}
+	switch {
+	case reachedThreshold(spent, budget.MonthlyLimit, model.BudgetExceededThreshold):
+		res.Status = api.BudgetStatusExceeded
+	case reachedThreshold(spent, budget.MonthlyLimit, model.BudgetWarningThreshold):
+		res.Status = api.BudgetStatusWarning
+	}
+	for _, alert := range alerts {
+		res.Alerts = append(res.Alerts, mapper.ToBudgetAlertResponse(alert))
+	}
+	return res, nil
+}
+
+// check пересчитывает бюджеты пользователей после изменения их подписок. Ошибки
+// только логируются: изменения подписок к этому моменту уже сохранены.
+func (s *BudgetService) check(ctx context.Context, userIDs []uuid.UUID) {
+	location := s.users.locations(ctx, userIDs)
+	for _, userID := range userIDs {
+		month := dates.CurrentMonth(location(userID))
+		budgets, err := s.repo.List(ctx, &userID, 0, 0)
+		if err != nil {
+			logger.GetLogger().WithError(err).WithField("user_id", userID).Error("failed to get budgets for check")
+			continue
+		}
+		for _, budget := range budgets {
+			s.checkBudget(ctx, *budget, month)
+		}
+	}
+}
+
+// checkBudget отправляет событие BudgetThresholdReached для каждого достигнутого порога,
+// о котором в этом месяце ещё не уведомляли.
+func (s *BudgetService) checkBudget(ctx context.Context, budget model.Budget, month time.Time) {
+	log := logger.GetLogger().WithField("budget_id", budget.ID)
+
+	spent, err := s.spent(ctx, budget, month)
+	if err != nil {
+		return
+	}
+
+	for _, threshold := range model.BudgetThresholds {
+		if !reachedThreshold(spent, budget.MonthlyLimit, threshold) {
+			continue
+		}
+		alert := model.BudgetAlert{
+			BudgetID:  budget.ID,
+			UserID:    budget.UserID,
+			Month:     month,
+			Threshold: threshold,
+			Spent:     spent,
+			Limit:     budget.MonthlyLimit,
+		}
+		recorded, err := s.repo.RecordAlert(ctx, &alert)
+		if err != nil {
+			log.WithError(err).Error("failed to record budget alert")
+			return
+		}
+		if !recorded {
+			continue
+		}
+
+		log.WithFields(logrus.Fields{
+			"user_id":   budget.UserID,
+			"threshold": threshold,
+			"spent":     spent,
+			"limit":     budget.MonthlyLimit,
+		}).Warn("budget threshold reached")
+		s.broker.Publish(event.Event{
+			Type:   event.BudgetThresholdReached,
+			Budget: &alert,
+		})
+	}
+}
+
+func (s *BudgetService) spent(ctx context.Context, budget model.Budget, month time.Time) (int, error) {
+	filter := repository.ChargeFilter{
+		UserID:    &budget.UserID,
+		ServiceID: budget.ServiceID,
+		Category:  budget.Category,
+	}
+	spent, err := s.subsRepo.ChargesSum(ctx, filter, month, month)
+	if err != nil {
+		logger.GetLogger().WithError(err).Errorf("failed to calculate spending of budget: %d", budget.ID)
+		return 0, fmt.Errorf("budget spending failed: %w", err)
+	}
+	return spent, nil
+}
+
+func reachedThreshold(spent, limit, threshold int) bool {
+	return spent*100 >= limit*threshold
+}
+
+// currentMonth возвращает текущий месяц в часовом поясе пользователя userID.
+func (s *BudgetService) currentMonth(ctx context.Context, userID uuid.UUID) time.Time {
+	return dates.CurrentMonth(s.users.userLocation(ctx, userID))
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/mapper"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+// CatalogService управляет каталогом сервисов и сопоставляет с ним названия из подписок.
+type CatalogService struct {
+	repo CatalogStore
+	// autoCreate добавляет в каталог сервисы с неизвестными названиями вместо ошибки.
+	autoCreate bool
+}
+
+func NewCatalogService(repo CatalogStore, autoCreate bool) *CatalogService {
+	return &CatalogService{
+		repo:       repo,
+		autoCreate: autoCreate,
+	}
+}
+
+func (s *CatalogService) Create(ctx context.Context, req api.ServiceRequest) (model.Service, error) {
+	log := logger.GetLogger()
+
+	svc := mapper.ToModelService(0, req)
+	if err := s.checkNames(ctx, svc); err != nil {
+		return model.Service{}, err
+	}
+
+	if err := s.repo.Create(ctx, &svc); err != nil {
+		if errors.Is(err, repository.ErrServiceExists) {
+			log.WithField("name", svc.Name).Warn("service name or alias already exists")
+			return model.Service{}, ErrServiceExists
+		}
+		log.WithError(err).Error("failed to create service in repository")
+		return model.Service{}, fmt.Errorf("could not create service: %w", err)
+	}
+
+	log.WithFields(logrus.Fields{
+		"id":   svc.ID,
+		"name": svc.Name,
+	}).Info("service created successfully")
+	return svc, nil
+}
+
+func (s *CatalogService) GetByID(ctx context.Context, id int64) (*model.Service, error) {
+	log := logger.GetLogger()
+	svc, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		log.WithError(err).Errorf("failed to get service by ID: %d", id)
+		return nil, fmt.Errorf("get service failed: %w", err)
+	}
+	if svc == nil {
+		log.Warnf("service not found: %d", id)
+		return nil, nil
+	}
+	return svc, nil
+}
+
+// Find ищет сервис по названию или псевдониму. Для неизвестного названия возвращается nil.
+func (s *CatalogService) Find(ctx context.Context, name string) (*model.Service, error) {
+	svc, err := s.repo.FindByName(ctx, mapper.NormalizeServiceName(name))
+	if err != nil {
+		logger.GetLogger().WithError(err).Errorf("failed to find service: %s", name)
+		return nil, fmt.Errorf("find service failed: %w", err)
+	}
+	return svc, nil
+}
+
+func (s *CatalogService) List(ctx context.Context, filter dto.ListServicesFilter) ([]model.Service, error) {
+	var query, category *string
+	if filter.Query != "" {
+		query = &filter.Query
+	}
+	if filter.Category != "" {
+		category = &filter.Category
+	}
+
+	services, err := s.repo.List(ctx, query, category, filter.Limit, filter.Offset)
+	if err != nil {
+		logger.GetLogger().WithError(err).Error("failed to get services")
+		return nil, fmt.Errorf("services failed: %w", err)
+	}
+
+	res := make([]model.Service, 0, len(services))
+	for _, svc := range services {
+		res = append(res, *svc)
+	}
+	return res, nil
+}
+
+// Update полностью заменяет запись каталога, включая список псевдонимов.
+// Подписки ссылаются на сервис по ID, поэтому переименование сразу видно во всех подписках.
+func (s *CatalogService) Update(ctx context.Context, id int64, req api.ServiceRequest) (model.Service, error) {
+	log := logger.GetLogger()
+
+	svc := mapper.ToModelService(id, req)
+	if err := s.checkNames(ctx, svc); err != nil {
+		return model.Service{}, err
+	}
+
+	if err := s.repo.Update(ctx, &svc); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrServiceNotFound):
+			log.Warnf("service to update not found: %d", id)
+			return model.Service{}, ErrServiceNotFound
+		case errors.Is(err, repository.ErrServiceExists):
+			log.WithField("name", svc.Name).Warn("service name or alias already exists")
+			return model.Service{}, ErrServiceExists
+		}
+		log.WithError(err).Errorf("failed to update service: %d", id)
+		return model.Service{}, fmt.Errorf("update service failed: %w", err)
+	}
+
+	log.WithFields(logrus.Fields{
+		"id":   svc.ID,
+		"name": svc.Name,
+	}).Info("service updated")
+	return svc, nil
+}
+
+func (s *CatalogService) Delete(ctx context.Context, id int64) error {
+	log := logger.GetLogger()
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrServiceNotFound):
+			log.WithField("id", id).Info("service to delete not found")
+			return ErrServiceNotFound
+		case errors.Is(err, repository.ErrServiceInUse):
+			log.WithField("id", id).Warn("service to delete is in use")
+			return ErrServiceInUse
+		}
+		log.WithError(err).Errorf("failed to delete service: %d", id)
+		return fmt.Errorf("delete service failed: %w", err)
+	}
+
+	log.WithField("id", id).Info("service deleted")
+	return nil
+}
+
+// filter возвращает ID сервиса для фильтра по service_id или service_name.
+// Название сопоставляется с каталогом так же, как при создании подписки; если сервис
+// не найден, found == false и подписок по фильтру нет.
+func (s *CatalogService) filter(ctx context.Context, id int64, name string) (serviceID *int64, found bool, err error) {
+	if id != 0 {
+		return &id, true, nil
+	}
+	if name == "" {
+		return nil, true, nil
+	}
+
+	svc, err := s.Find(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+	if svc == nil {
+		logger.GetLogger().WithField("service_name", name).Info("service for filter not found")
+		return nil, false, nil
+	}
+	return &svc.ID, true, nil
+}
+
+// checkNames проверяет, что название и псевдонимы не заняты другими сервисами:
+// уникальные индексы не сравнивают псевдоним одного сервиса с названием другого.
+func (s *CatalogService) checkNames(ctx context.Context, svc model.Service) error {
+	for _, name := range append([]string{svc.Name}, svc.Aliases...) {
+		other, err := s.repo.FindByName(ctx, name)
+		if err != nil {
+			logger.GetLogger().WithError(err).Error("failed to check service names")
+			return fmt.Errorf("check service names failed: %w", err)
+		}
+		if other != nil && other.ID != svc.ID {
+			return fmt.Errorf("%w: %q is used by service %d (%s)", ErrServiceExists, name, other.ID, other.Name)
+		}
+	}
+	return nil
+}
+
+// serviceResolver сопоставляет подписки с каталогом и кэширует найденные сервисы,
+// чтобы импорт и пакетные операции не искали один и тот же сервис для каждой строки.
+type serviceResolver struct {
+	catalog *CatalogService
+	// dryRun только проверяет данные: неизвестный сервис не создаётся, а подписка
+	// остаётся с ServiceID == 0, чтобы создать его при сохранении.
+	dryRun bool
+	byID   map[int64]*model.Service
+	byName map[string]*model.Service
+}
+
+func (s *CatalogService) resolver(dryRun bool) *serviceResolver {
+	return &serviceResolver{
+		catalog: s,
+		dryRun:  dryRun,
+		byID:    make(map[int64]*model.Service),
+		byName:  make(map[string]*model.Service),
+	}
+}
+
+// resolve заполняет ServiceID и каноническое название подписки. Если задан ServiceID,
+// название из запроса не используется. Подписка без цены получает цену сервиса
+// по умолчанию. Ошибки данных оборачивают ErrInvalidInput.
+func (r *serviceResolver) resolve(ctx context.Context, sub *model.Subscription) error {
+	svc, err := r.lookup(ctx, sub.ServiceID, sub.ServiceName)
+	if err != nil {
+		return err
+	}
+
+	sub.ServiceID = svc.ID
+	sub.ServiceName = svc.Name
+	if sub.Price == 0 {
+		if svc.DefaultPrice == nil {
+			return fmt.Errorf("%w: price is required, service %q has no default price", ErrInvalidInput, svc.Name)
+		}
+		sub.Price = *svc.DefaultPrice
+	}
+	return nil
+}
+
+func (r *serviceResolver) lookup(ctx context.Context, id int64, name string) (*model.Service, error) {
+	if id != 0 {
+		if svc, ok := r.byID[id]; ok {
+			return svc, nil
+		}
+		svc, err := r.catalog.repo.GetByID(ctx, id)
+		if err != nil {
+			logger.GetLogger().WithError(err).Errorf("failed to get service by ID: %d", id)
+			return nil, fmt.Errorf("resolve service failed: %w", err)
+		}
+		if svc == nil {
+			return nil, fmt.Errorf("%w: unknown service_id %d", ErrInvalidInput, id)
+		}
+		r.byID[id] = svc
+		return svc, nil
+	}
+
+	name = mapper.NormalizeServiceName(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: service_name or service_id is required", ErrInvalidInput)
+	}
+	key := strings.ToLower(name)
+	if svc, ok := r.byName[key]; ok {
+		return svc, nil
+	}
+
+	svc, err := r.catalog.Find(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if svc == nil {
+		if !r.catalog.autoCreate {
+			return nil, fmt.Errorf("%w: unknown service %q", ErrInvalidInput, name)
+		}
+		if r.dryRun {
+			svc = &model.Service{Name: name}
+			r.byName[key] = svc
+			return svc, nil
+		}
+		if svc, err = r.catalog.createFromName(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+	r.byName[key] = svc
+	r.byID[svc.ID] = svc
+	return svc, nil
+}
+
+// createFromName добавляет в каталог сервис, впервые встретившийся в подписке.
+// Если его одновременно создал другой запрос, возвращается существующая запись.
+func (s *CatalogService) createFromName(ctx context.Context, name string) (*model.Service, error) {
+	log := logger.GetLogger()
+
+	svc := mapper.ToModelService(0, api.ServiceRequest{Name: name})
+	err := s.repo.Create(ctx, &svc)
+	if errors.Is(err, repository.ErrServiceExists) {
+		return s.Find(ctx, name)
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to add service to catalog")
+		return nil, fmt.Errorf("could not create service: %w", err)
+	}
+
+	log.WithFields(logrus.Fields{
+		"id":   svc.ID,
+		"name": svc.Name,
+	}).Info("service added to catalog from subscription")
+	return &svc, nil
+}
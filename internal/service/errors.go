@@ -0,0 +1,45 @@
+package service
+
+import "errors"
+
+var (
+	ErrNotFound           = errors.New("subscription not found")
+	ErrPreconditionFailed = errors.New("subscription was modified, version mismatch")
+	ErrInvalidInput       = errors.New("invalid input")
+	ErrInvalidPatch       = errors.New("invalid patch document")
+	ErrPatchConflict      = errors.New("patch cannot be applied")
+	ErrPatchResultInvalid = errors.New("patched subscription is invalid")
+	ErrOverlap            = errors.New("subscription overlaps another subscription of the user to the service")
+	ErrAlreadyPaused      = errors.New("subscription is already paused in this period")
+	ErrNotPaused          = errors.New("subscription is not paused")
+	ErrAlreadyEnded       = errors.New("subscription has already ended")
+	ErrNotStarted         = errors.New("subscription has not started yet")
+	ErrMemberNotFound     = errors.New("user is not a member of the subscription")
+
+	ErrServiceNotFound = errors.New("service not found")
+	ErrServiceExists   = errors.New("service name or alias already exists")
+	ErrServiceInUse    = errors.New("service is referenced by subscriptions")
+
+	ErrUserNotFound       = errors.New("user not found")
+	ErrUserExists         = errors.New("user id or email already exists")
+	ErrUserInUse          = errors.New("user has subscriptions")
+	ErrUserIsMember       = errors.New("user is a member of shared subscriptions, remove them from the subscriptions first")
+	ErrUserInOrganisation = errors.New("user is a member of organisations, remove them from the organisations first")
+
+	ErrBudgetNotFound = errors.New("budget not found")
+
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag name already exists, merge the tags instead")
+
+	ErrOrganisationNotFound = errors.New("organisation not found")
+	ErrOrganisationExists   = errors.New("organisation name already exists")
+	ErrOrganisationInUse    = errors.New("organisation has cost centres with allocated subscriptions")
+	ErrUnitNotFound         = errors.New("unit not found")
+	ErrUnitExists           = errors.New("unit name already exists in the organisation")
+	ErrUnitInUse            = errors.New("unit has child units or cost centres")
+	ErrCostCentreNotFound   = errors.New("cost centre not found")
+	ErrCostCentreExists     = errors.New("cost centre code already exists in the organisation")
+	ErrCostCentreInUse      = errors.New("cost centre has allocated subscriptions")
+	ErrOrgMemberNotFound    = errors.New("user is not a member of the organisation")
+	ErrLastOwner            = errors.New("organisation must keep at least one owner")
+)
@@ -0,0 +1,99 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/testutil"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+var importUser = uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+
+// importRows возвращает строки импорта: вторая не прошла разбор, четвёртая содержит
+// дату, которую не принимает сервис.
+func importRows() []dto.ImportRow {
+	return []dto.ImportRow{
+		{Row: 1, Request: api.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 400, UserID: importUser, StartDate: "07-2025"}},
+		{Row: 2, Errors: []string{"price: invalid integer \"abc\""}},
+		{Row: 3, Request: api.CreateSubscriptionRequest{ServiceName: "Spotify", Price: 200, UserID: importUser, StartDate: "08-2025"}},
+		{Row: 4, Request: api.CreateSubscriptionRequest{ServiceName: "Yandex Plus", Price: 300, UserID: importUser, StartDate: "13-2025"}},
+	}
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     dto.ImportOptions
+		imported int
+		saved    []string
+	}{
+		{name: "dry run", opts: dto.ImportOptions{DryRun: true}},
+		{name: "partial", imported: 2, saved: []string{"Netflix", "Spotify"}},
+		{name: "all or nothing dry run", opts: dto.ImportOptions{DryRun: true, Mode: api.ImportModeAllOrNothing}},
+		{name: "all or nothing", opts: dto.ImportOptions{Mode: api.ImportModeAllOrNothing}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := testutil.NewStores()
+			store, svc := stores.Subscriptions, stores.SubscriptionService()
+
+			report, err := svc.Import(context.Background(), importRows(), tt.opts)
+			if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+
+			if report.DryRun != tt.opts.DryRun || report.Total != 4 || report.Valid != 2 || report.Invalid != 2 || report.Imported != tt.imported {
+				t.Errorf("Import() report = %+v, want total 4, valid 2, invalid 2, imported %d", report, tt.imported)
+			}
+			wantMode := tt.opts.Mode
+			if wantMode == "" {
+				wantMode = api.ImportModePartial
+			}
+			if report.Mode != wantMode {
+				t.Errorf("report.Mode = %q, want %q", report.Mode, wantMode)
+			}
+			if len(report.Errors) != 2 || report.Errors[0].Row != 2 || report.Errors[1].Row != 4 {
+				t.Errorf("report.Errors = %+v, want rows 2 and 4", report.Errors)
+			}
+
+			saved := store.All()
+			if len(saved) != len(tt.saved) {
+				t.Fatalf("saved %d subscriptions, want %d", len(saved), len(tt.saved))
+			}
+			for i, sub := range saved {
+				if sub.ServiceName != tt.saved[i] {
+					t.Errorf("saved[%d].ServiceName = %q, want %q", i, sub.ServiceName, tt.saved[i])
+				}
+			}
+		})
+	}
+}
+
+// Сервисы каталога и пользователи, созданные для импорта, откатываются вместе с подписками.
+func TestImportRepositoryFailure(t *testing.T) {
+	stores := testutil.NewStores()
+	store := stores.Subscriptions
+	store.FailCreateMany = true
+	svc := stores.SubscriptionService()
+
+	report, err := svc.Import(context.Background(), importRows(), dto.ImportOptions{})
+	if !errors.Is(err, testutil.ErrInjected) {
+		t.Fatalf("Import() error = %v, want %v", err, testutil.ErrInjected)
+	}
+	if report.Imported != 0 {
+		t.Errorf("report.Imported = %d, want 0", report.Imported)
+	}
+	if saved := store.All(); len(saved) != 0 {
+		t.Errorf("saved %d subscriptions, want 0", len(saved))
+	}
+	if services := stores.Services.All(); len(services) != 0 {
+		t.Errorf("catalog has %d services after failed import, want 0", len(services))
+	}
+	if users := stores.Users.All(); len(users) != 0 {
+		t.Errorf("registered %d users after failed import, want 0", len(users))
+	}
+}
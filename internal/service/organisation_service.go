@@ -0,0 +1,490 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+// OrganisationService управляет организациями, их подразделениями, центрами затрат и
+// участниками. Подписки относятся к центрам затрат через SubscriptionService.Allocate, а
+// расходы сворачиваются по иерархии: центр затрат → команда → отдел → организация.
+type OrganisationService struct {
+	repo  OrganisationStore
+	users *UserService
+}
+
+func NewOrganisationService(repo OrganisationStore, users *UserService) *OrganisationService {
+	return &OrganisationService{
+		repo:  repo,
+		users: users,
+	}
+}
+
+func (s *OrganisationService) Create(ctx context.Context, req api.CreateOrganisationRequest) (model.Organisation, error) {
+	log := logger.GetLogger()
+
+	org := model.Organisation{Name: strings.TrimSpace(req.Name)}
+	if org.Name == "" {
+		return model.Organisation{}, fmt.Errorf("%w: organisation name must not be blank", ErrInvalidInput)
+	}
+	if err := s.users.ensure(ctx, req.OwnerID); err != nil {
+		log.WithError(err).Warn("failed to check organisation owner")
+		return model.Organisation{}, err
+	}
+
+	if err := s.repo.Create(ctx, &org, req.OwnerID); err != nil {
+		return model.Organisation{}, organisationError(err, "create organisation failed")
+	}
+
+	log.WithFields(logrus.Fields{
+		"id":       org.ID,
+		"name":     org.Name,
+		"owner_id": req.OwnerID,
+	}).Info("organisation created")
+	return org, nil
+}
+
+func (s *OrganisationService) GetByID(ctx context.Context, id int64) (*model.Organisation, error) {
+	org, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		logger.GetLogger().WithError(err).Errorf("failed to get organisation by ID: %d", id)
+		return nil, fmt.Errorf("get organisation failed: %w", err)
+	}
+	return org, nil
+}
+
+func (s *OrganisationService) List(ctx context.Context, filter dto.ListOrganisationsFilter) ([]model.Organisation, error) {
+	var userID *uuid.UUID
+	if filter.UserID != "" {
+		id, err := uuid.Parse(filter.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid user_id", ErrInvalidInput)
+		}
+		userID = &id
+	}
+
+	orgs, err := s.repo.List(ctx, userID, filter.Limit, filter.Offset)
+	if err != nil {
+		logger.GetLogger().WithError(err).Error("failed to get organisations")
+		return nil, fmt.Errorf("organisations failed: %w", err)
+	}
+
+	res := make([]model.Organisation, 0, len(orgs))
+	for _, org := range orgs {
+		res = append(res, *org)
+	}
+	return res, nil
+}
+
+func (s *OrganisationService) Update(ctx context.Context, id int64, req api.OrganisationRequest) (model.Organisation, error) {
+	org := model.Organisation{ID: id, Name: strings.TrimSpace(req.Name)}
+	if org.Name == "" {
+		return model.Organisation{}, fmt.Errorf("%w: organisation name must not be blank", ErrInvalidInput)
+	}
+	if err := s.repo.Update(ctx, &org); err != nil {
+		return model.Organisation{}, organisationError(err, "update organisation failed")
+	}
+
+	logger.GetLogger().WithFields(logrus.Fields{"id": id, "name": org.Name}).Info("organisation updated")
+	return org, nil
+}
+
+// Delete удаляет организацию. Организацию, к центрам затрат которой относили подписки,
+// удалить нельзя: сначала подписки нужно распределить в другие центры затрат.
+func (s *OrganisationService) Delete(ctx context.Context, id int64) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return organisationError(err, "delete organisation failed")
+	}
+	logger.GetLogger().WithField("id", id).Info("organisation deleted")
+	return nil
+}
+
+func (s *OrganisationService) Units(ctx context.Context, orgID int64) ([]model.OrgUnit, error) {
+	if _, err := s.get(ctx, orgID); err != nil {
+		return nil, err
+	}
+	units, err := s.repo.Units(ctx, orgID)
+	if err != nil {
+		logger.GetLogger().WithError(err).Errorf("failed to get units of organisation: %d", orgID)
+		return nil, fmt.Errorf("units failed: %w", err)
+	}
+
+	res := make([]model.OrgUnit, 0, len(units))
+	for _, unit := range units {
+		res = append(res, *unit)
+	}
+	return res, nil
+}
+
+func (s *OrganisationService) CreateUnit(ctx context.Context, unit model.OrgUnit) (model.OrgUnit, error) {
+	if err := s.validateUnit(ctx, &unit); err != nil {
+		return model.OrgUnit{}, err
+	}
+	if err := s.repo.CreateUnit(ctx, &unit); err != nil {
+		return model.OrgUnit{}, organisationError(err, "create unit failed")
+	}
+
+	logger.GetLogger().WithFields(logrus.Fields{
+		"id":              unit.ID,
+		"organisation_id": unit.OrganisationID,
+		"kind":            unit.Kind,
+	}).Info("unit created")
+	return unit, nil
+}
+
+func (s *OrganisationService) UpdateUnit(ctx context.Context, unit model.OrgUnit) (model.OrgUnit, error) {
+	if err := s.validateUnit(ctx, &unit); err != nil {
+		return model.OrgUnit{}, err
+	}
+	if err := s.repo.UpdateUnit(ctx, &unit); err != nil {
+		return model.OrgUnit{}, organisationError(err, "update unit failed")
+	}
+
+	logger.GetLogger().WithFields(logrus.Fields{
+		"id":              unit.ID,
+		"organisation_id": unit.OrganisationID,
+		"kind":            unit.Kind,
+	}).Info("unit updated")
+	return unit, nil
+}
+
+// DeleteUnit удаляет подразделение без команд и центров затрат.
+func (s *OrganisationService) DeleteUnit(ctx context.Context, orgID, id int64) error {
+	if err := s.repo.DeleteUnit(ctx, orgID, id); err != nil {
+		return organisationError(err, "delete unit failed")
+	}
+	logger.GetLogger().WithFields(logrus.Fields{"id": id, "organisation_id": orgID}).Info("unit deleted")
+	return nil
+}
+
+// validateUnit проверяет место подразделения в иерархии: отдел входит только в организацию,
+// команда — в отдел той же организации или в организацию. Отдел с командами нельзя
+// превратить в команду.
+func (s *OrganisationService) validateUnit(ctx context.Context, unit *model.OrgUnit) error {
+	unit.Name = strings.TrimSpace(unit.Name)
+	if unit.Name == "" {
+		return fmt.Errorf("%w: unit name must not be blank", ErrInvalidInput)
+	}
+	units, err := s.Units(ctx, unit.OrganisationID)
+	if err != nil {
+		return err
+	}
+
+	switch unit.Kind {
+	case model.UnitDepartment:
+		if unit.ParentID != nil {
+			return fmt.Errorf("%w: department cannot have parent_id", ErrInvalidInput)
+		}
+	case model.UnitTeam:
+		for _, u := range units {
+			if unit.ID != 0 && u.ParentID != nil && *u.ParentID == unit.ID {
+				return fmt.Errorf("%w: unit %d has teams and must stay a department", ErrInvalidInput, unit.ID)
+			}
+		}
+		if unit.ParentID == nil {
+			break
+		}
+		parent := findUnit(units, *unit.ParentID)
+		if parent == nil || parent.ID == unit.ID || parent.Kind != model.UnitDepartment {
+			return fmt.Errorf("%w: parent_id must be a department of the organisation", ErrInvalidInput)
+		}
+	default:
+		return fmt.Errorf("%w: unknown unit kind %q", ErrInvalidInput, unit.Kind)
+	}
+	return nil
+}
+
+func (s *OrganisationService) CostCentres(ctx context.Context, orgID int64) ([]model.CostCentre, error) {
+	if _, err := s.get(ctx, orgID); err != nil {
+		return nil, err
+	}
+	centres, err := s.repo.CostCentres(ctx, orgID)
+	if err != nil {
+		logger.GetLogger().WithError(err).Errorf("failed to get cost centres of organisation: %d", orgID)
+		return nil, fmt.Errorf("cost centres failed: %w", err)
+	}
+
+	res := make([]model.CostCentre, 0, len(centres))
+	for _, cc := range centres {
+		res = append(res, *cc)
+	}
+	return res, nil
+}
+
+func (s *OrganisationService) CreateCostCentre(ctx context.Context, cc model.CostCentre) (model.CostCentre, error) {
+	if err := s.validateCostCentre(ctx, &cc); err != nil {
+		return model.CostCentre{}, err
+	}
+	if err := s.repo.CreateCostCentre(ctx, &cc); err != nil {
+		return model.CostCentre{}, organisationError(err, "create cost centre failed")
+	}
+
+	logger.GetLogger().WithFields(logrus.Fields{
+		"id":              cc.ID,
+		"organisation_id": cc.OrganisationID,
+		"code":            cc.Code,
+	}).Info("cost centre created")
+	return cc, nil
+}
+
+func (s *OrganisationService) UpdateCostCentre(ctx context.Context, cc model.CostCentre) (model.CostCentre, error) {
+	if err := s.validateCostCentre(ctx, &cc); err != nil {
+		return model.CostCentre{}, err
+	}
+	if err := s.repo.UpdateCostCentre(ctx, &cc); err != nil {
+		return model.CostCentre{}, organisationError(err, "update cost centre failed")
+	}
+
+	logger.GetLogger().WithFields(logrus.Fields{
+		"id":              cc.ID,
+		"organisation_id": cc.OrganisationID,
+		"code":            cc.Code,
+	}).Info("cost centre updated")
+	return cc, nil
+}
+
+// DeleteCostCentre удаляет центр затрат, к которому никогда не относили подписки.
+func (s *OrganisationService) DeleteCostCentre(ctx context.Context, orgID, id int64) error {
+	if err := s.repo.DeleteCostCentre(ctx, orgID, id); err != nil {
+		return organisationError(err, "delete cost centre failed")
+	}
+	logger.GetLogger().WithFields(logrus.Fields{"id": id, "organisation_id": orgID}).Info("cost centre deleted")
+	return nil
+}
+
+// validateCostCentre проверяет, что подразделение центра затрат входит в его организацию.
+func (s *OrganisationService) validateCostCentre(ctx context.Context, cc *model.CostCentre) error {
+	cc.Code, cc.Name = strings.TrimSpace(cc.Code), strings.TrimSpace(cc.Name)
+	if cc.Code == "" || cc.Name == "" {
+		return fmt.Errorf("%w: cost centre code and name must not be blank", ErrInvalidInput)
+	}
+	return s.checkUnit(ctx, cc.OrganisationID, cc.UnitID)
+}
+
+func (s *OrganisationService) Members(ctx context.Context, orgID int64) ([]model.OrgMember, error) {
+	if _, err := s.get(ctx, orgID); err != nil {
+		return nil, err
+	}
+	members, err := s.repo.Members(ctx, orgID)
+	if err != nil {
+		logger.GetLogger().WithError(err).Errorf("failed to get members of organisation: %d", orgID)
+		return nil, fmt.Errorf("organisation members failed: %w", err)
+	}
+
+	res := make([]model.OrgMember, 0, len(members))
+	for _, m := range members {
+		res = append(res, *m)
+	}
+	return res, nil
+}
+
+// SetMember добавляет пользователя в организацию или меняет его роль и подразделение.
+func (s *OrganisationService) SetMember(ctx context.Context, orgID int64, userID uuid.UUID, req api.OrgMemberRequest) (model.OrgMember, error) {
+	log := logger.GetLogger()
+
+	if err := s.checkUnit(ctx, orgID, req.UnitID); err != nil {
+		return model.OrgMember{}, err
+	}
+	if err := s.users.ensure(ctx, userID); err != nil {
+		log.WithError(err).Warn("failed to check organisation member")
+		return model.OrgMember{}, err
+	}
+
+	member := model.OrgMember{OrganisationID: orgID, UserID: userID, Role: req.Role, UnitID: req.UnitID}
+	if err := s.repo.SetMember(ctx, &member); err != nil {
+		return model.OrgMember{}, organisationError(err, "set organisation member failed")
+	}
+
+	log.WithFields(logrus.Fields{
+		"organisation_id": orgID,
+		"user_id":         userID,
+		"role":            member.Role,
+	}).Info("organisation member saved")
+	return member, nil
+}
+
+// RemoveMember исключает пользователя из организации. Последнего владельца исключить нельзя.
+func (s *OrganisationService) RemoveMember(ctx context.Context, orgID int64, userID uuid.UUID) error {
+	if err := s.repo.RemoveMember(ctx, orgID, userID); err != nil {
+		return organisationError(err, "remove organisation member failed")
+	}
+	logger.GetLogger().WithFields(logrus.Fields{
+		"organisation_id": orgID,
+		"user_id":         userID,
+	}).Info("organisation member removed")
+	return nil
+}
+
+// Spending возвращает расходы организации за месяцы периода по центрам затрат, свёрнутые
+// вверх по иерархии. Центры затрат без начислений тоже входят в отчёт с нулевой суммой.
+func (s *OrganisationService) Spending(ctx context.Context, orgID int64, req dto.OrgSpendingFilter) (api.OrgSpendingResponse, error) {
+	log := logger.GetLogger()
+
+	from, to, err := reportPeriod(req.From, req.To, s.users.resolve(ctx, nil))
+	if err != nil {
+		return api.OrgSpendingResponse{}, err
+	}
+	units, err := s.Units(ctx, orgID)
+	if err != nil {
+		return api.OrgSpendingResponse{}, err
+	}
+	centres, err := s.CostCentres(ctx, orgID)
+	if err != nil {
+		return api.OrgSpendingResponse{}, err
+	}
+	spending, err := s.repo.Spending(ctx, orgID, from, to)
+	if err != nil {
+		log.WithError(err).Errorf("failed to get spending of organisation: %d", orgID)
+		return api.OrgSpendingResponse{}, fmt.Errorf("organisation spending failed: %w", err)
+	}
+
+	res := rollUpSpending(units, centres, spending)
+	res.OrganisationID, res.From, res.To = orgID, req.From, req.To
+
+	log.WithFields(logrus.Fields{
+		"organisation_id": orgID,
+		"from":            req.From,
+		"to":              req.To,
+		"total":           res.Total,
+	}).Info("organisation spending report built")
+	return res, nil
+}
+
+// rollUpSpending раскладывает расходы центров затрат по подразделениям: сумма команды —
+// это её центры затрат, отдела — его центры затрат и команды, организации — все центры.
+func rollUpSpending(units []model.OrgUnit, centres []model.CostCentre, spending []model.CostCentreSpending) api.OrgSpendingResponse {
+	spent := make(map[int64]model.CostCentreSpending, len(spending))
+	for _, sp := range spending {
+		spent[sp.CostCentreID] = sp
+	}
+
+	// Ключ 0 — организация целиком: ID подразделений начинаются с 1.
+	byUnit := map[int64][]api.CostCentreSpending{}
+	res := api.OrgSpendingResponse{}
+	for _, cc := range centres {
+		sp := spent[cc.ID]
+		var unitID int64
+		if cc.UnitID != nil {
+			unitID = *cc.UnitID
+		}
+		byUnit[unitID] = append(byUnit[unitID], api.CostCentreSpending{
+			ID:            cc.ID,
+			Code:          cc.Code,
+			Name:          cc.Name,
+			Total:         sp.Total,
+			Subscriptions: sp.Subscriptions,
+		})
+		res.Total += sp.Total
+	}
+
+	node := func(u model.OrgUnit) api.UnitSpending {
+		n := api.UnitSpending{
+			ID:          u.ID,
+			Kind:        u.Kind,
+			Name:        u.Name,
+			Units:       []api.UnitSpending{},
+			CostCentres: append([]api.CostCentreSpending{}, byUnit[u.ID]...),
+		}
+		for _, cc := range n.CostCentres {
+			n.Total += cc.Total
+		}
+		return n
+	}
+
+	teams := map[int64][]api.UnitSpending{}
+	for _, u := range units {
+		if u.Kind != model.UnitTeam {
+			continue
+		}
+		var parentID int64
+		if u.ParentID != nil {
+			parentID = *u.ParentID
+		}
+		teams[parentID] = append(teams[parentID], node(u))
+	}
+
+	res.Units = []api.UnitSpending{}
+	for _, u := range units {
+		if u.Kind != model.UnitDepartment {
+			continue
+		}
+		dept := node(u)
+		for _, team := range teams[u.ID] {
+			dept.Units = append(dept.Units, team)
+			dept.Total += team.Total
+		}
+		res.Units = append(res.Units, dept)
+	}
+	res.Units = append(res.Units, teams[0]...)
+	res.CostCentres = append([]api.CostCentreSpending{}, byUnit[0]...)
+	return res
+}
+
+// checkUnit проверяет, что подразделение unitID, если оно задано, входит в организацию orgID.
+func (s *OrganisationService) checkUnit(ctx context.Context, orgID int64, unitID *int64) error {
+	units, err := s.Units(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if unitID != nil && findUnit(units, *unitID) == nil {
+		return fmt.Errorf("%w: unit %d does not belong to the organisation", ErrInvalidInput, *unitID)
+	}
+	return nil
+}
+
+func (s *OrganisationService) get(ctx context.Context, id int64) (model.Organisation, error) {
+	org, err := s.GetByID(ctx, id)
+	if err != nil {
+		return model.Organisation{}, err
+	}
+	if org == nil {
+		return model.Organisation{}, ErrOrganisationNotFound
+	}
+	return *org, nil
+}
+
+func findUnit(units []model.OrgUnit, id int64) *model.OrgUnit {
+	for i := range units {
+		if units[i].ID == id {
+			return &units[i]
+		}
+	}
+	return nil
+}
+
+// organisationError переводит ошибки репозитория организаций в ошибки сервиса.
+func organisationError(err error, msg string) error {
+	for repoErr, svcErr := range map[error]error{
+		repository.ErrOrganisationNotFound: ErrOrganisationNotFound,
+		repository.ErrOrganisationExists:   ErrOrganisationExists,
+		repository.ErrOrganisationInUse:    ErrOrganisationInUse,
+		repository.ErrUnitNotFound:         ErrUnitNotFound,
+		repository.ErrUnitExists:           ErrUnitExists,
+		repository.ErrUnitInUse:            ErrUnitInUse,
+		repository.ErrCostCentreNotFound:   ErrCostCentreNotFound,
+		repository.ErrCostCentreExists:     ErrCostCentreExists,
+		repository.ErrCostCentreInUse:      ErrCostCentreInUse,
+		repository.ErrOrgMemberNotFound:    ErrOrgMemberNotFound,
+		repository.ErrLastOwner:            ErrLastOwner,
+	} {
+		if errors.Is(err, repoErr) {
+			logger.GetLogger().WithError(err).Warn(msg)
+			return svcErr
+		}
+	}
+	if errors.Is(err, repository.ErrOrgReference) {
+		return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	logger.GetLogger().WithError(err).Error(msg)
+	return fmt.Errorf("%s: %w", msg, err)
+}
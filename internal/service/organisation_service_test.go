@@ -0,0 +1,127 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+func TestRollUpSpending(t *testing.T) {
+	id := func(v int64) *int64 { return &v }
+	// Отдел 1 с командами 2 и 3, команда 4 вне отделов.
+	units := []model.OrgUnit{
+		{ID: 1, Kind: model.UnitDepartment, Name: "Engineering"},
+		{ID: 2, Kind: model.UnitTeam, Name: "Backend", ParentID: id(1)},
+		{ID: 3, Kind: model.UnitTeam, Name: "Frontend", ParentID: id(1)},
+		{ID: 4, Kind: model.UnitTeam, Name: "Design"},
+	}
+	centres := []model.CostCentre{
+		{ID: 10, Code: "ENG", Name: "Engineering tools", UnitID: id(1)},
+		{ID: 11, Code: "BE-1", Name: "Backend infra", UnitID: id(2)},
+		{ID: 12, Code: "BE-2", Name: "Backend licences", UnitID: id(2)},
+		{ID: 13, Code: "FE", Name: "Frontend", UnitID: id(3)},
+		{ID: 14, Code: "DS", Name: "Design", UnitID: id(4)},
+		{ID: 15, Code: "HQ", Name: "Headquarters"},
+	}
+	spending := []model.CostCentreSpending{
+		{CostCentreID: 10, Total: 100, Subscriptions: 1},
+		{CostCentreID: 11, Total: 200, Subscriptions: 2},
+		{CostCentreID: 12, Total: 50, Subscriptions: 1},
+		{CostCentreID: 14, Total: 30, Subscriptions: 1},
+		{CostCentreID: 15, Total: 7, Subscriptions: 1},
+	}
+
+	got := rollUpSpending(units, centres, spending)
+	want := api.OrgSpendingResponse{
+		Total: 387,
+		Units: []api.UnitSpending{
+			{
+				ID: 1, Kind: model.UnitDepartment, Name: "Engineering", Total: 350,
+				CostCentres: []api.CostCentreSpending{{ID: 10, Code: "ENG", Name: "Engineering tools", Total: 100, Subscriptions: 1}},
+				Units: []api.UnitSpending{
+					{
+						ID: 2, Kind: model.UnitTeam, Name: "Backend", Total: 250, Units: []api.UnitSpending{},
+						CostCentres: []api.CostCentreSpending{
+							{ID: 11, Code: "BE-1", Name: "Backend infra", Total: 200, Subscriptions: 2},
+							{ID: 12, Code: "BE-2", Name: "Backend licences", Total: 50, Subscriptions: 1},
+						},
+					},
+					// Центр затрат без начислений входит в отчёт с нулевой суммой.
+					{
+						ID: 3, Kind: model.UnitTeam, Name: "Frontend", Units: []api.UnitSpending{},
+						CostCentres: []api.CostCentreSpending{{ID: 13, Code: "FE", Name: "Frontend"}},
+					},
+				},
+			},
+			{
+				ID: 4, Kind: model.UnitTeam, Name: "Design", Total: 30, Units: []api.UnitSpending{},
+				CostCentres: []api.CostCentreSpending{{ID: 14, Code: "DS", Name: "Design", Total: 30, Subscriptions: 1}},
+			},
+		},
+		CostCentres: []api.CostCentreSpending{{ID: 15, Code: "HQ", Name: "Headquarters", Total: 7, Subscriptions: 1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rollUpSpending =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestRollUpSpendingEmpty(t *testing.T) {
+	units := []model.OrgUnit{{ID: 1, Kind: model.UnitDepartment, Name: "Sales"}}
+	got := rollUpSpending(units, nil, nil)
+	want := api.OrgSpendingResponse{
+		Units:       []api.UnitSpending{{ID: 1, Kind: model.UnitDepartment, Name: "Sales", Units: []api.UnitSpending{}, CostCentres: []api.CostCentreSpending{}}},
+		CostCentres: []api.CostCentreSpending{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rollUpSpending = %+v, want %+v", got, want)
+	}
+}
+
+// Подписку перевели из одной команды в другую в середине периода: каждый месяц начисления
+// остаётся за центром затрат, действовавшим в этом месяце, и сумма отдела не меняется.
+func TestRollUpSpendingAllocationChange(t *testing.T) {
+	id := func(v int64) *int64 { return &v }
+	month := func(m time.Month) time.Time { return time.Date(2025, m, 1, 0, 0, 0, 0, time.UTC) }
+	units := []model.OrgUnit{
+		{ID: 1, Kind: model.UnitDepartment, Name: "Engineering"},
+		{ID: 2, Kind: model.UnitTeam, Name: "Backend", ParentID: id(1)},
+		{ID: 3, Kind: model.UnitTeam, Name: "Frontend", ParentID: id(1)},
+	}
+	centres := []model.CostCentre{
+		{ID: 11, Code: "BE", Name: "Backend", UnitID: id(2)},
+		{ID: 12, Code: "FE", Name: "Frontend", UnitID: id(3)},
+	}
+	sub := model.Subscription{ID: 1, Price: 100, StartDate: month(time.January), Allocations: []model.Allocation{
+		{EffectiveFrom: month(time.January), CostCentreID: id(11)},
+		{EffectiveFrom: month(time.March), CostCentreID: id(12)},
+	}}
+
+	// Начисления за январь — апрель, как их группирует запрос репозитория.
+	byCentre := map[int64]*model.CostCentreSpending{}
+	for m := month(time.January); !m.After(month(time.April)); m = m.AddDate(0, 1, 0) {
+		cc := sub.CostCentreIn(m)
+		if cc == nil {
+			t.Fatalf("CostCentreIn(%s) = nil", m.Format("01-2006"))
+		}
+		if byCentre[*cc] == nil {
+			byCentre[*cc] = &model.CostCentreSpending{CostCentreID: *cc, Subscriptions: 1}
+		}
+		byCentre[*cc].Total += sub.Price
+	}
+	var spending []model.CostCentreSpending
+	for _, sp := range byCentre {
+		spending = append(spending, *sp)
+	}
+
+	got := rollUpSpending(units, centres, spending)
+	if got.Total != 400 || len(got.Units) != 1 || got.Units[0].Total != 400 {
+		t.Fatalf("rollUpSpending = %+v, want department total 400", got)
+	}
+	teams := got.Units[0].Units
+	if len(teams) != 2 || teams[0].Total != 200 || teams[1].Total != 200 {
+		t.Errorf("teams = %+v, want Backend 200 for January–February and Frontend 200 for March–April", teams)
+	}
+}
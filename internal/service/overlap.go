@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shenikar/subscription-service/internal/model"
+)
+
+// overlapCheck — результат проверки подписок одной операции на пересечения.
+type overlapCheck struct {
+	subs []*model.Subscription
+	// errs содержит ErrOverlap для подписок, отклонённых в режиме reject.
+	errs []error
+	// pending — индексы более ранних подписок операции, с которыми пересекается подписка.
+	pending map[int][]int
+}
+
+// checkOverlaps проверяет подписки subs на пересечение с сохранёнными подписками и друг
+// с другом и в зависимости от режима отклоняет их или отмечает AllowOverlap.
+// current — сохранённые версии обновляемых подписок: пересечения, которые были и до
+// изменения, не отклоняются. Сохранённые подписки из replaced операция изменяет или
+// удаляет, поэтому их прежние периоды не учитываются.
+func (s *SubscriptionService) checkOverlaps(ctx context.Context, subs []*model.Subscription, current map[int64]*model.Subscription, replaced map[int64]bool) (*overlapCheck, error) {
+	check := &overlapCheck{
+		subs:    subs,
+		errs:    make([]error, len(subs)),
+		pending: map[int][]int{},
+	}
+	if len(subs) == 0 {
+		return check, nil
+	}
+
+	found, err := s.repo.Overlapping(ctx, subs)
+	if err != nil {
+		return nil, fmt.Errorf("overlap check failed: %w", err)
+	}
+
+	for i, sub := range subs {
+		sub.AllowOverlap, sub.OverlapsWith = false, nil
+		cur := current[sub.ID]
+		added := false
+
+		var ids []int64
+		for _, other := range found[i] {
+			if replaced[other.ID] {
+				continue
+			}
+			ids = append(ids, other.ID)
+			added = added || cur == nil || !cur.Overlaps(*other)
+		}
+		var pending []int
+		for j, other := range subs[:i] {
+			if check.errs[j] != nil || !overlapping(*sub, *other) {
+				continue
+			}
+			pending = append(pending, j)
+			added = added || cur == nil || !cur.Overlaps(*other)
+		}
+		if len(ids) == 0 && len(pending) == 0 {
+			continue
+		}
+
+		if s.overlapMode == model.OverlapReject && added {
+			check.errs[i] = overlapError(ids)
+			continue
+		}
+		sub.AllowOverlap = true
+		if s.overlapMode != model.OverlapAllow {
+			sub.OverlapsWith = ids
+			check.pending[i] = pending
+		}
+	}
+	return check, nil
+}
+
+// complete дополняет OverlapsWith подписками той же операции, которые получили ID при сохранении.
+func (c *overlapCheck) complete() {
+	for i, pending := range c.pending {
+		for _, j := range pending {
+			if id := c.subs[j].ID; id != 0 {
+				c.subs[i].OverlapsWith = append(c.subs[i].OverlapsWith, id)
+			}
+		}
+	}
+}
+
+// overlapping сравнивает подписки, в том числе на сервисы, которых ещё нет в каталоге:
+// у таких подписок нет ServiceID, и сервис определяется названием.
+func overlapping(a, b model.Subscription) bool {
+	if a.ID != 0 && a.ID == b.ID {
+		return false
+	}
+	if a.ServiceID == 0 || b.ServiceID == 0 {
+		if a.ServiceID != b.ServiceID || !strings.EqualFold(a.ServiceName, b.ServiceName) {
+			return false
+		}
+	}
+	return a.Overlaps(b)
+}
+
+// overlapWarnings описывает пересечения строки импорта с сохранёнными подписками ids
+// и более ранними строками файла; pending — индексы этих строк в rows.
+func overlapWarnings(ids []int64, pending []int, rows []int) []string {
+	var res []string
+	if len(ids) > 0 {
+		res = append(res, "overlapping subscriptions "+joinInts(ids))
+	}
+	if len(pending) > 0 {
+		nums := make([]int64, len(pending))
+		for i, j := range pending {
+			nums[i] = int64(rows[j])
+		}
+		res = append(res, "overlapping rows "+joinInts(nums))
+	}
+	return res
+}
+
+func overlapError(ids []int64) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("%w: overlapping subscription earlier in the request", ErrOverlap)
+	}
+	return fmt.Errorf("%w: overlapping subscriptions %s", ErrOverlap, joinInts(ids))
+}
+
+func joinInts(nums []int64) string {
+	s := make([]string, len(nums))
+	for i, n := range nums {
+		s[i] = strconv.FormatInt(n, 10)
+	}
+	return strings.Join(s, ", ")
+}
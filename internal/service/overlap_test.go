@@ -0,0 +1,60 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/service"
+	"github.com/shenikar/subscription-service/internal/testutil"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+func TestCreateOverlapModes(t *testing.T) {
+	end := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+	stored := model.Subscription{
+		ServiceName: "Netflix", Price: 400, UserID: importUser,
+		StartDate: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), EndDate: &end,
+	}
+
+	tests := []struct {
+		name      string
+		mode      string
+		startDate string
+		err       error
+		allow     bool
+		overlaps  []int64
+	}{
+		{name: "reject", mode: model.OverlapReject, startDate: "06-2025", err: service.ErrOverlap},
+		{name: "warn", mode: model.OverlapWarn, startDate: "06-2025", allow: true, overlaps: []int64{1}},
+		{name: "allow", mode: model.OverlapAllow, startDate: "06-2025", allow: true},
+		// Подписка действует до конца месяца end_date: следующий месяц уже не пересекается.
+		{name: "next month", mode: model.OverlapReject, startDate: "07-2025"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := testutil.NewStores(stored)
+			stores.OverlapMode = tt.mode
+			svc := stores.SubscriptionService()
+
+			sub, err := svc.Create(context.Background(), api.CreateSubscriptionRequest{
+				ServiceName: "Netflix", Price: 500, UserID: importUser, StartDate: tt.startDate,
+			})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				if n := len(stores.Subscriptions.All()); n != 1 {
+					t.Errorf("stored %d subscriptions, want 1", n)
+				}
+				return
+			}
+			if sub.AllowOverlap != tt.allow || !reflect.DeepEqual(sub.OverlapsWith, tt.overlaps) {
+				t.Errorf("Create() = allow_overlap %v, overlaps_with %v; want %v, %v", sub.AllowOverlap, sub.OverlapsWith, tt.allow, tt.overlaps)
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/dates"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/mapper"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+// maxReportMonths ограничивает длину периода отчётов.
+const maxReportMonths = 120
+
+const defaultForecastMonths = 12
+
+// ReportService строит отчёты по помесячным начислениям подписок.
+type ReportService struct {
+	repo     ReportStore
+	subsRepo SubscriptionStore
+	catalog  *CatalogService
+	users    *UserService
+}
+
+func NewReportService(repo ReportStore, subsRepo SubscriptionStore, catalog *CatalogService, users *UserService) *ReportService {
+	return &ReportService{
+		repo:     repo,
+		subsRepo: subsRepo,
+		catalog:  catalog,
+		users:    users,
+	}
+}
+
+// Spending возвращает расходы за месяцы периода, сгруппированные по месяцам (по умолчанию),
+// сервисам, пользователям, категориям или меткам.
+func (s *ReportService) Spending(ctx context.Context, req dto.SpendingReportFilter) (api.SpendingReportResponse, error) {
+	log := logger.GetLogger()
+
+	groupBy := req.GroupBy
+	if groupBy == "" {
+		groupBy = model.GroupByMonth
+	}
+	from, to, err := reportPeriod(req.From, req.To, s.location(ctx, req.UserID))
+	if err != nil {
+		return api.SpendingReportResponse{}, err
+	}
+
+	res := api.SpendingReportResponse{
+		From:    req.From,
+		To:      req.To,
+		GroupBy: groupBy,
+		Buckets: []api.SpendingBucket{},
+	}
+
+	filter, err := s.chargeFilter(ctx, req.UserID, req.ServiceID, req.ServiceName)
+	if err != nil {
+		return api.SpendingReportResponse{}, err
+	}
+	filter.Category, filter.Tags = categoryFilter(req.Category), model.NormalizeTags(req.Tags)
+
+	buckets, err := s.repo.Spending(ctx, filter, from, to, groupBy)
+	if err != nil {
+		log.WithError(err).Error("failed to build spending report")
+		return api.SpendingReportResponse{}, fmt.Errorf("spending report failed: %w", err)
+	}
+	for _, b := range buckets {
+		res.Total += b.Total
+		res.Buckets = append(res.Buckets, mapper.ToSpendingBucketResponse(b))
+	}
+
+	log.WithFields(logrus.Fields{
+		"from":     req.From,
+		"to":       req.To,
+		"group_by": groupBy,
+		"buckets":  len(res.Buckets),
+		"total":    res.Total,
+	}).Info("spending report built")
+
+	return res, nil
+}
+
+// Forecast прогнозирует начисления на months месяцев, начиная со следующего: подписки
+// оплачиваются первого числа месяца, поэтому текущий месяц уже оплачен. Без учёта
+// запланированных изменений каждый месяц считается по цене текущего месяца.
+func (s *ReportService) Forecast(ctx context.Context, req dto.ForecastFilter) (api.ForecastResponse, error) {
+	log := logger.GetLogger()
+
+	months := req.Months
+	if months == 0 {
+		months = defaultForecastMonths
+	}
+	includeChanges := req.IncludePriceChanges == nil || *req.IncludePriceChanges
+
+	current := dates.CurrentMonth(s.location(ctx, req.UserID))
+	from := current.AddDate(0, 1, 0)
+	to := current.AddDate(0, months, 0)
+
+	res := api.ForecastResponse{
+		From:                dates.FormatMonth(from),
+		To:                  dates.FormatMonth(to),
+		IncludePriceChanges: includeChanges,
+		Months:              make([]api.ForecastMonth, 0, months),
+	}
+
+	filter, err := s.chargeFilter(ctx, req.UserID, req.ServiceID, req.ServiceName)
+	if err != nil {
+		return api.ForecastResponse{}, err
+	}
+	subs, err := s.subsRepo.ListActiveBetween(ctx, filter, from, to)
+	if err != nil {
+		log.WithError(err).Error("failed to get subscriptions for forecast")
+		return api.ForecastResponse{}, fmt.Errorf("forecast failed: %w", err)
+	}
+
+	for month := from; !month.After(to); month = month.AddDate(0, 1, 0) {
+		forecast := api.ForecastMonth{
+			Month:         dates.FormatMonth(month),
+			Subscriptions: []api.ForecastCharge{},
+		}
+		for _, sub := range subs {
+			price, ok := sub.ChargeIn(month)
+			if !ok {
+				continue
+			}
+			if !includeChanges {
+				price = sub.PriceIn(current)
+			}
+			// Пользователю совместной подписки прогнозируется только его доля.
+			userID := sub.UserID
+			if filter.UserID != nil {
+				userID = *filter.UserID
+				price = sub.ShareOf(userID, price)
+			}
+			forecast.Total += price
+			forecast.Subscriptions = append(forecast.Subscriptions, api.ForecastCharge{
+				SubscriptionID: sub.ID,
+				ServiceID:      sub.ServiceID,
+				ServiceName:    sub.ServiceName,
+				UserID:         userID,
+				Price:          price,
+			})
+		}
+		res.Total += forecast.Total
+		res.Months = append(res.Months, forecast)
+	}
+
+	log.WithFields(logrus.Fields{
+		"months":        months,
+		"subscriptions": len(subs),
+		"total":         res.Total,
+	}).Info("spending forecast built")
+
+	return res, nil
+}
+
+// Duplicates возвращает пары подписок одного пользователя на один сервис с пересекающимися
+// периодами, в том числе допущенные режимами OVERLAP_MODE warn и allow.
+func (s *ReportService) Duplicates(ctx context.Context, req dto.DuplicatesFilter) (api.DuplicatesReportResponse, error) {
+	log := logger.GetLogger()
+
+	res := api.DuplicatesReportResponse{Duplicates: []api.DuplicatePair{}}
+
+	filter, err := s.chargeFilter(ctx, req.UserID, req.ServiceID, req.ServiceName)
+	if err != nil {
+		return api.DuplicatesReportResponse{}, err
+	}
+	pairs, err := s.repo.Duplicates(ctx, filter.UserID, filter.ServiceID)
+	if err != nil {
+		log.WithError(err).Error("failed to build duplicates report")
+		return api.DuplicatesReportResponse{}, fmt.Errorf("duplicates report failed: %w", err)
+	}
+	for _, p := range pairs {
+		res.Duplicates = append(res.Duplicates, mapper.ToDuplicatePairResponse(p))
+	}
+	res.Count = len(res.Duplicates)
+
+	log.WithField("count", res.Count).Info("duplicates report built")
+	return res, nil
+}
+
+// Cancellations возвращает отмены подписок за месяцы периода, сгруппированные по причинам
+// и по сервисам. Месяц отмены определяется в часовом поясе отчёта.
+func (s *ReportService) Cancellations(ctx context.Context, req dto.CancellationReportFilter) (api.CancellationReportResponse, error) {
+	log := logger.GetLogger()
+
+	loc := s.location(ctx, req.UserID)
+	from, to, err := reportPeriod(req.From, req.To, loc)
+	if err != nil {
+		return api.CancellationReportResponse{}, err
+	}
+
+	res := api.CancellationReportResponse{
+		From:      req.From,
+		To:        req.To,
+		ByReason:  []api.CancellationBucket{},
+		ByService: []api.CancellationBucket{},
+	}
+
+	filter, err := s.chargeFilter(ctx, req.UserID, req.ServiceID, req.ServiceName)
+	if err != nil {
+		return api.CancellationReportResponse{}, err
+	}
+
+	start := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, loc)
+	end := time.Date(to.Year(), to.Month()+1, 1, 0, 0, 0, 0, loc)
+	byReason, byService, err := s.repo.Cancellations(ctx, start, end, filter.UserID, filter.ServiceID)
+	if err != nil {
+		log.WithError(err).Error("failed to build cancellations report")
+		return api.CancellationReportResponse{}, fmt.Errorf("cancellations report failed: %w", err)
+	}
+	for _, b := range byReason {
+		res.Count += b.Count
+		res.LostRevenue += b.LostRevenue
+		res.ByReason = append(res.ByReason, mapper.ToCancellationBucketResponse(b))
+	}
+	for _, b := range byService {
+		res.ByService = append(res.ByService, mapper.ToCancellationBucketResponse(b))
+	}
+
+	log.WithFields(logrus.Fields{
+		"from":         req.From,
+		"to":           req.To,
+		"count":        res.Count,
+		"lost_revenue": res.LostRevenue,
+	}).Info("cancellations report built")
+
+	return res, nil
+}
+
+// Balances возвращает, кто кому должен за совместные подписки в месяце: участники должны
+// владельцам свои доли, встречные долги двух пользователей взаимно погашаются.
+func (s *ReportService) Balances(ctx context.Context, req dto.BalancesFilter) (api.BalancesResponse, error) {
+	log := logger.GetLogger()
+
+	loc := s.location(ctx, req.UserID)
+	month := dates.CurrentMonth(loc)
+	if req.Month != "" {
+		var err error
+		if month, err = dates.ParseMonthIn(req.Month, loc); err != nil {
+			return api.BalancesResponse{}, fmt.Errorf("%w: invalid month: %v", ErrInvalidInput, err)
+		}
+	}
+	var userID *uuid.UUID
+	if req.UserID != "" {
+		id, err := uuid.Parse(req.UserID)
+		if err != nil {
+			return api.BalancesResponse{}, fmt.Errorf("%w: invalid user_id", ErrInvalidInput)
+		}
+		userID = &id
+	}
+
+	debts, err := s.repo.Balances(ctx, month, userID)
+	if err != nil {
+		log.WithError(err).Error("failed to build balances")
+		return api.BalancesResponse{}, fmt.Errorf("balances failed: %w", err)
+	}
+
+	res := api.BalancesResponse{
+		Month:    dates.FormatMonth(month),
+		Balances: []api.BalanceResponse{},
+	}
+	for _, b := range netBalances(debts) {
+		res.Balances = append(res.Balances, mapper.ToBalanceResponse(b))
+	}
+
+	log.WithFields(logrus.Fields{
+		"month":    res.Month,
+		"balances": len(res.Balances),
+	}).Info("balances built")
+
+	return res, nil
+}
+
+// netBalances погашает встречные долги каждой пары пользователей и возвращает остатки
+// по убыванию суммы.
+func netBalances(debts []model.Balance) []model.Balance {
+	type pair struct{ a, b uuid.UUID }
+	net := make(map[pair]*model.Balance)
+	var order []pair
+	for _, d := range debts {
+		p, amount := pair{d.From, d.To}, d.Amount
+		if d.To.String() < d.From.String() {
+			p, amount = pair{d.To, d.From}, -d.Amount
+		}
+		b, ok := net[p]
+		if !ok {
+			b = &model.Balance{From: p.a, To: p.b}
+			net[p] = b
+			order = append(order, p)
+		}
+		b.Amount += amount
+		b.SubscriptionIDs = append(b.SubscriptionIDs, d.SubscriptionIDs...)
+	}
+
+	var res []model.Balance
+	for _, p := range order {
+		b := net[p]
+		if b.Amount == 0 {
+			continue
+		}
+		if b.Amount < 0 {
+			b.From, b.To, b.Amount = b.To, b.From, -b.Amount
+		}
+		slices.Sort(b.SubscriptionIDs)
+		b.SubscriptionIDs = slices.Compact(b.SubscriptionIDs)
+		res = append(res, *b)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Amount > res[j].Amount
+	})
+	return res
+}
+
+// chargeFilter собирает фильтр начислений из параметров запроса. Для неизвестного сервиса
+// фильтр не отбирает ни одной подписки, но отчёты всё равно содержат все месяцы периода.
+func (s *ReportService) chargeFilter(ctx context.Context, userID string, serviceID int64, serviceName string) (repository.ChargeFilter, error) {
+	var filter repository.ChargeFilter
+	if userID != "" {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return filter, fmt.Errorf("%w: invalid user_id", ErrInvalidInput)
+		}
+		filter.UserID = &id
+	}
+
+	id, found, err := s.catalog.filter(ctx, serviceID, serviceName)
+	if err != nil {
+		return filter, err
+	}
+	if !found {
+		missing := int64(0)
+		id = &missing
+	}
+	filter.ServiceID = id
+	return filter, nil
+}
+
+// categoryFilter возвращает nil для пустой категории: она не ограничивает выборку.
+func categoryFilter(category string) *string {
+	if category == "" {
+		return nil
+	}
+	return &category
+}
+
+// location возвращает часовой пояс отчёта: отчёт по одному пользователю строится в его
+// часовом поясе, остальные — в часовом поясе запроса или сервиса.
+func (s *ReportService) location(ctx context.Context, userID string) *time.Location {
+	if id, err := uuid.Parse(userID); err == nil {
+		return s.users.userLocation(ctx, id)
+	}
+	return s.users.resolve(ctx, nil)
+}
+
+// reportPeriod разбирает границы периода отчёта в часовом поясе loc до первого числа месяца.
+func reportPeriod(fromStr, toStr string, loc *time.Location) (from, to time.Time, err error) {
+	from, err = dates.ParseMonthIn(fromStr, loc)
+	if err != nil {
+		return from, to, fmt.Errorf("%w: invalid from: %v", ErrInvalidInput, err)
+	}
+	to, err = dates.ParseMonthIn(toStr, loc)
+	if err != nil {
+		return from, to, fmt.Errorf("%w: invalid to: %v", ErrInvalidInput, err)
+	}
+	if to.Before(from) {
+		return from, to, fmt.Errorf("%w: to must not be before from", ErrInvalidInput)
+	}
+	if months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1; months > maxReportMonths {
+		return from, to, fmt.Errorf("%w: period must not exceed %d months", ErrInvalidInput, maxReportMonths)
+	}
+	return from, to, nil
+}
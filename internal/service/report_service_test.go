@@ -0,0 +1,121 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shenikar/subscription-service/internal/dates"
+	"github.com/shenikar/subscription-service/internal/model"
+)
+
+func loadZone(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := dates.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+// Период отчёта и итогов задаётся моментами на стыке лет и в дни перехода на летнее
+// и зимнее время; месяцы считаются в поясе пользователя.
+func TestPeriodBucketingInUserZone(t *testing.T) {
+	tests := []struct {
+		name      string
+		zone      string
+		from, to  string
+		wantFrom  string
+		wantTo    string
+		wantTotal int
+	}{
+		{
+			name: "year boundary in UTC", zone: "UTC",
+			from: "2024-12-31T23:30:00Z", to: "2025-01-01T03:00:00Z",
+			wantFrom: "12-2024", wantTo: "01-2025", wantTotal: 100,
+		},
+		{
+			name: "December in UTC is January in Berlin", zone: "Europe/Berlin",
+			from: "2024-12-31T23:30:00Z", to: "2025-01-01T03:00:00Z",
+			wantFrom: "01-2025", wantTo: "01-2025", wantTotal: 100,
+		},
+		{
+			name: "January in UTC is December in New York", zone: "America/New_York",
+			from: "2024-12-31T23:30:00Z", to: "2025-01-01T03:00:00Z",
+			wantFrom: "12-2024", wantTo: "12-2024", wantTotal: 0,
+		},
+		{
+			name: "Berlin spring forward", zone: "Europe/Berlin",
+			from: "2025-03-29T23:00:00Z", to: "2025-03-31T21:59:00Z",
+			wantFrom: "03-2025", wantTo: "03-2025", wantTotal: 100,
+		},
+		{
+			name: "Berlin fall back", zone: "Europe/Berlin",
+			from: "2025-10-26T22:59:00Z", to: "2025-10-31T22:59:00Z",
+			wantFrom: "10-2025", wantTo: "10-2025", wantTotal: 100,
+		},
+		{
+			name: "Berlin fall back month end", zone: "Europe/Berlin",
+			from: "2025-10-26T22:59:00Z", to: "2025-10-31T23:00:00Z",
+			wantFrom: "10-2025", wantTo: "11-2025", wantTotal: 200,
+		},
+		{
+			name: "New York spring forward", zone: "America/New_York",
+			from: "2025-03-01T04:59:00Z", to: "2025-03-09T05:00:00Z",
+			wantFrom: "02-2025", wantTo: "03-2025", wantTotal: 200,
+		},
+		{
+			name: "New York fall back", zone: "America/New_York",
+			from: "2025-11-01T03:59:00Z", to: "2025-11-02T04:00:00Z",
+			wantFrom: "10-2025", wantTo: "11-2025", wantTotal: 200,
+		},
+	}
+
+	// Подписка оформлена в первую минуту 2025 года по Берлину: в UTC это ещё декабрь,
+	// но первым оплачиваемым месяцем у берлинского пользователя будет январь.
+	berlin := loadZone(t, "Europe/Berlin")
+	start, err := dates.ParseMonthIn("2024-12-31T23:01:00Z", berlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := model.Subscription{Price: 100, StartDate: start}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := loadZone(t, tt.zone)
+
+			from, to, err := reportPeriod(tt.from, tt.to, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := dates.FormatMonth(from); got != tt.wantFrom {
+				t.Errorf("report from = %s, want %s", got, tt.wantFrom)
+			}
+			if got := dates.FormatMonth(to); got != tt.wantTo {
+				t.Errorf("report to = %s, want %s", got, tt.wantTo)
+			}
+
+			// Итоги разбирают границы тем же поясом, а месяц берётся при запросе.
+			totalFrom, err := parseFilterDate(tt.from, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			totalTo, err := parseFilterDate(tt.to, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !model.MonthStart(totalFrom).Equal(from) || !model.MonthStart(totalTo).Equal(to) {
+				t.Errorf("total period = %s..%s, want %s..%s", totalFrom, totalTo, from, to)
+			}
+
+			total := 0
+			for month := from; !month.After(to); month = month.AddDate(0, 1, 0) {
+				if price, ok := sub.ChargeIn(month); ok {
+					total += price
+				}
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", total, tt.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/model"
+)
+
+const defaultSearchLimit = 20
+
+// Search ищет подписки по названию сервиса, меткам и заметкам и возвращает их по убыванию
+// релевантности с подсвеченными совпадениями.
+func (s *SubscriptionService) Search(ctx context.Context, filter dto.SearchSubscriptionsFilter) ([]model.SearchHit, error) {
+	log := logger.GetLogger()
+
+	query := strings.TrimSpace(filter.Query)
+	if len([]rune(query)) < 2 {
+		return nil, fmt.Errorf("%w: search query must have at least 2 characters", ErrInvalidInput)
+	}
+	var userID *uuid.UUID
+	if filter.UserID != "" {
+		id, err := uuid.Parse(filter.UserID)
+		if err != nil {
+			log.WithError(err).Warnf("invalid user_id format: %s", filter.UserID)
+			return nil, fmt.Errorf("%w: invalid user_id", ErrInvalidInput)
+		}
+		userID = &id
+	}
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+
+	hits, err := s.repo.Search(ctx, query, userID, limit, filter.Offset)
+	if err != nil {
+		log.WithError(err).Error("failed to search subscriptions")
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+
+	terms := searchTerms(query)
+	for i := range hits {
+		h := &hits[i].Highlights
+		if h.ServiceName != "" {
+			h.ServiceName = highlight(h.ServiceName, terms)
+		}
+		for j, tag := range h.Tags {
+			h.Tags[j] = highlight(tag, terms)
+		}
+	}
+	return hits, nil
+}
+
+// searchTerms разбивает запрос на слова без учёта регистра.
+func searchTerms(query string) [][]rune {
+	var terms [][]rune
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		terms = append(terms, []rune(word))
+	}
+	return terms
+}
+
+// highlight обрамляет маркерами <mark> вхождения слов запроса в text без учёта регистра.
+// Если ни одно слово не входит в text целиком (совпадение нашлось с опечаткой),
+// отмечается весь text.
+func highlight(text string, terms [][]rune) string {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		return "<mark>" + text + "</mark>"
+	}
+
+	marked := make([]bool, len(runes))
+	found := false
+	for _, term := range terms {
+		for i := 0; i+len(term) <= len(lower); i++ {
+			if string(lower[i:i+len(term)]) != string(term) {
+				continue
+			}
+			for j := i; j < i+len(term); j++ {
+				marked[j] = true
+			}
+			found = true
+		}
+	}
+	if !found {
+		return "<mark>" + text + "</mark>"
+	}
+
+	var b strings.Builder
+	for i, r := range runes {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString("<mark>")
+		}
+		b.WriteRune(r)
+		if marked[i] && (i == len(runes)-1 || !marked[i+1]) {
+			b.WriteString("</mark>")
+		}
+	}
+	return b.String()
+}
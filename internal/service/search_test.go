@@ -0,0 +1,27 @@
+package service
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		want  string
+	}{
+		{"word", "Яндекс Плюс", "плюс", "Яндекс <mark>Плюс</mark>"},
+		{"several words", "Yandex Plus Family", "family yandex", "<mark>Yandex</mark> Plus <mark>Family</mark>"},
+		{"adjacent matches merge", "Netflix", "net flix", "<mark>Netflix</mark>"},
+		{"repeated word", "ab ab", "ab", "<mark>ab</mark> <mark>ab</mark>"},
+		{"punctuation in query", "Spotify", "spot-ify!", "<mark>Spotify</mark>"},
+		// Совпадение с опечаткой нашёл поиск по триграммам: отмечается всё название.
+		{"typo", "Netflix", "netflx", "<mark>Netflix</mark>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlight(tt.text, searchTerms(tt.query)); got != tt.want {
+				t.Errorf("highlight(%q, %q) = %q, want %q", tt.text, tt.query, got, tt.want)
+			}
+		})
+	}
+}
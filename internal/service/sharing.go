@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/dates"
+	"github.com/shenikar/subscription-service/internal/event"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/mapper"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+// Members возвращает подписку и текущий месяц её владельца, для которого считаются доли.
+func (s *SubscriptionService) Members(ctx context.Context, id int64) (model.Subscription, time.Time, error) {
+	sub, err := s.getAfterChange(ctx, id)
+	if err != nil {
+		return model.Subscription{}, time.Time{}, err
+	}
+	return sub, dates.CurrentMonth(s.users.userLocation(ctx, sub.UserID)), nil
+}
+
+// SetMembers заменяет правило разделения стоимости и всех участников подписки.
+func (s *SubscriptionService) SetMembers(ctx context.Context, id int64, req api.SetMembersRequest, ifMatch *int) (model.Subscription, time.Time, error) {
+	current, err := s.getForUpdate(ctx, id, ifMatch)
+	if err != nil {
+		return model.Subscription{}, time.Time{}, err
+	}
+	members := make([]model.Member, 0, len(req.Members))
+	for _, m := range req.Members {
+		members = append(members, mapper.ToModelMember(m))
+	}
+	return s.share(ctx, *current, req.Split, members)
+}
+
+// AddMember добавляет участника по текущему правилу разделения или меняет условия
+// существующего участника.
+func (s *SubscriptionService) AddMember(ctx context.Context, id int64, req api.MemberRequest, ifMatch *int) (model.Subscription, time.Time, error) {
+	current, err := s.getForUpdate(ctx, id, ifMatch)
+	if err != nil {
+		return model.Subscription{}, time.Time{}, err
+	}
+	member := mapper.ToModelMember(req)
+	members := make([]model.Member, 0, len(current.Members)+1)
+	added := false
+	for _, m := range current.Members {
+		if m.UserID == member.UserID {
+			m, added = member, true
+		}
+		members = append(members, m)
+	}
+	if !added {
+		members = append(members, member)
+	}
+	return s.share(ctx, *current, current.Split, members)
+}
+
+// RemoveMember исключает участника: его доля снова приходится на владельца.
+func (s *SubscriptionService) RemoveMember(ctx context.Context, id int64, userID uuid.UUID, ifMatch *int) (model.Subscription, time.Time, error) {
+	current, err := s.getForUpdate(ctx, id, ifMatch)
+	if err != nil {
+		return model.Subscription{}, time.Time{}, err
+	}
+	members := make([]model.Member, 0, len(current.Members))
+	for _, m := range current.Members {
+		if m.UserID != userID {
+			members = append(members, m)
+		}
+	}
+	if len(members) == len(current.Members) {
+		return model.Subscription{}, time.Time{}, ErrMemberNotFound
+	}
+	return s.share(ctx, *current, current.Split, members)
+}
+
+// share проверяет и сохраняет участников подписки current. Бюджеты проверяются и для
+// прежних участников: их доли тоже изменились.
+func (s *SubscriptionService) share(ctx context.Context, current model.Subscription, split string, members []model.Member) (model.Subscription, time.Time, error) {
+	log := logger.GetLogger()
+
+	month := dates.CurrentMonth(s.users.userLocation(ctx, current.UserID))
+	if err := validateMembers(current, split, members, current.PriceIn(month)); err != nil {
+		log.WithError(err).Warnf("invalid members of subscription: %d", current.ID)
+		return model.Subscription{}, time.Time{}, err
+	}
+	for _, m := range members {
+		if err := s.users.ensure(ctx, m.UserID); err != nil {
+			log.WithError(err).Warn("failed to check subscription member")
+			return model.Subscription{}, time.Time{}, err
+		}
+	}
+
+	if err := s.repo.SetMembers(ctx, current.ID, split, members, &current.Version); err != nil {
+		log.WithError(err).Errorf("failed to save members of subscription: %d", current.ID)
+		return model.Subscription{}, time.Time{}, repositoryError(err, "members update failed")
+	}
+
+	updated, err := s.getAfterChange(ctx, current.ID)
+	if err != nil {
+		return model.Subscription{}, time.Time{}, err
+	}
+	log.WithFields(logrus.Fields{
+		"id":      updated.ID,
+		"split":   updated.Split,
+		"members": len(updated.Members),
+		"version": updated.Version,
+	}).Info("subscription members changed")
+
+	s.publish(event.SubscriptionUpdated, updated)
+	s.budgets.check(ctx, subscriptionUserIDs([]*model.Subscription{&current, &updated}))
+	return updated, month, nil
+}
+
+// validateMembers проверяет участников по правилу split: владелец не может быть участником,
+// проценты участников в сумме не больше 100, а фиксированные суммы — не больше цены
+// подписки price в текущем месяце.
+func validateMembers(sub model.Subscription, split string, members []model.Member, price int) error {
+	if len(members) > model.MaxMembers {
+		return fmt.Errorf("%w: at most %d members allowed", ErrInvalidInput, model.MaxMembers)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(members))
+	percents, amounts := 0, 0
+	for _, m := range members {
+		switch {
+		case m.UserID == uuid.Nil:
+			return fmt.Errorf("%w: member user_id is required", ErrInvalidInput)
+		case m.UserID == sub.UserID:
+			return fmt.Errorf("%w: owner %s cannot be a member", ErrInvalidInput, m.UserID)
+		case seen[m.UserID]:
+			return fmt.Errorf("%w: duplicate member %s", ErrInvalidInput, m.UserID)
+		}
+		seen[m.UserID] = true
+
+		switch split {
+		case model.SplitEqual:
+			if m.Percent != nil || m.Amount != nil {
+				return fmt.Errorf("%w: member %s: percent and amount are not allowed for equal split", ErrInvalidInput, m.UserID)
+			}
+		case model.SplitPercentage:
+			if m.Percent == nil || m.Amount != nil {
+				return fmt.Errorf("%w: member %s: percentage split requires percent only", ErrInvalidInput, m.UserID)
+			}
+			percents += *m.Percent
+		case model.SplitFixed:
+			if m.Amount == nil || m.Percent != nil {
+				return fmt.Errorf("%w: member %s: fixed split requires amount only", ErrInvalidInput, m.UserID)
+			}
+			amounts += *m.Amount
+		default:
+			return fmt.Errorf("%w: unknown split %q", ErrInvalidInput, split)
+		}
+	}
+	if percents > 100 {
+		return fmt.Errorf("%w: member percents sum to %d, more than 100", ErrInvalidInput, percents)
+	}
+	if amounts > price {
+		return fmt.Errorf("%w: member amounts sum to %d, more than the price %d", ErrInvalidInput, amounts, price)
+	}
+	return nil
+}
+
+// ownerIsMember запрещает передать подписку пользователю, который уже её участник.
+func ownerIsMember(sub model.Subscription) error {
+	for _, m := range sub.Members {
+		if m.UserID == sub.UserID {
+			return fmt.Errorf("%w: user %s is a member of the subscription, remove the member first", ErrInvalidInput, sub.UserID)
+		}
+	}
+	return nil
+}
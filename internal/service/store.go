@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/repository"
+)
+
+// SubscriptionStore — хранилище подписок, с которым работает SubscriptionService.
+// Реализуется repository.SubscriptionRepository.
+type SubscriptionStore interface {
+	ListActiveBetween(ctx context.Context, filter repository.ChargeFilter, from, to time.Time) ([]*model.Subscription, error)
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	Create(ctx context.Context, sub *model.Subscription) error
+	CreateMany(ctx context.Context, subs []*model.Subscription) error
+	GetByID(ctx context.Context, id int64) (*model.Subscription, error)
+	List(ctx context.Context, filter repository.ChargeFilter, limit, offset int) ([]*model.Subscription, error)
+	Search(ctx context.Context, q string, userID *uuid.UUID, limit, offset int) ([]model.SearchHit, error)
+	ListByUserIDs(ctx context.Context, userIDs []uuid.UUID) ([]*model.Subscription, error)
+	ListByServiceNames(ctx context.Context, names []string) ([]*model.Subscription, error)
+	ServiceNames(ctx context.Context) ([]string, error)
+	Update(ctx context.Context, sub *model.Subscription, expectedVersion *int) error
+	Delete(ctx context.Context, id int64, expectedVersion *int) (*model.Subscription, error)
+	SchedulePrice(ctx context.Context, id int64, change model.PriceChange, expectedVersion *int) error
+	Allocate(ctx context.Context, id int64, allocation model.Allocation, expectedVersion *int) error
+	Pause(ctx context.Context, id int64, pause model.Pause, expectedVersion *int) error
+	Resume(ctx context.Context, id int64, month time.Time, expectedVersion *int) error
+	SetMembers(ctx context.Context, id int64, split string, members []model.Member, expectedVersion *int) error
+	Cancel(ctx context.Context, c *model.Cancellation, expectedVersion *int) error
+	ChargesSum(ctx context.Context, filter repository.ChargeFilter, from, to time.Time) (int, error)
+	ChargesByGroup(ctx context.Context, filter repository.ChargeFilter, from, to time.Time, groupBy string) ([]model.SpendingBucket, error)
+	Overlapping(ctx context.Context, subs []*model.Subscription) (map[int][]*model.Subscription, error)
+	GetByIDs(ctx context.Context, ids []int64) (map[int64]*model.Subscription, error)
+	ApplyBatch(ctx context.Context, ops []model.BatchOp, atomic bool) ([]error, error)
+}
+
+// CatalogStore — каталог сервисов, с которым работает CatalogService.
+// Реализуется repository.ServiceRepository.
+type CatalogStore interface {
+	Create(ctx context.Context, svc *model.Service) error
+	GetByID(ctx context.Context, id int64) (*model.Service, error)
+	FindByName(ctx context.Context, name string) (*model.Service, error)
+	List(ctx context.Context, query, category *string, limit, offset int) ([]*model.Service, error)
+	Update(ctx context.Context, svc *model.Service) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// UserStore — хранилище пользователей, с которым работает UserService.
+// Реализуется repository.UserRepository.
+type UserStore interface {
+	Create(ctx context.Context, user *model.User) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	List(ctx context.Context, query *string, limit, offset int) ([]*model.User, error)
+	Update(ctx context.Context, user *model.User) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	Missing(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error)
+	Register(ctx context.Context, ids []uuid.UUID) error
+	TimeZones(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error)
+}
+
+// ReportStore — отчёты по начислениям, с которыми работает ReportService.
+// Реализуется repository.ReportRepository.
+type ReportStore interface {
+	Spending(ctx context.Context, filter repository.ChargeFilter, from, to time.Time, groupBy string) ([]model.SpendingBucket, error)
+	Balances(ctx context.Context, month time.Time, userID *uuid.UUID) ([]model.Balance, error)
+	Duplicates(ctx context.Context, userID *uuid.UUID, serviceID *int64) ([]model.DuplicatePair, error)
+	Cancellations(ctx context.Context, from, to time.Time, userID *uuid.UUID, serviceID *int64) (byReason, byService []model.CancellationBucket, err error)
+}
+
+// BudgetStore — хранилище бюджетов, с которым работает BudgetService.
+// Реализуется repository.BudgetRepository.
+type BudgetStore interface {
+	Create(ctx context.Context, budget *model.Budget) error
+	GetByID(ctx context.Context, id int64) (*model.Budget, error)
+	List(ctx context.Context, userID *uuid.UUID, limit, offset int) ([]*model.Budget, error)
+	Update(ctx context.Context, budget *model.Budget) error
+	Delete(ctx context.Context, id int64) error
+	RecordAlert(ctx context.Context, alert *model.BudgetAlert) (bool, error)
+	Alerts(ctx context.Context, budgetID int64, month time.Time) ([]model.BudgetAlert, error)
+}
+
+// TagStore — хранилище меток, с которым работает TagService.
+// Реализуется repository.TagRepository.
+type TagStore interface {
+	GetByID(ctx context.Context, id int64) (*model.Tag, error)
+	List(ctx context.Context, query *string, limit, offset int) ([]*model.Tag, error)
+	Rename(ctx context.Context, id int64, name string) ([]int64, error)
+	Merge(ctx context.Context, sourceIDs []int64, targetID int64) ([]int64, error)
+}
+
+// OrganisationStore — хранилище организаций, с которым работает OrganisationService.
+// Реализуется repository.OrganisationRepository.
+type OrganisationStore interface {
+	Create(ctx context.Context, org *model.Organisation, owner uuid.UUID) error
+	GetByID(ctx context.Context, id int64) (*model.Organisation, error)
+	List(ctx context.Context, userID *uuid.UUID, limit, offset int) ([]*model.Organisation, error)
+	Update(ctx context.Context, org *model.Organisation) error
+	Delete(ctx context.Context, id int64) error
+	CreateUnit(ctx context.Context, unit *model.OrgUnit) error
+	Units(ctx context.Context, orgID int64) ([]*model.OrgUnit, error)
+	UpdateUnit(ctx context.Context, unit *model.OrgUnit) error
+	DeleteUnit(ctx context.Context, orgID, id int64) error
+	CreateCostCentre(ctx context.Context, cc *model.CostCentre) error
+	CostCentres(ctx context.Context, orgID int64) ([]*model.CostCentre, error)
+	UpdateCostCentre(ctx context.Context, cc *model.CostCentre) error
+	DeleteCostCentre(ctx context.Context, orgID, id int64) error
+	Members(ctx context.Context, orgID int64) ([]*model.OrgMember, error)
+	SetMember(ctx context.Context, m *model.OrgMember) error
+	RemoveMember(ctx context.Context, orgID int64, userID uuid.UUID) error
+	Spending(ctx context.Context, orgID int64, from, to time.Time) ([]model.CostCentreSpending, error)
+}
@@ -1,40 +1,87 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
 
+	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/dates"
 	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/event"
 	"github.com/shenikar/subscription-service/internal/logger"
 	"github.com/shenikar/subscription-service/internal/mapper"
 	"github.com/shenikar/subscription-service/internal/model"
 	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/internal/validation"
+	"github.com/shenikar/subscription-service/pkg/api"
 	"github.com/sirupsen/logrus"
 )
 
 type SubscriptionService struct {
-	repo *repository.SubscriptionRepository
+	repo    SubscriptionStore
+	catalog *CatalogService
+	users   *UserService
+	budgets *BudgetService
+	broker  *event.Broker
+	// overlapMode — model.OverlapReject, model.OverlapWarn или model.OverlapAllow.
+	overlapMode string
 }
 
-func NewSubscriptionService(repo *repository.SubscriptionRepository) *SubscriptionService {
+func NewSubscriptionService(repo SubscriptionStore, catalog *CatalogService, users *UserService, budgets *BudgetService, broker *event.Broker, overlapMode string) *SubscriptionService {
 	return &SubscriptionService{
-		repo: repo,
+		repo:        repo,
+		catalog:     catalog,
+		users:       users,
+		budgets:     budgets,
+		broker:      broker,
+		overlapMode: overlapMode,
 	}
 }
 
-func (s *SubscriptionService) Create(ctx context.Context, req dto.CreateSubscriptionRequest) (model.Subscription, error) {
+func (s *SubscriptionService) publish(eventType string, sub model.Subscription) {
+	s.broker.Publish(event.Event{
+		Type:           eventType,
+		SubscriptionID: sub.ID,
+		Subscription:   &sub,
+	})
+}
+
+func (s *SubscriptionService) Create(ctx context.Context, req api.CreateSubscriptionRequest) (model.Subscription, error) {
 	log := logger.GetLogger()
-	sub, err := mapper.ToModelSubscription(req)
+	sub, err := mapper.ToModelSubscription(req, s.users.userLocation(ctx, req.UserID))
 	if err != nil {
 		log.WithError(err).Warn("Create: invalid subscription data")
+		return model.Subscription{}, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if err := s.catalog.resolver(false).resolve(ctx, &sub); err != nil {
+		log.WithError(err).Warn("Create: failed to resolve service")
+		return model.Subscription{}, err
+	}
+	if err := s.users.ensure(ctx, sub.UserID); err != nil {
+		log.WithError(err).Warn("Create: failed to check user")
+		return model.Subscription{}, err
+	}
+	overlaps, err := s.checkOverlaps(ctx, []*model.Subscription{&sub}, nil, nil)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	if err := overlaps.errs[0]; err != nil {
+		log.WithError(err).Warn("Create: subscription overlaps")
 		return model.Subscription{}, err
 	}
 
 	err = s.repo.Create(ctx, &sub)
 	if err != nil {
 		log.WithError(err).Error("failed to create subscription in repository")
-		return model.Subscription{}, fmt.Errorf("could not create subscription: %w", err)
+		return model.Subscription{}, repositoryError(err, "could not create subscription")
 	}
 	log.WithFields(logrus.Fields{
 		"id":           sub.ID,
@@ -42,9 +89,458 @@ func (s *SubscriptionService) Create(ctx context.Context, req dto.CreateSubscrip
 		"user_id":      sub.UserID,
 	}).Info("subscription created successfully")
 
+	s.publish(event.SubscriptionCreated, sub)
+	s.budgets.check(ctx, []uuid.UUID{sub.UserID})
 	return sub, nil
 }
 
+func (s *SubscriptionService) Import(ctx context.Context, rows []dto.ImportRow, opts dto.ImportOptions) (api.ImportReport, error) {
+	log := logger.GetLogger()
+
+	report := api.ImportReport{
+		DryRun: opts.DryRun,
+		Mode:   opts.Mode,
+		Total:  len(rows),
+		Errors: []api.ImportRowError{},
+	}
+	if report.Mode == "" {
+		report.Mode = api.ImportModePartial
+	}
+
+	// Сервисы сопоставляются без создания новых записей каталога, пока не ясно,
+	// что импорт будет выполнен.
+	resolver := s.catalog.resolver(true)
+	var userIDs []uuid.UUID
+	for _, row := range rows {
+		if len(row.Errors) == 0 {
+			userIDs = append(userIDs, row.Request.UserID)
+		}
+	}
+	unknownUsers, err := s.users.unknown(ctx, userIDs)
+	if err != nil {
+		return report, fmt.Errorf("could not import subscriptions: %w", err)
+	}
+	location := s.users.locations(ctx, userIDs)
+
+	var subs []*model.Subscription
+	var subRows []int
+	for _, row := range rows {
+		errs := row.Errors
+		if len(errs) == 0 {
+			sub, err := mapper.ToModelSubscription(row.Request, location(row.Request.UserID))
+			if err != nil {
+				errs = append(errs, err.Error())
+			} else if err := resolver.resolve(ctx, &sub); errors.Is(err, ErrInvalidInput) {
+				errs = append(errs, err.Error())
+			} else if err != nil {
+				return report, fmt.Errorf("could not import subscriptions: %w", err)
+			} else if unknownUsers[sub.UserID] {
+				errs = append(errs, unknownUserError(sub.UserID).Error())
+			} else {
+				subs = append(subs, &sub)
+				subRows = append(subRows, row.Row)
+			}
+		}
+		if len(errs) > 0 {
+			report.Errors = append(report.Errors, api.ImportRowError{Row: row.Row, Errors: errs})
+		}
+	}
+
+	overlaps, err := s.checkOverlaps(ctx, subs, nil, nil)
+	if err != nil {
+		return report, fmt.Errorf("could not import subscriptions: %w", err)
+	}
+	var accepted []*model.Subscription
+	for i, sub := range subs {
+		if err := overlaps.errs[i]; err != nil {
+			report.Errors = append(report.Errors, api.ImportRowError{Row: subRows[i], Errors: []string{err.Error()}})
+			continue
+		}
+		accepted = append(accepted, sub)
+		if warnings := overlapWarnings(sub.OverlapsWith, overlaps.pending[i], subRows); len(warnings) > 0 {
+			report.Warnings = append(report.Warnings, api.ImportRowError{Row: subRows[i], Errors: warnings})
+		}
+	}
+	slices.SortFunc(report.Errors, func(a, b api.ImportRowError) int { return a.Row - b.Row })
+	subs = accepted
+
+	report.Valid = len(subs)
+	report.Invalid = len(report.Errors)
+
+	if opts.DryRun || len(subs) == 0 {
+		return report, nil
+	}
+	if report.Mode == api.ImportModeAllOrNothing && report.Invalid > 0 {
+		log.WithField("invalid", report.Invalid).Warn("Import: rejected, invalid rows in all-or-nothing mode")
+		return report, nil
+	}
+
+	// Новые сервисы каталога, пользователи и подписки сохраняются в одной транзакции:
+	// при ошибке не остаётся записей, на которые не ссылается ни одна подписка.
+	err = s.repo.InTx(ctx, func(ctx context.Context) error {
+		if err := s.resolveNew(ctx, subs); err != nil {
+			return err
+		}
+		if err := s.users.register(ctx, subscriptionUserIDs(subs)); err != nil {
+			return err
+		}
+		return s.repo.CreateMany(ctx, subs)
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to import subscriptions in repository")
+		return report, repositoryError(err, "could not import subscriptions")
+	}
+	report.Imported = len(subs)
+	for _, sub := range subs {
+		s.publish(event.SubscriptionCreated, *sub)
+	}
+	s.budgets.check(ctx, subscriptionUserIDs(subs))
+
+	log.WithFields(logrus.Fields{
+		"total":    report.Total,
+		"imported": report.Imported,
+		"invalid":  report.Invalid,
+	}).Info("subscriptions imported successfully")
+
+	return report, nil
+}
+
+// resolveNew добавляет в каталог сервисы, которые при проверке в режиме dryRun
+// оказались неизвестными, и проставляет их ID подпискам.
+func (s *SubscriptionService) resolveNew(ctx context.Context, subs []*model.Subscription) error {
+	resolver := s.catalog.resolver(false)
+	for _, sub := range subs {
+		if sub.ServiceID != 0 {
+			continue
+		}
+		if err := resolver.resolve(ctx, sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Batch выполняет операции пакета; даты подписок в результатах форматируются в формате dateFormat.
+func (s *SubscriptionService) Batch(ctx context.Context, items []dto.BatchItem, atomic bool, dateFormat string) (api.BatchResponse, error) {
+	log := logger.GetLogger()
+
+	resp := api.BatchResponse{
+		Atomic:  atomic,
+		Results: make([]api.BatchItemResult, len(items)),
+	}
+
+	// Текущие версии нужны изменяемым подпискам и удаляемым с проверкой версии:
+	// для отсутствующей подписки операция получает 404, а не 412.
+	var currentIDs []int64
+	var userIDs []uuid.UUID
+	for _, item := range items {
+		if len(item.Errors) > 0 {
+			continue
+		}
+		switch item.Op {
+		case api.BatchOpCreate:
+			userIDs = append(userIDs, item.Create.UserID)
+		case api.BatchOpUpdate:
+			currentIDs = append(currentIDs, item.ID)
+			userIDs = append(userIDs, item.Update.UserID)
+		case api.BatchOpDelete:
+			if item.Version != nil {
+				currentIDs = append(currentIDs, item.ID)
+			}
+		}
+	}
+	current := map[int64]*model.Subscription{}
+	if len(currentIDs) > 0 {
+		var err error
+		current, err = s.repo.GetByIDs(ctx, currentIDs)
+		if err != nil {
+			log.WithError(err).Error("failed to get subscriptions for batch update")
+			return resp, fmt.Errorf("batch failed: %w", err)
+		}
+	}
+
+	unknownUsers, err := s.users.unknown(ctx, userIDs)
+	if err != nil {
+		return resp, fmt.Errorf("batch failed: %w", err)
+	}
+	location := s.users.locations(ctx, userIDs)
+
+	resolver := s.catalog.resolver(true)
+	var ops []model.BatchOp
+	var opIndex []int
+	rejected := false
+	for i, item := range items {
+		res := &resp.Results[i]
+		res.Index = item.Index
+		res.Op = item.Op
+		res.ID = item.ID
+
+		if len(item.Errors) > 0 {
+			res.Status = http.StatusBadRequest
+			res.Error = strings.Join(item.Errors, "; ")
+			rejected = true
+			continue
+		}
+
+		var op model.BatchOp
+		switch item.Op {
+		case api.BatchOpCreate:
+			sub, err := mapper.ToModelSubscription(*item.Create, location(item.Create.UserID))
+			if err == nil {
+				err = resolveBatchItem(ctx, resolver, &sub)
+			}
+			if errors.Is(err, errBatchFailed) {
+				return resp, err
+			}
+			if err == nil && unknownUsers[sub.UserID] {
+				err = unknownUserError(sub.UserID)
+			}
+			if err != nil {
+				res.Status = http.StatusBadRequest
+				res.Error = err.Error()
+				rejected = true
+				continue
+			}
+			op = model.BatchOp{Kind: model.BatchCreate, Subscription: &sub}
+		case api.BatchOpUpdate:
+			cur, ok := current[item.ID]
+			if !ok {
+				res.Status = http.StatusNotFound
+				res.Error = "subscription not found"
+				rejected = true
+				continue
+			}
+			if item.Version != nil && *item.Version != cur.Version {
+				res.Status = http.StatusPreconditionFailed
+				res.Error = ErrPreconditionFailed.Error()
+				rejected = true
+				continue
+			}
+			sub, err := mapper.ToModelSubscriptionFromUpdate(item.ID, *item.Update, location(item.Update.UserID))
+			if err == nil {
+				err = resolveBatchItem(ctx, resolver, &sub)
+			}
+			if errors.Is(err, errBatchFailed) {
+				return resp, err
+			}
+			if err == nil && unknownUsers[sub.UserID] {
+				err = unknownUserError(sub.UserID)
+			}
+			sub.Split, sub.Members = cur.Split, cur.Members
+			if err == nil {
+				err = ownerIsMember(sub)
+			}
+			if err != nil {
+				res.Status = http.StatusBadRequest
+				res.Error = err.Error()
+				rejected = true
+				continue
+			}
+			sub.Prices = cur.Prices
+			sub.Pauses = cur.Pauses
+			op = model.BatchOp{Kind: model.BatchUpdate, Subscription: &sub, ExpectedVersion: item.Version}
+		case api.BatchOpDelete:
+			if item.Version != nil {
+				cur, ok := current[item.ID]
+				if !ok {
+					res.Status = http.StatusNotFound
+					res.Error = "subscription not found"
+					rejected = true
+					continue
+				}
+				if *item.Version != cur.Version {
+					res.Status = http.StatusPreconditionFailed
+					res.Error = ErrPreconditionFailed.Error()
+					rejected = true
+					continue
+				}
+			}
+			op = model.BatchOp{Kind: model.BatchDelete, ID: item.ID, Subscription: &model.Subscription{}, ExpectedVersion: item.Version}
+		}
+		ops = append(ops, op)
+		opIndex = append(opIndex, i)
+	}
+
+	// Периоды изменяемых и удаляемых подписок сравниваются в их новом состоянии
+	// внутри пакета, а не в сохранённом.
+	var checked []*model.Subscription
+	var checkedOps []int
+	replaced := map[int64]bool{}
+	for n, op := range ops {
+		switch op.Kind {
+		case model.BatchCreate:
+			checked = append(checked, op.Subscription)
+			checkedOps = append(checkedOps, n)
+		case model.BatchUpdate:
+			replaced[op.Subscription.ID] = true
+			checked = append(checked, op.Subscription)
+			checkedOps = append(checkedOps, n)
+		case model.BatchDelete:
+			replaced[op.ID] = true
+		}
+	}
+	overlaps, err := s.checkOverlaps(ctx, checked, current, replaced)
+	if err != nil {
+		return resp, fmt.Errorf("batch failed: %w", err)
+	}
+	overlapping := map[int]bool{}
+	for k, err := range overlaps.errs {
+		if err == nil {
+			continue
+		}
+		n := checkedOps[k]
+		res := &resp.Results[opIndex[n]]
+		res.Status = http.StatusConflict
+		res.Error = err.Error()
+		overlapping[n] = true
+		rejected = true
+	}
+	if len(overlapping) > 0 {
+		var keptOps []model.BatchOp
+		var keptIndex []int
+		for n, op := range ops {
+			if !overlapping[n] {
+				keptOps = append(keptOps, op)
+				keptIndex = append(keptIndex, opIndex[n])
+			}
+		}
+		ops, opIndex = keptOps, keptIndex
+	}
+
+	if atomic && rejected {
+		for _, i := range opIndex {
+			resp.Results[i].Status = http.StatusFailedDependency
+			resp.Results[i].Error = "not executed: batch rejected"
+		}
+		resp.Failed = len(items)
+		log.WithField("count", len(items)).Warn("Batch: atomic batch rejected by validation")
+		return resp, nil
+	}
+
+	var resolved []*model.Subscription
+	for _, op := range ops {
+		if op.Kind != model.BatchDelete {
+			resolved = append(resolved, op.Subscription)
+		}
+	}
+
+	var changed []*model.Subscription
+	if len(ops) > 0 {
+		// Как и при импорте, сервисы каталога и пользователи создаются в транзакции пакета.
+		var errs []error
+		err := s.repo.InTx(ctx, func(ctx context.Context) error {
+			if err := s.resolveNew(ctx, resolved); err != nil {
+				return err
+			}
+			if err := s.users.register(ctx, subscriptionUserIDs(resolved)); err != nil {
+				return err
+			}
+			var err error
+			errs, err = s.repo.ApplyBatch(ctx, ops, atomic)
+			if err == nil && atomic && slices.ContainsFunc(errs, func(err error) bool { return err != nil }) {
+				// Откаченный атомарный пакет не оставляет созданных для него сервисов и пользователей.
+				return errBatchRolledBack
+			}
+			return err
+		})
+		if err != nil && !errors.Is(err, errBatchRolledBack) {
+			log.WithError(err).Error("failed to apply batch in repository")
+			return resp, fmt.Errorf("batch failed: %w", err)
+		}
+		overlaps.complete()
+
+		for n, opErr := range errs {
+			res := &resp.Results[opIndex[n]]
+			op := ops[n]
+			switch {
+			case opErr == nil:
+				res.Status = batchSuccessStatus(op.Kind)
+				if op.Kind != model.BatchDelete {
+					res.ID = op.Subscription.ID
+					sub := mapper.ToResponseDTO(*op.Subscription, dateFormat)
+					res.Subscription = &sub
+				}
+				s.publishBatchOp(op)
+				if op.Kind != model.BatchDelete {
+					changed = append(changed, op.Subscription)
+				}
+			case errors.Is(opErr, repository.ErrNotFound):
+				res.Status = http.StatusNotFound
+				res.Error = "subscription not found"
+			case errors.Is(opErr, repository.ErrVersionConflict):
+				res.Status = http.StatusPreconditionFailed
+				res.Error = ErrPreconditionFailed.Error()
+			case errors.Is(opErr, repository.ErrOverlap):
+				res.Status = http.StatusConflict
+				res.Error = ErrOverlap.Error()
+			case errors.Is(opErr, repository.ErrRolledBack):
+				res.Status = http.StatusFailedDependency
+				res.Error = "rolled back"
+			default:
+				log.WithError(opErr).WithField("index", res.Index).Error("Batch: operation failed")
+				res.Status = http.StatusInternalServerError
+				res.Error = fmt.Sprintf("failed to %s subscription", op.Kind)
+			}
+		}
+	}
+
+	s.budgets.check(ctx, subscriptionUserIDs(changed))
+
+	for _, res := range resp.Results {
+		if res.Status < http.StatusBadRequest {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"atomic":    atomic,
+		"succeeded": resp.Succeeded,
+		"failed":    resp.Failed,
+	}).Info("batch processed")
+
+	return resp, nil
+}
+
+var (
+	errBatchFailed     = errors.New("batch failed")
+	errBatchRolledBack = errors.New("batch rolled back")
+)
+
+// resolveBatchItem сопоставляет сервис операции пакета с каталогом. Ошибки данных
+// относятся к операции, ошибки базы оборачивают errBatchFailed и прерывают пакет.
+func resolveBatchItem(ctx context.Context, resolver *serviceResolver, sub *model.Subscription) error {
+	err := resolver.resolve(ctx, sub)
+	if err != nil && !errors.Is(err, ErrInvalidInput) {
+		logger.GetLogger().WithError(err).Error("failed to resolve service for batch")
+		return fmt.Errorf("%w: %w", errBatchFailed, err)
+	}
+	return err
+}
+
+func (s *SubscriptionService) publishBatchOp(op model.BatchOp) {
+	switch op.Kind {
+	case model.BatchCreate:
+		s.publish(event.SubscriptionCreated, *op.Subscription)
+	case model.BatchUpdate:
+		s.publish(event.SubscriptionUpdated, *op.Subscription)
+	case model.BatchDelete:
+		s.publish(event.SubscriptionDeleted, *op.Subscription)
+	}
+}
+
+func batchSuccessStatus(kind string) int {
+	switch kind {
+	case model.BatchCreate:
+		return http.StatusCreated
+	case model.BatchDelete:
+		return http.StatusNoContent
+	default:
+		return http.StatusOK
+	}
+}
+
 func (s *SubscriptionService) GetByID(ctx context.Context, id int64) (*model.Subscription, error) {
 	log := logger.GetLogger()
 	sub, err := s.repo.GetByID(ctx, id)
@@ -60,10 +556,26 @@ func (s *SubscriptionService) GetByID(ctx context.Context, id int64) (*model.Sub
 	return sub, nil
 }
 
-func (s *SubscriptionService) GelAll(ctx context.Context) ([]model.Subscription, error) {
+func (s *SubscriptionService) List(ctx context.Context, filter dto.ListSubscriptionsFilter) ([]model.Subscription, error) {
 	log := logger.GetLogger()
 
-	subs, err := s.repo.GelAll(ctx)
+	var listFilter repository.ChargeFilter
+	if filter.UserID != "" {
+		id, err := uuid.Parse(filter.UserID)
+		if err != nil {
+			log.WithError(err).Warnf("invalid user_id format: %s", filter.UserID)
+			return nil, fmt.Errorf("%w: invalid user_id", ErrInvalidInput)
+		}
+		listFilter.UserID = &id
+	}
+	serviceID, found, err := s.catalog.filter(ctx, filter.ServiceID, filter.ServiceName)
+	if err != nil || !found {
+		return nil, err
+	}
+	listFilter.ServiceID = serviceID
+	listFilter.Category, listFilter.Tags = categoryFilter(filter.Category), model.NormalizeTags(filter.Tags)
+
+	subs, err := s.repo.List(ctx, listFilter, filter.Limit, filter.Offset)
 	if err != nil {
 		log.WithError(err).Error("failed to get subscriptions")
 		return nil, fmt.Errorf("subscriptions failed: %w", err)
@@ -76,62 +588,513 @@ func (s *SubscriptionService) GelAll(ctx context.Context) ([]model.Subscription,
 
 	return res, nil
 }
-func (s *SubscriptionService) Update(ctx context.Context, id int64, req dto.UpdateSubscriptionRequest) (model.Subscription, error) {
+
+// ListByUsers возвращает подписки нескольких пользователей одним запросом.
+func (s *SubscriptionService) ListByUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]model.Subscription, error) {
+	subs, err := s.repo.ListByUserIDs(ctx, userIDs)
+	if err != nil {
+		logger.GetLogger().WithError(err).Error("failed to get subscriptions by users")
+		return nil, fmt.Errorf("subscriptions failed: %w", err)
+	}
+
+	res := make(map[uuid.UUID][]model.Subscription, len(userIDs))
+	for _, sub := range subs {
+		res[sub.UserID] = append(res[sub.UserID], *sub)
+	}
+	return res, nil
+}
+
+// CurrentMonths возвращает текущий месяц в часовом поясе каждого из пользователей.
+func (s *SubscriptionService) CurrentMonths(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]time.Time, error) {
+	location := s.users.locations(ctx, userIDs)
+	res := make(map[uuid.UUID]time.Time, len(userIDs))
+	for _, id := range userIDs {
+		res[id] = dates.CurrentMonth(location(id))
+	}
+	return res, nil
+}
+
+// CurrentMonth возвращает текущий месяц в часовом поясе запроса или сервиса.
+func (s *SubscriptionService) CurrentMonth(ctx context.Context) time.Time {
+	return dates.CurrentMonth(s.users.resolve(ctx, nil))
+}
+
+// ListByServices возвращает подписки нескольких сервисов одним запросом.
+func (s *SubscriptionService) ListByServices(ctx context.Context, names []string) (map[string][]model.Subscription, error) {
+	subs, err := s.repo.ListByServiceNames(ctx, names)
+	if err != nil {
+		logger.GetLogger().WithError(err).Error("failed to get subscriptions by services")
+		return nil, fmt.Errorf("subscriptions failed: %w", err)
+	}
+
+	res := make(map[string][]model.Subscription, len(names))
+	for _, sub := range subs {
+		res[sub.ServiceName] = append(res[sub.ServiceName], *sub)
+	}
+	return res, nil
+}
+
+func (s *SubscriptionService) ServiceNames(ctx context.Context) ([]string, error) {
+	names, err := s.repo.ServiceNames(ctx)
+	if err != nil {
+		logger.GetLogger().WithError(err).Error("failed to get service names")
+		return nil, fmt.Errorf("service names failed: %w", err)
+	}
+	return names, nil
+}
+
+// Update полностью заменяет подписку. Если ifMatch задан, обновление выполняется
+// только для этой версии подписки, иначе возвращается ErrPreconditionFailed.
+func (s *SubscriptionService) Update(ctx context.Context, id int64, req api.UpdateSubscriptionRequest, ifMatch *int) (model.Subscription, error) {
+	current, err := s.getForUpdate(ctx, id, ifMatch)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	return s.replace(ctx, *current, req, ifMatch)
+}
+
+// Patch применяет к подписке JSON Merge Patch (RFC 7386) или JSON Patch (RFC 6902).
+// Патч применяется к представлению подписки в формате запроса PUT, результат
+// проверяется теми же правилами, что и при полной замене.
+func (s *SubscriptionService) Patch(ctx context.Context, id int64, patchType string, patch []byte, ifMatch *int) (model.Subscription, error) {
+	log := logger.GetLogger()
+
+	current, err := s.getForUpdate(ctx, id, ifMatch)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+
+	doc, err := json.Marshal(mapper.ToUpdateRequest(*current))
+	if err != nil {
+		return model.Subscription{}, fmt.Errorf("failed to encode subscription: %w", err)
+	}
+
+	var patched []byte
+	switch patchType {
+	case api.PatchTypeMerge:
+		patched, err = jsonpatch.MergePatch(doc, patch)
+		if err != nil {
+			log.WithError(err).Warn("invalid merge patch document")
+			return model.Subscription{}, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+	case api.PatchTypeJSON:
+		ops, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			log.WithError(err).Warn("invalid json patch document")
+			return model.Subscription{}, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		patched, err = ops.Apply(doc)
+		if err != nil {
+			log.WithError(err).Warn("json patch cannot be applied")
+			return model.Subscription{}, fmt.Errorf("%w: %v", ErrPatchConflict, err)
+		}
+	default:
+		return model.Subscription{}, fmt.Errorf("%w: unsupported patch type %q", ErrInvalidPatch, patchType)
+	}
+
+	var req api.UpdateSubscriptionRequest
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return model.Subscription{}, fmt.Errorf("%w: %v", ErrPatchResultInvalid, err)
+	}
+	if errs := validation.Struct(&req); len(errs) > 0 {
+		return model.Subscription{}, fmt.Errorf("%w: %s", ErrPatchResultInvalid, strings.Join(errs, "; "))
+	}
+
+	return s.replace(ctx, *current, req, &current.Version)
+}
+
+func (s *SubscriptionService) getForUpdate(ctx context.Context, id int64, ifMatch *int) (*model.Subscription, error) {
 	log := logger.GetLogger()
 
 	current, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		log.WithError(err).Errorf("failed to get subscription for update: %d", id)
-		return model.Subscription{}, fmt.Errorf("get for update failed: %w", err)
+		return nil, fmt.Errorf("get for update failed: %w", err)
 	}
 	if current == nil {
 		log.Warnf("subscription to update not found: %d", id)
-		return model.Subscription{}, fmt.Errorf("subscription not found")
+		return nil, ErrNotFound
 	}
+	if ifMatch != nil && *ifMatch != current.Version {
+		log.WithFields(logrus.Fields{
+			"id":       id,
+			"version":  current.Version,
+			"if_match": *ifMatch,
+		}).Warn("subscription version mismatch")
+		return nil, ErrPreconditionFailed
+	}
+	return current, nil
+}
 
-	updated, err := mapper.ToModelSubscriptionFromUpdate(id, req, *current)
+// replace сохраняет новое состояние подписки. Если expectedVersion задан, запись
+// обновляется только при совпадении версии.
+func (s *SubscriptionService) replace(ctx context.Context, current model.Subscription, req api.UpdateSubscriptionRequest, expectedVersion *int) (model.Subscription, error) {
+	log := logger.GetLogger()
+
+	updated, err := mapper.ToModelSubscriptionFromUpdate(current.ID, req, s.users.userLocation(ctx, req.UserID))
 	if err != nil {
-		log.WithError(err).Error("failed to map update request")
-		return model.Subscription{}, fmt.Errorf("invalid input: %w", err)
+		log.WithError(err).Warn("failed to map update request")
+		return model.Subscription{}, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if err := s.catalog.resolver(false).resolve(ctx, &updated); err != nil {
+		log.WithError(err).Warn("failed to resolve service for update")
+		return model.Subscription{}, err
+	}
+	if err := s.users.ensure(ctx, updated.UserID); err != nil {
+		log.WithError(err).Warn("failed to check user for update")
+		return model.Subscription{}, err
+	}
+	updated.Prices = current.Prices
+	updated.Pauses = current.Pauses
+	updated.Split, updated.Members = current.Split, current.Members
+	if err := ownerIsMember(updated); err != nil {
+		log.WithError(err).Warnf("subscription owner is a member: %d", current.ID)
+		return model.Subscription{}, err
+	}
+	overlaps, err := s.checkOverlaps(ctx, []*model.Subscription{&updated}, map[int64]*model.Subscription{current.ID: &current}, nil)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	if err := overlaps.errs[0]; err != nil {
+		log.WithError(err).Warnf("subscription update overlaps: %d", current.ID)
+		return model.Subscription{}, err
 	}
 
-	if err := s.repo.Update(ctx, &updated); err != nil {
-		log.WithError(err).Errorf("failed to update subscription: %d", id)
-		return model.Subscription{}, fmt.Errorf("update failed: %w", err)
+	if err := s.repo.Update(ctx, &updated, expectedVersion); err != nil {
+		log.WithError(err).Errorf("failed to update subscription: %d", current.ID)
+		return model.Subscription{}, repositoryError(err, "update failed")
 	}
 
 	log.WithFields(logrus.Fields{
 		"id":      updated.ID,
 		"user_id": updated.UserID,
+		"version": updated.Version,
 	}).Info("subscription updated")
 
+	s.publish(event.SubscriptionUpdated, updated)
+	s.budgets.check(ctx, updated.UserIDs())
+	return updated, nil
+}
+
+// SchedulePrice планирует новую цену подписки с месяца effective_from. Месяц должен быть
+// позже месяца начала подписки и не позже её окончания: начальную цену меняет Update.
+func (s *SubscriptionService) SchedulePrice(ctx context.Context, id int64, req api.SchedulePriceRequest, ifMatch *int) (model.Subscription, error) {
+	log := logger.GetLogger()
+
+	current, err := s.getForUpdate(ctx, id, ifMatch)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+
+	month, err := dates.ParseMonthIn(req.EffectiveFrom, s.users.userLocation(ctx, current.UserID))
+	if err != nil {
+		return model.Subscription{}, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if !month.After(model.MonthStart(current.StartDate)) {
+		return model.Subscription{}, fmt.Errorf("%w: effective_from must be after start_date %s, use PUT to change the initial price",
+			ErrInvalidInput, dates.FormatMonth(current.StartDate))
+	}
+	if current.EndDate != nil && month.After(*current.EndDate) {
+		return model.Subscription{}, fmt.Errorf("%w: effective_from must not be after end_date %s",
+			ErrInvalidInput, dates.FormatMonth(*current.EndDate))
+	}
+
+	change := model.PriceChange{EffectiveFrom: month, Price: req.Price}
+	if err := s.repo.SchedulePrice(ctx, id, change, &current.Version); err != nil {
+		log.WithError(err).Errorf("failed to schedule price for subscription: %d", id)
+		return model.Subscription{}, repositoryError(err, "schedule price failed")
+	}
+
+	updated, err := s.getAfterChange(ctx, id)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+
+	log.WithFields(logrus.Fields{
+		"id":             id,
+		"price":          req.Price,
+		"effective_from": req.EffectiveFrom,
+		"version":        updated.Version,
+	}).Info("subscription price change scheduled")
+
+	s.publish(event.SubscriptionUpdated, updated)
+	s.budgets.check(ctx, updated.UserIDs())
+	return updated, nil
+}
+
+// Pause приостанавливает оплату подписки с месяца from (по умолчанию следующего) по месяц
+// to включительно или до возобновления. Приостановка должна начинаться в периоде подписки
+// и не пересекаться с другими приостановками.
+func (s *SubscriptionService) Pause(ctx context.Context, id int64, req api.PauseRequest, ifMatch *int) (model.Subscription, error) {
+	log := logger.GetLogger()
+
+	current, err := s.getForUpdate(ctx, id, ifMatch)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+
+	loc := s.users.userLocation(ctx, current.UserID)
+	pause := model.Pause{From: dates.CurrentMonth(loc).AddDate(0, 1, 0)}
+	if req.From != nil {
+		if pause.From, err = dates.ParseMonthIn(*req.From, loc); err != nil {
+			return model.Subscription{}, fmt.Errorf("%w: invalid from: %v", ErrInvalidInput, err)
+		}
+	}
+	if req.To != nil {
+		to, err := dates.ParseMonthIn(*req.To, loc)
+		if err != nil {
+			return model.Subscription{}, fmt.Errorf("%w: invalid to: %v", ErrInvalidInput, err)
+		}
+		if to.Before(pause.From) {
+			return model.Subscription{}, fmt.Errorf("%w: to must not be before from", ErrInvalidInput)
+		}
+		pause.To = &to
+	}
+	if pause.From.Before(model.MonthStart(current.StartDate)) {
+		return model.Subscription{}, fmt.Errorf("%w: from must not be before start_date %s",
+			ErrInvalidInput, dates.FormatMonth(current.StartDate))
+	}
+	if current.EndDate != nil && pause.From.After(*current.EndDate) {
+		return model.Subscription{}, fmt.Errorf("%w: from must not be after end_date %s",
+			ErrInvalidInput, dates.FormatMonth(*current.EndDate))
+	}
+
+	if err := s.repo.Pause(ctx, id, pause, &current.Version); err != nil {
+		if errors.Is(err, repository.ErrPauseOverlap) {
+			log.WithField("id", id).Warn("subscription is already paused")
+			return model.Subscription{}, ErrAlreadyPaused
+		}
+		log.WithError(err).Errorf("failed to pause subscription: %d", id)
+		return model.Subscription{}, repositoryError(err, "pause failed")
+	}
+
+	updated, err := s.getAfterChange(ctx, id)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	log.WithFields(logrus.Fields{
+		"id":      id,
+		"from":    dates.FormatMonth(pause.From),
+		"version": updated.Version,
+	}).Info("subscription paused")
+
+	s.publish(event.SubscriptionPaused, updated)
+	s.budgets.check(ctx, updated.UserIDs())
 	return updated, nil
 }
 
-func (s *SubscriptionService) Delete(ctx context.Context, id int64) error {
+// Resume возобновляет оплату подписки с месяца from, по умолчанию следующего.
+// Запланированные после него приостановки отменяются.
+func (s *SubscriptionService) Resume(ctx context.Context, id int64, req api.ResumeRequest, ifMatch *int) (model.Subscription, error) {
 	log := logger.GetLogger()
 
-	if err := s.repo.Delete(ctx, id); err != nil {
+	current, err := s.getForUpdate(ctx, id, ifMatch)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+
+	loc := s.users.userLocation(ctx, current.UserID)
+	month := dates.CurrentMonth(loc).AddDate(0, 1, 0)
+	if req.From != nil {
+		if month, err = dates.ParseMonthIn(*req.From, loc); err != nil {
+			return model.Subscription{}, fmt.Errorf("%w: invalid from: %v", ErrInvalidInput, err)
+		}
+	}
+
+	if err := s.repo.Resume(ctx, id, month, &current.Version); err != nil {
+		if errors.Is(err, repository.ErrNotPaused) {
+			log.WithField("id", id).Warn("subscription to resume is not paused")
+			return model.Subscription{}, ErrNotPaused
+		}
+		log.WithError(err).Errorf("failed to resume subscription: %d", id)
+		return model.Subscription{}, repositoryError(err, "resume failed")
+	}
+
+	updated, err := s.getAfterChange(ctx, id)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	log.WithFields(logrus.Fields{
+		"id":      id,
+		"from":    dates.FormatMonth(month),
+		"version": updated.Version,
+	}).Info("subscription resumed")
+
+	s.publish(event.SubscriptionResumed, updated)
+	s.budgets.check(ctx, updated.UserIDs())
+	return updated, nil
+}
+
+// Cancel отменяет подписку: устанавливает end_date по режиму отмены и сохраняет причину
+// для отчёта об оттоке. Подписку, которая ещё не началась, нужно удалить, а не отменить.
+func (s *SubscriptionService) Cancel(ctx context.Context, id int64, req api.CancelRequest, ifMatch *int) (model.Cancellation, model.Subscription, error) {
+	log := logger.GetLogger()
+
+	current, err := s.getForUpdate(ctx, id, ifMatch)
+	if err != nil {
+		return model.Cancellation{}, model.Subscription{}, err
+	}
+
+	loc := s.users.userLocation(ctx, current.UserID)
+	month := dates.CurrentMonth(loc)
+	if current.EndDate != nil && current.EndDate.Before(month) {
+		return model.Cancellation{}, model.Subscription{}, fmt.Errorf("%w on %s",
+			ErrAlreadyEnded, dates.FormatMonth(*current.EndDate))
+	}
+	if current.StartDate.After(month) {
+		return model.Cancellation{}, model.Subscription{}, fmt.Errorf("%w: starts on %s, delete it instead",
+			ErrNotStarted, dates.FormatMonth(current.StartDate))
+	}
+
+	c := model.Cancellation{
+		SubscriptionID: id,
+		UserID:         current.UserID,
+		ServiceID:      current.ServiceID,
+		Reason:         req.Reason,
+		Note:           req.Note,
+		Mode:           req.Mode,
+	}
+	if c.Mode == "" {
+		c.Mode = model.CancelEndOfPeriod
+	}
+	c.EndDate = cancelEnd(*current, c.Mode, month)
+	c.Price = current.PriceIn(c.EndDate)
+
+	if err := s.repo.Cancel(ctx, &c, &current.Version); err != nil {
+		log.WithError(err).Errorf("failed to cancel subscription: %d", id)
+		return model.Cancellation{}, model.Subscription{}, repositoryError(err, "cancel failed")
+	}
+
+	updated, err := s.getAfterChange(ctx, id)
+	if err != nil {
+		return model.Cancellation{}, model.Subscription{}, err
+	}
+	log.WithFields(logrus.Fields{
+		"id":       id,
+		"reason":   c.Reason,
+		"mode":     c.Mode,
+		"end_date": dates.FormatMonth(c.EndDate),
+		"version":  updated.Version,
+	}).Info("subscription cancelled")
+
+	s.publish(event.SubscriptionCancelled, updated)
+	s.budgets.check(ctx, updated.UserIDs())
+	return c, updated, nil
+}
+
+// cancelEnd возвращает последний месяц подписки, отменённой в месяце month. В конце
+// периода подписка в пробном периоде заканчивается последним бесплатным месяцем.
+// Более ранний end_date сохраняется.
+func cancelEnd(sub model.Subscription, mode string, month time.Time) time.Time {
+	end := month
+	if mode == model.CancelEndOfPeriod && sub.InTrialOn(month) {
+		if last := model.MonthStart(sub.TrialEndsAt.AddDate(0, 0, -1)); last.After(end) {
+			end = last
+		}
+	}
+	if sub.EndDate != nil && sub.EndDate.Before(end) {
+		end = *sub.EndDate
+	}
+	return end
+}
+
+// getAfterChange перечитывает подписку после изменения её цен или приостановок.
+func (s *SubscriptionService) getAfterChange(ctx context.Context, id int64) (model.Subscription, error) {
+	updated, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		logger.GetLogger().WithError(err).Errorf("failed to get subscription after change: %d", id)
+		return model.Subscription{}, fmt.Errorf("get by id failed: %w", err)
+	}
+	if updated == nil {
+		return model.Subscription{}, ErrNotFound
+	}
+	return *updated, nil
+}
+
+func (s *SubscriptionService) Delete(ctx context.Context, id int64, ifMatch *int) error {
+	log := logger.GetLogger()
+
+	deleted, err := s.repo.Delete(ctx, id, ifMatch)
+	if err != nil {
+		// Удаление без If-Match идемпотентно, а версию отсутствующей подписки проверить нельзя.
+		if errors.Is(err, repository.ErrNotFound) && ifMatch == nil {
+			log.WithField("id", id).Info("subscription to delete not found")
+			return nil
+		}
 		log.WithError(err).Errorf("failed to delete subscription: %d", id)
-		return fmt.Errorf("delete failed: %w", err)
+		return repositoryError(err, "delete failed")
 	}
 
 	log.WithField("id", id).Info("subscription deleted")
+	s.publish(event.SubscriptionDeleted, *deleted)
 	return nil
 }
 
+func repositoryError(err error, msg string) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrNotFound
+	case errors.Is(err, repository.ErrVersionConflict):
+		return ErrPreconditionFailed
+	case errors.Is(err, repository.ErrOverlap):
+		return ErrOverlap
+	default:
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+}
+
 func (s *SubscriptionService) TotalPrice(ctx context.Context, req dto.TotalPriceFilterDTO) (int, error) {
+	sum, _, err := s.total(ctx, req, "")
+	return sum, err
+}
+
+// TotalByGroup считает сумму так же, как TotalPrice, и разбивает её по группам req.GroupBy.
+// Подписка с несколькими метками входит в группу каждой из них.
+func (s *SubscriptionService) TotalByGroup(ctx context.Context, req dto.TotalPriceFilterDTO) (int, []model.SpendingBucket, error) {
+	return s.total(ctx, req, req.GroupBy)
+}
+
+func (s *SubscriptionService) total(ctx context.Context, req dto.TotalPriceFilterDTO, groupBy string) (int, []model.SpendingBucket, error) {
 	log := logger.GetLogger()
 
 	userUUID, err := uuid.Parse(req.UserID)
 	if err != nil {
 		log.WithError(err).Errorf("invalid user_id format: %s", req.UserID)
-		return 0, fmt.Errorf("invalid user_id: %w", err)
+		return 0, nil, fmt.Errorf("%w: invalid user_id", ErrInvalidInput)
+	}
+	loc := s.users.userLocation(ctx, userUUID)
+	from, err := parseFilterDate(req.FromDate, loc)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: invalid from_date: %v", ErrInvalidInput, err)
+	}
+	to, err := parseFilterDate(req.ToDate, loc)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: invalid to_date: %v", ErrInvalidInput, err)
+	}
+	serviceID, found, err := s.catalog.filter(ctx, req.ServiceID, req.ServiceName)
+	if err != nil || !found {
+		return 0, nil, err
 	}
-	sum, err := s.repo.TotalSumSubscription(ctx, &userUUID, &req.ServiceName, req.FromDate, req.ToDate)
+	filter := repository.ChargeFilter{
+		UserID:    &userUUID,
+		ServiceID: serviceID,
+		Category:  categoryFilter(req.Category),
+		Tags:      model.NormalizeTags(req.Tags),
+	}
+
+	sum, err := s.repo.ChargesSum(ctx, filter, from, to)
 	if err != nil {
 		log.WithError(err).Error("failed to calculate total subscription price")
-		return 0, fmt.Errorf("calculate total failed: %w", err)
+		return 0, nil, fmt.Errorf("calculate total failed: %w", err)
+	}
+	var groups []model.SpendingBucket
+	if groupBy != "" {
+		if groups, err = s.repo.ChargesByGroup(ctx, filter, from, to, groupBy); err != nil {
+			log.WithError(err).Error("failed to group total subscription price")
+			return 0, nil, fmt.Errorf("calculate total failed: %w", err)
+		}
 	}
 
 	log.WithFields(logrus.Fields{
@@ -139,8 +1102,18 @@ func (s *SubscriptionService) TotalPrice(ctx context.Context, req dto.TotalPrice
 		"service_name": req.ServiceName,
 		"from":         req.FromDate,
 		"to":           req.ToDate,
+		"group_by":     groupBy,
 		"sum":          sum,
 	}).Info("calculated total subscription price")
 
-	return sum, nil
+	return sum, groups, nil
+}
+
+// parseFilterDate разбирает границу периода фильтра в часовом поясе loc; пустая строка
+// даёт нулевую дату, которую репозиторий считает открытой границей.
+func parseFilterDate(s string, loc *time.Location) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return dates.ParseIn(s, loc)
 }
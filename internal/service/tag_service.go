@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/event"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+// TagService управляет метками подписок. Метки создаются при сохранении подписок, поэтому
+// отдельного создания нет: сервис показывает метки и переименовывает или объединяет их
+// во всех подписках сразу.
+type TagService struct {
+	repo     TagStore
+	subsRepo SubscriptionStore
+	broker   *event.Broker
+}
+
+func NewTagService(repo TagStore, subsRepo SubscriptionStore, broker *event.Broker) *TagService {
+	return &TagService{
+		repo:     repo,
+		subsRepo: subsRepo,
+		broker:   broker,
+	}
+}
+
+func (s *TagService) GetByID(ctx context.Context, id int64) (*model.Tag, error) {
+	tag, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		logger.GetLogger().WithError(err).Errorf("failed to get tag by ID: %d", id)
+		return nil, fmt.Errorf("get tag failed: %w", err)
+	}
+	return tag, nil
+}
+
+// List возвращает метки по убыванию числа подписок с ними.
+func (s *TagService) List(ctx context.Context, filter dto.ListTagsFilter) ([]model.Tag, error) {
+	var query *string
+	if filter.Query != "" {
+		query = &filter.Query
+	}
+
+	tags, err := s.repo.List(ctx, query, filter.Limit, filter.Offset)
+	if err != nil {
+		logger.GetLogger().WithError(err).Error("failed to get tags")
+		return nil, fmt.Errorf("tags failed: %w", err)
+	}
+
+	res := make([]model.Tag, 0, len(tags))
+	for _, tag := range tags {
+		res = append(res, *tag)
+	}
+	return res, nil
+}
+
+// Rename переименовывает метку во всех подписках. Занятое другой меткой название не
+// принимается: такие метки нужно объединить.
+func (s *TagService) Rename(ctx context.Context, id int64, req api.RenameTagRequest) (model.Tag, error) {
+	log := logger.GetLogger()
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return model.Tag{}, fmt.Errorf("%w: tag name must not be blank", ErrInvalidInput)
+	}
+
+	changed, err := s.repo.Rename(ctx, id, name)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTagNotFound):
+			log.WithField("id", id).Warn("tag to rename not found")
+			return model.Tag{}, ErrTagNotFound
+		case errors.Is(err, repository.ErrTagExists):
+			log.WithField("name", name).Warn("tag name already exists")
+			return model.Tag{}, ErrTagExists
+		}
+		log.WithError(err).Errorf("failed to rename tag: %d", id)
+		return model.Tag{}, fmt.Errorf("rename tag failed: %w", err)
+	}
+	s.publishChanged(ctx, changed)
+
+	log.WithFields(logrus.Fields{
+		"id":            id,
+		"name":          name,
+		"subscriptions": len(changed),
+	}).Info("tag renamed")
+	return s.get(ctx, id)
+}
+
+// Merge переносит подписки меток req.SourceIDs на метку req.TargetID и удаляет исходные метки.
+func (s *TagService) Merge(ctx context.Context, req api.MergeTagsRequest) (model.Tag, error) {
+	log := logger.GetLogger()
+
+	seen := map[int64]bool{}
+	var sources []int64
+	for _, id := range req.SourceIDs {
+		if id == req.TargetID {
+			return model.Tag{}, fmt.Errorf("%w: source_ids must not contain target_id", ErrInvalidInput)
+		}
+		if !seen[id] {
+			seen[id] = true
+			sources = append(sources, id)
+		}
+	}
+
+	changed, err := s.repo.Merge(ctx, sources, req.TargetID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTagNotFound) {
+			log.WithField("target_id", req.TargetID).Warn("tag to merge not found")
+			return model.Tag{}, ErrTagNotFound
+		}
+		log.WithError(err).Errorf("failed to merge tags into %d", req.TargetID)
+		return model.Tag{}, fmt.Errorf("merge tags failed: %w", err)
+	}
+	s.publishChanged(ctx, changed)
+
+	log.WithFields(logrus.Fields{
+		"source_ids":    sources,
+		"target_id":     req.TargetID,
+		"subscriptions": len(changed),
+	}).Info("tags merged")
+	return s.get(ctx, req.TargetID)
+}
+
+func (s *TagService) get(ctx context.Context, id int64) (model.Tag, error) {
+	tag, err := s.GetByID(ctx, id)
+	if err != nil {
+		return model.Tag{}, err
+	}
+	if tag == nil {
+		return model.Tag{}, ErrTagNotFound
+	}
+	return *tag, nil
+}
+
+// publishChanged публикует SubscriptionUpdated для подписок, метки которых изменились.
+// Ошибка чтения подписок только логируется: изменение уже сохранено.
+func (s *TagService) publishChanged(ctx context.Context, ids []int64) {
+	if len(ids) == 0 {
+		return
+	}
+	subs, err := s.subsRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		logger.GetLogger().WithError(err).Error("failed to get subscriptions with changed tags")
+		return
+	}
+	for _, id := range ids {
+		if sub, ok := subs[id]; ok {
+			s.broker.Publish(event.Event{
+				Type:           event.SubscriptionUpdated,
+				SubscriptionID: sub.ID,
+				Subscription:   sub,
+			})
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/dates"
+	"github.com/shenikar/subscription-service/internal/event"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// TrialNotifier предупреждает об окончании пробных периодов: за days дней до перехода
+// подписки в платную публикует событие SubscriptionTrialEnding. О каждой дате окончания
+// уведомление отправляется один раз.
+type TrialNotifier struct {
+	repo     *repository.SubscriptionRepository
+	users    *UserService
+	broker   *event.Broker
+	days     int
+	interval time.Duration
+}
+
+func NewTrialNotifier(repo *repository.SubscriptionRepository, users *UserService, broker *event.Broker, days int, interval time.Duration) *TrialNotifier {
+	return &TrialNotifier{
+		repo:     repo,
+		users:    users,
+		broker:   broker,
+		days:     days,
+		interval: interval,
+	}
+}
+
+// Run проверяет пробные периоды сразу и затем каждые interval, пока не отменён ctx.
+func (n *TrialNotifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(n.interval)
+	defer ticker.Stop()
+
+	for {
+		n.notify(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// notify отправляет уведомления о пробных периодах, до окончания которых в часовом поясе
+// пользователя осталось не больше days дней, включая день перехода в платную подписку:
+// при days = 0 уведомление отправляется только в этот день. Подписки выбираются с запасом
+// в день с каждой стороны, чтобы учесть пользователей в разных часовых поясах.
+func (n *TrialNotifier) notify(ctx context.Context) {
+	log := logger.GetLogger()
+
+	today := dates.Today(time.UTC)
+	subs, err := n.repo.TrialsEnding(ctx, today.AddDate(0, 0, -1), today.AddDate(0, 0, n.days+1))
+	if err != nil {
+		log.WithError(err).Error("failed to get ending trials")
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+
+	userIDs := make([]uuid.UUID, len(subs))
+	for i, sub := range subs {
+		userIDs[i] = sub.UserID
+	}
+	location := n.users.locations(ctx, userIDs)
+
+	for _, sub := range subs {
+		userToday := dates.Today(location(sub.UserID))
+		if sub.TrialEndsAt.Before(userToday) || sub.TrialEndsAt.After(userToday.AddDate(0, 0, n.days)) {
+			continue
+		}
+		recorded, err := n.repo.RecordTrialNotice(ctx, sub.ID, *sub.TrialEndsAt)
+		if err != nil {
+			log.WithError(err).WithField("id", sub.ID).Error("failed to record trial notification")
+			continue
+		}
+		if !recorded {
+			continue
+		}
+
+		log.WithFields(logrus.Fields{
+			"id":            sub.ID,
+			"user_id":       sub.UserID,
+			"trial_ends_at": dates.Format(*sub.TrialEndsAt, dates.Date),
+		}).Info("subscription trial is ending")
+		n.broker.Publish(event.Event{
+			Type:           event.SubscriptionTrialEnding,
+			SubscriptionID: sub.ID,
+			Subscription:   sub,
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/dates"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/mapper"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultRenewalWindowDays = 30
+
+type UserService struct {
+	repo     UserStore
+	subsRepo SubscriptionStore
+	// registerUnknown включает режим для старых данных: подписка с неизвестным
+	// user_id не отклоняется, а пользователь регистрируется без профиля.
+	registerUnknown bool
+	// location — часовой пояс сервиса для пользователей, у которых он не задан.
+	location *time.Location
+}
+
+func NewUserService(repo UserStore, subsRepo SubscriptionStore, registerUnknown bool, location *time.Location) *UserService {
+	return &UserService{
+		repo:            repo,
+		subsRepo:        subsRepo,
+		registerUnknown: registerUnknown,
+		location:        location,
+	}
+}
+
+func (s *UserService) Create(ctx context.Context, req api.UserRequest) (model.User, error) {
+	log := logger.GetLogger()
+
+	var id uuid.UUID
+	if req.ID != nil {
+		id = *req.ID
+	}
+	user := mapper.ToModelUser(id, req)
+
+	if err := s.repo.Create(ctx, &user); err != nil {
+		if errors.Is(err, repository.ErrUserExists) {
+			log.WithField("id", id).Warn("user id or email already exists")
+			return model.User{}, ErrUserExists
+		}
+		log.WithError(err).Error("failed to create user in repository")
+		return model.User{}, fmt.Errorf("could not create user: %w", err)
+	}
+
+	log.WithField("id", user.ID).Info("user created successfully")
+	return user, nil
+}
+
+func (s *UserService) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	log := logger.GetLogger()
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		log.WithError(err).Errorf("failed to get user by ID: %s", id)
+		return nil, fmt.Errorf("get user failed: %w", err)
+	}
+	if user == nil {
+		log.Warnf("user not found: %s", id)
+		return nil, nil
+	}
+	return user, nil
+}
+
+func (s *UserService) List(ctx context.Context, filter dto.ListUsersFilter) ([]model.User, error) {
+	var query *string
+	if filter.Query != "" {
+		query = &filter.Query
+	}
+
+	users, err := s.repo.List(ctx, query, filter.Limit, filter.Offset)
+	if err != nil {
+		logger.GetLogger().WithError(err).Error("failed to get users")
+		return nil, fmt.Errorf("users failed: %w", err)
+	}
+
+	res := make([]model.User, 0, len(users))
+	for _, user := range users {
+		res = append(res, *user)
+	}
+	return res, nil
+}
+
+// Update полностью заменяет профиль пользователя, ID в запросе не учитывается.
+func (s *UserService) Update(ctx context.Context, id uuid.UUID, req api.UserRequest) (model.User, error) {
+	log := logger.GetLogger()
+
+	user := mapper.ToModelUser(id, req)
+	if err := s.repo.Update(ctx, &user); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound):
+			log.Warnf("user to update not found: %s", id)
+			return model.User{}, ErrUserNotFound
+		case errors.Is(err, repository.ErrUserExists):
+			log.WithField("id", id).Warn("user email already exists")
+			return model.User{}, ErrUserExists
+		}
+		log.WithError(err).Errorf("failed to update user: %s", id)
+		return model.User{}, fmt.Errorf("update user failed: %w", err)
+	}
+
+	log.WithField("id", id).Info("user updated")
+	return user, nil
+}
+
+func (s *UserService) Delete(ctx context.Context, id uuid.UUID) error {
+	log := logger.GetLogger()
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound):
+			log.WithField("id", id).Info("user to delete not found")
+			return ErrUserNotFound
+		case errors.Is(err, repository.ErrUserInUse):
+			log.WithField("id", id).Warn("user to delete has subscriptions")
+			return ErrUserInUse
+		case errors.Is(err, repository.ErrUserIsMember):
+			log.WithField("id", id).Warn("user to delete is a member of shared subscriptions")
+			return ErrUserIsMember
+		case errors.Is(err, repository.ErrUserInOrganisation):
+			log.WithField("id", id).Warn("user to delete is a member of organisations")
+			return ErrUserInOrganisation
+		}
+		log.WithError(err).Errorf("failed to delete user: %s", id)
+		return fmt.Errorf("delete user failed: %w", err)
+	}
+
+	log.WithField("id", id).Info("user deleted")
+	return nil
+}
+
+// Summary возвращает сводку по подпискам пользователя: количество, расходы за текущий
+// месяц и продления в ближайшие withinDays дней (по умолчанию 30). Подписки
+// оплачиваются первого числа каждого месяца.
+func (s *UserService) Summary(ctx context.Context, id uuid.UUID, withinDays int) (api.UserSummaryResponse, error) {
+	log := logger.GetLogger()
+
+	user, err := s.GetByID(ctx, id)
+	if err != nil {
+		return api.UserSummaryResponse{}, err
+	}
+	if user == nil {
+		return api.UserSummaryResponse{}, ErrUserNotFound
+	}
+
+	subs, err := s.subsRepo.ListByUserIDs(ctx, []uuid.UUID{id})
+	if err != nil {
+		log.WithError(err).Errorf("failed to get subscriptions for user summary: %s", id)
+		return api.UserSummaryResponse{}, fmt.Errorf("user summary failed: %w", err)
+	}
+
+	if withinDays <= 0 {
+		withinDays = defaultRenewalWindowDays
+	}
+	loc := s.resolve(ctx, user.TimeZone)
+	today := dates.Today(loc)
+	month := dates.CurrentMonth(loc)
+	horizon := today.AddDate(0, 0, withinDays)
+
+	// Ближайшее списание — первое число месяца, начиная с сегодняшнего дня.
+	nextCharge := month
+	if nextCharge.Before(today) {
+		nextCharge = month.AddDate(0, 1, 0)
+	}
+
+	res := api.UserSummaryResponse{
+		UserID:            id,
+		SubscriptionCount: len(subs),
+		UpcomingRenewals:  []api.RenewalResponse{},
+	}
+	var renewals []*model.Subscription
+	renewsOn := make(map[int64]time.Time)
+	for _, sub := range subs {
+		if sub.ActiveIn(month) {
+			res.ActiveCount++
+		}
+		if price, ok := sub.ChargeIn(month); ok {
+			res.MonthlySpend += price
+		}
+
+		renewal := nextCharge
+		if sub.StartDate.After(renewal) {
+			renewal = sub.StartDate
+		}
+		if _, ok := sub.ChargeIn(renewal); renewal.After(horizon) || !ok {
+			continue
+		}
+		renewals = append(renewals, sub)
+		renewsOn[sub.ID] = renewal
+	}
+
+	slices.SortStableFunc(renewals, func(a, b *model.Subscription) int {
+		return renewsOn[a.ID].Compare(renewsOn[b.ID])
+	})
+	reminder := time.Duration(user.ReminderDays) * 24 * time.Hour
+	for _, sub := range renewals {
+		renewal := renewsOn[sub.ID]
+		res.UpcomingRenewals = append(res.UpcomingRenewals, api.RenewalResponse{
+			SubscriptionID: sub.ID,
+			ServiceID:      sub.ServiceID,
+			ServiceName:    sub.ServiceName,
+			Price:          sub.PriceIn(renewal),
+			RenewsOn:       renewal.Format("02-01-2006"),
+			ReminderDue:    renewal.Sub(today) <= reminder,
+		})
+	}
+
+	log.WithFields(logrus.Fields{
+		"user_id":  id,
+		"active":   res.ActiveCount,
+		"spend":    res.MonthlySpend,
+		"renewals": len(res.UpcomingRenewals),
+	}).Info("user summary calculated")
+
+	return res, nil
+}
+
+// unknown возвращает незарегистрированных пользователей из ids. В режиме регистрации
+// неизвестные пользователи не считаются ошибкой, и результат пуст.
+func (s *UserService) unknown(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	res := make(map[uuid.UUID]bool)
+	if s.registerUnknown || len(ids) == 0 {
+		return res, nil
+	}
+
+	missing, err := s.repo.Missing(ctx, ids)
+	if err != nil {
+		logger.GetLogger().WithError(err).Error("failed to check users")
+		return nil, fmt.Errorf("check users failed: %w", err)
+	}
+	for _, id := range missing {
+		res[id] = true
+	}
+	return res, nil
+}
+
+// register регистрирует неизвестных пользователей перед сохранением подписок,
+// если включён режим регистрации.
+func (s *UserService) register(ctx context.Context, ids []uuid.UUID) error {
+	if !s.registerUnknown || len(ids) == 0 {
+		return nil
+	}
+	if err := s.repo.Register(ctx, ids); err != nil {
+		logger.GetLogger().WithError(err).Error("failed to register users")
+		return fmt.Errorf("register users failed: %w", err)
+	}
+	return nil
+}
+
+// ensure проверяет пользователя подписки перед сохранением: в строгом режиме неизвестный
+// пользователь — ошибка данных, в режиме регистрации он создаётся.
+func (s *UserService) ensure(ctx context.Context, id uuid.UUID) error {
+	unknown, err := s.unknown(ctx, []uuid.UUID{id})
+	if err != nil {
+		return err
+	}
+	if unknown[id] {
+		return unknownUserError(id)
+	}
+	return s.register(ctx, []uuid.UUID{id})
+}
+
+func subscriptionUserIDs(subs []*model.Subscription) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(subs))
+	var ids []uuid.UUID
+	for _, sub := range subs {
+		for _, id := range sub.UserIDs() {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+func unknownUserError(id uuid.UUID) error {
+	return fmt.Errorf("%w: unknown user_id %s", ErrInvalidInput, id)
+}
+
+// resolve возвращает часовой пояс запроса: явно заданный в запросе, иначе часовой пояс
+// пользователя zone, иначе часовой пояс сервиса.
+func (s *UserService) resolve(ctx context.Context, zone *string) *time.Location {
+	if loc, ok := dates.LocationFrom(ctx); ok {
+		return loc
+	}
+	if zone != nil {
+		loc, err := dates.LoadLocation(*zone)
+		if err == nil {
+			return loc
+		}
+		logger.GetLogger().WithError(err).Warn("invalid user time zone, using default")
+	}
+	return s.location
+}
+
+// locations возвращает часовые пояса пользователей ids по правилам resolve. Если пояса
+// не удалось прочитать, используется часовой пояс сервиса.
+func (s *UserService) locations(ctx context.Context, ids []uuid.UUID) func(uuid.UUID) *time.Location {
+	if loc, ok := dates.LocationFrom(ctx); ok {
+		return func(uuid.UUID) *time.Location { return loc }
+	}
+
+	res := make(map[uuid.UUID]*time.Location)
+	if len(ids) > 0 {
+		zones, err := s.repo.TimeZones(ctx, ids)
+		if err != nil {
+			logger.GetLogger().WithError(err).Error("failed to get user time zones, using default")
+		}
+		for id, zone := range zones {
+			res[id] = s.resolve(ctx, &zone)
+		}
+	}
+	return func(id uuid.UUID) *time.Location {
+		if loc, ok := res[id]; ok {
+			return loc
+		}
+		return s.location
+	}
+}
+
+// userLocation возвращает часовой пояс пользователя id по правилам resolve.
+func (s *UserService) userLocation(ctx context.Context, id uuid.UUID) *time.Location {
+	return s.locations(ctx, []uuid.UUID{id})(id)
+}
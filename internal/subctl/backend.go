@@ -0,0 +1,318 @@
+package subctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shenikar/subscription-service/internal/config"
+	"github.com/shenikar/subscription-service/internal/dates"
+	"github.com/shenikar/subscription-service/internal/db"
+	"github.com/shenikar/subscription-service/internal/dto"
+	"github.com/shenikar/subscription-service/internal/event"
+	"github.com/shenikar/subscription-service/internal/importer"
+	"github.com/shenikar/subscription-service/internal/mapper"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/internal/service"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"github.com/shenikar/subscription-service/pkg/client"
+)
+
+const (
+	formatCSV  = "csv"
+	formatJSON = "json"
+	formatYAML = "yaml"
+)
+
+var errNotFound = errors.New("subscription not found")
+
+type listFilter struct {
+	UserID      uuid.UUID
+	ServiceID   int64
+	ServiceName string
+	Limit       int
+	Offset      int
+	// All выбирает все страницы, Limit при этом задаёт размер страницы.
+	All bool
+}
+
+type totalFilter struct {
+	UserID      uuid.UUID
+	ServiceID   int64
+	ServiceName string
+	From        time.Time
+	To          time.Time
+}
+
+type importOptions struct {
+	Format  string
+	DryRun  bool
+	Mode    string
+	Columns string
+}
+
+// backend — общий интерфейс для работы через HTTP API и напрямую с базой.
+type backend interface {
+	Create(ctx context.Context, req api.CreateSubscriptionRequest) (api.SubscriptionResponse, error)
+	Get(ctx context.Context, id int64) (api.SubscriptionResponse, error)
+	List(ctx context.Context, filter listFilter) ([]api.SubscriptionResponse, error)
+	Update(ctx context.Context, id int64, req api.UpdateSubscriptionRequest, ifMatch *int) (api.SubscriptionResponse, error)
+	Delete(ctx context.Context, id int64, ifMatch *int) error
+	Total(ctx context.Context, filter totalFilter) (int, error)
+	Import(ctx context.Context, data []byte, opts importOptions) (api.ImportReport, error)
+	Close()
+}
+
+type httpBackend struct {
+	client *client.Client
+}
+
+func newHTTPBackend(p Profile) (*httpBackend, error) {
+	if p.URL == "" {
+		return nil, errors.New("api url is not set: use --url or a profile with url")
+	}
+
+	var opts []client.Option
+	if p.Token != "" {
+		opts = append(opts, client.WithBearerToken(p.Token))
+	}
+	c, err := client.New(p.URL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &httpBackend{client: c}, nil
+}
+
+func (b *httpBackend) Create(ctx context.Context, req api.CreateSubscriptionRequest) (api.SubscriptionResponse, error) {
+	sub, err := b.client.Create(ctx, req)
+	if err != nil {
+		return api.SubscriptionResponse{}, err
+	}
+	return *sub, nil
+}
+
+func (b *httpBackend) Get(ctx context.Context, id int64) (api.SubscriptionResponse, error) {
+	sub, err := b.client.Get(ctx, id)
+	if client.IsNotFound(err) {
+		return api.SubscriptionResponse{}, errNotFound
+	}
+	if err != nil {
+		return api.SubscriptionResponse{}, err
+	}
+	return *sub, nil
+}
+
+func (b *httpBackend) List(ctx context.Context, filter listFilter) ([]api.SubscriptionResponse, error) {
+	opts := client.ListOptions{
+		UserID:      filter.UserID,
+		ServiceID:   filter.ServiceID,
+		ServiceName: filter.ServiceName,
+		Limit:       filter.Limit,
+		Offset:      filter.Offset,
+	}
+	if !filter.All {
+		return b.client.List(ctx, opts)
+	}
+
+	var res []api.SubscriptionResponse
+	for sub, err := range b.client.All(ctx, opts) {
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, sub)
+	}
+	return res, nil
+}
+
+func (b *httpBackend) Update(ctx context.Context, id int64, req api.UpdateSubscriptionRequest, ifMatch *int) (api.SubscriptionResponse, error) {
+	var opts []client.RequestOption
+	if ifMatch != nil {
+		opts = append(opts, client.WithIfMatch(*ifMatch))
+	}
+	sub, err := b.client.Update(ctx, id, req, opts...)
+	if err != nil {
+		return api.SubscriptionResponse{}, err
+	}
+	return *sub, nil
+}
+
+func (b *httpBackend) Delete(ctx context.Context, id int64, ifMatch *int) error {
+	var opts []client.RequestOption
+	if ifMatch != nil {
+		opts = append(opts, client.WithIfMatch(*ifMatch))
+	}
+	return b.client.Delete(ctx, id, opts...)
+}
+
+func (b *httpBackend) Total(ctx context.Context, filter totalFilter) (int, error) {
+	return b.client.Total(ctx, client.TotalOptions{
+		UserID:      filter.UserID,
+		ServiceID:   filter.ServiceID,
+		ServiceName: filter.ServiceName,
+		From:        filter.From,
+		To:          filter.To,
+	})
+}
+
+func (b *httpBackend) Import(ctx context.Context, data []byte, opts importOptions) (api.ImportReport, error) {
+	importOpts := client.ImportOptions{DryRun: opts.DryRun, Mode: opts.Mode, Columns: opts.Columns}
+
+	var contentType string
+	switch opts.Format {
+	case formatCSV:
+		contentType = "text/csv"
+	case formatJSON:
+		contentType = "application/json"
+	default:
+		return api.ImportReport{}, fmt.Errorf("unsupported import format %q", opts.Format)
+	}
+
+	// Маппинг колонок применяет сервер, поэтому файл отправляется как есть.
+	report, err := b.client.Import(ctx, bytes.NewReader(data), contentType, importOpts)
+	if report == nil {
+		return api.ImportReport{}, err
+	}
+	return *report, err
+}
+
+func (b *httpBackend) Close() {}
+
+// dbBackend работает с базой напрямую через service.SubscriptionService, минуя HTTP.
+type dbBackend struct {
+	pool    *pgxpool.Pool
+	service *service.SubscriptionService
+}
+
+func newDBBackend(p Profile) (*dbBackend, error) {
+	cfg := config.LoadConfig()
+	if p.DB != nil {
+		cfg.DBHost = p.DB.Host
+		cfg.DBPort = p.DB.Port
+		cfg.DBUser = p.DB.User
+		cfg.DBPassword = p.DB.Password
+		cfg.DBName = p.DB.Name
+		cfg.DBSSLMode = p.DB.SSLMode
+	}
+
+	pool, err := db.Connect(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connect db: %w", err)
+	}
+	catalog := service.NewCatalogService(repository.NewServiceRepository(pool), cfg.ServiceAutoCreate)
+	repo := repository.NewSubscriptionRepository(pool)
+	users := service.NewUserService(repository.NewUserRepository(pool), repo, cfg.UserAutoRegister, cfg.TimeZone)
+	broker := event.NewBroker()
+	budgets := service.NewBudgetService(repository.NewBudgetRepository(pool), repo, users, broker)
+	return &dbBackend{
+		pool:    pool,
+		service: service.NewSubscriptionService(repo, catalog, users, budgets, broker, cfg.OverlapMode),
+	}, nil
+}
+
+func (b *dbBackend) Create(ctx context.Context, req api.CreateSubscriptionRequest) (api.SubscriptionResponse, error) {
+	if err := validate(&req); err != nil {
+		return api.SubscriptionResponse{}, err
+	}
+	sub, err := b.service.Create(ctx, req)
+	if err != nil {
+		return api.SubscriptionResponse{}, err
+	}
+	return mapper.ToResponseDTO(sub, dates.Default), nil
+}
+
+func (b *dbBackend) Get(ctx context.Context, id int64) (api.SubscriptionResponse, error) {
+	sub, err := b.service.GetByID(ctx, id)
+	if err != nil {
+		return api.SubscriptionResponse{}, err
+	}
+	if sub == nil {
+		return api.SubscriptionResponse{}, errNotFound
+	}
+	return mapper.ToResponseDTO(*sub, dates.Default), nil
+}
+
+func (b *dbBackend) List(ctx context.Context, filter listFilter) ([]api.SubscriptionResponse, error) {
+	f := dto.ListSubscriptionsFilter{ServiceID: filter.ServiceID, ServiceName: filter.ServiceName, Offset: filter.Offset}
+	if filter.UserID != uuid.Nil {
+		f.UserID = filter.UserID.String()
+	}
+	if !filter.All {
+		f.Limit = filter.Limit
+	}
+
+	subs, err := b.service.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]api.SubscriptionResponse, 0, len(subs))
+	for _, sub := range subs {
+		res = append(res, mapper.ToResponseDTO(sub, dates.Default))
+	}
+	return res, nil
+}
+
+func (b *dbBackend) Update(ctx context.Context, id int64, req api.UpdateSubscriptionRequest, ifMatch *int) (api.SubscriptionResponse, error) {
+	if err := validate(&req); err != nil {
+		return api.SubscriptionResponse{}, err
+	}
+	sub, err := b.service.Update(ctx, id, req, ifMatch)
+	if errors.Is(err, service.ErrNotFound) {
+		return api.SubscriptionResponse{}, errNotFound
+	}
+	if err != nil {
+		return api.SubscriptionResponse{}, err
+	}
+	return mapper.ToResponseDTO(sub, dates.Default), nil
+}
+
+func (b *dbBackend) Delete(ctx context.Context, id int64, ifMatch *int) error {
+	return b.service.Delete(ctx, id, ifMatch)
+}
+
+func (b *dbBackend) Total(ctx context.Context, filter totalFilter) (int, error) {
+	return b.service.TotalPrice(ctx, dto.TotalPriceFilterDTO{
+		UserID:      filter.UserID.String(),
+		ServiceID:   filter.ServiceID,
+		ServiceName: filter.ServiceName,
+		FromDate:    formatFilterDate(filter.From),
+		ToDate:      formatFilterDate(filter.To),
+	})
+}
+
+func formatFilterDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return dates.Format(t, dates.Date)
+}
+
+func (b *dbBackend) Import(ctx context.Context, data []byte, opts importOptions) (api.ImportReport, error) {
+	columns, err := importer.ParseColumns(opts.Columns)
+	if err != nil {
+		return api.ImportReport{}, err
+	}
+
+	var rows []dto.ImportRow
+	switch opts.Format {
+	case formatCSV:
+		rows, err = importer.ParseCSV(bytes.NewReader(data), columns)
+	case formatJSON:
+		rows, err = importer.ParseJSON(bytes.NewReader(data), columns)
+	default:
+		return api.ImportReport{}, fmt.Errorf("unsupported import format %q", opts.Format)
+	}
+	if err != nil {
+		return api.ImportReport{}, err
+	}
+
+	return b.service.Import(ctx, rows, dto.ImportOptions{DryRun: opts.DryRun, Mode: opts.Mode, Columns: opts.Columns})
+}
+
+func (b *dbBackend) Close() {
+	b.pool.Close()
+}
@@ -0,0 +1,561 @@
+package subctl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/dates"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func (a *app) listCommand() *cobra.Command {
+	var (
+		userID string
+		filter listFilter
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Список подписок",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if filter.UserID, err = parseOptionalUUID(userID); err != nil {
+				return err
+			}
+
+			b, err := a.connect()
+			if err != nil {
+				return err
+			}
+			subs, err := b.List(commandContext(cmd), filter)
+			if err != nil {
+				return err
+			}
+			return printSubscriptions(cmd.OutOrStdout(), a.opts.output, subs)
+		},
+	}
+
+	cmd.Flags().StringVar(&userID, "user-id", "", "UUID пользователя")
+	cmd.Flags().Int64Var(&filter.ServiceID, "service-id", 0, "ID сервиса из каталога")
+	cmd.Flags().StringVar(&filter.ServiceName, "service", "", "название или псевдоним сервиса")
+	cmd.Flags().IntVar(&filter.Limit, "limit", 100, "количество записей (размер страницы для --all)")
+	cmd.Flags().IntVar(&filter.Offset, "offset", 0, "смещение")
+	cmd.Flags().BoolVar(&filter.All, "all", false, "выбрать все страницы")
+	return cmd
+}
+
+func (a *app) getCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get ID",
+		Short: "Показать подписку",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			b, err := a.connect()
+			if err != nil {
+				return err
+			}
+			sub, err := b.Get(commandContext(cmd), id)
+			if err != nil {
+				return err
+			}
+			return printSubscription(cmd.OutOrStdout(), a.opts.output, sub)
+		},
+	}
+}
+
+// subscriptionFlags — поля подписки для create и update.
+type subscriptionFlags struct {
+	serviceID   int64
+	serviceName string
+	price       int
+	userID      string
+	startDate   string
+	endDate     string
+}
+
+func (f *subscriptionFlags) register(cmd *cobra.Command) {
+	cmd.Flags().Int64Var(&f.serviceID, "service-id", 0, "ID сервиса из каталога")
+	cmd.Flags().StringVar(&f.serviceName, "service", "", "название или псевдоним сервиса из каталога")
+	cmd.Flags().IntVar(&f.price, "price", 0, "стоимость в рублях (по умолчанию цена сервиса из каталога)")
+	cmd.Flags().StringVar(&f.userID, "user-id", "", "UUID пользователя")
+	cmd.Flags().StringVar(&f.startDate, "start", "", "месяц начала: MM-YYYY, YYYY-MM, YYYY-MM-DD или RFC 3339")
+	cmd.Flags().StringVar(&f.endDate, "end", "", `месяц окончания в том же формате ("" — бессрочная)`)
+}
+
+func (f *subscriptionFlags) serviceIDPtr() *int64 {
+	if f.serviceID == 0 {
+		return nil
+	}
+	return &f.serviceID
+}
+
+func (a *app) createCommand() *cobra.Command {
+	var f subscriptionFlags
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Создать подписку",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userID, err := uuid.Parse(f.userID)
+			if err != nil {
+				return fmt.Errorf("invalid --user-id: %w", err)
+			}
+
+			req := api.CreateSubscriptionRequest{
+				ServiceID:   f.serviceIDPtr(),
+				ServiceName: f.serviceName,
+				Price:       f.price,
+				UserID:      userID,
+				StartDate:   f.startDate,
+			}
+			if f.endDate != "" {
+				req.EndDate = &f.endDate
+			}
+
+			b, err := a.connect()
+			if err != nil {
+				return err
+			}
+			sub, err := b.Create(commandContext(cmd), req)
+			if err != nil {
+				return err
+			}
+			return printSubscription(cmd.OutOrStdout(), a.opts.output, sub)
+		},
+	}
+
+	f.register(cmd)
+	for _, name := range []string{"user-id", "start"} {
+		_ = cmd.MarkFlagRequired(name)
+	}
+	cmd.MarkFlagsOneRequired("service", "service-id")
+	return cmd
+}
+
+func (a *app) updateCommand() *cobra.Command {
+	var (
+		f       subscriptionFlags
+		ifMatch int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "update ID",
+		Short: "Изменить подписку",
+		Long: `Изменяет только переданные поля: текущая подписка читается, поля заменяются
+и сохраняются целиком с проверкой версии, так что параллельное изменение не потеряется.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			b, err := a.connect()
+			if err != nil {
+				return err
+			}
+			ctx := commandContext(cmd)
+
+			current, err := b.Get(ctx, id)
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("if-match") && ifMatch != current.Version {
+				return fmt.Errorf("subscription %d has version %d, expected %d", id, current.Version, ifMatch)
+			}
+
+			req := api.UpdateSubscriptionRequest{
+				ServiceName: current.ServiceName,
+				Price:       current.Price,
+				UserID:      current.UserID,
+				StartDate:   current.StartDate,
+				EndDate:     current.EndDate,
+			}
+			flags := cmd.Flags()
+			if flags.Changed("service") {
+				req.ServiceName = f.serviceName
+			}
+			if flags.Changed("service-id") {
+				req.ServiceID = f.serviceIDPtr()
+			}
+			if flags.Changed("price") {
+				req.Price = f.price
+			}
+			if flags.Changed("user-id") {
+				if req.UserID, err = uuid.Parse(f.userID); err != nil {
+					return fmt.Errorf("invalid --user-id: %w", err)
+				}
+			}
+			if flags.Changed("start") {
+				req.StartDate = f.startDate
+			}
+			if flags.Changed("end") {
+				req.EndDate = nil
+				if f.endDate != "" {
+					req.EndDate = &f.endDate
+				}
+			}
+
+			sub, err := b.Update(ctx, id, req, &current.Version)
+			if err != nil {
+				return err
+			}
+			return printSubscription(cmd.OutOrStdout(), a.opts.output, sub)
+		},
+	}
+
+	f.register(cmd)
+	cmd.Flags().IntVar(&ifMatch, "if-match", 0, "изменить только если подписка имеет эту версию")
+	return cmd
+}
+
+func (a *app) deleteCommand() *cobra.Command {
+	var ifMatch int
+
+	cmd := &cobra.Command{
+		Use:   "delete ID...",
+		Short: "Удалить подписки",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids := make([]int64, 0, len(args))
+			for _, arg := range args {
+				id, err := parseID(arg)
+				if err != nil {
+					return err
+				}
+				ids = append(ids, id)
+			}
+
+			var version *int
+			if cmd.Flags().Changed("if-match") {
+				if len(ids) > 1 {
+					return fmt.Errorf("--if-match can be used with a single ID only")
+				}
+				version = &ifMatch
+			}
+
+			b, err := a.connect()
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if err := b.Delete(commandContext(cmd), id, version); err != nil {
+					return fmt.Errorf("delete %d: %w", id, err)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "subscription %d deleted\n", id)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&ifMatch, "if-match", 0, "удалить только если подписка имеет эту версию")
+	return cmd
+}
+
+func (a *app) totalCommand() *cobra.Command {
+	var (
+		userID, serviceName, from, to string
+		serviceID                     int64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "total",
+		Short: "Суммарная стоимость подписок пользователя за период",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter := totalFilter{ServiceID: serviceID, ServiceName: serviceName}
+
+			var err error
+			if filter.UserID, err = uuid.Parse(userID); err != nil {
+				return fmt.Errorf("invalid --user-id: %w", err)
+			}
+			if filter.From, err = parseFilterDate(from); err != nil {
+				return fmt.Errorf("invalid --from: %w", err)
+			}
+			if filter.To, err = parseFilterDate(to); err != nil {
+				return fmt.Errorf("invalid --to: %w", err)
+			}
+
+			b, err := a.connect()
+			if err != nil {
+				return err
+			}
+			total, err := b.Total(commandContext(cmd), filter)
+			if err != nil {
+				return err
+			}
+			return printTotal(cmd.OutOrStdout(), a.opts.output, total)
+		},
+	}
+
+	cmd.Flags().StringVar(&userID, "user-id", "", "UUID пользователя")
+	cmd.Flags().Int64Var(&serviceID, "service-id", 0, "ID сервиса из каталога")
+	cmd.Flags().StringVar(&serviceName, "service", "", "название или псевдоним сервиса")
+	cmd.Flags().StringVar(&from, "from", "", "начало периода: MM-YYYY, YYYY-MM, YYYY-MM-DD или RFC 3339")
+	cmd.Flags().StringVar(&to, "to", "", "конец периода: MM-YYYY, YYYY-MM, YYYY-MM-DD или RFC 3339")
+	_ = cmd.MarkFlagRequired("user-id")
+	return cmd
+}
+
+func (a *app) importCommand() *cobra.Command {
+	var opts importOptions
+
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Импортировать подписки из CSV или JSON",
+		Long:  `Импортирует подписки из файла ("-" — стандартный ввод). Формат определяется по расширению или флагу --format.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := readInput(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			if opts.Format == "" {
+				opts.Format = formatFromPath(args[0], formatCSV)
+			}
+
+			b, err := a.connect()
+			if err != nil {
+				return err
+			}
+			report, importErr := b.Import(commandContext(cmd), data, opts)
+			if report.Mode == "" {
+				return importErr
+			}
+			if err := printImportReport(cmd.OutOrStdout(), a.opts.output, report); err != nil {
+				return err
+			}
+			if importErr == nil && report.Invalid > 0 && report.Mode == api.ImportModeAllOrNothing {
+				importErr = fmt.Errorf("%d invalid rows, nothing imported", report.Invalid)
+			}
+			return importErr
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Format, "format", "", "формат файла: csv или json")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "только проверить данные, без записи")
+	cmd.Flags().StringVar(&opts.Mode, "mode", api.ImportModePartial, "режим: partial или all_or_nothing")
+	cmd.Flags().StringVar(&opts.Columns, "columns", "", "маппинг колонок, например Сервис:service_name,Цена:price")
+	_ = cmd.RegisterFlagCompletionFunc("format", fixedCompletion(formatCSV, formatJSON))
+	_ = cmd.RegisterFlagCompletionFunc("mode", fixedCompletion(api.ImportModePartial, api.ImportModeAllOrNothing))
+	return cmd
+}
+
+func (a *app) exportCommand() *cobra.Command {
+	var (
+		userID string
+		format string
+		file   string
+		filter = listFilter{All: true}
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Выгрузить подписки в CSV, JSON или YAML",
+		Long:  "Выгружает все подписки, подходящие под фильтр. CSV и JSON можно загрузить обратно командой import.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if filter.UserID, err = parseOptionalUUID(userID); err != nil {
+				return err
+			}
+			if format == "" {
+				format = formatFromPath(file, formatCSV)
+			}
+
+			b, err := a.connect()
+			if err != nil {
+				return err
+			}
+			subs, err := b.List(commandContext(cmd), filter)
+			if err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			if file != "" && file != "-" {
+				f, err := os.Create(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+
+			switch format {
+			case formatCSV:
+				err = writeCSV(w, subs)
+			case formatJSON:
+				err = printSubscriptions(w, formatJSON, subs)
+			case formatYAML:
+				err = yaml.NewEncoder(w).Encode(toRecords(subs))
+			default:
+				return fmt.Errorf("unknown export format %q, expected csv, json or yaml", format)
+			}
+			if err != nil {
+				return err
+			}
+			if file != "" && file != "-" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%d subscriptions exported to %s\n", len(subs), file)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&userID, "user-id", "", "UUID пользователя")
+	cmd.Flags().Int64Var(&filter.ServiceID, "service-id", 0, "ID сервиса из каталога")
+	cmd.Flags().StringVar(&filter.ServiceName, "service", "", "название или псевдоним сервиса")
+	cmd.Flags().IntVar(&filter.Limit, "page-size", 500, "размер страницы при выгрузке через API")
+	cmd.Flags().StringVar(&format, "format", "", "формат: csv, json или yaml (по умолчанию по расширению файла или csv)")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "файл для выгрузки (по умолчанию стандартный вывод)")
+	_ = cmd.RegisterFlagCompletionFunc("format", fixedCompletion(formatCSV, formatJSON, formatYAML))
+	return cmd
+}
+
+func (a *app) configCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Профили окружений",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "Список профилей",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(a.opts.configPath)
+			if err != nil {
+				return err
+			}
+			for _, name := range cfg.profileNames() {
+				p := cfg.Profiles[name]
+				marker := " "
+				if name == cfg.Current {
+					marker = "*"
+				}
+				target := p.URL
+				if p.Mode == modeDB {
+					target = "database"
+					if p.DB != nil {
+						target = fmt.Sprintf("database %s@%s:%s/%s", p.DB.User, p.DB.Host, p.DB.Port, p.DB.Name)
+					}
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %s\t%s\n", marker, name, target)
+			}
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:               "use PROFILE",
+		Short:             "Сделать профиль текущим",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(a.opts.configPath)
+			if err != nil {
+				return err
+			}
+			if _, ok := cfg.Profiles[args[0]]; !ok {
+				return fmt.Errorf("profile %q not found in %s", args[0], a.opts.configPath)
+			}
+			cfg.Current = args[0]
+			return saveConfig(a.opts.configPath, cfg)
+		},
+	})
+
+	var p Profile
+	set := &cobra.Command{
+		Use:   "set PROFILE",
+		Short: "Создать или изменить профиль HTTP API",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(a.opts.configPath)
+			if err != nil {
+				return err
+			}
+			profile := cfg.Profiles[args[0]]
+			if cmd.Flags().Changed("url") {
+				profile.URL = p.URL
+			}
+			if cmd.Flags().Changed("token") {
+				profile.Token = p.Token
+			}
+			if cmd.Flags().Changed("mode") {
+				profile.Mode = p.Mode
+			}
+			cfg.Profiles[args[0]] = profile
+			if cfg.Current == "" {
+				cfg.Current = args[0]
+			}
+			return saveConfig(a.opts.configPath, cfg)
+		},
+	}
+	set.Flags().StringVar(&p.URL, "url", "", "адрес HTTP API")
+	set.Flags().StringVar(&p.Token, "token", "", "токен авторизации")
+	set.Flags().StringVar(&p.Mode, "mode", modeAPI, "режим: api или db")
+	cmd.AddCommand(set)
+
+	return cmd
+}
+
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid subscription id %q", s)
+	}
+	return id, nil
+}
+
+func parseOptionalUUID(s string) (uuid.UUID, error) {
+	if s == "" {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid --user-id: %w", err)
+	}
+	return id, nil
+}
+
+func parseFilterDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return dates.Parse(s)
+}
+
+func formatFromPath(path, def string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return formatCSV
+	case ".json":
+		return formatJSON
+	case ".yaml", ".yml":
+		return formatYAML
+	default:
+		return def
+	}
+}
+
+func readInput(cmd *cobra.Command, path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(cmd.InOrStdin())
+	}
+	return os.ReadFile(path)
+}
@@ -0,0 +1,96 @@
+package subctl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	modeAPI = "api"
+	modeDB  = "db"
+)
+
+// Profile описывает одно окружение: HTTP API (url, token) или прямое
+// подключение к базе (db). Для режима db без параметров подключения
+// используются переменные окружения и .env, как у сервиса.
+type Profile struct {
+	Mode  string    `yaml:"mode,omitempty"`
+	URL   string    `yaml:"url,omitempty"`
+	Token string    `yaml:"token,omitempty"`
+	DB    *DBConfig `yaml:"db,omitempty"`
+}
+
+type DBConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+}
+
+type Config struct {
+	Current  string             `yaml:"current"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+func defaultConfigPath() string {
+	if path := os.Getenv("SUBCTL_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".subctl.yaml"
+	}
+	return filepath.Join(dir, "subctl", "config.yaml")
+}
+
+// loadConfig читает файл профилей. Отсутствующий файл не ошибка: тогда
+// используется только профиль из флагов.
+func loadConfig(path string) (Config, error) {
+	cfg := Config{Profiles: map[string]Profile{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("read config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]Profile{}
+	}
+	return cfg, nil
+}
+
+func saveConfig(path string, cfg Config) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	// В профилях лежат токены и пароли базы.
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return nil
+}
+
+func (c Config) profileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
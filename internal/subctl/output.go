@@ -0,0 +1,154 @@
+package subctl
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/shenikar/subscription-service/pkg/api"
+	"gopkg.in/yaml.v3"
+)
+
+const outputTable = "table"
+
+var outputFormats = []string{outputTable, formatJSON, formatYAML}
+
+// subscriptionRecord — представление подписки для YAML: yaml.v3 не использует json-теги.
+type subscriptionRecord struct {
+	ID          int64   `yaml:"id"`
+	ServiceID   int64   `yaml:"service_id"`
+	ServiceName string  `yaml:"service_name"`
+	Price       int     `yaml:"price"`
+	UserID      string  `yaml:"user_id"`
+	StartDate   string  `yaml:"start_date"`
+	EndDate     *string `yaml:"end_date,omitempty"`
+	Version     int     `yaml:"version"`
+}
+
+func toRecords(subs []api.SubscriptionResponse) []subscriptionRecord {
+	res := make([]subscriptionRecord, 0, len(subs))
+	for _, sub := range subs {
+		res = append(res, subscriptionRecord{
+			ID:          sub.ID,
+			ServiceID:   sub.ServiceID,
+			ServiceName: sub.ServiceName,
+			Price:       sub.Price,
+			UserID:      sub.UserID.String(),
+			StartDate:   sub.StartDate,
+			EndDate:     sub.EndDate,
+			Version:     sub.Version,
+		})
+	}
+	return res
+}
+
+func printSubscriptions(w io.Writer, format string, subs []api.SubscriptionResponse) error {
+	switch format {
+	case formatJSON:
+		if subs == nil {
+			subs = []api.SubscriptionResponse{}
+		}
+		return printJSON(w, subs)
+	case formatYAML:
+		return yaml.NewEncoder(w).Encode(toRecords(subs))
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tSERVICE\tPRICE\tUSER ID\tSTART\tEND\tVERSION")
+		for _, sub := range subs {
+			end := "-"
+			if sub.EndDate != nil {
+				end = *sub.EndDate
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\t%s\t%d\n",
+				sub.ID, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, end, sub.Version)
+		}
+		return tw.Flush()
+	}
+}
+
+func printSubscription(w io.Writer, format string, sub api.SubscriptionResponse) error {
+	switch format {
+	case formatJSON:
+		return printJSON(w, sub)
+	case formatYAML:
+		return yaml.NewEncoder(w).Encode(toRecords([]api.SubscriptionResponse{sub})[0])
+	default:
+		return printSubscriptions(w, format, []api.SubscriptionResponse{sub})
+	}
+}
+
+func printTotal(w io.Writer, format string, total int) error {
+	switch format {
+	case formatJSON:
+		return printJSON(w, api.TotalPriceResponse{Total: total})
+	case formatYAML:
+		return yaml.NewEncoder(w).Encode(map[string]int{"total": total})
+	default:
+		_, err := fmt.Fprintln(w, total)
+		return err
+	}
+}
+
+func printImportReport(w io.Writer, format string, report api.ImportReport) error {
+	switch format {
+	case formatJSON:
+		return printJSON(w, report)
+	case formatYAML:
+		errs := make([]map[string]any, 0, len(report.Errors))
+		for _, e := range report.Errors {
+			errs = append(errs, map[string]any{"row": e.Row, "errors": e.Errors})
+		}
+		return yaml.NewEncoder(w).Encode(map[string]any{
+			"dry_run":  report.DryRun,
+			"mode":     report.Mode,
+			"total":    report.Total,
+			"valid":    report.Valid,
+			"invalid":  report.Invalid,
+			"imported": report.Imported,
+			"errors":   errs,
+		})
+	default:
+		fmt.Fprintf(w, "mode: %s, dry run: %t\n", report.Mode, report.DryRun)
+		fmt.Fprintf(w, "total: %d, valid: %d, invalid: %d, imported: %d\n",
+			report.Total, report.Valid, report.Invalid, report.Imported)
+		if len(report.Errors) == 0 {
+			return nil
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ROW\tERRORS")
+		for _, e := range report.Errors {
+			fmt.Fprintf(tw, "%d\t%s\n", e.Row, strings.Join(e.Errors, "; "))
+		}
+		return tw.Flush()
+	}
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeCSV пишет подписки с заголовком в формате, который принимает импорт.
+func writeCSV(w io.Writer, subs []api.SubscriptionResponse) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"service_name", "price", "user_id", "start_date", "end_date"}); err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		var end string
+		if sub.EndDate != nil {
+			end = *sub.EndDate
+		}
+		record := []string{sub.ServiceName, strconv.Itoa(sub.Price), sub.UserID.String(), sub.StartDate, end}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
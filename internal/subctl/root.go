@@ -0,0 +1,196 @@
+// Package subctl реализует утилиту командной строки subctl для операторов сервиса подписок.
+package subctl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/shenikar/subscription-service/internal/logger"
+	"github.com/shenikar/subscription-service/internal/validation"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type globalOptions struct {
+	configPath string
+	profile    string
+	url        string
+	token      string
+	db         bool
+	output     string
+	verbose    bool
+}
+
+type app struct {
+	opts    globalOptions
+	backend backend
+}
+
+// Execute запускает subctl и возвращает код завершения процесса.
+func Execute() int {
+	return run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	root := newRootCommand()
+	root.SetArgs(args)
+	root.SetIn(stdin)
+	root.SetOut(stdout)
+	root.SetErr(stderr)
+	if err := root.Execute(); err != nil {
+		fmt.Fprintln(stderr, "Error:", err)
+		return 1
+	}
+	return 0
+}
+
+func newRootCommand() *cobra.Command {
+	a := &app{}
+
+	root := &cobra.Command{
+		Use:   "subctl",
+		Short: "Управление подписками через HTTP API или напрямую в базе",
+		Long: `subctl работает с сервисом подписок через HTTP API (профиль с url)
+или напрямую с базой (профиль с mode: db или флаг --db).
+
+Профили хранятся в ` + defaultConfigPath() + `:
+
+  current: local
+  profiles:
+    local:
+      url: http://localhost:8080
+    prod:
+      url: https://subscriptions.example.com
+      token: secret
+    prod-db:
+      mode: db
+      db: {host: db.internal, port: "5432", user: app, password: secret, name: subscriptions, sslmode: require}`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(outputFormats, a.opts.output) {
+				return fmt.Errorf("unknown output format %q, expected one of %v", a.opts.output, outputFormats)
+			}
+
+			// Сервис пишет логи logrus; в CLI они уходят в stderr, чтобы не смешиваться с выводом.
+			log := logger.GetLogger()
+			log.SetOutput(os.Stderr)
+			log.SetLevel(logrus.WarnLevel)
+			if a.opts.verbose {
+				log.SetLevel(logrus.InfoLevel)
+			}
+			return nil
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			if a.backend != nil {
+				a.backend.Close()
+			}
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&a.opts.configPath, "config", defaultConfigPath(), "файл профилей")
+	flags.StringVarP(&a.opts.profile, "profile", "p", os.Getenv("SUBCTL_PROFILE"), "профиль окружения (по умолчанию current из файла профилей)")
+	flags.StringVar(&a.opts.url, "url", "", "адрес HTTP API, переопределяет профиль")
+	flags.StringVar(&a.opts.token, "token", "", "токен авторизации, переопределяет профиль")
+	flags.BoolVar(&a.opts.db, "db", false, "работать напрямую с базой (параметры из профиля или переменных окружения)")
+	flags.StringVarP(&a.opts.output, "output", "o", outputTable, "формат вывода: table, json или yaml")
+	flags.BoolVarP(&a.opts.verbose, "verbose", "v", false, "выводить логи сервиса")
+
+	_ = root.RegisterFlagCompletionFunc("output", fixedCompletion(outputFormats...))
+	_ = root.RegisterFlagCompletionFunc("profile", a.completeProfiles)
+
+	root.AddCommand(
+		a.listCommand(),
+		a.getCommand(),
+		a.createCommand(),
+		a.updateCommand(),
+		a.deleteCommand(),
+		a.totalCommand(),
+		a.importCommand(),
+		a.exportCommand(),
+		a.configCommand(),
+	)
+	return root
+}
+
+// connect выбирает профиль и создаёт backend. Вызывается командами, которым нужен сервис.
+func (a *app) connect() (backend, error) {
+	if a.backend != nil {
+		return a.backend, nil
+	}
+
+	cfg, err := loadConfig(a.opts.configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	name := a.opts.profile
+	if name == "" {
+		name = cfg.Current
+	}
+	var profile Profile
+	if name != "" {
+		p, ok := cfg.Profiles[name]
+		if !ok && a.opts.url == "" && !a.opts.db {
+			return nil, fmt.Errorf("profile %q not found in %s", name, a.opts.configPath)
+		}
+		profile = p
+	}
+
+	if a.opts.url != "" {
+		profile.URL = a.opts.url
+		profile.Mode = modeAPI
+	}
+	if a.opts.token != "" {
+		profile.Token = a.opts.token
+	}
+	if a.opts.db {
+		profile.Mode = modeDB
+	}
+
+	switch profile.Mode {
+	case modeDB:
+		a.backend, err = newDBBackend(profile)
+	case modeAPI, "":
+		a.backend, err = newHTTPBackend(profile)
+	default:
+		return nil, fmt.Errorf("unknown profile mode %q, expected api or db", profile.Mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.backend, nil
+}
+
+func (a *app) completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := loadConfig(a.opts.configPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return cfg.profileNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func fixedCompletion(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func validate(obj any) error {
+	if errs := validation.Struct(obj); len(errs) > 0 {
+		return errors.New(errs[0])
+	}
+	return nil
+}
+
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
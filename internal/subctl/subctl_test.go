@@ -0,0 +1,290 @@
+package subctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/config"
+	"github.com/shenikar/subscription-service/internal/gql"
+	"github.com/shenikar/subscription-service/internal/handler"
+	"github.com/shenikar/subscription-service/internal/middleware"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/router"
+	"github.com/shenikar/subscription-service/internal/testutil"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"gopkg.in/yaml.v3"
+)
+
+var userID = uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+
+// newServer поднимает сервис поверх хранилища в памяти; subctl обращается к нему
+// через pkg/client, как к настоящему серверу.
+func newServer(t *testing.T, subs ...model.Subscription) (string, *testutil.Subscriptions) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.Config{BatchMaxSize: 100, GraphQLMaxDepth: 8, GraphQLMaxComplexity: 1000}
+	stores := testutil.NewStores(subs...)
+	svc := stores.SubscriptionService()
+	executor, err := gql.NewExecutor(svc, cfg.GraphQLMaxDepth, cfg.GraphQLMaxComplexity)
+	if err != nil {
+		t.Fatalf("build graphql schema: %v", err)
+	}
+	engine := router.SetupRouter(
+		handler.NewSubscriptionHandler(svc, cfg),
+		handler.NewServiceHandler(stores.Catalog()),
+		handler.NewUserHandler(stores.UserService(), svc),
+		handler.NewReportHandler(stores.ReportService()),
+		handler.NewBudgetHandler(stores.BudgetService()),
+		handler.NewTagHandler(stores.TagService()),
+		handler.NewOrganisationHandler(stores.OrganisationService()),
+		handler.NewGraphQLHandler(executor),
+		middleware.Idempotency(testutil.NewIdempotencyKeys(), time.Hour),
+	)
+
+	srv := httptest.NewServer(engine)
+	t.Cleanup(srv.Close)
+	return srv.URL, stores.Subscriptions
+}
+
+type result struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+// runCLI запускает subctl с файлом профилей во временном каталоге, чтобы тесты
+// не читали профили пользователя.
+func runCLI(t *testing.T, stdin string, args ...string) result {
+	t.Helper()
+	args = append([]string{"--config", filepath.Join(t.TempDir(), "config.yaml")}, args...)
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return result{code: code, stdout: stdout.String(), stderr: stderr.String()}
+}
+
+func netflix() model.Subscription {
+	return model.Subscription{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func TestOutputFormats(t *testing.T) {
+	url, _ := newServer(t, netflix())
+	want := api.SubscriptionResponse{
+		ID: 1, ServiceID: 1, ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: "07-2025", Version: 1,
+		PriceTimeline: []api.PricePeriod{{From: "07-2025", Price: 400}},
+		Pauses:        []api.PausePeriod{},
+		Tags:          []string{},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		res := runCLI(t, "", "--url", url, "-o", "json", "get", "1")
+		if res.code != 0 {
+			t.Fatalf("exit code %d, stderr %q", res.code, res.stderr)
+		}
+		var got api.SubscriptionResponse
+		if err := json.Unmarshal([]byte(res.stdout), &got); err != nil {
+			t.Fatalf("decode %q: %v", res.stdout, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("get = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		res := runCLI(t, "", "--url", url, "--output", "yaml", "list", "--user-id", userID.String())
+		if res.code != 0 {
+			t.Fatalf("exit code %d, stderr %q", res.code, res.stderr)
+		}
+		var got []subscriptionRecord
+		if err := yaml.Unmarshal([]byte(res.stdout), &got); err != nil {
+			t.Fatalf("decode %q: %v", res.stdout, err)
+		}
+		wantRecord := subscriptionRecord{ID: 1, ServiceID: 1, ServiceName: "Netflix", Price: 400, UserID: userID.String(), StartDate: "07-2025", Version: 1}
+		if len(got) != 1 || got[0] != wantRecord {
+			t.Errorf("list = %+v, want [%+v]", got, wantRecord)
+		}
+	})
+
+	t.Run("table", func(t *testing.T) {
+		res := runCLI(t, "", "--url", url, "get", "1")
+		if res.code != 0 {
+			t.Fatalf("exit code %d, stderr %q", res.code, res.stderr)
+		}
+		lines := strings.Split(strings.TrimSpace(res.stdout), "\n")
+		if len(lines) != 2 || strings.Join(strings.Fields(lines[0]), " ") != "ID SERVICE PRICE USER ID START END VERSION" ||
+			strings.Join(strings.Fields(lines[1]), " ") != "1 Netflix 400 "+userID.String()+" 07-2025 - 1" {
+			t.Errorf("table output %q", res.stdout)
+		}
+	})
+
+	t.Run("total", func(t *testing.T) {
+		res := runCLI(t, "", "--url", url, "-o", "yaml", "total", "--user-id", userID.String(), "--from", "01-01-2025", "--to", "31-12-2025")
+		if res.code != 0 {
+			t.Fatalf("exit code %d, stderr %q", res.code, res.stderr)
+		}
+		// Подписка начисляется помесячно с июля по декабрь.
+		if res.stdout != "total: 2400\n" {
+			t.Errorf("total output %q, want %q", res.stdout, "total: 2400\n")
+		}
+	})
+}
+
+func TestCreateAndUpdateFlags(t *testing.T) {
+	url, store := newServer(t)
+
+	res := runCLI(t, "", "--url", url, "-o", "json", "create",
+		"--service", "Yandex Plus", "--price", "300", "--user-id", userID.String(), "--start", "07-2025", "--end", "12-2025")
+	if res.code != 0 {
+		t.Fatalf("create: exit code %d, stderr %q", res.code, res.stderr)
+	}
+
+	// update меняет только переданные флаги, остальные поля берутся из текущей подписки.
+	res = runCLI(t, "", "--url", url, "-o", "json", "update", "1", "--price", "500", "--end", "")
+	if res.code != 0 {
+		t.Fatalf("update: exit code %d, stderr %q", res.code, res.stderr)
+	}
+	var got api.SubscriptionResponse
+	if err := json.Unmarshal([]byte(res.stdout), &got); err != nil {
+		t.Fatalf("decode %q: %v", res.stdout, err)
+	}
+	want := api.SubscriptionResponse{
+		ID: 1, ServiceID: 1, ServiceName: "Yandex Plus", Price: 500, UserID: userID, StartDate: "07-2025", Version: 2,
+		PriceTimeline: []api.PricePeriod{{From: "07-2025", Price: 500}},
+		Pauses:        []api.PausePeriod{},
+		Tags:          []string{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("update = %+v, want %+v", got, want)
+	}
+
+	res = runCLI(t, "", "--url", url, "delete", "1", "--if-match", "2")
+	if res.code != 0 {
+		t.Fatalf("delete: exit code %d, stderr %q", res.code, res.stderr)
+	}
+	if res.stderr != "subscription 1 deleted\n" {
+		t.Errorf("delete stderr %q", res.stderr)
+	}
+	if subs := store.All(); len(subs) != 0 {
+		t.Errorf("store after delete = %+v, want empty", subs)
+	}
+}
+
+func TestImport(t *testing.T) {
+	url, store := newServer(t)
+	csv := "service_name,price,user_id,start_date\n" +
+		"Netflix,400," + userID.String() + ",07-2025\n" +
+		"Spotify,200," + userID.String() + ",13-2025\n"
+
+	res := runCLI(t, csv, "--url", url, "-o", "json", "import", "-", "--mode", "all_or_nothing")
+	if res.code != 1 {
+		t.Fatalf("import all_or_nothing: exit code %d, want 1", res.code)
+	}
+	var report api.ImportReport
+	if err := json.Unmarshal([]byte(res.stdout), &report); err != nil {
+		t.Fatalf("decode %q: %v", res.stdout, err)
+	}
+	if report.Total != 2 || report.Invalid != 1 || report.Imported != 0 || len(report.Errors) != 1 || report.Errors[0].Row != 2 {
+		t.Errorf("report = %+v, want row 2 rejected and nothing imported", report)
+	}
+	if !strings.Contains(res.stderr, "422") {
+		t.Errorf("stderr %q, want the 422 response", res.stderr)
+	}
+
+	path := filepath.Join(t.TempDir(), "subs.json")
+	data := `[{"service_name": "Netflix", "price": 400, "user_id": "` + userID.String() + `", "start_date": "07-2025"}]`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	res = runCLI(t, "", "--url", url, "import", path)
+	if res.code != 0 {
+		t.Fatalf("import %s: exit code %d, stderr %q", path, res.code, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "total: 1, valid: 1, invalid: 0, imported: 1") {
+		t.Errorf("import output %q", res.stdout)
+	}
+	if subs := store.All(); len(subs) != 1 {
+		t.Errorf("store = %+v, want one imported subscription", subs)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	url, _ := newServer(t, netflix())
+
+	tests := []struct {
+		name   string
+		args   []string
+		stderr string
+	}{
+		{"unknown command", []string{"--url", url, "show"}, `unknown command "show"`},
+		{"unknown flag", []string{"--url", url, "list", "--users"}, "unknown flag: --users"},
+		{"unknown output format", []string{"--url", url, "-o", "xml", "list"}, `unknown output format "xml"`},
+		{"missing required flag", []string{"--url", url, "create", "--service", "Netflix"}, `required flag(s) "start", "user-id" not set`},
+		{"invalid id", []string{"--url", url, "get", "abc"}, `invalid subscription id "abc"`},
+		{"invalid user id", []string{"--url", url, "list", "--user-id", "42"}, "invalid --user-id"},
+		{"invalid date", []string{"--url", url, "total", "--user-id", userID.String(), "--from", "2025/01/01"}, "invalid --from: invalid date, expected MM-YYYY, YYYY-MM, YYYY-MM-DD or RFC 3339: \"2025/01/01\""},
+		{"not found", []string{"--url", url, "get", "2"}, "subscription not found"},
+		{"stale version", []string{"--url", url, "update", "1", "--price", "500", "--if-match", "7"}, "subscription 1 has version 1, expected 7"},
+		{"if-match with several ids", []string{"--url", url, "delete", "1", "2", "--if-match", "1"}, "--if-match can be used with a single ID only"},
+		{"bad request", []string{"--url", url, "create", "--service", "Netflix", "--price", "400", "--user-id", userID.String(), "--start", "2025-07", "--end", "06-2025"}, "subscription api: 400"},
+		{"missing url", []string{"list"}, "api url is not set"},
+		{"missing profile", []string{"-p", "prod", "list"}, `profile "prod" not found`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := runCLI(t, "", tt.args...)
+			if res.code != 1 {
+				t.Fatalf("exit code %d, want 1; stdout %q", res.code, res.stdout)
+			}
+			if !strings.HasPrefix(res.stderr, "Error: ") || !strings.Contains(res.stderr, tt.stderr) {
+				t.Errorf("stderr %q, want an error containing %q", res.stderr, tt.stderr)
+			}
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	url, _ := newServer(t, netflix())
+	path := filepath.Join(t.TempDir(), "subctl", "config.yaml")
+	subctl := func(args ...string) result {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		code := run(append([]string{"--config", path}, args...), strings.NewReader(""), &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("subctl %v: exit code %d, stderr %q", args, code, stderr.String())
+		}
+		return result{code: code, stdout: stdout.String(), stderr: stderr.String()}
+	}
+
+	subctl("config", "set", "local", "--url", url)
+	subctl("config", "set", "prod", "--url", "https://subscriptions.example.com", "--token", "secret")
+
+	if got := subctl("config", "list").stdout; got != "* local\t"+url+"\n  prod\thttps://subscriptions.example.com\n" {
+		t.Errorf("config list = %q", got)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("config mode = %v, want 0600: profiles hold tokens", perm)
+	}
+
+	// Текущий профиль local указывает на тестовый сервер.
+	if got := subctl("-o", "json", "list").stdout; !strings.Contains(got, `"service_name": "Netflix"`) {
+		t.Errorf("list through the current profile = %q", got)
+	}
+
+	subctl("config", "use", "prod")
+	if got := subctl("config", "list").stdout; !strings.HasPrefix(got, "  local") || !strings.Contains(got, "* prod") {
+		t.Errorf("config list after use = %q", got)
+	}
+}
@@ -0,0 +1,176 @@
+package testutil
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/internal/service"
+)
+
+// Budgets хранит бюджеты и отправленные уведомления в памяти.
+type Budgets struct {
+	service.BudgetStore
+
+	// Users и Services, если заданы, проверяют ссылки бюджета, как внешние ключи
+	// budgets.user_id и budgets.service_id.
+	Users    *Users
+	Services *Services
+
+	mu      sync.Mutex
+	budgets map[int64]model.Budget
+	alerts  map[budgetAlertKey]model.BudgetAlert
+	nextID  int64
+}
+
+// budgetAlertKey — первичный ключ budget_alerts. Месяц хранится как дата без часового
+// пояса, как столбец month типа DATE.
+type budgetAlertKey struct {
+	budgetID  int64
+	month     string
+	threshold int
+}
+
+func NewBudgets(budgets ...model.Budget) *Budgets {
+	s := &Budgets{budgets: map[int64]model.Budget{}, alerts: map[budgetAlertKey]model.BudgetAlert{}}
+	for _, budget := range budgets {
+		if err := s.Create(context.Background(), &budget); err != nil {
+			panic(err)
+		}
+	}
+	return s
+}
+
+func (s *Budgets) track(ctx context.Context) {
+	track(ctx, s, func() func() {
+		budgets, alerts, nextID := maps.Clone(s.budgets), maps.Clone(s.alerts), s.nextID
+		return func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.budgets, s.alerts, s.nextID = budgets, alerts, nextID
+		}
+	})
+}
+
+// checkReferences возвращает repository.ErrBudgetReference, если пользователь или
+// сервис бюджета не существует.
+func (s *Budgets) checkReferences(ctx context.Context, budget *model.Budget) error {
+	if s.Users != nil {
+		if user, _ := s.Users.GetByID(ctx, budget.UserID); user == nil {
+			return repository.ErrBudgetReference
+		}
+	}
+	if s.Services != nil && budget.ServiceID != nil {
+		if svc, _ := s.Services.GetByID(ctx, *budget.ServiceID); svc == nil {
+			return repository.ErrBudgetReference
+		}
+	}
+	return nil
+}
+
+func (s *Budgets) Create(ctx context.Context, budget *model.Budget) error {
+	if err := s.checkReferences(ctx, budget); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.track(ctx)
+	s.nextID++
+	budget.ID = s.nextID
+	budget.CreatedAt = time.Now()
+	budget.UpdatedAt = budget.CreatedAt
+	s.budgets[budget.ID] = *budget
+	return nil
+}
+
+func (s *Budgets) GetByID(_ context.Context, id int64) (*model.Budget, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	budget, ok := s.budgets[id]
+	if !ok {
+		return nil, nil
+	}
+	return &budget, nil
+}
+
+func (s *Budgets) List(_ context.Context, userID *uuid.UUID, limit, offset int) ([]*model.Budget, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []*model.Budget
+	for _, budget := range s.budgets {
+		if userID != nil && budget.UserID != *userID {
+			continue
+		}
+		res = append(res, &budget)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	res = res[min(offset, len(res)):]
+	if limit > 0 {
+		res = res[:min(limit, len(res))]
+	}
+	return res, nil
+}
+
+func (s *Budgets) Update(ctx context.Context, budget *model.Budget) error {
+	if err := s.checkReferences(ctx, budget); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.budgets[budget.ID]
+	if !ok {
+		return repository.ErrBudgetNotFound
+	}
+	s.track(ctx)
+	budget.CreatedAt = current.CreatedAt
+	budget.UpdatedAt = time.Now()
+	s.budgets[budget.ID] = *budget
+	return nil
+}
+
+func (s *Budgets) Delete(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.budgets[id]; !ok {
+		return repository.ErrBudgetNotFound
+	}
+	s.track(ctx)
+	delete(s.budgets, id)
+	for key := range s.alerts {
+		if key.budgetID == id {
+			delete(s.alerts, key)
+		}
+	}
+	return nil
+}
+
+func (s *Budgets) RecordAlert(ctx context.Context, alert *model.BudgetAlert) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := budgetAlertKey{budgetID: alert.BudgetID, month: alert.Month.Format(time.DateOnly), threshold: alert.Threshold}
+	if _, ok := s.alerts[key]; ok {
+		return false, nil
+	}
+	s.track(ctx)
+	alert.CreatedAt = time.Now()
+	s.alerts[key] = *alert
+	return true, nil
+}
+
+func (s *Budgets) Alerts(_ context.Context, budgetID int64, month time.Time) ([]model.BudgetAlert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []model.BudgetAlert
+	for key, alert := range s.alerts {
+		if key.budgetID == budgetID && key.month == month.Format(time.DateOnly) {
+			res = append(res, alert)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Threshold < res[j].Threshold })
+	return res, nil
+}
@@ -0,0 +1,166 @@
+package testutil
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/internal/service"
+)
+
+// Services хранит каталог сервисов в памяти. Delete не знает о подписках и не
+// возвращает repository.ErrServiceInUse.
+type Services struct {
+	service.CatalogStore
+
+	mu       sync.Mutex
+	services map[int64]*model.Service
+	nextID   int64
+}
+
+func NewServices(services ...model.Service) *Services {
+	s := &Services{services: map[int64]*model.Service{}}
+	for _, svc := range services {
+		if err := s.Create(context.Background(), &svc); err != nil {
+			panic(err)
+		}
+	}
+	return s
+}
+
+// All возвращает сервисы каталога по возрастанию ID.
+func (s *Services) All() []model.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]model.Service, 0, len(s.services))
+	for _, svc := range s.services {
+		res = append(res, *svc)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+func (s *Services) track(ctx context.Context) {
+	track(ctx, s, func() func() {
+		services, nextID := maps.Clone(s.services), s.nextID
+		return func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.services, s.nextID = services, nextID
+		}
+	})
+}
+
+// find ищет сервис по названию или псевдониму без учёта регистра.
+func (s *Services) find(name string) *model.Service {
+	for _, svc := range s.services {
+		if strings.EqualFold(svc.Name, name) {
+			return svc
+		}
+		for _, alias := range svc.Aliases {
+			if strings.EqualFold(alias, name) {
+				return svc
+			}
+		}
+	}
+	return nil
+}
+
+// checkNames проверяет уникальность названия и псевдонимов, как уникальные индексы
+// services и service_aliases.
+func (s *Services) checkNames(svc *model.Service) error {
+	for _, name := range append([]string{svc.Name}, svc.Aliases...) {
+		if other := s.find(name); other != nil && other.ID != svc.ID {
+			return repository.ErrServiceExists
+		}
+	}
+	return nil
+}
+
+func (s *Services) Create(ctx context.Context, svc *model.Service) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkNames(svc); err != nil {
+		return err
+	}
+	s.track(ctx)
+	s.nextID++
+	svc.ID = s.nextID
+	saved := *svc
+	s.services[svc.ID] = &saved
+	return nil
+}
+
+func (s *Services) GetByID(_ context.Context, id int64) (*model.Service, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	svc, ok := s.services[id]
+	if !ok {
+		return nil, nil
+	}
+	res := *svc
+	return &res, nil
+}
+
+func (s *Services) FindByName(_ context.Context, name string) (*model.Service, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	svc := s.find(name)
+	if svc == nil {
+		return nil, nil
+	}
+	res := *svc
+	return &res, nil
+}
+
+func (s *Services) List(_ context.Context, query, category *string, limit, offset int) ([]*model.Service, error) {
+	var res []*model.Service
+	for _, svc := range s.All() {
+		if query != nil && !containsFold(svc.Name, *query) && !slices.ContainsFunc(svc.Aliases, func(alias string) bool {
+			return containsFold(alias, *query)
+		}) {
+			continue
+		}
+		if category != nil && (svc.Category == nil || !strings.EqualFold(*svc.Category, *category)) {
+			continue
+		}
+		res = append(res, &svc)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	res = res[min(offset, len(res)):]
+	if limit > 0 {
+		res = res[:min(limit, len(res))]
+	}
+	return res, nil
+}
+
+func (s *Services) Update(ctx context.Context, svc *model.Service) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.services[svc.ID]; !ok {
+		return repository.ErrServiceNotFound
+	}
+	if err := s.checkNames(svc); err != nil {
+		return err
+	}
+	s.track(ctx)
+	saved := *svc
+	s.services[svc.ID] = &saved
+	return nil
+}
+
+func (s *Services) Delete(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.services[id]; !ok {
+		return repository.ErrServiceNotFound
+	}
+	s.track(ctx)
+	delete(s.services, id)
+	return nil
+}
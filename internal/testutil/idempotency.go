@@ -0,0 +1,46 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+
+	"github.com/shenikar/subscription-service/internal/model"
+)
+
+// IdempotencyKeys хранит ключи идемпотентности в памяти и реализует middleware.IdempotencyStore.
+type IdempotencyKeys struct {
+	mu   sync.Mutex
+	keys map[string]model.IdempotencyKey
+}
+
+func NewIdempotencyKeys() *IdempotencyKeys {
+	return &IdempotencyKeys{keys: map[string]model.IdempotencyKey{}}
+}
+
+func (s *IdempotencyKeys) id(key model.IdempotencyKey) string {
+	return key.Method + " " + key.Path + " " + key.Key
+}
+
+func (s *IdempotencyKeys) Reserve(_ context.Context, key model.IdempotencyKey) (*model.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.keys[s.id(key)]; ok {
+		return &existing, nil
+	}
+	s.keys[s.id(key)] = key
+	return nil, nil
+}
+
+func (s *IdempotencyKeys) Complete(_ context.Context, key model.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[s.id(key)] = key
+	return nil
+}
+
+func (s *IdempotencyKeys) Release(_ context.Context, key model.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, s.id(key))
+	return nil
+}
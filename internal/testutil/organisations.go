@@ -0,0 +1,508 @@
+package testutil
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/internal/service"
+)
+
+// Organisations хранит организации, подразделения, центры затрат и участников в памяти
+// с ограничениями миграции 000017.
+type Organisations struct {
+	service.OrganisationStore
+
+	// Users, если задано, проверяет участников, как внешний ключ organisation_members.user_id.
+	Users *Users
+	// Subscriptions, если задано, не даёт удалить центры затрат, к которым относили
+	// подписки, и по нему считаются расходы.
+	Subscriptions *Subscriptions
+
+	mu      sync.Mutex
+	orgs    map[int64]model.Organisation
+	units   map[int64]model.OrgUnit
+	centres map[int64]model.CostCentre
+	members map[orgMemberKey]model.OrgMember
+	// seq хранит порядок добавления участников, по которому Members их сортирует.
+	seq    map[orgMemberKey]int
+	nextID int64
+}
+
+type orgMemberKey struct {
+	orgID  int64
+	userID uuid.UUID
+}
+
+func NewOrganisations() *Organisations {
+	return &Organisations{
+		orgs:    map[int64]model.Organisation{},
+		units:   map[int64]model.OrgUnit{},
+		centres: map[int64]model.CostCentre{},
+		members: map[orgMemberKey]model.OrgMember{},
+		seq:     map[orgMemberKey]int{},
+	}
+}
+
+func (s *Organisations) track(ctx context.Context) {
+	track(ctx, s, func() func() {
+		orgs, units, centres := maps.Clone(s.orgs), maps.Clone(s.units), maps.Clone(s.centres)
+		members, seq, nextID := maps.Clone(s.members), maps.Clone(s.seq), s.nextID
+		return func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.orgs, s.units, s.centres = orgs, units, centres
+			s.members, s.seq, s.nextID = members, seq, nextID
+		}
+	})
+}
+
+// allocated возвращает центры затрат, к которым когда-либо относили подписки. Вызывается
+// до блокировки s.mu: Subscriptions блокирует свой мьютекс.
+func (s *Organisations) allocated() map[int64]bool {
+	res := map[int64]bool{}
+	if s.Subscriptions == nil {
+		return res
+	}
+	for _, sub := range s.Subscriptions.All() {
+		for _, a := range sub.Allocations {
+			if a.CostCentreID != nil {
+				res[*a.CostCentreID] = true
+			}
+		}
+	}
+	return res
+}
+
+// userExists проверяет пользователя до блокировки s.mu.
+func (s *Organisations) userExists(ctx context.Context, id uuid.UUID) bool {
+	if s.Users == nil {
+		return true
+	}
+	user, _ := s.Users.GetByID(ctx, id)
+	return user != nil
+}
+
+// IsMember сообщает, состоит ли пользователь хотя бы в одной организации.
+func (s *Organisations) IsMember(id uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.members {
+		if key.userID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// CostCentreExists проверяет центр затрат, как внешний ключ subscription_allocations.cost_centre_id.
+func (s *Organisations) CostCentreExists(id int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.centres[id]
+	return ok
+}
+
+func (s *Organisations) Create(ctx context.Context, org *model.Organisation, owner uuid.UUID) error {
+	if !s.userExists(ctx, owner) {
+		return repository.ErrOrgReference
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, other := range s.orgs {
+		if strings.EqualFold(other.Name, org.Name) {
+			return repository.ErrOrganisationExists
+		}
+	}
+	s.track(ctx)
+	s.nextID++
+	org.ID = s.nextID
+	org.CreatedAt = time.Now()
+	s.orgs[org.ID] = *org
+	s.putMember(model.OrgMember{OrganisationID: org.ID, UserID: owner, Role: model.RoleOwner})
+	return nil
+}
+
+func (s *Organisations) GetByID(_ context.Context, id int64) (*model.Organisation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	org, ok := s.orgs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &org, nil
+}
+
+func (s *Organisations) List(_ context.Context, userID *uuid.UUID, limit, offset int) ([]*model.Organisation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []*model.Organisation
+	for _, org := range s.orgs {
+		if userID != nil {
+			if _, ok := s.members[orgMemberKey{org.ID, *userID}]; !ok {
+				continue
+			}
+		}
+		res = append(res, &org)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := strings.ToLower(res[i].Name), strings.ToLower(res[j].Name)
+		if a != b {
+			return a < b
+		}
+		return res[i].ID < res[j].ID
+	})
+	res = res[min(offset, len(res)):]
+	if limit > 0 {
+		res = res[:min(limit, len(res))]
+	}
+	return res, nil
+}
+
+func (s *Organisations) Update(ctx context.Context, org *model.Organisation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.orgs[org.ID]
+	if !ok {
+		return repository.ErrOrganisationNotFound
+	}
+	for _, other := range s.orgs {
+		if other.ID != org.ID && strings.EqualFold(other.Name, org.Name) {
+			return repository.ErrOrganisationExists
+		}
+	}
+	s.track(ctx)
+	org.CreatedAt = current.CreatedAt
+	s.orgs[org.ID] = *org
+	return nil
+}
+
+// Delete удаляет организацию каскадом, если к её центрам затрат не относили подписки.
+func (s *Organisations) Delete(ctx context.Context, id int64) error {
+	allocated := s.allocated()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orgs[id]; !ok {
+		return repository.ErrOrganisationNotFound
+	}
+	for _, cc := range s.centres {
+		if cc.OrganisationID == id && allocated[cc.ID] {
+			return repository.ErrOrganisationInUse
+		}
+	}
+	s.track(ctx)
+	delete(s.orgs, id)
+	maps.DeleteFunc(s.units, func(_ int64, u model.OrgUnit) bool { return u.OrganisationID == id })
+	maps.DeleteFunc(s.centres, func(_ int64, cc model.CostCentre) bool { return cc.OrganisationID == id })
+	maps.DeleteFunc(s.members, func(key orgMemberKey, _ model.OrgMember) bool { return key.orgID == id })
+	return nil
+}
+
+// checkUnit проверяет подразделение unit: организацию и родителя, как внешние ключи,
+// и название, как уникальный индекс idx_org_units_name.
+func (s *Organisations) checkUnit(unit *model.OrgUnit) error {
+	if _, ok := s.orgs[unit.OrganisationID]; !ok {
+		return repository.ErrOrgReference
+	}
+	if unit.ParentID != nil {
+		if _, ok := s.units[*unit.ParentID]; !ok {
+			return repository.ErrOrgReference
+		}
+	}
+	for _, other := range s.units {
+		if other.ID != unit.ID && other.OrganisationID == unit.OrganisationID && strings.EqualFold(other.Name, unit.Name) {
+			return repository.ErrUnitExists
+		}
+	}
+	return nil
+}
+
+func (s *Organisations) CreateUnit(ctx context.Context, unit *model.OrgUnit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkUnit(unit); err != nil {
+		return err
+	}
+	s.track(ctx)
+	s.nextID++
+	unit.ID = s.nextID
+	unit.CreatedAt = time.Now()
+	s.units[unit.ID] = *unit
+	return nil
+}
+
+// Units возвращает подразделения в порядке репозитория: отделы перед командами, по названию.
+func (s *Organisations) Units(_ context.Context, orgID int64) ([]*model.OrgUnit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []*model.OrgUnit
+	for _, unit := range s.units {
+		if unit.OrganisationID == orgID {
+			res = append(res, &unit)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if a, b := res[i].Kind == model.UnitTeam, res[j].Kind == model.UnitTeam; a != b {
+			return b
+		}
+		if a, b := strings.ToLower(res[i].Name), strings.ToLower(res[j].Name); a != b {
+			return a < b
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res, nil
+}
+
+func (s *Organisations) UpdateUnit(ctx context.Context, unit *model.OrgUnit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.units[unit.ID]
+	if !ok || current.OrganisationID != unit.OrganisationID {
+		return repository.ErrUnitNotFound
+	}
+	if err := s.checkUnit(unit); err != nil {
+		return err
+	}
+	s.track(ctx)
+	unit.CreatedAt = current.CreatedAt
+	s.units[unit.ID] = *unit
+	return nil
+}
+
+// DeleteUnit удаляет подразделение без команд и центров затрат; его участники остаются
+// в организации без подразделения.
+func (s *Organisations) DeleteUnit(ctx context.Context, orgID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if unit, ok := s.units[id]; !ok || unit.OrganisationID != orgID {
+		return repository.ErrUnitNotFound
+	}
+	for _, unit := range s.units {
+		if unit.ParentID != nil && *unit.ParentID == id {
+			return repository.ErrUnitInUse
+		}
+	}
+	for _, cc := range s.centres {
+		if cc.UnitID != nil && *cc.UnitID == id {
+			return repository.ErrUnitInUse
+		}
+	}
+	s.track(ctx)
+	delete(s.units, id)
+	for key, m := range s.members {
+		if m.UnitID != nil && *m.UnitID == id {
+			m.UnitID = nil
+			s.members[key] = m
+		}
+	}
+	return nil
+}
+
+// checkCostCentre проверяет ссылки центра затрат и код, как уникальный индекс
+// idx_cost_centres_code.
+func (s *Organisations) checkCostCentre(cc *model.CostCentre) error {
+	if _, ok := s.orgs[cc.OrganisationID]; !ok {
+		return repository.ErrOrgReference
+	}
+	if cc.UnitID != nil {
+		if _, ok := s.units[*cc.UnitID]; !ok {
+			return repository.ErrOrgReference
+		}
+	}
+	for _, other := range s.centres {
+		if other.ID != cc.ID && other.OrganisationID == cc.OrganisationID && strings.EqualFold(other.Code, cc.Code) {
+			return repository.ErrCostCentreExists
+		}
+	}
+	return nil
+}
+
+func (s *Organisations) CreateCostCentre(ctx context.Context, cc *model.CostCentre) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkCostCentre(cc); err != nil {
+		return err
+	}
+	s.track(ctx)
+	s.nextID++
+	cc.ID = s.nextID
+	cc.CreatedAt = time.Now()
+	s.centres[cc.ID] = *cc
+	return nil
+}
+
+func (s *Organisations) CostCentres(_ context.Context, orgID int64) ([]*model.CostCentre, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []*model.CostCentre
+	for _, cc := range s.centres {
+		if cc.OrganisationID == orgID {
+			res = append(res, &cc)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if a, b := strings.ToLower(res[i].Code), strings.ToLower(res[j].Code); a != b {
+			return a < b
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res, nil
+}
+
+func (s *Organisations) UpdateCostCentre(ctx context.Context, cc *model.CostCentre) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.centres[cc.ID]
+	if !ok || current.OrganisationID != cc.OrganisationID {
+		return repository.ErrCostCentreNotFound
+	}
+	if err := s.checkCostCentre(cc); err != nil {
+		return err
+	}
+	s.track(ctx)
+	cc.CreatedAt = current.CreatedAt
+	s.centres[cc.ID] = *cc
+	return nil
+}
+
+func (s *Organisations) DeleteCostCentre(ctx context.Context, orgID, id int64) error {
+	allocated := s.allocated()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cc, ok := s.centres[id]; !ok || cc.OrganisationID != orgID {
+		return repository.ErrCostCentreNotFound
+	}
+	if allocated[id] {
+		return repository.ErrCostCentreInUse
+	}
+	s.track(ctx)
+	delete(s.centres, id)
+	return nil
+}
+
+func (s *Organisations) Members(_ context.Context, orgID int64) ([]*model.OrgMember, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []*model.OrgMember
+	for key, m := range s.members {
+		if key.orgID == orgID {
+			res = append(res, &m)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return s.seq[orgMemberKey{orgID, res[i].UserID}] < s.seq[orgMemberKey{orgID, res[j].UserID}]
+	})
+	return res, nil
+}
+
+// putMember сохраняет участника; повторное сохранение не меняет его место в списке.
+func (s *Organisations) putMember(m model.OrgMember) {
+	key := orgMemberKey{m.OrganisationID, m.UserID}
+	if current, ok := s.members[key]; ok {
+		m.CreatedAt = current.CreatedAt
+	} else {
+		m.CreatedAt = time.Now()
+		s.seq[key] = len(s.seq) + 1
+	}
+	s.members[key] = m
+}
+
+// owners возвращает число владельцев организации.
+func (s *Organisations) owners(orgID int64) int {
+	n := 0
+	for key, m := range s.members {
+		if key.orgID == orgID && m.Role == model.RoleOwner {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Organisations) SetMember(ctx context.Context, m *model.OrgMember) error {
+	userExists := s.userExists(ctx, m.UserID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orgs[m.OrganisationID]; !ok {
+		return repository.ErrOrganisationNotFound
+	}
+	if !userExists {
+		return repository.ErrOrgReference
+	}
+	if m.UnitID != nil {
+		if _, ok := s.units[*m.UnitID]; !ok {
+			return repository.ErrOrgReference
+		}
+	}
+	key := orgMemberKey{m.OrganisationID, m.UserID}
+	if current, ok := s.members[key]; ok && current.Role == model.RoleOwner && m.Role != model.RoleOwner && s.owners(m.OrganisationID) == 1 {
+		return repository.ErrLastOwner
+	}
+	s.track(ctx)
+	s.putMember(*m)
+	m.CreatedAt = s.members[key].CreatedAt
+	return nil
+}
+
+func (s *Organisations) RemoveMember(ctx context.Context, orgID int64, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orgs[orgID]; !ok {
+		return repository.ErrOrganisationNotFound
+	}
+	key := orgMemberKey{orgID, userID}
+	current, ok := s.members[key]
+	if !ok {
+		return repository.ErrOrgMemberNotFound
+	}
+	if current.Role == model.RoleOwner && s.owners(orgID) == 1 {
+		return repository.ErrLastOwner
+	}
+	s.track(ctx)
+	delete(s.members, key)
+	delete(s.seq, key)
+	return nil
+}
+
+// Spending, как запрос репозитория, относит начисление каждого месяца к центру затрат,
+// действующему в этом месяце. Совместные подписки учитываются целиком.
+func (s *Organisations) Spending(_ context.Context, orgID int64, from, to time.Time) ([]model.CostCentreSpending, error) {
+	var charges []charge
+	if s.Subscriptions != nil {
+		charges = s.Subscriptions.charges(repository.ChargeFilter{}, from, to)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	spent := map[int64]*model.CostCentreSpending{}
+	subs := map[int64][]int64{}
+	for _, c := range charges {
+		id := c.sub.CostCentreIn(c.month)
+		if id == nil || s.centres[*id].OrganisationID != orgID {
+			continue
+		}
+		sp, ok := spent[*id]
+		if !ok {
+			sp = &model.CostCentreSpending{CostCentreID: *id}
+			spent[*id] = sp
+		}
+		sp.Total += c.amount
+		if !slices.Contains(subs[*id], c.sub.ID) {
+			subs[*id] = append(subs[*id], c.sub.ID)
+			sp.Subscriptions++
+		}
+	}
+
+	var res []model.CostCentreSpending
+	for _, sp := range spent {
+		res = append(res, *sp)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].CostCentreID < res[j].CostCentreID })
+	return res, nil
+}
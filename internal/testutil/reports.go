@@ -0,0 +1,233 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/internal/service"
+)
+
+// Reports строит отчёты по хранилищам Stores так же, как запросы repository.ReportRepository.
+type Reports struct {
+	service.ReportStore
+
+	stores *Stores
+}
+
+// bucket накапливает сумму группы и подписки, по которым были начисления.
+type bucket struct {
+	model.SpendingBucket
+	subs map[int64]bool
+}
+
+func (r *Reports) Spending(ctx context.Context, filter repository.ChargeFilter, from, to time.Time, groupBy string) ([]model.SpendingBucket, error) {
+	buckets := map[string]*bucket{}
+	var keys []string
+	add := func(key, label string, c *charge) {
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{SpendingBucket: model.SpendingBucket{Key: key, Label: label}, subs: map[int64]bool{}}
+			buckets[key] = b
+			keys = append(keys, key)
+		}
+		if c != nil {
+			b.Total += c.amount
+			b.subs[c.sub.ID] = true
+		}
+	}
+
+	// При группировке по месяцам пустые месяцы периода возвращаются с нулевой суммой.
+	if groupBy == model.GroupByMonth {
+		for month := model.MonthStart(from); !month.After(to); month = month.AddDate(0, 1, 0) {
+			add(month.Format("01-2006"), "", nil)
+		}
+	}
+	for _, c := range r.stores.Subscriptions.charges(filter, from, to) {
+		switch groupBy {
+		case model.GroupByMonth:
+			add(c.month.Format("01-2006"), "", &c)
+		case model.GroupByService:
+			add(strconv.FormatInt(c.sub.ServiceID, 10), c.sub.ServiceName, &c)
+		case model.GroupByUser:
+			var label string
+			if user, _ := r.stores.Users.GetByID(ctx, c.user); user != nil {
+				switch {
+				case user.Name != nil:
+					label = *user.Name
+				case user.Email != nil:
+					label = *user.Email
+				}
+			}
+			add(c.user.String(), label, &c)
+		case model.GroupByCategory:
+			var key string
+			if category := r.stores.Subscriptions.category(c.sub); category != nil {
+				key = *category
+			}
+			add(key, "", &c)
+		case model.GroupByTag:
+			// Подписка с несколькими метками входит в группу каждой из них.
+			if len(c.sub.Tags) == 0 {
+				add("", "", &c)
+			}
+			for _, tag := range c.sub.Tags {
+				add(tag, "", &c)
+			}
+		default:
+			return nil, fmt.Errorf("unknown grouping %q", groupBy)
+		}
+	}
+
+	res := make([]model.SpendingBucket, 0, len(keys))
+	for _, key := range keys {
+		b := buckets[key]
+		b.Subscriptions = len(b.subs)
+		res = append(res, b.SpendingBucket)
+	}
+	if groupBy != model.GroupByMonth {
+		sort.SliceStable(res, func(i, j int) bool {
+			if res[i].Total != res[j].Total {
+				return res[i].Total > res[j].Total
+			}
+			if groupBy == model.GroupByService {
+				return res[i].Label < res[j].Label
+			}
+			return res[i].Key < res[j].Key
+		})
+	}
+	return res, nil
+}
+
+// Balances суммирует доли участников совместных подписок за месяц month по парам
+// участник — владелец. Пары упорядочены по ID пользователей, чтобы тесты не зависели
+// от порядка строк запроса.
+func (r *Reports) Balances(_ context.Context, month time.Time, userID *uuid.UUID) ([]model.Balance, error) {
+	type pair struct{ from, to uuid.UUID }
+	debts := map[pair]*model.Balance{}
+	for _, c := range r.stores.Subscriptions.charges(repository.ChargeFilter{}, month, month) {
+		p := pair{c.user, c.sub.UserID}
+		if p.from == p.to || userID != nil && *userID != p.from && *userID != p.to {
+			continue
+		}
+		b, ok := debts[p]
+		if !ok {
+			b = &model.Balance{From: p.from, To: p.to}
+			debts[p] = b
+		}
+		b.Amount += c.amount
+		if !slices.Contains(b.SubscriptionIDs, c.sub.ID) {
+			b.SubscriptionIDs = append(b.SubscriptionIDs, c.sub.ID)
+		}
+	}
+
+	var res []model.Balance
+	for _, b := range debts {
+		if b.Amount > 0 {
+			slices.Sort(b.SubscriptionIDs)
+			res = append(res, *b)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].From != res[j].From {
+			return res[i].From.String() < res[j].From.String()
+		}
+		return res[i].To.String() < res[j].To.String()
+	})
+	return res, nil
+}
+
+// Duplicates возвращает пересекающиеся пары подписок в порядке запроса репозитория:
+// по пользователю, названию сервиса и ID подписок.
+func (r *Reports) Duplicates(_ context.Context, userID *uuid.UUID, serviceID *int64) ([]model.DuplicatePair, error) {
+	subs := r.stores.Subscriptions.All()
+	var pairs []model.DuplicatePair
+	for i, a := range subs {
+		if !r.stores.Subscriptions.matches(a, repository.ChargeFilter{UserID: userID, ServiceID: serviceID}) {
+			continue
+		}
+		for _, b := range subs[i+1:] {
+			if !a.Overlaps(b) {
+				continue
+			}
+			pair := model.DuplicatePair{
+				UserID:      a.UserID,
+				ServiceID:   a.ServiceID,
+				ServiceName: a.ServiceName,
+				FirstID:     a.ID,
+				SecondID:    b.ID,
+				OverlapFrom: a.StartDate,
+			}
+			if b.StartDate.After(a.StartDate) {
+				pair.OverlapFrom = b.StartDate
+			}
+			// LEAST в Postgres пропускает NULL: конец пересечения — более ранний из заданных.
+			for _, end := range []*time.Time{a.EndDate, b.EndDate} {
+				if end != nil && (pair.OverlapTo == nil || end.Before(*pair.OverlapTo)) {
+					pair.OverlapTo = end
+				}
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		if pairs[i].UserID != pairs[j].UserID {
+			return pairs[i].UserID.String() < pairs[j].UserID.String()
+		}
+		return pairs[i].ServiceName < pairs[j].ServiceName
+	})
+	return pairs, nil
+}
+
+// Cancellations, как запрос репозитория с GROUPING SETS, группирует отмены за период
+// [from, to) по причинам и по сервисам, по убыванию числа отмен.
+func (r *Reports) Cancellations(ctx context.Context, from, to time.Time, userID *uuid.UUID, serviceID *int64) (byReason, byService []model.CancellationBucket, err error) {
+	subs := r.stores.Subscriptions
+	subs.mu.Lock()
+	cancellations := slices.Clone(subs.cancellations)
+	subs.mu.Unlock()
+
+	reasons, services := map[string]*model.CancellationBucket{}, map[string]*model.CancellationBucket{}
+	add := func(buckets map[string]*model.CancellationBucket, key, label string, c model.Cancellation) {
+		b, ok := buckets[key]
+		if !ok {
+			b = &model.CancellationBucket{Key: key, Label: label}
+			buckets[key] = b
+		}
+		b.Count++
+		b.LostRevenue += c.Price
+	}
+	for _, c := range cancellations {
+		if c.CancelledAt.Before(from) || !c.CancelledAt.Before(to) ||
+			userID != nil && c.UserID != *userID || serviceID != nil && c.ServiceID != *serviceID {
+			continue
+		}
+		add(reasons, c.Reason, "", c)
+		var label string
+		if svc, _ := r.stores.Services.GetByID(ctx, c.ServiceID); svc != nil {
+			label = svc.Name
+		}
+		add(services, strconv.FormatInt(c.ServiceID, 10), label, c)
+	}
+	return sortedCancellations(reasons), sortedCancellations(services), nil
+}
+
+func sortedCancellations(buckets map[string]*model.CancellationBucket) []model.CancellationBucket {
+	var res []model.CancellationBucket
+	for _, b := range buckets {
+		res = append(res, *b)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Count != res[j].Count {
+			return res[i].Count > res[j].Count
+		}
+		return res[i].Key < res[j].Key
+	})
+	return res
+}
@@ -0,0 +1,101 @@
+package testutil
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/event"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/service"
+)
+
+// Stores — хранилища в памяти, из которых тесты собирают сервисы.
+type Stores struct {
+	Subscriptions *Subscriptions
+	Services      *Services
+	Users         *Users
+	Budgets       *Budgets
+	Tags          *Tags
+	Organisations *Organisations
+	Broker        *event.Broker
+	// OverlapMode — режим пересечений SubscriptionService, по умолчанию model.OverlapWarn,
+	// как OVERLAP_MODE по умолчанию.
+	OverlapMode string
+	// Location — часовой пояс сервиса, по умолчанию UTC.
+	Location *time.Location
+}
+
+// NewStores создаёт хранилища с подписками subs. Сервисы подписок без ServiceID
+// добавляются в каталог по названию, а пользователи регистрируются без профиля.
+func NewStores(subs ...model.Subscription) *Stores {
+	ctx := context.Background()
+	services, users := NewServices(), NewUsers()
+	subs = slices.Clone(subs)
+	for i := range subs {
+		if err := users.Register(ctx, []uuid.UUID{subs[i].UserID}); err != nil {
+			panic(err)
+		}
+		if subs[i].ServiceID != 0 {
+			continue
+		}
+		svc, _ := services.FindByName(ctx, subs[i].ServiceName)
+		if svc == nil {
+			svc = &model.Service{Name: subs[i].ServiceName, Currency: "RUB"}
+			if err := services.Create(ctx, svc); err != nil {
+				panic(err)
+			}
+		}
+		subs[i].ServiceID = svc.ID
+	}
+	users.Subscriptions = NewSubscriptions(subs...)
+	users.Subscriptions.Services = services
+	budgets := NewBudgets()
+	budgets.Users, budgets.Services = users, services
+	orgs := NewOrganisations()
+	orgs.Users, orgs.Subscriptions = users, users.Subscriptions
+	users.Organisations, users.Subscriptions.Organisations = orgs, orgs
+	return &Stores{
+		Subscriptions: users.Subscriptions,
+		Services:      services,
+		Users:         users,
+		Budgets:       budgets,
+		Tags:          NewTags(users.Subscriptions),
+		Organisations: orgs,
+		Broker:        event.NewBroker(),
+		OverlapMode:   model.OverlapWarn,
+		Location:      time.UTC,
+	}
+}
+
+// Catalog собирает CatalogService, который добавляет неизвестные сервисы в каталог.
+func (s *Stores) Catalog() *service.CatalogService {
+	return service.NewCatalogService(s.Services, true)
+}
+
+// UserService собирает UserService, который регистрирует неизвестных пользователей подписок.
+func (s *Stores) UserService() *service.UserService {
+	return service.NewUserService(s.Users, s.Subscriptions, true, s.Location)
+}
+
+func (s *Stores) ReportService() *service.ReportService {
+	return service.NewReportService(&Reports{stores: s}, s.Subscriptions, s.Catalog(), s.UserService())
+}
+
+// BudgetService собирает BudgetService, который публикует уведомления в Broker.
+func (s *Stores) BudgetService() *service.BudgetService {
+	return service.NewBudgetService(s.Budgets, s.Subscriptions, s.UserService(), s.Broker)
+}
+
+func (s *Stores) TagService() *service.TagService {
+	return service.NewTagService(s.Tags, s.Subscriptions, s.Broker)
+}
+
+func (s *Stores) OrganisationService() *service.OrganisationService {
+	return service.NewOrganisationService(s.Organisations, s.UserService())
+}
+
+func (s *Stores) SubscriptionService() *service.SubscriptionService {
+	return service.NewSubscriptionService(s.Subscriptions, s.Catalog(), s.UserService(), s.BudgetService(), s.Broker, s.OverlapMode)
+}
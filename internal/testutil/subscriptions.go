@@ -0,0 +1,598 @@
+// Package testutil содержит хранилища в памяти для тестов сервисов, обработчиков и клиента.
+package testutil
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/internal/service"
+)
+
+// ErrInjected возвращается методами хранилища, для которых задан сбой.
+var ErrInjected = errors.New("injected failure")
+
+// Subscriptions хранит подписки в памяти. Методы, которые не нужны тестам, не реализованы:
+// их вызов через встроенный nil-интерфейс завершает тест паникой.
+type Subscriptions struct {
+	service.SubscriptionStore
+
+	mu     sync.Mutex
+	subs   map[int64]*model.Subscription
+	nextID int64
+	// cancellations — строки subscription_cancellations, по ним Reports строит отчёт об оттоке.
+	cancellations []model.Cancellation
+	// tags — таблица tags: метки добавляются при сохранении подписок, как upsertTagsQuery.
+	tags      map[int64]model.Tag
+	nextTagID int64
+	// FailCreateMany заставляет CreateMany вернуть ErrInjected, ничего не сохранив.
+	FailCreateMany bool
+	// Services, если задано, нужен для отбора начислений по категории сервиса.
+	Services *Services
+	// Organisations, если задано, проверяет центры затрат, как внешний ключ
+	// subscription_allocations.cost_centre_id.
+	Organisations *Organisations
+}
+
+func NewSubscriptions(subs ...model.Subscription) *Subscriptions {
+	s := &Subscriptions{subs: map[int64]*model.Subscription{}, tags: map[int64]model.Tag{}}
+	for _, sub := range subs {
+		s.put(&sub)
+	}
+	return s
+}
+
+// All возвращает сохранённые подписки по возрастанию ID.
+func (s *Subscriptions) All() []model.Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]model.Subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		res = append(res, *sub)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+// put сохраняет подписку: новая получает ID и первую версию, изменённая — следующую версию.
+func (s *Subscriptions) put(sub *model.Subscription) {
+	if sub.ID == 0 {
+		s.nextID++
+		sub.ID = s.nextID
+	} else if sub.ID > s.nextID {
+		s.nextID = sub.ID
+	}
+	if prev, ok := s.subs[sub.ID]; ok {
+		sub.Version = prev.Version + 1
+	} else if sub.Version == 0 {
+		sub.Version = 1
+	}
+	saved := *sub
+	saved.OverlapsWith = nil
+	saved.Tags = s.saveTags(sub.Tags)
+	s.subs[sub.ID] = &saved
+}
+
+// saveTags добавляет новые метки в tags и возвращает названия меток подписки так, как они
+// сохранены в tags, по алфавиту без учёта регистра.
+func (s *Subscriptions) saveTags(names []string) []string {
+	var res []string
+	for _, name := range names {
+		tag, ok := s.tagByName(name)
+		if !ok {
+			s.nextTagID++
+			tag = model.Tag{ID: s.nextTagID, Name: name, CreatedAt: time.Now()}
+			s.tags[tag.ID] = tag
+		}
+		res = append(res, tag.Name)
+	}
+	return model.NormalizeTags(res)
+}
+
+// tagByName ищет метку по названию без учёта регистра.
+func (s *Subscriptions) tagByName(name string) (model.Tag, bool) {
+	for _, tag := range s.tags {
+		if strings.EqualFold(tag.Name, name) {
+			return tag, true
+		}
+	}
+	return model.Tag{}, false
+}
+
+func (s *Subscriptions) track(ctx context.Context) {
+	track(ctx, s, func() func() {
+		subs, nextID, cancellations := maps.Clone(s.subs), s.nextID, slices.Clone(s.cancellations)
+		tags, nextTagID := maps.Clone(s.tags), s.nextTagID
+		return func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.subs, s.nextID, s.cancellations = subs, nextID, cancellations
+			s.tags, s.nextTagID = tags, nextTagID
+		}
+	})
+}
+
+// InTx выполняет fn в транзакции: при ошибке fn изменения всех хранилищ testutil,
+// сделанные с контекстом fn, откатываются.
+func (s *Subscriptions) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return inTx(ctx, fn)
+}
+
+// check возвращает ошибку репозитория для изменения подписки id с версией expectedVersion.
+func (s *Subscriptions) check(id int64, expectedVersion *int) error {
+	sub, ok := s.subs[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if expectedVersion != nil && *expectedVersion != sub.Version {
+		return repository.ErrVersionConflict
+	}
+	return nil
+}
+
+func (s *Subscriptions) Create(ctx context.Context, sub *model.Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.track(ctx)
+	s.put(sub)
+	return nil
+}
+
+func (s *Subscriptions) CreateMany(ctx context.Context, subs []*model.Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.FailCreateMany {
+		return ErrInjected
+	}
+	s.track(ctx)
+	for _, sub := range subs {
+		s.put(sub)
+	}
+	return nil
+}
+
+func (s *Subscriptions) GetByID(_ context.Context, id int64) (*model.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subs[id]
+	if !ok {
+		return nil, nil
+	}
+	res := *sub
+	return &res, nil
+}
+
+func (s *Subscriptions) List(_ context.Context, filter repository.ChargeFilter, limit, offset int) ([]*model.Subscription, error) {
+	var res []*model.Subscription
+	for _, sub := range s.All() {
+		if s.matches(sub, filter) {
+			res = append(res, &sub)
+		}
+	}
+	res = res[min(offset, len(res)):]
+	if limit > 0 {
+		res = res[:min(limit, len(res))]
+	}
+	return res, nil
+}
+
+// Search заменяет сходство триграмм и полнотекстовый поиск репозитория вхождением слов
+// запроса без учёта регистра; веса полей те же: сервис — 1, метки — 0,8, заметки — 0,6.
+// Заметки, как и название сервиса, возвращаются целиком, без отметок ts_headline.
+func (s *Subscriptions) Search(_ context.Context, q string, userID *uuid.UUID, limit, offset int) ([]model.SearchHit, error) {
+	words := strings.Fields(strings.ToLower(q))
+	matches := func(text string) bool {
+		return slices.ContainsFunc(words, func(word string) bool { return strings.Contains(strings.ToLower(text), word) })
+	}
+
+	var hits []model.SearchHit
+	for _, sub := range s.All() {
+		if userID != nil && sub.UserID != *userID {
+			continue
+		}
+		hit := model.SearchHit{Subscription: sub}
+		if matches(sub.ServiceName) {
+			hit.Rank += 1
+			hit.Highlights.ServiceName = sub.ServiceName
+		}
+		for _, tag := range sub.Tags {
+			if matches(tag) {
+				hit.Highlights.Tags = append(hit.Highlights.Tags, tag)
+			}
+		}
+		if len(hit.Highlights.Tags) > 0 {
+			hit.Rank += 0.8
+		}
+		if sub.Notes != nil && matches(*sub.Notes) {
+			hit.Rank += 0.6
+			hit.Highlights.Notes = *sub.Notes
+		}
+		if hit.Rank > 0 {
+			hits = append(hits, hit)
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Rank > hits[j].Rank })
+	hits = hits[min(offset, len(hits)):]
+	return hits[:min(limit, len(hits))], nil
+}
+
+func (s *Subscriptions) ListByUserIDs(_ context.Context, userIDs []uuid.UUID) ([]*model.Subscription, error) {
+	var res []*model.Subscription
+	for _, sub := range s.All() {
+		if slices.Contains(userIDs, sub.UserID) {
+			res = append(res, &sub)
+		}
+	}
+	return res, nil
+}
+
+func (s *Subscriptions) ListByServiceNames(_ context.Context, names []string) ([]*model.Subscription, error) {
+	var res []*model.Subscription
+	for _, sub := range s.All() {
+		if slices.Contains(names, sub.ServiceName) {
+			res = append(res, &sub)
+		}
+	}
+	return res, nil
+}
+
+func (s *Subscriptions) ServiceNames(_ context.Context) ([]string, error) {
+	var names []string
+	for _, sub := range s.All() {
+		names = append(names, sub.ServiceName)
+	}
+	slices.Sort(names)
+	return slices.Compact(names), nil
+}
+
+// charge — доля пользователя user в начислении подписки за месяц, строка CTE charges
+// репозитория.
+type charge struct {
+	sub    model.Subscription
+	user   uuid.UUID
+	month  time.Time
+	amount int
+}
+
+// matches проверяет подписку фильтром начислений.
+func (s *Subscriptions) matches(sub model.Subscription, filter repository.ChargeFilter) bool {
+	if filter.UserID != nil && sub.UserID != *filter.UserID || filter.ServiceID != nil && sub.ServiceID != *filter.ServiceID {
+		return false
+	}
+	for _, tag := range filter.Tags {
+		if !slices.ContainsFunc(sub.Tags, func(name string) bool { return strings.EqualFold(name, tag) }) {
+			return false
+		}
+	}
+	if filter.Category == nil {
+		return true
+	}
+	category := s.category(sub)
+	return category != nil && strings.EqualFold(*category, *filter.Category)
+}
+
+// category возвращает категорию подписки: собственную или категорию сервиса из каталога.
+func (s *Subscriptions) category(sub model.Subscription) *string {
+	if sub.Category != nil || s.Services == nil {
+		return sub.Category
+	}
+	if svc, _ := s.Services.GetByID(context.Background(), sub.ServiceID); svc != nil {
+		return svc.Category
+	}
+	return nil
+}
+
+// charges, как chargesQuery, возвращает доли начислений за каждый месяц с from по to,
+// в котором подписка действует, по цене этого месяца. Нулевой from начинает период с
+// начала подписки, нулевой to заканчивает его с подпиской, а бессрочную — текущим месяцем.
+// Пользователь фильтра отбирает свои и совместные подписки и только свои доли в них.
+func (s *Subscriptions) charges(filter repository.ChargeFilter, from, to time.Time) []charge {
+	userID := filter.UserID
+	filter.UserID = nil
+	var res []charge
+	for _, sub := range s.All() {
+		if !s.matches(sub, filter) || userID != nil && !slices.Contains(sub.UserIDs(), *userID) {
+			continue
+		}
+		first, last := from, to
+		if first.IsZero() {
+			first = sub.StartDate
+		}
+		if last.IsZero() {
+			last = model.MonthStart(time.Now())
+			if sub.EndDate != nil {
+				last = *sub.EndDate
+			}
+		}
+		for month := model.MonthStart(first); !month.After(last); month = month.AddDate(0, 1, 0) {
+			amount, ok := sub.ChargeIn(month)
+			if !ok {
+				continue
+			}
+			for _, share := range sub.Shares(amount) {
+				if userID == nil || share.UserID == *userID {
+					res = append(res, charge{sub: sub, user: share.UserID, month: month, amount: share.Amount})
+				}
+			}
+		}
+	}
+	return res
+}
+
+func (s *Subscriptions) ListActiveBetween(_ context.Context, filter repository.ChargeFilter, from, to time.Time) ([]*model.Subscription, error) {
+	var res []*model.Subscription
+	for _, sub := range s.All() {
+		if !s.matches(sub, filter) || !to.IsZero() && sub.StartDate.After(to) || !from.IsZero() && sub.EndDate != nil && sub.EndDate.Before(model.MonthStart(from)) {
+			continue
+		}
+		res = append(res, &sub)
+	}
+	return res, nil
+}
+
+// Overlapping, как запрос репозитория, находит для каждой подписки subs сохранённые подписки
+// того же пользователя на тот же сервис с пересекающимся периодом.
+func (s *Subscriptions) Overlapping(_ context.Context, subs []*model.Subscription) (map[int][]*model.Subscription, error) {
+	stored := s.All()
+	res := map[int][]*model.Subscription{}
+	for i, sub := range subs {
+		for _, other := range stored {
+			if other.ID != sub.ID && sub.Overlaps(other) {
+				res[i] = append(res[i], &other)
+			}
+		}
+	}
+	return res, nil
+}
+
+func (s *Subscriptions) TotalSumSubscription(ctx context.Context, userID *uuid.UUID, serviceID *int64, from, to time.Time) (int, error) {
+	return s.ChargesSum(ctx, repository.ChargeFilter{UserID: userID, ServiceID: serviceID}, from, to)
+}
+
+func (s *Subscriptions) ChargesSum(_ context.Context, filter repository.ChargeFilter, from, to time.Time) (int, error) {
+	var sum int
+	for _, c := range s.charges(filter, from, to) {
+		sum += c.amount
+	}
+	return sum, nil
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// ChargesByGroup, как репозиторий, группирует начисления запросом отчёта о расходах.
+func (s *Subscriptions) ChargesByGroup(ctx context.Context, filter repository.ChargeFilter, from, to time.Time, groupBy string) ([]model.SpendingBucket, error) {
+	reports := &Reports{stores: &Stores{Subscriptions: s, Services: s.Services}}
+	return reports.Spending(ctx, filter, from, to, groupBy)
+}
+
+func (s *Subscriptions) Update(ctx context.Context, sub *model.Subscription, expectedVersion *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(sub.ID, expectedVersion); err != nil {
+		return err
+	}
+	s.track(ctx)
+	s.put(sub)
+	return nil
+}
+
+func (s *Subscriptions) Delete(ctx context.Context, id int64, expectedVersion *int) (*model.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(id, expectedVersion); err != nil {
+		return nil, err
+	}
+	s.track(ctx)
+	deleted := s.subs[id]
+	delete(s.subs, id)
+	return deleted, nil
+}
+
+// SchedulePrice добавляет изменение цены или заменяет изменение того же месяца.
+func (s *Subscriptions) SchedulePrice(ctx context.Context, id int64, change model.PriceChange, expectedVersion *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(id, expectedVersion); err != nil {
+		return err
+	}
+	s.track(ctx)
+	sub := *s.subs[id]
+	sub.Prices = slices.DeleteFunc(slices.Clone(sub.Prices), func(p model.PriceChange) bool {
+		return p.EffectiveFrom.Equal(change.EffectiveFrom)
+	})
+	sub.Prices = append(sub.Prices, change)
+	slices.SortFunc(sub.Prices, func(a, b model.PriceChange) int { return a.EffectiveFrom.Compare(b.EffectiveFrom) })
+	sub.Version++
+	s.subs[id] = &sub
+	return nil
+}
+
+// Pause добавляет приостановку; пересечение с другой приостановкой подписки возвращает
+// repository.ErrPauseOverlap, как ограничение excl_subscription_pauses_overlap.
+func (s *Subscriptions) Pause(ctx context.Context, id int64, pause model.Pause, expectedVersion *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(id, expectedVersion); err != nil {
+		return err
+	}
+	sub := *s.subs[id]
+	for _, other := range sub.Pauses {
+		if (pause.To == nil || !other.From.After(*pause.To)) && (other.To == nil || !pause.From.After(*other.To)) {
+			return repository.ErrPauseOverlap
+		}
+	}
+	s.track(ctx)
+	sub.Pauses = append(slices.Clone(sub.Pauses), pause)
+	slices.SortFunc(sub.Pauses, func(a, b model.Pause) int { return a.From.Compare(b.From) })
+	sub.Version++
+	s.subs[id] = &sub
+	return nil
+}
+
+// Allocate, как репозиторий, заменяет распределение с того же месяца или добавляет новое.
+func (s *Subscriptions) Allocate(ctx context.Context, id int64, allocation model.Allocation, expectedVersion *int) error {
+	if s.Organisations != nil && allocation.CostCentreID != nil && !s.Organisations.CostCentreExists(*allocation.CostCentreID) {
+		return repository.ErrUnknownCostCentre
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(id, expectedVersion); err != nil {
+		return err
+	}
+	s.track(ctx)
+	sub := *s.subs[id]
+	sub.Allocations = slices.DeleteFunc(slices.Clone(sub.Allocations), func(a model.Allocation) bool {
+		return a.EffectiveFrom.Equal(allocation.EffectiveFrom)
+	})
+	sub.Allocations = append(sub.Allocations, allocation)
+	slices.SortFunc(sub.Allocations, func(a, b model.Allocation) int { return a.EffectiveFrom.Compare(b.EffectiveFrom) })
+	sub.Version++
+	s.subs[id] = &sub
+	return nil
+}
+
+// Resume, как репозиторий, удаляет приостановки с месяца month и заканчивает действующую
+// в нём месяцем раньше.
+func (s *Subscriptions) Resume(ctx context.Context, id int64, month time.Time, expectedVersion *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(id, expectedVersion); err != nil {
+		return err
+	}
+	sub := *s.subs[id]
+	var pauses []model.Pause
+	changed := false
+	for _, p := range sub.Pauses {
+		switch {
+		case !p.From.Before(month):
+			changed = true
+			continue
+		case p.To == nil || !p.To.Before(month):
+			to := month.AddDate(0, -1, 0)
+			p.To = &to
+			changed = true
+		}
+		pauses = append(pauses, p)
+	}
+	if !changed {
+		return repository.ErrNotPaused
+	}
+	s.track(ctx)
+	sub.Pauses = pauses
+	sub.Version++
+	s.subs[id] = &sub
+	return nil
+}
+
+// Cancel устанавливает end_date подписки и сохраняет отмену, заполняя её ID и время.
+func (s *Subscriptions) Cancel(ctx context.Context, c *model.Cancellation, expectedVersion *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(c.SubscriptionID, expectedVersion); err != nil {
+		return err
+	}
+	s.track(ctx)
+	sub := *s.subs[c.SubscriptionID]
+	end := c.EndDate
+	sub.EndDate = &end
+	sub.Version++
+	s.subs[c.SubscriptionID] = &sub
+	c.ID = int64(len(s.cancellations) + 1)
+	c.CancelledAt = time.Now()
+	s.cancellations = append(s.cancellations, *c)
+	return nil
+}
+
+// SetMembers заменяет правило разделения и участников подписки. У оставшихся участников,
+// как в репозитории, сохраняется порядок добавления.
+func (s *Subscriptions) SetMembers(ctx context.Context, id int64, split string, members []model.Member, expectedVersion *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(id, expectedVersion); err != nil {
+		return err
+	}
+	s.track(ctx)
+	sub := *s.subs[id]
+	var kept []model.Member
+	for _, m := range sub.Members {
+		if i := slices.IndexFunc(members, func(n model.Member) bool { return n.UserID == m.UserID }); i >= 0 {
+			kept = append(kept, members[i])
+		}
+	}
+	for _, m := range members {
+		if !slices.ContainsFunc(kept, func(k model.Member) bool { return k.UserID == m.UserID }) {
+			kept = append(kept, m)
+		}
+	}
+	sub.Split, sub.Members = split, kept
+	sub.Version++
+	s.subs[id] = &sub
+	return nil
+}
+
+func (s *Subscriptions) GetByIDs(_ context.Context, ids []int64) (map[int64]*model.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make(map[int64]*model.Subscription, len(ids))
+	for _, id := range ids {
+		if sub, ok := s.subs[id]; ok {
+			saved := *sub
+			res[id] = &saved
+		}
+	}
+	return res, nil
+}
+
+// ApplyBatch выполняет операции так же, как репозиторий: в атомарном режиме первая
+// ошибка отменяет весь пакет, иначе ошибка операции не влияет на остальные.
+func (s *Subscriptions) ApplyBatch(ctx context.Context, ops []model.BatchOp, atomic bool) ([]error, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.track(ctx)
+
+	saved, nextID := maps.Clone(s.subs), s.nextID
+
+	errs := make([]error, len(ops))
+	for i, op := range ops {
+		errs[i] = s.apply(op)
+		if errs[i] != nil && atomic {
+			s.subs, s.nextID = saved, nextID
+			for j := range errs {
+				if j != i {
+					errs[j] = repository.ErrRolledBack
+				}
+			}
+			return errs, nil
+		}
+	}
+	return errs, nil
+}
+
+func (s *Subscriptions) apply(op model.BatchOp) error {
+	switch op.Kind {
+	case model.BatchCreate:
+		s.put(op.Subscription)
+	case model.BatchUpdate:
+		if err := s.check(op.Subscription.ID, op.ExpectedVersion); err != nil {
+			return err
+		}
+		s.put(op.Subscription)
+	case model.BatchDelete:
+		if err := s.check(op.ID, op.ExpectedVersion); err != nil {
+			return err
+		}
+		*op.Subscription = *s.subs[op.ID]
+		delete(s.subs, op.ID)
+	}
+	return nil
+}
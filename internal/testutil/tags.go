@@ -0,0 +1,144 @@
+package testutil
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/internal/service"
+)
+
+// Tags работает с метками, которые Subscriptions сохраняет вместе с подписками, так же,
+// как repository.TagRepository с таблицами tags и subscription_tags.
+type Tags struct {
+	service.TagStore
+
+	subs *Subscriptions
+}
+
+func NewTags(subs *Subscriptions) *Tags {
+	return &Tags{subs: subs}
+}
+
+// count возвращает метку с числом подписок с ней.
+func (t *Tags) count(tag model.Tag) *model.Tag {
+	for _, sub := range t.subs.subs {
+		if hasTag(*sub, tag.Name) {
+			tag.Subscriptions++
+		}
+	}
+	return &tag
+}
+
+func hasTag(sub model.Subscription, name string) bool {
+	return slices.ContainsFunc(sub.Tags, func(tag string) bool { return strings.EqualFold(tag, name) })
+}
+
+func (t *Tags) GetByID(_ context.Context, id int64) (*model.Tag, error) {
+	t.subs.mu.Lock()
+	defer t.subs.mu.Unlock()
+	tag, ok := t.subs.tags[id]
+	if !ok {
+		return nil, nil
+	}
+	return t.count(tag), nil
+}
+
+func (t *Tags) List(_ context.Context, query *string, limit, offset int) ([]*model.Tag, error) {
+	t.subs.mu.Lock()
+	defer t.subs.mu.Unlock()
+	var res []*model.Tag
+	for _, tag := range t.subs.tags {
+		if query != nil && !strings.Contains(strings.ToLower(tag.Name), strings.ToLower(*query)) {
+			continue
+		}
+		res = append(res, t.count(tag))
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Subscriptions != res[j].Subscriptions {
+			return res[i].Subscriptions > res[j].Subscriptions
+		}
+		return strings.ToLower(res[i].Name) < strings.ToLower(res[j].Name)
+	})
+	res = res[min(offset, len(res)):]
+	if limit > 0 {
+		res = res[:min(limit, len(res))]
+	}
+	return res, nil
+}
+
+func (t *Tags) Rename(ctx context.Context, id int64, name string) ([]int64, error) {
+	t.subs.mu.Lock()
+	defer t.subs.mu.Unlock()
+	tag, ok := t.subs.tags[id]
+	if !ok {
+		return nil, repository.ErrTagNotFound
+	}
+	if other, ok := t.subs.tagByName(name); ok && other.ID != id {
+		return nil, repository.ErrTagExists
+	}
+	t.subs.track(ctx)
+	ids := t.retag(func(sub *model.Subscription) bool {
+		if !hasTag(*sub, tag.Name) {
+			return false
+		}
+		sub.Tags = slices.DeleteFunc(slices.Clone(sub.Tags), func(n string) bool { return strings.EqualFold(n, tag.Name) })
+		sub.Tags = model.NormalizeTags(append(sub.Tags, name))
+		return true
+	})
+	tag.Name = name
+	t.subs.tags[id] = tag
+	return ids, nil
+}
+
+func (t *Tags) Merge(ctx context.Context, sourceIDs []int64, targetID int64) ([]int64, error) {
+	t.subs.mu.Lock()
+	defer t.subs.mu.Unlock()
+	target, ok := t.subs.tags[targetID]
+	if !ok {
+		return nil, repository.ErrTagNotFound
+	}
+	var sources []string
+	for _, id := range sourceIDs {
+		source, ok := t.subs.tags[id]
+		if !ok {
+			return nil, repository.ErrTagNotFound
+		}
+		sources = append(sources, source.Name)
+	}
+	t.subs.track(ctx)
+	ids := t.retag(func(sub *model.Subscription) bool {
+		tags := slices.DeleteFunc(slices.Clone(sub.Tags), func(n string) bool {
+			return slices.ContainsFunc(sources, func(source string) bool { return strings.EqualFold(n, source) })
+		})
+		if len(tags) == len(sub.Tags) {
+			return false
+		}
+		sub.Tags = model.NormalizeTags(append(tags, target.Name))
+		return true
+	})
+	for _, id := range sourceIDs {
+		delete(t.subs.tags, id)
+	}
+	return ids, nil
+}
+
+// retag применяет fn к копиям подписок и, как touchTagged, увеличивает версию изменённых.
+// Возвращает ID изменённых подписок по возрастанию.
+func (t *Tags) retag(fn func(sub *model.Subscription) bool) []int64 {
+	var ids []int64
+	for id, stored := range t.subs.subs {
+		sub := *stored
+		if !fn(&sub) {
+			continue
+		}
+		sub.Version++
+		t.subs.subs[id] = &sub
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
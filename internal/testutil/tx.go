@@ -0,0 +1,50 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+)
+
+type txKey struct{}
+
+// fakeTx — транзакция хранилищ в памяти. Хранилище при первом изменении внутри
+// транзакции регистрирует восстановление своего состояния, и откат вызывает их
+// в обратном порядке.
+type fakeTx struct {
+	mu       sync.Mutex
+	tracked  map[any]bool
+	restores []func()
+}
+
+// inTx выполняет fn так же, как repository: ошибка fn откатывает изменения всех
+// хранилищ, а вложенный вызов выполняется во внешней транзакции.
+func inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*fakeTx); ok {
+		return fn(ctx)
+	}
+
+	tx := &fakeTx{tracked: map[any]bool{}}
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		for i := len(tx.restores) - 1; i >= 0; i-- {
+			tx.restores[i]()
+		}
+		return err
+	}
+	return nil
+}
+
+// track запоминает состояние store перед первым изменением в транзакции ctx.
+// snapshot вызывается под блокировкой хранилища и возвращает функцию восстановления.
+func track(ctx context.Context, store any, snapshot func() func()) {
+	tx, ok := ctx.Value(txKey{}).(*fakeTx)
+	if !ok {
+		return
+	}
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.tracked[store] {
+		return
+	}
+	tx.tracked[store] = true
+	tx.restores = append(tx.restores, snapshot())
+}
@@ -0,0 +1,202 @@
+package testutil
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/repository"
+	"github.com/shenikar/subscription-service/internal/service"
+)
+
+// Users хранит пользователей в памяти.
+type Users struct {
+	service.UserStore
+
+	// Subscriptions, если задано, запрещает удалять пользователей с подписками,
+	// как внешний ключ subscriptions.user_id.
+	Subscriptions *Subscriptions
+	// Organisations, если задано, запрещает удалять участников организаций, как внешний
+	// ключ organisation_members.user_id.
+	Organisations *Organisations
+
+	mu    sync.Mutex
+	users map[uuid.UUID]model.User
+	// seq хранит порядок создания, по которому List сортирует пользователей.
+	seq     map[uuid.UUID]int
+	nextSeq int
+}
+
+func NewUsers(users ...model.User) *Users {
+	s := &Users{users: map[uuid.UUID]model.User{}, seq: map[uuid.UUID]int{}}
+	for _, user := range users {
+		if err := s.Create(context.Background(), &user); err != nil {
+			panic(err)
+		}
+	}
+	return s
+}
+
+// All возвращает пользователей в порядке создания.
+func (s *Users) All() []model.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]model.User, 0, len(s.users))
+	for _, user := range s.users {
+		res = append(res, user)
+	}
+	sort.Slice(res, func(i, j int) bool { return s.seq[res[i].ID] < s.seq[res[j].ID] })
+	return res
+}
+
+func (s *Users) track(ctx context.Context) {
+	track(ctx, s, func() func() {
+		users, seq, nextSeq := maps.Clone(s.users), maps.Clone(s.seq), s.nextSeq
+		return func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.users, s.seq, s.nextSeq = users, seq, nextSeq
+		}
+	})
+}
+
+// emailTaken проверяет уникальность email без учёта регистра, как индекс idx_users_email.
+func (s *Users) emailTaken(user *model.User) bool {
+	if user.Email == nil {
+		return false
+	}
+	for _, other := range s.users {
+		if other.ID != user.ID && other.Email != nil && strings.EqualFold(*other.Email, *user.Email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Users) put(user model.User) {
+	s.nextSeq++
+	s.seq[user.ID] = s.nextSeq
+	s.users[user.ID] = user
+}
+
+func (s *Users) Create(ctx context.Context, user *model.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+	if _, ok := s.users[user.ID]; ok || s.emailTaken(user) {
+		return repository.ErrUserExists
+	}
+	s.track(ctx)
+	s.put(*user)
+	return nil
+}
+
+func (s *Users) GetByID(_ context.Context, id uuid.UUID) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[id]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (s *Users) List(_ context.Context, query *string, limit, offset int) ([]*model.User, error) {
+	var res []*model.User
+	for _, user := range s.All() {
+		if query != nil && !(user.Email != nil && containsFold(*user.Email, *query)) && !(user.Name != nil && containsFold(*user.Name, *query)) {
+			continue
+		}
+		res = append(res, &user)
+	}
+	res = res[min(offset, len(res)):]
+	if limit > 0 {
+		res = res[:min(limit, len(res))]
+	}
+	return res, nil
+}
+
+func (s *Users) Update(ctx context.Context, user *model.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.users[user.ID]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	if s.emailTaken(user) {
+		return repository.ErrUserExists
+	}
+	s.track(ctx)
+	user.CreatedAt = current.CreatedAt
+	s.users[user.ID] = *user
+	return nil
+}
+
+func (s *Users) Delete(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[id]; !ok {
+		return repository.ErrUserNotFound
+	}
+	if s.Subscriptions != nil && slices.ContainsFunc(s.Subscriptions.All(), func(sub model.Subscription) bool {
+		return sub.UserID == id
+	}) {
+		return repository.ErrUserInUse
+	}
+	if s.Subscriptions != nil && slices.ContainsFunc(s.Subscriptions.All(), func(sub model.Subscription) bool {
+		return slices.Contains(sub.UserIDs(), id)
+	}) {
+		return repository.ErrUserIsMember
+	}
+	if s.Organisations != nil && s.Organisations.IsMember(id) {
+		return repository.ErrUserInOrganisation
+	}
+	s.track(ctx)
+	delete(s.users, id)
+	delete(s.seq, id)
+	return nil
+}
+
+func (s *Users) Missing(_ context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []uuid.UUID
+	for _, id := range ids {
+		if _, ok := s.users[id]; !ok {
+			res = append(res, id)
+		}
+	}
+	return res, nil
+}
+
+// Register создаёт пользователей без профиля со значениями по умолчанию из миграции.
+func (s *Users) Register(ctx context.Context, ids []uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.track(ctx)
+	for _, id := range ids {
+		if _, ok := s.users[id]; !ok {
+			s.put(model.User{ID: id, Currency: "RUB", ReminderDays: 3})
+		}
+	}
+	return nil
+}
+
+func (s *Users) TimeZones(_ context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	zones := make(map[uuid.UUID]string)
+	for _, id := range ids {
+		if user, ok := s.users[id]; ok && user.TimeZone != nil {
+			zones[id] = *user.TimeZone
+		}
+	}
+	return zones, nil
+}
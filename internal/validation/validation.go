@@ -0,0 +1,39 @@
+package validation
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/shenikar/subscription-service/internal/dates"
+)
+
+// Тег date принимает даты в форматах, которые понимает dates.Parse.
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		_ = v.RegisterValidation("date", func(fl validator.FieldLevel) bool {
+			_, err := dates.Parse(fl.Field().String())
+			return err == nil
+		})
+	}
+}
+
+// Struct проверяет структуру по binding-тегам так же, как gin при ShouldBindJSON,
+// и возвращает ошибки по каждому полю.
+func Struct(obj any) []string {
+	err := binding.Validator.ValidateStruct(obj)
+	if err == nil {
+		return nil
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return []string{err.Error()}
+	}
+
+	msgs := make([]string, 0, len(verrs))
+	for _, fe := range verrs {
+		msgs = append(msgs, fe.Error())
+	}
+	return msgs
+}
@@ -0,0 +1,12 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS service_name VARCHAR(255);
+
+UPDATE subscriptions s
+SET service_name = sv.name
+FROM services sv
+WHERE sv.id = s.service_id;
+
+ALTER TABLE subscriptions ALTER COLUMN service_name SET NOT NULL;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS service_id;
+
+DROP TABLE IF EXISTS service_aliases;
+DROP TABLE IF EXISTS services;
\ No newline at end of file
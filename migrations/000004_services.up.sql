@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS services (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    category VARCHAR(100),
+    website VARCHAR(255),
+    default_price INTEGER CHECK (default_price > 0),
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_services_name ON services (lower(name));
+
+CREATE TABLE IF NOT EXISTS service_aliases (
+    alias VARCHAR(255) NOT NULL,
+    service_id INTEGER NOT NULL REFERENCES services (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_service_aliases_alias ON service_aliases (lower(alias));
+CREATE INDEX IF NOT EXISTS idx_service_aliases_service_id ON service_aliases (service_id);
+
+-- Каждое различающееся без учёта регистра и лишних пробелов название становится
+-- записью каталога; каноническим выбирается самое частое написание.
+WITH names AS (
+    SELECT regexp_replace(btrim(service_name), '\s+', ' ', 'g') AS name, count(*) AS cnt
+    FROM subscriptions
+    GROUP BY 1
+)
+INSERT INTO services (name)
+SELECT DISTINCT ON (lower(name)) name
+FROM names
+ORDER BY lower(name), cnt DESC, name;
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS service_id INTEGER REFERENCES services (id);
+
+UPDATE subscriptions s
+SET service_id = sv.id
+FROM services sv
+WHERE lower(sv.name) = lower(regexp_replace(btrim(s.service_name), '\s+', ' ', 'g'));
+
+ALTER TABLE subscriptions ALTER COLUMN service_id SET NOT NULL;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS service_name;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_id ON subscriptions (service_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_subscriptions_user_id;
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS fk_subscriptions_user_id;
+DROP TABLE IF EXISTS users;
\ No newline at end of file
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    email VARCHAR(255),
+    name VARCHAR(255),
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    reminder_days INTEGER NOT NULL DEFAULT 3 CHECK (reminder_days >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (lower(email)) WHERE email IS NOT NULL;
+
+-- Пользователи из существующих подписок регистрируются без профиля.
+INSERT INTO users (id)
+SELECT DISTINCT user_id FROM subscriptions
+ON CONFLICT (id) DO NOTHING;
+
+-- Ограничение добавляется без проверки и проверяется отдельно, чтобы не блокировать
+-- запись в subscriptions на время проверки существующих строк.
+ALTER TABLE subscriptions
+    ADD CONSTRAINT fk_subscriptions_user_id FOREIGN KEY (user_id) REFERENCES users (id) NOT VALID;
+ALTER TABLE subscriptions VALIDATE CONSTRAINT fk_subscriptions_user_id;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_id ON subscriptions (user_id);
@@ -0,0 +1,9 @@
+-- Изменения цены подписки, действующие с первого числа месяца effective_from.
+-- До первого изменения действует subscriptions.price.
+CREATE TABLE IF NOT EXISTS subscription_prices (
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    effective_from DATE NOT NULL CHECK (effective_from = date_trunc('month', effective_from)),
+    price INTEGER NOT NULL CHECK (price > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscription_id, effective_from)
+);
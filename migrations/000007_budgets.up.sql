@@ -0,0 +1,23 @@
+-- Месячный бюджет пользователя: на все подписки, на один сервис или на категорию сервисов.
+CREATE TABLE IF NOT EXISTS budgets (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    service_id INTEGER REFERENCES services (id) ON DELETE CASCADE,
+    category VARCHAR(100),
+    monthly_limit INTEGER NOT NULL CHECK (monthly_limit > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (service_id IS NULL OR category IS NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_budgets_user_id ON budgets (user_id);
+
+-- Отправленные уведомления: каждый порог срабатывает один раз за месяц.
+CREATE TABLE IF NOT EXISTS budget_alerts (
+    budget_id INTEGER NOT NULL REFERENCES budgets (id) ON DELETE CASCADE,
+    month DATE NOT NULL,
+    threshold INTEGER NOT NULL,
+    spent INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (budget_id, month, threshold)
+);
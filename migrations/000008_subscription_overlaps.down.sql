@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS excl_subscriptions_overlap;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS allow_overlap;
\ No newline at end of file
@@ -0,0 +1,28 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- allow_overlap отмечает подписки, пересечение которых с другими подписками того же
+-- пользователя и сервиса было допущено явно (режимы OVERLAP_MODE warn и allow).
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS allow_overlap BOOLEAN NOT NULL DEFAULT false;
+
+-- Уже существующие пересечения сохраняются: из каждой пересекающейся пары
+-- отмечается более поздняя подписка.
+UPDATE subscriptions s SET allow_overlap = true
+WHERE EXISTS (
+    SELECT 1 FROM subscriptions o
+    WHERE o.user_id = s.user_id AND o.service_id = s.service_id AND o.id < s.id
+        AND daterange(LEAST(o.start_date, o.end_date),
+                CASE WHEN o.end_date IS NOT NULL THEN (GREATEST(o.start_date, o.end_date) + interval '1 month')::date END)
+            && daterange(LEAST(s.start_date, s.end_date),
+                CASE WHEN s.end_date IS NOT NULL THEN (GREATEST(s.start_date, s.end_date) + interval '1 month')::date END)
+);
+
+-- Подписка действует до конца месяца end_date включительно. Старые записи могут
+-- заканчиваться раньше, чем начинаются, а daterange не строится с нижней границей больше
+-- верхней, поэтому период таких записей берётся от меньшей даты до большей. Без end_date
+-- LEAST и GREATEST возвращают start_date, а CASE оставляет период открытым.
+ALTER TABLE subscriptions ADD CONSTRAINT excl_subscriptions_overlap EXCLUDE USING gist (
+    user_id WITH =,
+    service_id WITH =,
+    daterange(LEAST(start_date, end_date),
+        CASE WHEN end_date IS NOT NULL THEN (GREATEST(start_date, end_date) + interval '1 month')::date END) WITH &&
+) WHERE (NOT allow_overlap);
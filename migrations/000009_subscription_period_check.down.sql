@@ -0,0 +1 @@
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS chk_subscriptions_period;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- Подписка не может закончиться раньше, чем началась. Записи, нарушающие правило,
+-- считаются закончившимися в месяце начала. Новый период лежит внутри прежнего, поэтому
+-- исключающее ограничение из 000008 не нарушается.
+UPDATE subscriptions SET end_date = start_date WHERE end_date < start_date;
+
+ALTER TABLE subscriptions ADD CONSTRAINT chk_subscriptions_period CHECK (end_date IS NULL OR end_date >= start_date);
@@ -0,0 +1,2 @@
+-- Часовой пояс пользователя (имя IANA). NULL — часовой пояс сервиса TIME_ZONE.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64);
@@ -0,0 +1,15 @@
+-- Приостановки оплаты подписки с первого числа месяца paused_from по месяц paused_to
+-- включительно. paused_to не задан, пока подписку не возобновили. Приостановки одной
+-- подписки не пересекаются.
+CREATE TABLE IF NOT EXISTS subscription_pauses (
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    paused_from DATE NOT NULL CHECK (paused_from = date_trunc('month', paused_from)),
+    paused_to DATE CHECK (paused_to = date_trunc('month', paused_to)),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscription_id, paused_from),
+    CONSTRAINT chk_subscription_pauses_period CHECK (paused_to IS NULL OR paused_to >= paused_from),
+    CONSTRAINT excl_subscription_pauses_overlap EXCLUDE USING gist (
+        subscription_id WITH =,
+        daterange(paused_from, (paused_to + interval '1 month')::date) WITH &&
+    )
+);
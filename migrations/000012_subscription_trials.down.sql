@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS trial_notifications;
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS chk_subscriptions_trial;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS trial_ends_at;
\ No newline at end of file
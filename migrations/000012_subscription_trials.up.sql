@@ -0,0 +1,13 @@
+-- Пробный период подписки заканчивается днём trial_ends_at: с этого дня подписка платная.
+-- Месяцы, первое число которых приходится на пробный период, не оплачиваются.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS trial_ends_at DATE;
+ALTER TABLE subscriptions ADD CONSTRAINT chk_subscriptions_trial CHECK (trial_ends_at IS NULL OR trial_ends_at > start_date);
+
+-- Отправленные уведомления о скором окончании пробного периода. Если дату окончания
+-- изменить, уведомление о новой дате отправляется снова.
+CREATE TABLE IF NOT EXISTS trial_notifications (
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    trial_ends_at DATE NOT NULL,
+    notified_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscription_id, trial_ends_at)
+);
@@ -0,0 +1,17 @@
+-- Отмены подписок с причиной и датой окончания. Пользователь и сервис копируются из
+-- подписки, чтобы отчёт об оттоке не терял отмены удалённых подписок.
+CREATE TABLE IF NOT EXISTS subscription_cancellations (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id INTEGER REFERENCES subscriptions (id) ON DELETE SET NULL,
+    user_id UUID NOT NULL,
+    service_id INTEGER NOT NULL REFERENCES services (id),
+    reason VARCHAR(32) NOT NULL,
+    note TEXT,
+    mode VARCHAR(16) NOT NULL CHECK (mode IN ('immediately', 'end_of_period')),
+    end_date DATE NOT NULL,
+    price INTEGER NOT NULL,
+    cancelled_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_cancellations_cancelled_at ON subscription_cancellations (cancelled_at);
+CREATE INDEX IF NOT EXISTS idx_subscription_cancellations_subscription_id ON subscription_cancellations (subscription_id);
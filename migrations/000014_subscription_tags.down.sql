@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS subscription_tags;
+DROP TABLE IF EXISTS tags;
+
+DROP INDEX IF EXISTS idx_subscriptions_category;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS notes;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS category;
\ No newline at end of file
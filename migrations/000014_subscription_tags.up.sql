@@ -0,0 +1,21 @@
+-- Категория подписки заменяет категорию сервиса из каталога в фильтрах, отчётах и бюджетах.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS category VARCHAR(100);
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS notes TEXT;
+
+-- Метки сравниваются без учёта регистра: «Work» и «work» — одна метка.
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (lower(name));
+
+CREATE TABLE IF NOT EXISTS subscription_tags (
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (subscription_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_tags_tag_id ON subscription_tags (tag_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_category ON subscriptions (lower(category));
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_subscriptions_notes_fts;
+DROP INDEX IF EXISTS idx_subscriptions_notes_trgm;
+DROP INDEX IF EXISTS idx_tags_name_trgm;
+DROP INDEX IF EXISTS idx_service_aliases_alias_trgm;
+DROP INDEX IF EXISTS idx_services_name_trgm;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- pg_trgm даёт нечёткий поиск по названиям сервисов, меткам и заметкам; триграммные
+-- индексы ускоряют и операторы сходства, и ILIKE '%...%' в поиске по каталогу и меткам.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_services_name_trgm ON services USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_service_aliases_alias_trgm ON service_aliases USING gin (alias gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_tags_name_trgm ON tags USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_notes_trgm ON subscriptions USING gin (notes gin_trgm_ops);
+
+-- Конфигурация russian обрабатывает и латиницу (стеммер english), поэтому подходит для смешанных заметок.
+CREATE INDEX IF NOT EXISTS idx_subscriptions_notes_fts ON subscriptions
+    USING gin (to_tsvector('russian', COALESCE(notes, '')));
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS subscription_members;
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS split_rule;
\ No newline at end of file
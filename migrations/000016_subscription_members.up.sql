@@ -0,0 +1,19 @@
+-- Совместную подписку оплачивает владелец user_id, а её стоимость делится с участниками
+-- по правилу split_rule: поровну, в процентах или фиксированными суммами.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS split_rule VARCHAR(16) NOT NULL DEFAULT 'equal'
+    CHECK (split_rule IN ('equal', 'percentage', 'fixed'));
+
+CREATE TABLE IF NOT EXISTS subscription_members (
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    percent INTEGER CHECK (percent BETWEEN 1 AND 100),
+    amount INTEGER CHECK (amount > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscription_id, user_id),
+    -- Участника совместных подписок удалить нельзя: сначала его исключают из подписок.
+    -- По имени ограничения удаление пользователя отличает участие в чужих подписках
+    -- от собственных подписок.
+    CONSTRAINT fk_subscription_members_user_id FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_members_user_id ON subscription_members (user_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS subscription_allocations;
+DROP TABLE IF EXISTS organisation_members;
+DROP TABLE IF EXISTS cost_centres;
+DROP TABLE IF EXISTS org_units;
+DROP TABLE IF EXISTS organisations;
\ No newline at end of file
@@ -0,0 +1,65 @@
+-- Организации объединяют пользователей и расходы компании. Подразделения образуют иерархию
+-- отдел → команда, центры затрат принадлежат подразделению или организации целиком.
+-- Подразделение с командами или центрами затрат, центр затрат с историей распределения
+-- и пользователя, состоящего в организациях, удалить нельзя (RESTRICT): сначала их
+-- освобождают. При удалении организации её подразделения и центры затрат удаляются
+-- каскадом в том же запросе, поэтому RESTRICT между ними не мешает, а распределённые
+-- подписки его останавливают. Обработчики различают ограничения по именам.
+CREATE TABLE IF NOT EXISTS organisations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organisations_name ON organisations (lower(name));
+
+CREATE TABLE IF NOT EXISTS org_units (
+    id SERIAL PRIMARY KEY,
+    organisation_id INTEGER NOT NULL REFERENCES organisations (id) ON DELETE CASCADE,
+    parent_id INTEGER,
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('department', 'team')),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT fk_org_units_parent_id FOREIGN KEY (parent_id) REFERENCES org_units (id) ON DELETE RESTRICT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_org_units_name ON org_units (organisation_id, lower(name));
+CREATE INDEX IF NOT EXISTS idx_org_units_parent_id ON org_units (parent_id);
+
+CREATE TABLE IF NOT EXISTS cost_centres (
+    id SERIAL PRIMARY KEY,
+    organisation_id INTEGER NOT NULL REFERENCES organisations (id) ON DELETE CASCADE,
+    unit_id INTEGER,
+    code VARCHAR(32) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT fk_cost_centres_unit_id FOREIGN KEY (unit_id) REFERENCES org_units (id) ON DELETE RESTRICT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cost_centres_code ON cost_centres (organisation_id, lower(code));
+CREATE INDEX IF NOT EXISTS idx_cost_centres_unit_id ON cost_centres (unit_id);
+
+CREATE TABLE IF NOT EXISTS organisation_members (
+    organisation_id INTEGER NOT NULL REFERENCES organisations (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'admin', 'finance', 'member')),
+    unit_id INTEGER REFERENCES org_units (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (organisation_id, user_id),
+    CONSTRAINT fk_organisation_members_user_id FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_organisation_members_user_id ON organisation_members (user_id);
+
+-- Центр затрат подписки действует с месяца effective_from до следующего перераспределения;
+-- cost_centre_id NULL снимает подписку с центра затрат.
+CREATE TABLE IF NOT EXISTS subscription_allocations (
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    effective_from DATE NOT NULL CHECK (effective_from = date_trunc('month', effective_from)),
+    cost_centre_id INTEGER,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscription_id, effective_from),
+    CONSTRAINT fk_subscription_allocations_cost_centre_id FOREIGN KEY (cost_centre_id) REFERENCES cost_centres (id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_allocations_cost_centre_id ON subscription_allocations (cost_centre_id);
@@ -0,0 +1,37 @@
+package api
+
+import "encoding/json"
+
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+type BatchOperation struct {
+	Op      string          `json:"op" enums:"create,update,delete"`
+	ID      *int64          `json:"id,omitempty" swaggertype:"integer"`
+	Version *int            `json:"version,omitempty" swaggertype:"integer"`
+	Data    json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+}
+
+type BatchRequest struct {
+	Atomic     bool             `json:"atomic"`
+	Operations []BatchOperation `json:"operations" binding:"required,min=1"`
+}
+
+type BatchItemResult struct {
+	Index        int                   `json:"index"`
+	Op           string                `json:"op"`
+	Status       int                   `json:"status"`
+	ID           int64                 `json:"id,omitempty"`
+	Subscription *SubscriptionResponse `json:"subscription,omitempty"`
+	Error        string                `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Atomic    bool              `json:"atomic"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Состояния бюджета в ответе статуса.
+const (
+	BudgetStatusOK       = "ok"
+	BudgetStatusWarning  = "warning"
+	BudgetStatusExceeded = "exceeded"
+)
+
+// BudgetRequest создаёт или полностью заменяет месячный бюджет пользователя. Бюджет
+// ограничивает все подписки пользователя либо, если задан service_id или category,
+// только подписки этого сервиса или этой категории.
+type BudgetRequest struct {
+	UserID       uuid.UUID `json:"user_id" binding:"required"`
+	ServiceID    *int64    `json:"service_id,omitempty" binding:"omitempty,min=1,excluded_with=Category"`
+	Category     *string   `json:"category,omitempty" binding:"omitempty,min=1,max=100"`
+	MonthlyLimit int       `json:"monthly_limit" binding:"required,min=1"`
+}
+
+type BudgetResponse struct {
+	ID           int64     `json:"id"`
+	UserID       uuid.UUID `json:"user_id"`
+	ServiceID    *int64    `json:"service_id,omitempty"`
+	Category     *string   `json:"category,omitempty"`
+	MonthlyLimit int       `json:"monthly_limit"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// BudgetAlertResponse — уведомление о достижении порога бюджета (в процентах).
+type BudgetAlertResponse struct {
+	Threshold int       `json:"threshold"`
+	Spent     int       `json:"spent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BudgetStatusResponse struct {
+	BudgetID     int64  `json:"budget_id"`
+	Month        string `json:"month"`
+	MonthlyLimit int    `json:"monthly_limit"`
+	Spent        int    `json:"spent"`
+	Remaining    int    `json:"remaining"`
+	// Utilization — доля использованного бюджета в процентах.
+	Utilization float64               `json:"utilization"`
+	Status      string                `json:"status"`
+	Alerts      []BudgetAlertResponse `json:"alerts"`
+}
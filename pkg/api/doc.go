@@ -0,0 +1,3 @@
+// Package api содержит публичные типы запросов и ответов REST API сервиса подписок.
+// Их использует как сам сервер, так и клиент из пакета pkg/client.
+package api
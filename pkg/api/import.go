@@ -0,0 +1,24 @@
+package api
+
+const (
+	ImportModePartial      = "partial"
+	ImportModeAllOrNothing = "all_or_nothing"
+)
+
+type ImportRowError struct {
+	Row    int      `json:"row"`
+	Errors []string `json:"errors"`
+}
+
+type ImportReport struct {
+	DryRun   bool             `json:"dry_run"`
+	Mode     string           `json:"mode"`
+	Total    int              `json:"total"`
+	Valid    int              `json:"valid"`
+	Invalid  int              `json:"invalid"`
+	Imported int              `json:"imported"`
+	Errors   []ImportRowError `json:"errors"`
+	// Warnings перечисляет строки, пересекающиеся с другими подписками пользователя
+	// на тот же сервис (режим OVERLAP_MODE=warn).
+	Warnings []ImportRowError `json:"warnings,omitempty"`
+}
@@ -0,0 +1,64 @@
+package api
+
+import "github.com/google/uuid"
+
+// MemberRequest — участник совместной подписки. Percent обязателен для правила percentage,
+// Amount — для fixed; для equal оба не задаются.
+type MemberRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+	// Percent — доля участника в процентах от начисления.
+	Percent *int `json:"percent,omitempty" binding:"omitempty,min=1,max=100"`
+	// Amount — фиксированная сумма участника в месяц.
+	Amount *int `json:"amount,omitempty" binding:"omitempty,min=1"`
+}
+
+// SetMembersRequest заменяет правило разделения стоимости и всех участников подписки.
+// Пустой members делает подписку обычной: всё начисление снова приходится на владельца.
+type SetMembersRequest struct {
+	// Split — правило разделения: equal (поровну), percentage (проценты) или fixed (суммы).
+	Split   string          `json:"split" binding:"required,oneof=equal percentage fixed"`
+	Members []MemberRequest `json:"members" binding:"max=20,dive"`
+}
+
+// MemberResponse — участник совместной подписки и его условия.
+type MemberResponse struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Percent *int      `json:"percent,omitempty"`
+	Amount  *int      `json:"amount,omitempty"`
+}
+
+// SharingResponse — доли владельца и участников подписки в начислении за месяц.
+type SharingResponse struct {
+	SubscriptionID int64  `json:"subscription_id"`
+	Split          string `json:"split"`
+	// Month и Price — месяц, для которого рассчитаны доли, и цена подписки в нём.
+	Month  string          `json:"month"`
+	Price  int             `json:"price"`
+	Shares []ShareResponse `json:"shares"`
+}
+
+// ShareResponse — доля пользователя в начислении. Владелец идёт первым и платит остаток
+// после долей участников, округлённых вниз.
+type ShareResponse struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Owner   bool      `json:"owner"`
+	Percent *int      `json:"percent,omitempty"`
+	Amount  *int      `json:"amount,omitempty"`
+	Share   int       `json:"share"`
+}
+
+// BalanceResponse — сумма, которую пользователь from_user_id должен пользователю to_user_id
+// за месяц с учётом встречных долгов.
+type BalanceResponse struct {
+	FromUserID uuid.UUID `json:"from_user_id"`
+	ToUserID   uuid.UUID `json:"to_user_id"`
+	Amount     int       `json:"amount"`
+	// Subscriptions — ID совместных подписок, из которых сложился долг.
+	Subscriptions []int64 `json:"subscriptions"`
+}
+
+// BalancesResponse — взаиморасчёты по совместным подпискам за месяц.
+type BalancesResponse struct {
+	Month    string            `json:"month"`
+	Balances []BalanceResponse `json:"balances"`
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateOrganisationRequest создаёт организацию; owner_id становится её владельцем.
+type CreateOrganisationRequest struct {
+	Name    string    `json:"name" binding:"required,max=200"`
+	OwnerID uuid.UUID `json:"owner_id" binding:"required"`
+}
+
+// OrganisationRequest переименовывает организацию.
+type OrganisationRequest struct {
+	Name string `json:"name" binding:"required,max=200"`
+}
+
+type OrganisationResponse struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrgUnitRequest создаёт или полностью заменяет подразделение. Отдел (department) входит
+// в организацию, команда (team) — в отдел parent_id или, без него, в организацию.
+type OrgUnitRequest struct {
+	Kind     string `json:"kind" binding:"required,oneof=department team"`
+	Name     string `json:"name" binding:"required,max=200"`
+	ParentID *int64 `json:"parent_id,omitempty" binding:"omitempty,min=1"`
+}
+
+type OrgUnitResponse struct {
+	ID             int64     `json:"id"`
+	OrganisationID int64     `json:"organisation_id"`
+	ParentID       *int64    `json:"parent_id,omitempty"`
+	Kind           string    `json:"kind"`
+	Name           string    `json:"name"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CostCentreRequest создаёт или полностью заменяет центр затрат. Без unit_id центр затрат
+// относится к организации целиком.
+type CostCentreRequest struct {
+	Code   string `json:"code" binding:"required,max=50"`
+	Name   string `json:"name" binding:"required,max=200"`
+	UnitID *int64 `json:"unit_id,omitempty" binding:"omitempty,min=1"`
+}
+
+type CostCentreResponse struct {
+	ID             int64     `json:"id"`
+	OrganisationID int64     `json:"organisation_id"`
+	UnitID         *int64    `json:"unit_id,omitempty"`
+	Code           string    `json:"code"`
+	Name           string    `json:"name"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// OrgMemberRequest добавляет пользователя в организацию или меняет его роль и подразделение.
+type OrgMemberRequest struct {
+	// Role — owner, admin, finance или member.
+	Role   string `json:"role" binding:"required,oneof=owner admin finance member"`
+	UnitID *int64 `json:"unit_id,omitempty" binding:"omitempty,min=1"`
+}
+
+type OrgMemberResponse struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Role      string    `json:"role"`
+	UnitID    *int64    `json:"unit_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AllocateRequest относит подписку к центру затрат начиная с месяца from. Без
+// cost_centre_id подписка с этого месяца ни к какому центру затрат не относится.
+type AllocateRequest struct {
+	CostCentreID *int64 `json:"cost_centre_id,omitempty" binding:"omitempty,min=1"`
+	// From — любая дата первого месяца нового распределения, не раньше начала подписки.
+	From string `json:"from" binding:"required,date"`
+}
+
+// AllocationPeriod — период, в который подписка относилась к центру затрат cost_centre_id.
+// To не задан у действующего распределения.
+type AllocationPeriod struct {
+	From         string  `json:"from"`
+	To           *string `json:"to,omitempty"`
+	CostCentreID *int64  `json:"cost_centre_id,omitempty"`
+}
+
+// OrgSpendingResponse — расходы организации за месяцы периода, свёрнутые по иерархии:
+// центр затрат → команда → отдел → организация. Каждый месяц начисление подписки
+// относится к центру затрат, действующему в этом месяце.
+type OrgSpendingResponse struct {
+	OrganisationID int64  `json:"organisation_id"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+	Total          int    `json:"total"`
+	// Units — отделы и команды вне отделов.
+	Units []UnitSpending `json:"units"`
+	// CostCentres — центры затрат, относящиеся к организации целиком.
+	CostCentres []CostCentreSpending `json:"cost_centres"`
+}
+
+// UnitSpending — расходы подразделения: его центров затрат и, для отдела, его команд.
+type UnitSpending struct {
+	ID          int64                `json:"id"`
+	Kind        string               `json:"kind"`
+	Name        string               `json:"name"`
+	Total       int                  `json:"total"`
+	Units       []UnitSpending       `json:"units"`
+	CostCentres []CostCentreSpending `json:"cost_centres"`
+}
+
+// CostCentreSpending — расходы центра затрат и число подписок, из которых они сложились.
+type CostCentreSpending struct {
+	ID            int64  `json:"id"`
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	Total         int    `json:"total"`
+	Subscriptions int    `json:"subscriptions"`
+}
@@ -0,0 +1,88 @@
+package api
+
+import "github.com/google/uuid"
+
+// Группировки отчёта о расходах.
+const (
+	GroupByMonth    = "month"
+	GroupByService  = "service"
+	GroupByUser     = "user"
+	GroupByCategory = "category"
+	GroupByTag      = "tag"
+)
+
+// SpendingBucket — расходы одной группы отчёта. Key — месяц в формате MM-YYYY, ID сервиса,
+// UUID пользователя, категория или метка (пустая строка — без категории или меток).
+type SpendingBucket struct {
+	Key           string `json:"key"`
+	Label         string `json:"label,omitempty"`
+	Total         int    `json:"total"`
+	Subscriptions int    `json:"subscriptions"`
+}
+
+type SpendingReportResponse struct {
+	From    string           `json:"from"`
+	To      string           `json:"to"`
+	GroupBy string           `json:"group_by"`
+	Total   int              `json:"total"`
+	Buckets []SpendingBucket `json:"buckets"`
+}
+
+// ForecastCharge — ожидаемое начисление по подписке в месяце прогноза.
+type ForecastCharge struct {
+	SubscriptionID int64     `json:"subscription_id"`
+	ServiceID      int64     `json:"service_id"`
+	ServiceName    string    `json:"service_name"`
+	UserID         uuid.UUID `json:"user_id"`
+	Price          int       `json:"price"`
+}
+
+type ForecastMonth struct {
+	Month         string           `json:"month"`
+	Total         int              `json:"total"`
+	Subscriptions []ForecastCharge `json:"subscriptions"`
+}
+
+type ForecastResponse struct {
+	From                string          `json:"from"`
+	To                  string          `json:"to"`
+	IncludePriceChanges bool            `json:"include_price_changes"`
+	Total               int             `json:"total"`
+	Months              []ForecastMonth `json:"months"`
+}
+
+// DuplicatePair — две подписки пользователя на один сервис, периоды которых пересекаются
+// с месяца OverlapFrom по месяц OverlapTo включительно. OverlapTo не задан, если обе
+// подписки бессрочные.
+type DuplicatePair struct {
+	UserID      uuid.UUID `json:"user_id"`
+	ServiceID   int64     `json:"service_id"`
+	ServiceName string    `json:"service_name"`
+	FirstID     int64     `json:"first_id"`
+	SecondID    int64     `json:"second_id"`
+	OverlapFrom string    `json:"overlap_from"`
+	OverlapTo   *string   `json:"overlap_to,omitempty"`
+}
+
+type DuplicatesReportResponse struct {
+	Count      int             `json:"count"`
+	Duplicates []DuplicatePair `json:"duplicates"`
+}
+
+// CancellationBucket — отмены одной группы отчёта об оттоке. Key — причина отмены или
+// ID сервиса, LostRevenue — сумма цен отменённых подписок в их последнем месяце.
+type CancellationBucket struct {
+	Key         string `json:"key"`
+	Label       string `json:"label,omitempty"`
+	Count       int    `json:"count"`
+	LostRevenue int    `json:"lost_revenue"`
+}
+
+type CancellationReportResponse struct {
+	From        string               `json:"from"`
+	To          string               `json:"to"`
+	Count       int                  `json:"count"`
+	LostRevenue int                  `json:"lost_revenue"`
+	ByReason    []CancellationBucket `json:"by_reason"`
+	ByService   []CancellationBucket `json:"by_service"`
+}
@@ -0,0 +1,27 @@
+package api
+
+import "time"
+
+// ServiceRequest создаёт или полностью заменяет запись каталога сервисов.
+// Псевдонимы используются при сопоставлении service_name подписок с каталогом.
+type ServiceRequest struct {
+	Name         string   `json:"name" binding:"required,max=255"`
+	Aliases      []string `json:"aliases" binding:"omitempty,dive,required,max=255"`
+	Category     *string  `json:"category,omitempty" binding:"omitempty,max=100"`
+	Website      *string  `json:"website,omitempty" binding:"omitempty,url,max=255"`
+	DefaultPrice *int     `json:"default_price,omitempty" binding:"omitempty,min=1"`
+	// Currency — код валюты ISO 4217, по умолчанию RUB.
+	Currency string `json:"currency,omitempty" binding:"omitempty,len=3,uppercase"`
+}
+
+type ServiceResponse struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	Aliases      []string  `json:"aliases"`
+	Category     *string   `json:"category,omitempty"`
+	Website      *string   `json:"website,omitempty"`
+	DefaultPrice *int      `json:"default_price,omitempty"`
+	Currency     string    `json:"currency"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Типы документов для PATCH /subscriptions/{id}.
+const (
+	PatchTypeMerge = "application/merge-patch+json"
+	PatchTypeJSON  = "application/json-patch+json"
+)
+
+// CreateSubscriptionRequest задаёт сервис по service_id или по service_name: название
+// сопоставляется с каталогом по названию и псевдонимам без учёта регистра. Если price
+// не указан, используется цена сервиса по умолчанию. Даты принимаются в форматах MM-YYYY,
+// YYYY-MM, YYYY-MM-DD и RFC 3339 и сохраняются первым числом месяца; end_date не может
+// быть раньше start_date.
+type CreateSubscriptionRequest struct {
+	ServiceID   *int64    `json:"service_id,omitempty" binding:"omitempty,min=1"`
+	ServiceName string    `json:"service_name" binding:"required_without=ServiceID"`
+	Price       int       `json:"price" binding:"omitempty,min=1"`
+	UserID      uuid.UUID `json:"user_id" binding:"required"`
+	StartDate   string    `json:"start_date" binding:"required,date"`
+	EndDate     *string   `json:"end_date,omitempty" binding:"omitempty,date"`
+	// TrialDays — длина пробного периода в днях от start_date, вместо trial_ends_at.
+	TrialDays *int `json:"trial_days,omitempty" binding:"omitempty,min=1,max=366,excluded_with=TrialEndsAt"`
+	// TrialEndsAt — день окончания пробного периода, с которого подписка платная.
+	TrialEndsAt *string `json:"trial_ends_at,omitempty" binding:"omitempty,date"`
+	// Category заменяет категорию сервиса из каталога в фильтрах, отчётах и бюджетах.
+	Category *string `json:"category,omitempty" binding:"omitempty,max=100"`
+	Notes    *string `json:"notes,omitempty" binding:"omitempty,max=2000"`
+	// Tags — метки подписки, регистр названий не учитывается.
+	Tags []string `json:"tags,omitempty" binding:"omitempty,max=20,dive,required,max=64"`
+}
+
+// UpdateSubscriptionRequest полностью заменяет подписку: отсутствующий end_date
+// означает бессрочную подписку. Сервис задаётся так же, как при создании.
+type UpdateSubscriptionRequest struct {
+	ServiceID   *int64    `json:"service_id,omitempty" binding:"omitempty,min=1"`
+	ServiceName string    `json:"service_name" binding:"required_without=ServiceID"`
+	Price       int       `json:"price" binding:"required,min=1"`
+	UserID      uuid.UUID `json:"user_id" binding:"required"`
+	StartDate   string    `json:"start_date" binding:"required,date"`
+	EndDate     *string   `json:"end_date" binding:"omitempty,date"`
+	TrialDays   *int      `json:"trial_days,omitempty" binding:"omitempty,min=1,max=366,excluded_with=TrialEndsAt"`
+	TrialEndsAt *string   `json:"trial_ends_at,omitempty" binding:"omitempty,date"`
+	Category    *string   `json:"category,omitempty" binding:"omitempty,max=100"`
+	Notes       *string   `json:"notes,omitempty" binding:"omitempty,max=2000"`
+	Tags        []string  `json:"tags,omitempty" binding:"omitempty,max=20,dive,required,max=64"`
+}
+
+type SubscriptionResponse struct {
+	ID          int64     `json:"id"`
+	ServiceID   int64     `json:"service_id"`
+	ServiceName string    `json:"service_name"`
+	Price       int       `json:"price"`
+	UserID      uuid.UUID `json:"user_id"`
+	StartDate   string    `json:"start_date"`
+	EndDate     *string   `json:"end_date,omitempty"`
+	Version     int       `json:"version"`
+	// PriceTimeline — периоды действия цен с учётом запланированных изменений.
+	PriceTimeline []PricePeriod `json:"price_timeline"`
+	// OverlapsWith — ID подписок пользователя на тот же сервис, с которыми пересекается
+	// период сохранённой подписки (режим OVERLAP_MODE=warn).
+	OverlapsWith []int64 `json:"overlaps_with,omitempty"`
+	// Paused — оплата подписки приостановлена в текущем месяце.
+	Paused bool `json:"paused"`
+	// Pauses — история приостановок, включая запланированные.
+	Pauses []PausePeriod `json:"pauses"`
+	// TrialEndsAt — день окончания пробного периода в формате YYYY-MM-DD.
+	TrialEndsAt *string `json:"trial_ends_at,omitempty"`
+	// InTrial — пробный период идёт сегодня.
+	InTrial bool `json:"in_trial"`
+	// Category — собственная категория подписки; без неё действует категория сервиса.
+	Category *string  `json:"category,omitempty"`
+	Notes    *string  `json:"notes,omitempty"`
+	Tags     []string `json:"tags"`
+	// Split и Members заданы у совместной подписки: её оплачивает user_id, а стоимость
+	// делится с участниками по правилу split.
+	Split   string           `json:"split,omitempty"`
+	Members []MemberResponse `json:"members,omitempty"`
+	// CostCentreID — центр затрат, к которому подписка относится в текущем месяце.
+	CostCentreID *int64 `json:"cost_centre_id,omitempty"`
+	// Allocations — история распределения подписки по центрам затрат.
+	Allocations []AllocationPeriod `json:"allocations,omitempty"`
+}
+
+// PausePeriod — приостановка оплаты подписки с месяца From по месяц To включительно.
+// To не задан, пока подписку не возобновили.
+type PausePeriod struct {
+	From string  `json:"from"`
+	To   *string `json:"to,omitempty"`
+}
+
+// PauseRequest приостанавливает оплату подписки с месяца from по месяц to включительно.
+type PauseRequest struct {
+	// From — первый месяц без оплаты, по умолчанию следующий: текущий месяц уже оплачен.
+	From *string `json:"from,omitempty" binding:"omitempty,date"`
+	// To — последний месяц без оплаты; без него подписка приостановлена до возобновления.
+	To *string `json:"to,omitempty" binding:"omitempty,date"`
+}
+
+// CancelRequest отменяет подписку. reason — too_expensive, not_using, switched_service,
+// missing_features, technical_issues, temporary или other; mode — immediately или
+// end_of_period (по умолчанию).
+type CancelRequest struct {
+	Reason string  `json:"reason" binding:"required,oneof=too_expensive not_using switched_service missing_features technical_issues temporary other"`
+	Note   *string `json:"note,omitempty" binding:"omitempty,max=1000"`
+	Mode   string  `json:"mode,omitempty" binding:"omitempty,oneof=immediately end_of_period"`
+}
+
+// CancellationResponse — сохранённая отмена и подписка с новым end_date.
+type CancellationResponse struct {
+	ID             int64                `json:"id"`
+	SubscriptionID int64                `json:"subscription_id"`
+	Reason         string               `json:"reason"`
+	Note           *string              `json:"note,omitempty"`
+	Mode           string               `json:"mode"`
+	EndDate        string               `json:"end_date"`
+	CancelledAt    time.Time            `json:"cancelled_at"`
+	Subscription   SubscriptionResponse `json:"subscription"`
+}
+
+// ResumeRequest возобновляет оплату приостановленной подписки с месяца from.
+type ResumeRequest struct {
+	// From — первый оплачиваемый месяц, по умолчанию следующий.
+	From *string `json:"from,omitempty" binding:"omitempty,date"`
+}
+
+// PricePeriod — цена подписки, действующая с месяца From по месяц To включительно.
+// To не задан у последнего периода бессрочной подписки.
+type PricePeriod struct {
+	From  string  `json:"from"`
+	To    *string `json:"to,omitempty"`
+	Price int     `json:"price"`
+}
+
+// SchedulePriceRequest планирует новую цену подписки с первого числа месяца effective_from.
+type SchedulePriceRequest struct {
+	Price         int    `json:"price" binding:"required,min=1"`
+	EffectiveFrom string `json:"effective_from" binding:"required,date"`
+}
+
+type TotalPriceResponse struct {
+	Total int `json:"total"`
+	// Groups — разбивка суммы по group_by. Подписка с несколькими метками входит в группу
+	// каждой из них, поэтому сумма групп по меткам может быть больше Total.
+	Groups []SpendingBucket `json:"groups,omitempty"`
+}
+
+// SearchResult — найденная подписка, её релевантность и совпавшие фрагменты.
+type SearchResult struct {
+	Subscription SubscriptionResponse `json:"subscription"`
+	// Rank — релевантность: результаты отсортированы по её убыванию.
+	Rank       float64          `json:"rank"`
+	Highlights SearchHighlights `json:"highlights"`
+}
+
+// SearchHighlights — совпавшие поля подписки. Найденные фрагменты обрамлены маркерами
+// <mark> и </mark>, остальной текст не экранируется; несовпавшие поля не выводятся.
+type SearchHighlights struct {
+	ServiceName string   `json:"service_name,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Notes       string   `json:"notes,omitempty"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
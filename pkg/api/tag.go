@@ -0,0 +1,23 @@
+package api
+
+import "time"
+
+// TagResponse — метка и число подписок с ней.
+type TagResponse struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	Subscriptions int       `json:"subscriptions"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// RenameTagRequest переименовывает метку во всех подписках.
+type RenameTagRequest struct {
+	Name string `json:"name" binding:"required,max=64"`
+}
+
+// MergeTagsRequest переносит подписки меток source_ids на метку target_id и удаляет
+// исходные метки.
+type MergeTagsRequest struct {
+	SourceIDs []int64 `json:"source_ids" binding:"required,min=1,max=100,dive,min=1"`
+	TargetID  int64   `json:"target_id" binding:"required,min=1"`
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserRequest создаёт или полностью заменяет профиль пользователя. ID можно задать
+// при создании, чтобы зарегистрировать пользователя с уже известным UUID.
+type UserRequest struct {
+	ID    *uuid.UUID `json:"id,omitempty"`
+	Email *string    `json:"email,omitempty" binding:"omitempty,email,max=255"`
+	Name  *string    `json:"name,omitempty" binding:"omitempty,max=255"`
+	// Currency — код валюты ISO 4217, по умолчанию RUB.
+	Currency string `json:"currency,omitempty" binding:"omitempty,len=3,uppercase"`
+	// ReminderDays — за сколько дней напоминать о продлении, по умолчанию 3.
+	ReminderDays *int `json:"reminder_days,omitempty" binding:"omitempty,min=0,max=365"`
+	// TimeZone — часовой пояс IANA, например Asia/Vladivostok. В нём определяются
+	// текущий месяц и месяц моментов времени RFC 3339, если запрос не задаёт пояс явно.
+	TimeZone *string `json:"time_zone,omitempty" binding:"omitempty,timezone"`
+}
+
+type UserResponse struct {
+	ID           uuid.UUID `json:"id"`
+	Email        *string   `json:"email,omitempty"`
+	Name         *string   `json:"name,omitempty"`
+	Currency     string    `json:"currency"`
+	ReminderDays int       `json:"reminder_days"`
+	TimeZone     *string   `json:"time_zone,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type RenewalResponse struct {
+	SubscriptionID int64  `json:"subscription_id"`
+	ServiceID      int64  `json:"service_id"`
+	ServiceName    string `json:"service_name"`
+	Price          int    `json:"price"`
+	// RenewsOn — дата следующего списания в формате DD-MM-YYYY.
+	RenewsOn string `json:"renews_on"`
+	// ReminderDue — до продления осталось не больше reminder_days пользователя.
+	ReminderDue bool `json:"reminder_due"`
+}
+
+type UserSummaryResponse struct {
+	UserID            uuid.UUID         `json:"user_id"`
+	SubscriptionCount int               `json:"subscription_count"`
+	ActiveCount       int               `json:"active_count"`
+	MonthlySpend      int               `json:"monthly_spend"`
+	UpcomingRenewals  []RenewalResponse `json:"upcoming_renewals"`
+}
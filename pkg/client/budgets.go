@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+type BudgetListOptions struct {
+	UserID uuid.UUID
+	Limit  int
+	Offset int
+}
+
+func (o BudgetListOptions) query() url.Values {
+	q := make(url.Values)
+	if o.UserID != uuid.Nil {
+		q.Set("user_id", o.UserID.String())
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		q.Set("offset", strconv.Itoa(o.Offset))
+	}
+	return q
+}
+
+func (c *Client) CreateBudget(ctx context.Context, budget api.BudgetRequest) (*api.BudgetResponse, error) {
+	req, err := jsonRequest(http.MethodPost, "/budgets/", budget, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.BudgetResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) GetBudget(ctx context.Context, id int64) (*api.BudgetResponse, error) {
+	var res api.BudgetResponse
+	if err := c.do(ctx, newRequest(http.MethodGet, budgetPath(id), nil), &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) ListBudgets(ctx context.Context, opts BudgetListOptions) ([]api.BudgetResponse, error) {
+	req := newRequest(http.MethodGet, "/budgets/", nil)
+	req.query = opts.query()
+
+	var res []api.BudgetResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) UpdateBudget(ctx context.Context, id int64, budget api.BudgetRequest) (*api.BudgetResponse, error) {
+	req, err := jsonRequest(http.MethodPut, budgetPath(id), budget, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.BudgetResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) DeleteBudget(ctx context.Context, id int64) error {
+	return c.do(ctx, newRequest(http.MethodDelete, budgetPath(id), nil), nil)
+}
+
+// BudgetStatus возвращает использование бюджета за месяц month. Нулевой month — текущий месяц.
+func (c *Client) BudgetStatus(ctx context.Context, id int64, month time.Time) (*api.BudgetStatusResponse, error) {
+	req := newRequest(http.MethodGet, budgetPath(id)+"/status", nil)
+	if !month.IsZero() {
+		req.query = url.Values{"month": {month.Format("01-2006")}}
+	}
+
+	var res api.BudgetStatusResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func budgetPath(id int64) string {
+	return "/budgets/" + strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,318 @@
+// Package client — Go-клиент REST API сервиса подписок.
+//
+//	c, err := client.New("http://localhost:8080", client.WithBearerToken(token))
+//	sub, err := c.Get(ctx, 42)
+//	for sub, err := range c.All(ctx, client.ListOptions{UserID: userID}) { ... }
+//
+// Запросы повторяются с экспоненциальной задержкой при ответах 429 и 5xx и при
+// сетевых ошибках, но только если повтор безопасен: для GET, PUT и DELETE, для
+// запросов с If-Match и для POST с Idempotency-Key. Create, Import и Batch
+// подставляют ключ идемпотентности сами, если он не передан явно.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+const (
+	apiPrefix = "/api/v1"
+
+	defaultTimeout    = 30 * time.Second
+	defaultMaxRetries = 3
+	defaultMinBackoff = 200 * time.Millisecond
+	defaultMaxBackoff = 5 * time.Second
+)
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	headers    http.Header
+
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient задаёт HTTP-клиент, например с собственным транспортом или таймаутом.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithBearerToken добавляет заголовок Authorization: Bearer <token> ко всем запросам.
+func WithBearerToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithHeader добавляет заголовок ко всем запросам.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.headers.Set(key, value)
+	}
+}
+
+func WithUserAgent(ua string) Option {
+	return WithHeader("User-Agent", ua)
+}
+
+// WithTimeZone задаёт часовой пояс IANA, в котором сервис определяет месяцы дат
+// запросов, вместо часового пояса пользователя.
+func WithTimeZone(name string) Option {
+	return WithHeader("X-Time-Zone", name)
+}
+
+// WithRetry настраивает повторы: maxRetries — число повторов после первой попытки
+// (0 отключает повторы), задержка растёт от minBackoff до maxBackoff.
+func WithRetry(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.minBackoff = minBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// New создаёт клиент. baseURL — адрес сервиса без префикса /api/v1,
+// например http://localhost:8080.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base url %q: scheme and host are required", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimRight(u.String(), "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		headers:    make(http.Header),
+		maxRetries: defaultMaxRetries,
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+	}
+	c.headers.Set("User-Agent", "subscription-service-go-client")
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// RequestOption задаёт заголовки отдельного запроса.
+type RequestOption func(http.Header)
+
+// WithIfMatch выполняет изменение только для указанной версии подписки.
+func WithIfMatch(version int) RequestOption {
+	return func(h http.Header) {
+		h.Set("If-Match", strconv.Quote(strconv.Itoa(version)))
+	}
+}
+
+// WithIdempotencyKey задаёт ключ идемпотентности для POST-запросов.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(h http.Header) {
+		h.Set("Idempotency-Key", key)
+	}
+}
+
+// Error — ответ API с кодом 4xx или 5xx.
+type Error struct {
+	StatusCode int
+	Message    string
+	Body       []byte
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("subscription api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("subscription api: %d %s", e.StatusCode, e.Message)
+}
+
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsPreconditionFailed сообщает, что подписка изменилась после чтения (If-Match не совпал).
+func IsPreconditionFailed(err error) bool {
+	return hasStatus(err, http.StatusPreconditionFailed)
+}
+
+func hasStatus(err error, code int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == code
+}
+
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   []byte
+}
+
+func newRequest(method, path string, opts []RequestOption) request {
+	req := request{method: method, path: path, header: make(http.Header)}
+	for _, opt := range opts {
+		opt(req.header)
+	}
+	return req
+}
+
+func jsonRequest(method, path string, body any, opts []RequestOption) (request, error) {
+	req := newRequest(method, path, opts)
+	data, err := json.Marshal(body)
+	if err != nil {
+		return request{}, fmt.Errorf("encode request: %w", err)
+	}
+	req.body = data
+	if req.header.Get("Content-Type") == "" {
+		req.header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// do выполняет запрос с повторами и декодирует JSON-ответ в out. Тело ответа с
+// ошибкой также декодируется в out, если это возможно: так Import и Batch
+// возвращают отчёт вместе с ошибкой 422.
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, req)
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			return decodeResponse(resp, out)
+		}
+
+		if attempt >= c.maxRetries || !req.retryable() || ctx.Err() != nil {
+			if err != nil {
+				return err
+			}
+			return decodeResponse(resp, out)
+		}
+
+		delay := c.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp); ok {
+				delay = after
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	u := c.baseURL + apiPrefix + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u, body)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	for key, values := range c.headers {
+		httpReq.Header[key] = values
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	for key, values := range req.header {
+		httpReq.Header[key] = values
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", req.method, req.path, err)
+	}
+	return resp, nil
+}
+
+// retryable сообщает, можно ли безопасно повторить запрос, не создав дубликат
+// и не применив изменение дважды.
+func (r request) retryable() bool {
+	switch r.method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return r.header.Get("Idempotency-Key") != "" || r.header.Get("If-Match") != ""
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.minBackoff << attempt
+	if d <= 0 || d > c.maxBackoff {
+		d = c.maxBackoff
+	}
+	// Полный джиттер, чтобы клиенты не повторяли запросы синхронно.
+	return time.Duration(rand.Int64N(int64(d) + 1))
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+func decodeResponse(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &Error{StatusCode: resp.StatusCode, Body: data}
+		var errResp api.ErrorResponse
+		if json.Unmarshal(data, &errResp) == nil && errResp.Error != "" {
+			apiErr.Message = errResp.Error
+		} else if out != nil {
+			_ = json.Unmarshal(data, out)
+		}
+		return apiErr
+	}
+
+	if out == nil || len(data) == 0 || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
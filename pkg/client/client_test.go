@@ -0,0 +1,1483 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/internal/config"
+	"github.com/shenikar/subscription-service/internal/gql"
+	"github.com/shenikar/subscription-service/internal/handler"
+	"github.com/shenikar/subscription-service/internal/middleware"
+	"github.com/shenikar/subscription-service/internal/model"
+	"github.com/shenikar/subscription-service/internal/router"
+	"github.com/shenikar/subscription-service/internal/testutil"
+	"github.com/shenikar/subscription-service/pkg/api"
+	"github.com/shenikar/subscription-service/pkg/client"
+)
+
+// Контрактные тесты запускают клиент против маршрутизатора сервиса, собранного так же,
+// как в cmd/app, но поверх хранилищ в памяти из internal/testutil.
+
+var userID = uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// attempt — запрос, дошедший до сервера.
+type attempt struct {
+	method string
+	path   string
+	query  string
+	key    string
+}
+
+// faultyServer пропускает запросы к маршрутизатору, но на первые запросы к пути
+// из faults отвечает заданными кодами, и запоминает все запросы.
+type faultyServer struct {
+	next http.Handler
+
+	mu       sync.Mutex
+	faults   map[string][]int
+	attempts []attempt
+}
+
+func (s *faultyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.attempts = append(s.attempts, attempt{
+		method: r.Method,
+		path:   r.URL.Path,
+		query:  r.URL.RawQuery,
+		key:    r.Header.Get(middleware.IdempotencyKeyHeader),
+	})
+	var status int
+	if codes := s.faults[r.Method+" "+r.URL.Path]; len(codes) > 0 {
+		status = codes[0]
+		s.faults[r.Method+" "+r.URL.Path] = codes[1:]
+	}
+	s.mu.Unlock()
+
+	if status != 0 {
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		http.Error(w, `{"error":"injected fault"}`, status)
+		return
+	}
+	s.next.ServeHTTP(w, r)
+}
+
+// fail задаёт коды ответов на следующие запросы method к path.
+func (s *faultyServer) fail(method, path string, codes ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[method+" "+path] = append(s.faults[method+" "+path], codes...)
+}
+
+// take возвращает запросы, дошедшие до сервера, и забывает их.
+func (s *faultyServer) take() []attempt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := s.attempts
+	s.attempts = nil
+	return res
+}
+
+// newServer собирает сервис поверх хранилища подписок subs.
+func newServer(t *testing.T, subs ...model.Subscription) (*client.Client, *faultyServer, *testutil.Subscriptions) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.Config{
+		BatchMaxSize:         100,
+		IdempotencyTTL:       time.Hour,
+		GraphQLMaxDepth:      8,
+		GraphQLMaxComplexity: 1000,
+	}
+	stores := testutil.NewStores(subs...)
+	svc := stores.SubscriptionService()
+	executor, err := gql.NewExecutor(svc, cfg.GraphQLMaxDepth, cfg.GraphQLMaxComplexity)
+	if err != nil {
+		t.Fatalf("build graphql schema: %v", err)
+	}
+
+	engine := router.SetupRouter(
+		handler.NewSubscriptionHandler(svc, cfg),
+		handler.NewServiceHandler(stores.Catalog()),
+		handler.NewUserHandler(stores.UserService(), svc),
+		handler.NewReportHandler(stores.ReportService()),
+		handler.NewBudgetHandler(stores.BudgetService()),
+		handler.NewTagHandler(stores.TagService()),
+		handler.NewOrganisationHandler(stores.OrganisationService()),
+		handler.NewGraphQLHandler(executor),
+		middleware.Idempotency(testutil.NewIdempotencyKeys(), cfg.IdempotencyTTL),
+	)
+
+	faulty := &faultyServer{next: engine, faults: map[string][]int{}}
+	srv := httptest.NewServer(faulty)
+	t.Cleanup(srv.Close)
+
+	c, err := client.New(srv.URL, client.WithRetry(3, time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, faulty, stores.Subscriptions
+}
+
+// statusOf возвращает код ответа API из ошибки клиента.
+func statusOf(err error) int {
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// wantAPIError проверяет, что err — ответ API с кодом status и сообщением message.
+func wantAPIError(t *testing.T, err error, status int, message string) {
+	t.Helper()
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got %v, want API error %d %q", err, status, message)
+	}
+	if apiErr.StatusCode != status || message != "" && apiErr.Message != message {
+		t.Fatalf("got API error %d %q, want %d %q", apiErr.StatusCode, apiErr.Message, status, message)
+	}
+}
+
+func TestSubscriptionLifecycle(t *testing.T) {
+	c, _, store := newServer(t)
+	ctx := context.Background()
+
+	created, err := c.Create(ctx, api.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: "07-2025"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	want := api.SubscriptionResponse{
+		ID: 1, ServiceID: 1, ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: "07-2025", Version: 1,
+		PriceTimeline: []api.PricePeriod{{From: "07-2025", Price: 400}},
+		Pauses:        []api.PausePeriod{},
+		Tags:          []string{},
+	}
+	if !reflect.DeepEqual(*created, want) {
+		t.Fatalf("Create = %+v, want %+v", *created, want)
+	}
+
+	got, err := c.Get(ctx, 1)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("Get = %+v, want %+v", *got, want)
+	}
+
+	updated, err := c.Update(ctx, 1, api.UpdateSubscriptionRequest{ServiceName: "Netflix", Price: 500, UserID: userID, StartDate: "07-2025"}, client.WithIfMatch(1))
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	want.Price, want.Version = 500, 2
+	want.PriceTimeline = []api.PricePeriod{{From: "07-2025", Price: 500}}
+	if !reflect.DeepEqual(*updated, want) {
+		t.Fatalf("Update = %+v, want %+v", *updated, want)
+	}
+
+	_, err = c.Update(ctx, 1, api.UpdateSubscriptionRequest{ServiceName: "Netflix", Price: 600, UserID: userID, StartDate: "07-2025"}, client.WithIfMatch(1))
+	if !client.IsPreconditionFailed(err) {
+		t.Fatalf("Update with a stale version: got %v, want 412", err)
+	}
+
+	merged, err := c.MergePatch(ctx, 1, map[string]any{"price": 600}, client.WithIfMatch(2))
+	if err != nil {
+		t.Fatalf("MergePatch: %v", err)
+	}
+	want.Price, want.Version = 600, 3
+	want.PriceTimeline = []api.PricePeriod{{From: "07-2025", Price: 600}}
+	if !reflect.DeepEqual(*merged, want) {
+		t.Fatalf("MergePatch = %+v, want %+v", *merged, want)
+	}
+
+	_, err = c.SchedulePrice(ctx, 1, api.SchedulePriceRequest{Price: 700, EffectiveFrom: "07-2025"})
+	wantAPIError(t, err, http.StatusBadRequest, "")
+	_, err = c.SchedulePrice(ctx, 1, api.SchedulePriceRequest{Price: 700, EffectiveFrom: "01-2026"}, client.WithIfMatch(2))
+	if !client.IsPreconditionFailed(err) {
+		t.Fatalf("SchedulePrice with a stale version: got %v, want 412", err)
+	}
+	scheduled, err := c.SchedulePrice(ctx, 1, api.SchedulePriceRequest{Price: 700, EffectiveFrom: "01-2026"}, client.WithIfMatch(3))
+	if err != nil {
+		t.Fatalf("SchedulePrice: %v", err)
+	}
+	// Цена подписки остаётся начальной, новая цена действует с месяца изменения.
+	december := "12-2025"
+	want.Version = 4
+	want.PriceTimeline = []api.PricePeriod{{From: "07-2025", To: &december, Price: 600}, {From: "01-2026", Price: 700}}
+	if !reflect.DeepEqual(*scheduled, want) {
+		t.Fatalf("SchedulePrice = %+v, want %+v", *scheduled, want)
+	}
+
+	patched, err := c.JSONPatch(ctx, 1, []client.PatchOperation{{Op: "replace", Path: "/service_name", Value: "Netflix Premium"}})
+	if err != nil {
+		t.Fatalf("JSONPatch: %v", err)
+	}
+	// Новое название добавляет сервис в каталог, и подписка ссылается на него.
+	want.ServiceID, want.ServiceName, want.Version = 2, "Netflix Premium", 5
+	if !reflect.DeepEqual(*patched, want) {
+		t.Fatalf("JSONPatch = %+v, want %+v", *patched, want)
+	}
+
+	if err := c.Delete(ctx, 1, client.WithIfMatch(4)); !client.IsPreconditionFailed(err) {
+		t.Fatalf("Delete with a stale version: got %v, want 412", err)
+	}
+	if err := c.Delete(ctx, 1, client.WithIfMatch(5)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if subs := store.All(); len(subs) != 0 {
+		t.Fatalf("store after Delete = %+v, want empty", subs)
+	}
+
+	_, err = c.Get(ctx, 1)
+	if !client.IsNotFound(err) {
+		t.Fatalf("Get after Delete: got %v, want 404", err)
+	}
+	wantAPIError(t, err, http.StatusNotFound, "subscription not found")
+
+	// Удаление без If-Match идемпотентно, а с If-Match отсутствующая подписка — 404.
+	if err := c.Delete(ctx, 1); err != nil {
+		t.Fatalf("repeated Delete: %v", err)
+	}
+	wantAPIError(t, c.Delete(ctx, 1, client.WithIfMatch(4)), http.StatusNotFound, "subscription not found")
+}
+
+func TestCreateRejectsInvalidRequest(t *testing.T) {
+	c, srv, store := newServer(t)
+
+	end := "06-2025"
+	_, err := c.Create(context.Background(), api.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: "2025-07", EndDate: &end})
+	wantAPIError(t, err, http.StatusBadRequest, "")
+	if attempts := srv.take(); len(attempts) != 1 {
+		t.Fatalf("attempts = %d, want 1: client errors are not retried", len(attempts))
+	}
+	if subs := store.All(); len(subs) != 0 {
+		t.Fatalf("store = %+v, want empty", subs)
+	}
+}
+
+func TestListAndTotal(t *testing.T) {
+	other := uuid.MustParse("2b1c6a4e-8a0f-4d3b-9c55-0d4e5f6a7b8c")
+	c, _, _ := newServer(t,
+		model.Subscription{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: month(2025, time.March)},
+		model.Subscription{ServiceName: "Spotify", Price: 200, UserID: userID, StartDate: month(2025, time.May)},
+		model.Subscription{ServiceName: "Netflix", Price: 300, UserID: other, StartDate: month(2025, time.March)},
+		model.Subscription{ServiceName: "Netflix Kids", Price: 100, UserID: userID, StartDate: month(2026, time.January)},
+	)
+	ctx := context.Background()
+
+	// Название сервиса в фильтре сопоставляется с каталогом без учёта регистра.
+	subs, err := c.List(ctx, client.ListOptions{UserID: userID, ServiceName: "netflix"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := []api.SubscriptionResponse{
+		{
+			ID: 1, ServiceID: 1, ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: "03-2025", Version: 1,
+			PriceTimeline: []api.PricePeriod{{From: "03-2025", Price: 400}},
+			Pauses:        []api.PausePeriod{},
+			Tags:          []string{},
+		},
+	}
+	if len(subs) != len(want) {
+		t.Fatalf("List = %+v, want %+v", subs, want)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(subs[i], want[i]) {
+			t.Errorf("List[%d] = %+v, want %+v", i, subs[i], want[i])
+		}
+	}
+
+	current := model.MonthStart(time.Now())
+	sinceMarch2025 := (current.Year()-2025)*12 + int(current.Month()) - int(time.March) + 1
+	tests := []struct {
+		name string
+		opts client.TotalOptions
+		want int
+	}{
+		// Начисления помесячные: Netflix с марта по 400 и Spotify с мая по 200.
+		{"user in 2025", client.TotalOptions{UserID: userID, From: month(2025, time.January), To: month(2025, time.December)}, 10*400 + 8*200},
+		{"service filter", client.TotalOptions{UserID: userID, ServiceName: "spotify", From: month(2025, time.January), To: month(2025, time.December)}, 8 * 200},
+		// Бессрочная подписка начисляется по to_date, будущие месяцы — прогноз.
+		{"other user", client.TotalOptions{UserID: other, From: month(2025, time.January), To: month(2026, time.December)}, 22 * 300},
+		{"empty period", client.TotalOptions{UserID: userID, From: month(2024, time.January), To: month(2024, time.December)}, 0},
+		// Без from_date период начинается с подписки, без to_date бессрочная считается по текущий месяц.
+		{"open start", client.TotalOptions{UserID: other, To: month(2025, time.December)}, 10 * 300},
+		{"open end", client.TotalOptions{UserID: other, From: current}, 300},
+		{"open period", client.TotalOptions{UserID: other}, sinceMarch2025 * 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, err := c.Total(ctx, tt.opts)
+			if err != nil {
+				t.Fatalf("Total: %v", err)
+			}
+			if total != tt.want {
+				t.Errorf("Total = %d, want %d", total, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllPaginates(t *testing.T) {
+	var subs []model.Subscription
+	for i := range 5 {
+		subs = append(subs, model.Subscription{ServiceName: "Netflix", Price: 100 + i, UserID: userID, StartDate: month(2025, time.January)})
+	}
+	c, srv, _ := newServer(t, subs...)
+	ctx := context.Background()
+	srv.take()
+
+	var ids []int64
+	for sub, err := range c.All(ctx, client.ListOptions{UserID: userID, Limit: 2}) {
+		if err != nil {
+			t.Fatalf("All: %v", err)
+		}
+		ids = append(ids, sub.ID)
+	}
+	if len(ids) != 5 {
+		t.Fatalf("All yielded %v, want subscriptions 1..5", ids)
+	}
+	for i, id := range ids {
+		if id != int64(i+1) {
+			t.Fatalf("All yielded %v, want subscriptions 1..5 in order", ids)
+		}
+	}
+
+	// Пять подписок страницами по две: три страницы, последняя неполная.
+	var queries []string
+	for _, a := range srv.take() {
+		queries = append(queries, a.query)
+	}
+	wantQueries := []string{
+		"limit=2&user_id=" + userID.String(),
+		"limit=2&offset=2&user_id=" + userID.String(),
+		"limit=2&offset=4&user_id=" + userID.String(),
+	}
+	if strings.Join(queries, " ") != strings.Join(wantQueries, " ") {
+		t.Fatalf("All requested %v, want %v", queries, wantQueries)
+	}
+
+	// Перебор можно прервать: следующая страница не запрашивается.
+	for range c.All(ctx, client.ListOptions{UserID: userID, Limit: 2}) {
+		break
+	}
+	if pages := srv.take(); len(pages) != 1 {
+		t.Fatalf("All requested %d pages after break, want 1", len(pages))
+	}
+}
+
+func TestAllStopsOnError(t *testing.T) {
+	c, srv, _ := newServer(t)
+	srv.fail(http.MethodGet, "/api/v1/subscriptions/", http.StatusBadRequest)
+
+	var errs int
+	for _, err := range c.All(context.Background(), client.ListOptions{Limit: 2}) {
+		if statusOf(err) != http.StatusBadRequest {
+			t.Fatalf("All: got %v, want 400", err)
+		}
+		errs++
+	}
+	if errs != 1 {
+		t.Fatalf("All yielded %d errors, want 1", errs)
+	}
+}
+
+func TestImport(t *testing.T) {
+	valid := api.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: "07-2025"}
+	invalid := api.CreateSubscriptionRequest{ServiceName: "Spotify", Price: 200, UserID: userID, StartDate: "13-2025"}
+	csv := "service_name,price,user_id,start_date\n" +
+		"Netflix,400," + userID.String() + ",07-2025\n" +
+		"Spotify,200," + userID.String() + ",13-2025\n"
+
+	tests := []struct {
+		name       string
+		call       func(c *client.Client) (*api.ImportReport, error)
+		wantStatus int
+		want       api.ImportReport
+		errorRow   int
+		saved      int
+	}{
+		{
+			name: "json dry run",
+			call: func(c *client.Client) (*api.ImportReport, error) {
+				return c.ImportJSON(context.Background(), []api.CreateSubscriptionRequest{valid, invalid}, client.ImportOptions{DryRun: true})
+			},
+			want:     api.ImportReport{DryRun: true, Mode: api.ImportModePartial, Total: 2, Valid: 1, Invalid: 1},
+			errorRow: 2,
+		},
+		{
+			name: "json partial",
+			call: func(c *client.Client) (*api.ImportReport, error) {
+				return c.ImportJSON(context.Background(), []api.CreateSubscriptionRequest{valid, invalid}, client.ImportOptions{})
+			},
+			want:     api.ImportReport{Mode: api.ImportModePartial, Total: 2, Valid: 1, Invalid: 1, Imported: 1},
+			errorRow: 2,
+			saved:    1,
+		},
+		{
+			name: "csv partial",
+			call: func(c *client.Client) (*api.ImportReport, error) {
+				return c.ImportCSV(context.Background(), strings.NewReader(csv), client.ImportOptions{})
+			},
+			want:     api.ImportReport{Mode: api.ImportModePartial, Total: 2, Valid: 1, Invalid: 1, Imported: 1},
+			errorRow: 2,
+			saved:    1,
+		},
+		{
+			name: "csv all or nothing",
+			call: func(c *client.Client) (*api.ImportReport, error) {
+				return c.ImportCSV(context.Background(), strings.NewReader(csv), client.ImportOptions{Mode: api.ImportModeAllOrNothing})
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			want:       api.ImportReport{Mode: api.ImportModeAllOrNothing, Total: 2, Valid: 1, Invalid: 1},
+			errorRow:   2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, store := newServer(t)
+
+			report, err := tt.call(c)
+			if statusOf(err) != tt.wantStatus || tt.wantStatus == 0 && err != nil {
+				t.Fatalf("got %v, want status %d", err, tt.wantStatus)
+			}
+			if report == nil {
+				t.Fatal("no import report")
+			}
+			if len(report.Errors) != 1 || report.Errors[0].Row != tt.errorRow {
+				t.Errorf("errors = %+v, want one error in row %d", report.Errors, tt.errorRow)
+			}
+			report.Errors = nil
+			if report.DryRun != tt.want.DryRun || report.Mode != tt.want.Mode || report.Total != tt.want.Total ||
+				report.Valid != tt.want.Valid || report.Invalid != tt.want.Invalid || report.Imported != tt.want.Imported {
+				t.Errorf("report = %+v, want %+v", *report, tt.want)
+			}
+			if saved := len(store.All()); saved != tt.saved {
+				t.Errorf("saved %d subscriptions, want %d", saved, tt.saved)
+			}
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	existing := model.Subscription{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: month(2025, time.July)}
+	create, err := client.NewBatchOperation(api.BatchOpCreate, nil, nil, api.CreateSubscriptionRequest{ServiceName: "Spotify", Price: 200, UserID: userID, StartDate: "08-2025"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, missing, stale := int64(1), int64(7), 5
+	remove, err := client.NewBatchOperation(api.BatchOpDelete, &id, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	removeMissing, err := client.NewBatchOperation(api.BatchOpDelete, &missing, &stale, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("applied", func(t *testing.T) {
+		c, _, store := newServer(t, existing)
+
+		resp, err := c.Batch(context.Background(), api.BatchRequest{Atomic: true, Operations: []api.BatchOperation{create, remove}})
+		if err != nil {
+			t.Fatalf("Batch: %v", err)
+		}
+		if !resp.Atomic || resp.Succeeded != 2 || resp.Failed != 0 || len(resp.Results) != 2 {
+			t.Fatalf("Batch = %+v, want two applied operations", resp)
+		}
+		if r := resp.Results[0]; r.Status != http.StatusCreated || r.ID != 2 || r.Subscription == nil || r.Subscription.ServiceName != "Spotify" {
+			t.Errorf("create result = %+v, want subscription 2 created", r)
+		}
+		if r := resp.Results[1]; r.Status != http.StatusNoContent || r.ID != 1 {
+			t.Errorf("delete result = %+v, want subscription 1 deleted", r)
+		}
+		if subs := store.All(); len(subs) != 1 || subs[0].ID != 2 {
+			t.Errorf("store = %+v, want only subscription 2", subs)
+		}
+	})
+
+	t.Run("rolled back", func(t *testing.T) {
+		c, _, store := newServer(t, existing)
+
+		resp, err := c.Batch(context.Background(), api.BatchRequest{Atomic: true, Operations: []api.BatchOperation{create, removeMissing}})
+		if statusOf(err) != http.StatusUnprocessableEntity {
+			t.Fatalf("Batch: got %v, want 422", err)
+		}
+		if resp == nil || resp.Succeeded != 0 || resp.Failed != 2 || len(resp.Results) != 2 {
+			t.Fatalf("Batch = %+v, want both operations failed", resp)
+		}
+		if r := resp.Results[1]; r.Status != http.StatusNotFound || r.Error != "subscription not found" {
+			t.Errorf("delete result = %+v, want 404", r)
+		}
+		if subs := store.All(); len(subs) != 1 || subs[0].ID != 1 {
+			t.Errorf("store = %+v, want the batch rolled back", subs)
+		}
+	})
+}
+
+func TestServiceCatalog(t *testing.T) {
+	c, _, _ := newServer(t)
+	ctx := context.Background()
+	video, price := "video", 400
+
+	created, err := c.CreateService(ctx, api.ServiceRequest{Name: "Netflix", Aliases: []string{"NFLX"}, Category: &video, DefaultPrice: &price})
+	if err != nil {
+		t.Fatalf("CreateService: %v", err)
+	}
+	want := api.ServiceResponse{ID: 1, Name: "Netflix", Aliases: []string{"NFLX"}, Category: &video, DefaultPrice: &price, Currency: "RUB"}
+	if !reflect.DeepEqual(*created, want) {
+		t.Fatalf("CreateService = %+v, want %+v", *created, want)
+	}
+
+	_, err = c.CreateService(ctx, api.ServiceRequest{Name: "nflx"})
+	wantAPIError(t, err, http.StatusConflict, "")
+
+	got, err := c.GetService(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetService: %v", err)
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("GetService = %+v, want %+v", *got, want)
+	}
+	_, err = c.GetService(ctx, 42)
+	wantAPIError(t, err, http.StatusNotFound, "service not found")
+
+	list, err := c.ListServices(ctx, client.ServiceListOptions{Query: "nfl"})
+	if err != nil {
+		t.Fatalf("ListServices: %v", err)
+	}
+	if !reflect.DeepEqual(list, []api.ServiceResponse{want}) {
+		t.Errorf("ListServices(q=nfl) = %+v, want %+v", list, want)
+	}
+	list, err = c.ListServices(ctx, client.ServiceListOptions{Category: "music"})
+	if err != nil {
+		t.Fatalf("ListServices: %v", err)
+	}
+	if len(list) != 0 {
+		t.Errorf("ListServices(category=music) = %+v, want empty", list)
+	}
+
+	// Подписка находит сервис по псевдониму и берёт цену по умолчанию из каталога.
+	sub, err := c.Create(ctx, api.CreateSubscriptionRequest{ServiceName: "nflx", UserID: userID, StartDate: "07-2025"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	wantSub := api.SubscriptionResponse{
+		ID: 1, ServiceID: 1, ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: "07-2025", Version: 1,
+		PriceTimeline: []api.PricePeriod{{From: "07-2025", Price: 400}},
+		Pauses:        []api.PausePeriod{},
+		Tags:          []string{},
+	}
+	if !reflect.DeepEqual(*sub, wantSub) {
+		t.Errorf("Create = %+v, want %+v", *sub, wantSub)
+	}
+
+	updated, err := c.UpdateService(ctx, 1, api.ServiceRequest{Name: "Netflix", Aliases: []string{"Нетфликс"}, Currency: "USD"})
+	if err != nil {
+		t.Fatalf("UpdateService: %v", err)
+	}
+	want = api.ServiceResponse{ID: 1, Name: "Netflix", Aliases: []string{"Нетфликс"}, Currency: "USD"}
+	if !reflect.DeepEqual(*updated, want) {
+		t.Errorf("UpdateService = %+v, want %+v", *updated, want)
+	}
+	_, err = c.UpdateService(ctx, 42, api.ServiceRequest{Name: "Spotify"})
+	wantAPIError(t, err, http.StatusNotFound, "service not found")
+
+	if err := c.DeleteService(ctx, 1); err != nil {
+		t.Fatalf("DeleteService: %v", err)
+	}
+	_, err = c.GetService(ctx, 1)
+	wantAPIError(t, err, http.StatusNotFound, "service not found")
+	wantAPIError(t, c.DeleteService(ctx, 1), http.StatusNotFound, "service not found")
+}
+
+func TestUsers(t *testing.T) {
+	c, _, _ := newServer(t)
+	ctx := context.Background()
+	id := uuid.MustParse("3f6c2b1a-5d4e-4f8a-9b7c-1e2d3c4b5a69")
+	email, name := "anna@example.com", "Anna"
+
+	created, err := c.CreateUser(ctx, api.UserRequest{ID: &id, Email: &email, Name: &name})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	want := api.UserResponse{ID: id, Email: &email, Name: &name, Currency: "RUB", ReminderDays: 3}
+	if !reflect.DeepEqual(*created, want) {
+		t.Fatalf("CreateUser = %+v, want %+v", *created, want)
+	}
+
+	upper := "ANNA@example.com"
+	_, err = c.CreateUser(ctx, api.UserRequest{Email: &upper})
+	wantAPIError(t, err, http.StatusConflict, "")
+
+	got, err := c.GetUser(ctx, id)
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("GetUser = %+v, want %+v", *got, want)
+	}
+	_, err = c.GetUser(ctx, userID)
+	wantAPIError(t, err, http.StatusNotFound, "user not found")
+
+	list, err := c.ListUsers(ctx, client.UserListOptions{Query: "anna"})
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	if !reflect.DeepEqual(list, []api.UserResponse{want}) {
+		t.Errorf("ListUsers(q=anna) = %+v, want %+v", list, want)
+	}
+
+	newName, days := "Anna K", 7
+	updated, err := c.UpdateUser(ctx, id, api.UserRequest{Email: &email, Name: &newName, Currency: "USD", ReminderDays: &days})
+	if err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	want = api.UserResponse{ID: id, Email: &email, Name: &newName, Currency: "USD", ReminderDays: 7}
+	if !reflect.DeepEqual(*updated, want) {
+		t.Errorf("UpdateUser = %+v, want %+v", *updated, want)
+	}
+	_, err = c.UpdateUser(ctx, userID, api.UserRequest{Name: &name})
+	wantAPIError(t, err, http.StatusNotFound, "user not found")
+
+	sub, err := c.Create(ctx, api.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 400, UserID: id, StartDate: "01-2025"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	subs, err := c.UserSubscriptions(ctx, id, client.ListOptions{})
+	if err != nil {
+		t.Fatalf("UserSubscriptions: %v", err)
+	}
+	if len(subs) != 1 || !reflect.DeepEqual(subs[0], *sub) {
+		t.Errorf("UserSubscriptions = %+v, want [%+v]", subs, *sub)
+	}
+	summary, err := c.UserSummary(ctx, id, 0)
+	if err != nil {
+		t.Fatalf("UserSummary: %v", err)
+	}
+	if summary.UserID != id || summary.SubscriptionCount != 1 || summary.ActiveCount != 1 || summary.MonthlySpend != 400 {
+		t.Errorf("UserSummary = %+v, want 1 active subscription for 400", *summary)
+	}
+
+	// Пользователя с подписками удалить нельзя.
+	wantAPIError(t, c.DeleteUser(ctx, id), http.StatusConflict, "user has subscriptions")
+	if err := c.Delete(ctx, sub.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := c.DeleteUser(ctx, id); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	_, err = c.GetUser(ctx, id)
+	wantAPIError(t, err, http.StatusNotFound, "user not found")
+	wantAPIError(t, c.DeleteUser(ctx, id), http.StatusNotFound, "user not found")
+}
+
+func TestSpending(t *testing.T) {
+	end := month(2025, time.February)
+	c, _, _ := newServer(t,
+		model.Subscription{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: month(2025, time.January), EndDate: &end},
+		model.Subscription{ServiceName: "Spotify", Price: 200, UserID: userID, StartDate: month(2025, time.February)},
+	)
+	ctx := context.Background()
+
+	// Декабрь до начала подписок возвращается с нулевой суммой.
+	report, err := c.Spending(ctx, client.SpendingOptions{From: month(2024, time.December), To: month(2025, time.March)})
+	if err != nil {
+		t.Fatalf("Spending: %v", err)
+	}
+	want := api.SpendingReportResponse{
+		From: "12-2024", To: "03-2025", GroupBy: api.GroupByMonth, Total: 400 + 600 + 200,
+		Buckets: []api.SpendingBucket{
+			{Key: "12-2024", Total: 0, Subscriptions: 0},
+			{Key: "01-2025", Total: 400, Subscriptions: 1},
+			{Key: "02-2025", Total: 600, Subscriptions: 2},
+			{Key: "03-2025", Total: 200, Subscriptions: 1},
+		},
+	}
+	if !reflect.DeepEqual(*report, want) {
+		t.Errorf("Spending by month = %+v, want %+v", *report, want)
+	}
+
+	report, err = c.Spending(ctx, client.SpendingOptions{From: month(2025, time.January), To: month(2025, time.March), GroupBy: api.GroupByService})
+	if err != nil {
+		t.Fatalf("Spending: %v", err)
+	}
+	want = api.SpendingReportResponse{
+		From: "01-2025", To: "03-2025", GroupBy: api.GroupByService, Total: 1200,
+		Buckets: []api.SpendingBucket{
+			{Key: "1", Label: "Netflix", Total: 800, Subscriptions: 1},
+			{Key: "2", Label: "Spotify", Total: 400, Subscriptions: 1},
+		},
+	}
+	if !reflect.DeepEqual(*report, want) {
+		t.Errorf("Spending by service = %+v, want %+v", *report, want)
+	}
+
+	_, err = c.Spending(ctx, client.SpendingOptions{From: month(2025, time.March), To: month(2025, time.January)})
+	wantAPIError(t, err, http.StatusBadRequest, "")
+}
+
+func TestForecast(t *testing.T) {
+	current := model.MonthStart(time.Now())
+	c, _, _ := newServer(t, model.Subscription{
+		ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: month(2025, time.January),
+		Prices: []model.PriceChange{{EffectiveFrom: current.AddDate(0, 2, 0), Price: 500}},
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		opts   client.ForecastOptions
+		prices []int
+	}{
+		{"with price changes", client.ForecastOptions{Months: 3}, []int{400, 500, 500}},
+		{"current prices", client.ForecastOptions{Months: 3, ExcludePriceChanges: true}, []int{400, 400, 400}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forecast, err := c.Forecast(ctx, tt.opts)
+			if err != nil {
+				t.Fatalf("Forecast: %v", err)
+			}
+			// Прогноз начинается со следующего месяца: текущий уже оплачен.
+			want := api.ForecastResponse{
+				From:                current.AddDate(0, 1, 0).Format("01-2006"),
+				To:                  current.AddDate(0, 3, 0).Format("01-2006"),
+				IncludePriceChanges: !tt.opts.ExcludePriceChanges,
+			}
+			for i, price := range tt.prices {
+				want.Total += price
+				want.Months = append(want.Months, api.ForecastMonth{
+					Month: current.AddDate(0, i+1, 0).Format("01-2006"),
+					Total: price,
+					Subscriptions: []api.ForecastCharge{
+						{SubscriptionID: 1, ServiceID: 1, ServiceName: "Netflix", UserID: userID, Price: price},
+					},
+				})
+			}
+			if !reflect.DeepEqual(*forecast, want) {
+				t.Errorf("Forecast = %+v, want %+v", *forecast, want)
+			}
+		})
+	}
+
+	_, err := c.Forecast(ctx, client.ForecastOptions{Months: 37})
+	wantAPIError(t, err, http.StatusBadRequest, "")
+}
+
+func TestPauseAndResume(t *testing.T) {
+	c, _, _ := newServer(t,
+		model.Subscription{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: month(2025, time.January)},
+	)
+	ctx := context.Background()
+	from, to := "03-2025", "05-2025"
+
+	sub, err := c.Pause(ctx, 1, api.PauseRequest{From: &from, To: &to})
+	if err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	if want := []api.PausePeriod{{From: "03-2025", To: &to}}; !reflect.DeepEqual(sub.Pauses, want) || sub.Version != 2 {
+		t.Errorf("Pause = %+v, want pauses %+v and version 2", sub, want)
+	}
+
+	// Приостановки подписки не пересекаются.
+	overlapping := "04-2025"
+	_, err = c.Pause(ctx, 1, api.PauseRequest{From: &overlapping})
+	wantAPIError(t, err, http.StatusConflict, "subscription is already paused in this period")
+
+	// Возобновление с апреля заканчивает приостановку мартом.
+	resume := "04-2025"
+	sub, err = c.Resume(ctx, 1, api.ResumeRequest{From: &resume})
+	if err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	march := "03-2025"
+	if want := []api.PausePeriod{{From: "03-2025", To: &march}}; !reflect.DeepEqual(sub.Pauses, want) || sub.Version != 3 {
+		t.Errorf("Resume = %+v, want pauses %+v and version 3", sub, want)
+	}
+
+	_, err = c.Resume(ctx, 1, api.ResumeRequest{From: &resume})
+	wantAPIError(t, err, http.StatusConflict, "subscription is not paused")
+
+	// Март не оплачивается: из первого полугодия начисляются пять месяцев.
+	total, err := c.Total(ctx, client.TotalOptions{UserID: userID, From: month(2025, time.January), To: month(2025, time.June)})
+	if err != nil {
+		t.Fatalf("Total: %v", err)
+	}
+	if total != 5*400 {
+		t.Errorf("Total = %d, want %d", total, 5*400)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ended := month(2025, time.February)
+	c, _, _ := newServer(t,
+		model.Subscription{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: month(2025, time.January)},
+		model.Subscription{ServiceName: "Spotify", Price: 200, UserID: userID, StartDate: month(2025, time.January)},
+		model.Subscription{ServiceName: "Netflix", Price: 500, UserID: userID, StartDate: month(2099, time.January)},
+		model.Subscription{ServiceName: "Spotify", Price: 200, UserID: userID, StartDate: month(2025, time.January), EndDate: &ended},
+	)
+	ctx := context.Background()
+	current := model.MonthStart(time.Now())
+
+	res, err := c.Cancel(ctx, 1, api.CancelRequest{Reason: model.CancelReasonTooExpensive, Mode: model.CancelImmediately})
+	if err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	end := current.Format("01-2006")
+	if res.SubscriptionID != 1 || res.Mode != model.CancelImmediately || res.EndDate != end ||
+		res.Subscription.EndDate == nil || *res.Subscription.EndDate != end || res.Subscription.Version != 2 {
+		t.Errorf("Cancel = %+v, want subscription 1 ending on %s", res, end)
+	}
+	// Режим по умолчанию — end_of_period: без пробного периода это текущий месяц.
+	if res, err = c.Cancel(ctx, 2, api.CancelRequest{Reason: model.CancelReasonNotUsing}); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if res.Mode != model.CancelEndOfPeriod || res.EndDate != end {
+		t.Errorf("Cancel = %+v, want end_of_period ending on %s", res, end)
+	}
+
+	_, err = c.Cancel(ctx, 3, api.CancelRequest{Reason: model.CancelReasonOther})
+	wantAPIError(t, err, http.StatusConflict, "subscription has not started yet: starts on 01-2099, delete it instead")
+	_, err = c.Cancel(ctx, 4, api.CancelRequest{Reason: model.CancelReasonOther})
+	wantAPIError(t, err, http.StatusConflict, "subscription has already ended on 02-2025")
+	_, err = c.Cancel(ctx, 1, api.CancelRequest{Reason: "bored"})
+	wantAPIError(t, err, http.StatusBadRequest, "")
+
+	report, err := c.Cancellations(ctx, client.CancellationsOptions{From: current, To: current})
+	if err != nil {
+		t.Fatalf("Cancellations: %v", err)
+	}
+	want := api.CancellationReportResponse{
+		From: end, To: end, Count: 2, LostRevenue: 600,
+		ByReason: []api.CancellationBucket{
+			{Key: model.CancelReasonNotUsing, Count: 1, LostRevenue: 200},
+			{Key: model.CancelReasonTooExpensive, Count: 1, LostRevenue: 400},
+		},
+		ByService: []api.CancellationBucket{
+			{Key: "1", Label: "Netflix", Count: 1, LostRevenue: 400},
+			{Key: "2", Label: "Spotify", Count: 1, LostRevenue: 200},
+		},
+	}
+	if !reflect.DeepEqual(*report, want) {
+		t.Errorf("Cancellations = %+v, want %+v", *report, want)
+	}
+}
+
+func TestTags(t *testing.T) {
+	end := month(2025, time.March)
+	music := "music"
+	c, _, _ := newServer(t,
+		model.Subscription{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: month(2025, time.January), EndDate: &end, Tags: []string{"family", "Video"}},
+		model.Subscription{ServiceName: "Spotify", Price: 200, UserID: userID, StartDate: month(2025, time.January), EndDate: &end, Tags: []string{"Family"}, Category: &music},
+		model.Subscription{ServiceName: "Netflix Kids", Price: 100, UserID: userID, StartDate: month(2025, time.January), EndDate: &end},
+	)
+	ctx := context.Background()
+
+	// Метки и категории сравниваются без учёта регистра.
+	subs, err := c.List(ctx, client.ListOptions{Tags: []string{"FAMILY"}})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(subs) != 2 || subs[0].ID != 1 || subs[1].ID != 2 || !reflect.DeepEqual(subs[1].Tags, []string{"family"}) {
+		t.Errorf("List = %+v, want subscriptions 1 and 2 tagged family", subs)
+	}
+	period := client.TotalOptions{UserID: userID, From: month(2025, time.January), To: end}
+	total, err := c.TotalByGroup(ctx, period, api.GroupByTag)
+	if err != nil {
+		t.Fatalf("TotalByGroup: %v", err)
+	}
+	want := api.TotalPriceResponse{Total: 3 * 700, Groups: []api.SpendingBucket{
+		{Key: "family", Total: 3 * 600, Subscriptions: 2},
+		{Key: "Video", Total: 3 * 400, Subscriptions: 1},
+		{Key: "", Total: 3 * 100, Subscriptions: 1},
+	}}
+	if !reflect.DeepEqual(*total, want) {
+		t.Errorf("TotalByGroup = %+v, want %+v", *total, want)
+	}
+	period.Category = "MUSIC"
+	if sum, err := c.Total(ctx, period); err != nil || sum != 3*200 {
+		t.Errorf("Total by category = %d, %v, want %d", sum, err, 3*200)
+	}
+
+	tags, err := c.ListTags(ctx, client.TagListOptions{})
+	if err != nil {
+		t.Fatalf("ListTags: %v", err)
+	}
+	if len(tags) != 2 || tags[0].Name != "family" || tags[0].Subscriptions != 2 || tags[1].Name != "Video" || tags[1].Subscriptions != 1 {
+		t.Errorf("ListTags = %+v, want family with 2 subscriptions and Video with 1", tags)
+	}
+
+	_, err = c.RenameTag(ctx, 2, "FAMILY")
+	wantAPIError(t, err, http.StatusConflict, "tag name already exists, merge the tags instead")
+	tag, err := c.RenameTag(ctx, 2, "movies")
+	if err != nil {
+		t.Fatalf("RenameTag: %v", err)
+	}
+	if tag.ID != 2 || tag.Name != "movies" || tag.Subscriptions != 1 {
+		t.Errorf("RenameTag = %+v, want tag 2 movies with 1 subscription", tag)
+	}
+	// Метки входят в представление подписки, поэтому их изменение меняет версию.
+	sub, err := c.Get(ctx, 1)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !reflect.DeepEqual(sub.Tags, []string{"family", "movies"}) || sub.Version != 2 {
+		t.Errorf("Get = %+v, want tags family and movies at version 2", sub)
+	}
+
+	if tag, err = c.MergeTags(ctx, []int64{2}, 1); err != nil {
+		t.Fatalf("MergeTags: %v", err)
+	}
+	if tag.ID != 1 || tag.Subscriptions != 2 {
+		t.Errorf("MergeTags = %+v, want tag 1 with 2 subscriptions", tag)
+	}
+	_, err = c.GetTag(ctx, 2)
+	wantAPIError(t, err, http.StatusNotFound, "tag not found")
+	_, err = c.MergeTags(ctx, []int64{2}, 1)
+	wantAPIError(t, err, http.StatusNotFound, "tag not found")
+	if sub, err = c.Get(ctx, 1); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !reflect.DeepEqual(sub.Tags, []string{"family"}) || sub.Version != 3 {
+		t.Errorf("Get = %+v, want tag family at version 3", sub)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	other := uuid.MustParse("2b1c6a4e-8a0f-4d3b-9c55-0d4e5f6a7b8c")
+	notes := "семейный тариф"
+	c, _, _ := newServer(t,
+		model.Subscription{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: month(2025, time.January), Tags: []string{"кино"}},
+		model.Subscription{ServiceName: "Spotify", Price: 200, UserID: userID, StartDate: month(2025, time.January), Notes: &notes, Tags: []string{"Netflix и музыка"}},
+		model.Subscription{ServiceName: "Netflix", Price: 400, UserID: other, StartDate: month(2025, time.January)},
+	)
+	ctx := context.Background()
+
+	// Совпадение с названием сервиса весит больше, чем с меткой.
+	res, err := c.Search(ctx, "netflix", client.SearchOptions{UserID: userID})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	want := []api.SearchHighlights{
+		{ServiceName: "<mark>Netflix</mark>"},
+		{Tags: []string{"<mark>Netflix</mark> и музыка"}},
+	}
+	if len(res) != len(want) || res[0].Subscription.ID != 1 || res[1].Subscription.ID != 2 || res[0].Rank <= res[1].Rank {
+		t.Fatalf("Search = %+v, want subscriptions 1 and 2 by rank", res)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(res[i].Highlights, want[i]) {
+			t.Errorf("Search[%d].Highlights = %+v, want %+v", i, res[i].Highlights, want[i])
+		}
+	}
+
+	if res, err = c.Search(ctx, "netflix", client.SearchOptions{Limit: 1, Offset: 2}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(res) != 1 || res[0].Subscription.ID != 2 {
+		t.Errorf("Search page = %+v, want subscription 2", res)
+	}
+
+	_, err = c.Search(ctx, "n", client.SearchOptions{})
+	wantAPIError(t, err, http.StatusBadRequest, "")
+	_, err = c.Search(ctx, " n ", client.SearchOptions{})
+	wantAPIError(t, err, http.StatusBadRequest, "invalid input: search query must have at least 2 characters")
+}
+
+func TestMembers(t *testing.T) {
+	anna := uuid.MustParse("3f6c2b1a-5d4e-4f8a-9b7c-1e2d3c4b5a69")
+	boris := uuid.MustParse("2b1c6a4e-8a0f-4d3b-9c55-0d4e5f6a7b8c")
+	c, _, _ := newServer(t,
+		model.Subscription{ServiceName: "Netflix", Price: 300, UserID: userID, StartDate: month(2025, time.January)},
+		model.Subscription{ServiceName: "Spotify", Price: 200, UserID: anna, StartDate: month(2025, time.January)},
+	)
+	ctx := context.Background()
+
+	sharing, err := c.SetMembers(ctx, 1, api.SetMembersRequest{Split: "equal", Members: []api.MemberRequest{{UserID: anna}, {UserID: boris}}})
+	if err != nil {
+		t.Fatalf("SetMembers: %v", err)
+	}
+	wantShares := []api.ShareResponse{{UserID: userID, Owner: true, Share: 100}, {UserID: anna, Share: 100}, {UserID: boris, Share: 100}}
+	if sharing.Split != "equal" || sharing.Price != 300 || !reflect.DeepEqual(sharing.Shares, wantShares) {
+		t.Errorf("SetMembers = %+v, want equal shares %+v", sharing, wantShares)
+	}
+	_, err = c.SetMembers(ctx, 1, api.SetMembersRequest{Split: "equal", Members: []api.MemberRequest{{UserID: userID}}})
+	wantAPIError(t, err, http.StatusBadRequest, "")
+	amount := 50
+	_, err = c.AddMember(ctx, 2, api.MemberRequest{UserID: userID, Amount: &amount})
+	wantAPIError(t, err, http.StatusBadRequest, "")
+	if _, err := c.SetMembers(ctx, 2, api.SetMembersRequest{Split: "fixed", Members: []api.MemberRequest{{UserID: userID, Amount: &amount}}}); err != nil {
+		t.Fatalf("SetMembers fixed: %v", err)
+	}
+
+	// Встречные долги userID и anna погашаются: остаток 100 - 50 за обе подписки.
+	balances, err := c.Balances(ctx, month(2025, time.February), uuid.Nil)
+	if err != nil {
+		t.Fatalf("Balances: %v", err)
+	}
+	want := api.BalancesResponse{Month: "02-2025", Balances: []api.BalanceResponse{
+		{FromUserID: boris, ToUserID: userID, Amount: 100, Subscriptions: []int64{1}},
+		{FromUserID: anna, ToUserID: userID, Amount: 50, Subscriptions: []int64{1, 2}},
+	}}
+	if !reflect.DeepEqual(*balances, want) {
+		t.Errorf("Balances = %+v, want %+v", *balances, want)
+	}
+	if balances, err = c.Balances(ctx, month(2025, time.February), boris); err != nil || len(balances.Balances) != 1 || balances.Balances[0].FromUserID != boris {
+		t.Errorf("Balances(boris) = %+v, %v, want only the debt of boris", balances, err)
+	}
+
+	err = c.DeleteUser(ctx, boris)
+	wantAPIError(t, err, http.StatusConflict, "user is a member of shared subscriptions, remove them from the subscriptions first")
+	_, err = c.RemoveMember(ctx, 1, uuid.New())
+	wantAPIError(t, err, http.StatusNotFound, "user is not a member of the subscription")
+	if sharing, err = c.RemoveMember(ctx, 1, boris); err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+	wantShares = []api.ShareResponse{{UserID: userID, Owner: true, Share: 150}, {UserID: anna, Share: 150}}
+	if !reflect.DeepEqual(sharing.Shares, wantShares) {
+		t.Errorf("RemoveMember shares = %+v, want %+v", sharing.Shares, wantShares)
+	}
+	if err := c.DeleteUser(ctx, boris); err != nil {
+		t.Errorf("DeleteUser after RemoveMember: %v", err)
+	}
+}
+
+func TestOrganisations(t *testing.T) {
+	anna := uuid.MustParse("3f6c2b1a-5d4e-4f8a-9b7c-1e2d3c4b5a69")
+	boris := uuid.MustParse("2b1c6a4e-8a0f-4d3b-9c55-0d4e5f6a7b8c")
+	c, _, _ := newServer(t,
+		model.Subscription{ServiceName: "Netflix", Price: 300, UserID: userID, StartDate: month(2025, time.January)},
+		model.Subscription{ServiceName: "Spotify", Price: 200, UserID: anna, StartDate: month(2025, time.January)},
+	)
+	ctx := context.Background()
+
+	org, err := c.CreateOrganisation(ctx, api.CreateOrganisationRequest{Name: "Acme", OwnerID: userID})
+	if err != nil {
+		t.Fatalf("CreateOrganisation: %v", err)
+	}
+	_, err = c.CreateOrganisation(ctx, api.CreateOrganisationRequest{Name: "ACME", OwnerID: anna})
+	wantAPIError(t, err, http.StatusConflict, "organisation name already exists")
+
+	dept, err := c.CreateUnit(ctx, org.ID, api.OrgUnitRequest{Kind: "department", Name: "Engineering"})
+	if err != nil {
+		t.Fatalf("CreateUnit department: %v", err)
+	}
+	backend, err := c.CreateUnit(ctx, org.ID, api.OrgUnitRequest{Kind: "team", Name: "Backend", ParentID: &dept.ID})
+	if err != nil {
+		t.Fatalf("CreateUnit team: %v", err)
+	}
+	frontend, err := c.CreateUnit(ctx, org.ID, api.OrgUnitRequest{Kind: "team", Name: "Frontend", ParentID: &dept.ID})
+	if err != nil {
+		t.Fatalf("CreateUnit team: %v", err)
+	}
+	be, err := c.CreateCostCentre(ctx, org.ID, api.CostCentreRequest{Code: "BE", Name: "Backend", UnitID: &backend.ID})
+	if err != nil {
+		t.Fatalf("CreateCostCentre: %v", err)
+	}
+	fe, err := c.CreateCostCentre(ctx, org.ID, api.CostCentreRequest{Code: "FE", Name: "Frontend", UnitID: &frontend.ID})
+	if err != nil {
+		t.Fatalf("CreateCostCentre: %v", err)
+	}
+	hq, err := c.CreateCostCentre(ctx, org.ID, api.CostCentreRequest{Code: "HQ", Name: "Headquarters"})
+	if err != nil {
+		t.Fatalf("CreateCostCentre: %v", err)
+	}
+
+	// Подписку 1 перевели из Backend во Frontend с марта, подписка 2 относится к организации.
+	if _, err := c.Allocate(ctx, 1, api.AllocateRequest{CostCentreID: &be.ID, From: "01-2025"}); err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	sub, err := c.Allocate(ctx, 1, api.AllocateRequest{CostCentreID: &fe.ID, From: "03-2025"})
+	if err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	if len(sub.Allocations) != 2 || sub.CostCentreID == nil || *sub.CostCentreID != fe.ID || sub.Version != 3 {
+		t.Errorf("Allocate = %+v, want two allocations ending with %d at version 3", sub, fe.ID)
+	}
+	if _, err := c.Allocate(ctx, 2, api.AllocateRequest{CostCentreID: &hq.ID, From: "01-2025"}); err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	unknown := int64(999)
+	_, err = c.Allocate(ctx, 2, api.AllocateRequest{CostCentreID: &unknown, From: "01-2025"})
+	wantAPIError(t, err, http.StatusBadRequest, "")
+
+	spending, err := c.OrgSpending(ctx, org.ID, month(2025, time.January), month(2025, time.April))
+	if err != nil {
+		t.Fatalf("OrgSpending: %v", err)
+	}
+	want := api.OrgSpendingResponse{
+		OrganisationID: org.ID, From: "01-2025", To: "04-2025", Total: 4*300 + 4*200,
+		Units: []api.UnitSpending{{
+			ID: dept.ID, Kind: "department", Name: "Engineering", Total: 4 * 300,
+			CostCentres: []api.CostCentreSpending{},
+			Units: []api.UnitSpending{
+				{ID: backend.ID, Kind: "team", Name: "Backend", Total: 2 * 300, Units: []api.UnitSpending{},
+					CostCentres: []api.CostCentreSpending{{ID: be.ID, Code: "BE", Name: "Backend", Total: 2 * 300, Subscriptions: 1}}},
+				{ID: frontend.ID, Kind: "team", Name: "Frontend", Total: 2 * 300, Units: []api.UnitSpending{},
+					CostCentres: []api.CostCentreSpending{{ID: fe.ID, Code: "FE", Name: "Frontend", Total: 2 * 300, Subscriptions: 1}}},
+			},
+		}},
+		CostCentres: []api.CostCentreSpending{{ID: hq.ID, Code: "HQ", Name: "Headquarters", Total: 4 * 200, Subscriptions: 1}},
+	}
+	if !reflect.DeepEqual(*spending, want) {
+		t.Errorf("OrgSpending =\n%+v\nwant\n%+v", *spending, want)
+	}
+
+	// История распределения сохраняется, поэтому её центры затрат и организацию удалить нельзя.
+	err = c.DeleteCostCentre(ctx, org.ID, be.ID)
+	wantAPIError(t, err, http.StatusConflict, "cost centre has allocated subscriptions")
+	err = c.DeleteUnit(ctx, org.ID, dept.ID)
+	wantAPIError(t, err, http.StatusConflict, "unit has child units or cost centres")
+	err = c.DeleteOrganisation(ctx, org.ID)
+	wantAPIError(t, err, http.StatusConflict, "organisation has cost centres with allocated subscriptions")
+
+	if _, err := c.SetOrgMember(ctx, org.ID, boris, api.OrgMemberRequest{Role: "finance", UnitID: &dept.ID}); err != nil {
+		t.Fatalf("SetOrgMember: %v", err)
+	}
+	err = c.DeleteUser(ctx, boris)
+	wantAPIError(t, err, http.StatusConflict, "user is a member of organisations, remove them from the organisations first")
+	err = c.RemoveOrgMember(ctx, org.ID, userID)
+	wantAPIError(t, err, http.StatusConflict, "organisation must keep at least one owner")
+	members, err := c.OrgMembers(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("OrgMembers: %v", err)
+	}
+	if len(members) != 2 || members[0].UserID != userID || members[0].Role != "owner" || members[1].UserID != boris {
+		t.Errorf("OrgMembers = %+v, want the owner and boris", members)
+	}
+	if err := c.RemoveOrgMember(ctx, org.ID, boris); err != nil {
+		t.Fatalf("RemoveOrgMember: %v", err)
+	}
+	err = c.RemoveOrgMember(ctx, org.ID, boris)
+	wantAPIError(t, err, http.StatusNotFound, "user is not a member of the organisation")
+}
+
+func TestBudgets(t *testing.T) {
+	current := model.MonthStart(time.Now())
+	c, _, _ := newServer(t, model.Subscription{ServiceName: "Netflix", Price: 300, UserID: userID, StartDate: current})
+	ctx := context.Background()
+
+	_, err := c.CreateBudget(ctx, api.BudgetRequest{UserID: uuid.New(), MonthlyLimit: 500})
+	wantAPIError(t, err, http.StatusBadRequest, "")
+
+	created, err := c.CreateBudget(ctx, api.BudgetRequest{UserID: userID, MonthlyLimit: 500})
+	if err != nil {
+		t.Fatalf("CreateBudget: %v", err)
+	}
+	if created.ID == 0 || created.UserID != userID || created.MonthlyLimit != 500 {
+		t.Fatalf("CreateBudget = %+v, want limit 500 for %s", *created, userID)
+	}
+	got, err := c.GetBudget(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetBudget: %v", err)
+	}
+	if !reflect.DeepEqual(got, created) {
+		t.Errorf("GetBudget = %+v, want %+v", *got, *created)
+	}
+	_, err = c.GetBudget(ctx, created.ID+1)
+	wantAPIError(t, err, http.StatusNotFound, "budget not found")
+
+	list, err := c.ListBudgets(ctx, client.BudgetListOptions{UserID: userID})
+	if err != nil {
+		t.Fatalf("ListBudgets: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != created.ID {
+		t.Errorf("ListBudgets = %+v, want budget %d", list, created.ID)
+	}
+
+	// Новая подписка доводит расходы до 700 из 500: оба порога срабатывают один раз.
+	if _, err := c.Create(ctx, api.CreateSubscriptionRequest{ServiceName: "Spotify", Price: 400, UserID: userID, StartDate: current.Format("01-2006")}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	status, err := c.BudgetStatus(ctx, created.ID, time.Time{})
+	if err != nil {
+		t.Fatalf("BudgetStatus: %v", err)
+	}
+	if status.Month != current.Format("01-2006") || status.Spent != 700 || status.Remaining != 0 ||
+		status.Utilization != 140 || status.Status != api.BudgetStatusExceeded {
+		t.Errorf("BudgetStatus = %+v, want 700 of 500 exceeded", *status)
+	}
+	var thresholds []int
+	for _, alert := range status.Alerts {
+		thresholds = append(thresholds, alert.Threshold)
+	}
+	if !reflect.DeepEqual(thresholds, []int{80, 100}) {
+		t.Errorf("alerts thresholds = %v, want [80 100]", thresholds)
+	}
+
+	past, err := c.BudgetStatus(ctx, created.ID, current.AddDate(0, -1, 0))
+	if err != nil {
+		t.Fatalf("BudgetStatus(previous month): %v", err)
+	}
+	if past.Spent != 0 || past.Status != api.BudgetStatusOK || len(past.Alerts) != 0 {
+		t.Errorf("BudgetStatus(previous month) = %+v, want nothing spent", *past)
+	}
+
+	updated, err := c.UpdateBudget(ctx, created.ID, api.BudgetRequest{UserID: userID, MonthlyLimit: 1000})
+	if err != nil {
+		t.Fatalf("UpdateBudget: %v", err)
+	}
+	if updated.MonthlyLimit != 1000 {
+		t.Errorf("UpdateBudget = %+v, want limit 1000", *updated)
+	}
+	if status, err = c.BudgetStatus(ctx, created.ID, time.Time{}); err != nil {
+		t.Fatalf("BudgetStatus: %v", err)
+	}
+	if status.Remaining != 300 || status.Utilization != 70 || status.Status != api.BudgetStatusOK {
+		t.Errorf("BudgetStatus after update = %+v, want 700 of 1000 ok", *status)
+	}
+	_, err = c.UpdateBudget(ctx, created.ID+1, api.BudgetRequest{UserID: userID, MonthlyLimit: 1000})
+	wantAPIError(t, err, http.StatusNotFound, "budget not found")
+
+	if err := c.DeleteBudget(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteBudget: %v", err)
+	}
+	wantAPIError(t, c.DeleteBudget(ctx, created.ID), http.StatusNotFound, "budget not found")
+}
+
+func TestDuplicates(t *testing.T) {
+	end := month(2025, time.June)
+	c, _, _ := newServer(t,
+		model.Subscription{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: month(2025, time.January), EndDate: &end},
+		model.Subscription{ServiceName: "Netflix", Price: 500, UserID: userID, StartDate: month(2025, time.March), AllowOverlap: true},
+		model.Subscription{ServiceName: "Spotify", Price: 200, UserID: userID, StartDate: month(2025, time.January)},
+	)
+	ctx := context.Background()
+
+	overlapTo := "06-2025"
+	pair := api.DuplicatePair{
+		UserID: userID, ServiceID: 1, ServiceName: "Netflix",
+		FirstID: 1, SecondID: 2, OverlapFrom: "03-2025", OverlapTo: &overlapTo,
+	}
+	tests := []struct {
+		name string
+		opts client.DuplicatesOptions
+		want []api.DuplicatePair
+	}{
+		{"all", client.DuplicatesOptions{}, []api.DuplicatePair{pair}},
+		{"by user", client.DuplicatesOptions{UserID: userID}, []api.DuplicatePair{pair}},
+		{"by service name", client.DuplicatesOptions{ServiceName: "spotify"}, []api.DuplicatePair{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := c.Duplicates(ctx, tt.opts)
+			if err != nil {
+				t.Fatalf("Duplicates: %v", err)
+			}
+			want := api.DuplicatesReportResponse{Count: len(tt.want), Duplicates: tt.want}
+			if !reflect.DeepEqual(*report, want) {
+				t.Errorf("Duplicates = %+v, want %+v", *report, want)
+			}
+		})
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	c, _, _ := newServer(t,
+		model.Subscription{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: month(2025, time.July)},
+		model.Subscription{ServiceName: "Spotify", Price: 200, UserID: userID, StartDate: month(2025, time.August)},
+	)
+	ctx := context.Background()
+
+	var out struct {
+		Subscription struct {
+			ID          string `json:"id"`
+			ServiceName string `json:"serviceName"`
+			StartDate   string `json:"startDate"`
+		} `json:"subscription"`
+		User struct {
+			SubscriptionCount int `json:"subscriptionCount"`
+		} `json:"user"`
+	}
+	query := `query($user: String!) { subscription(id: 2) { id serviceName startDate } user(userId: $user) { subscriptionCount } }`
+	err := c.GraphQL(ctx, api.GraphQLRequest{Query: query, Variables: map[string]any{"user": userID.String()}}, &out)
+	if err != nil {
+		t.Fatalf("GraphQL: %v", err)
+	}
+	if out.Subscription.ID != "2" || out.Subscription.ServiceName != "Spotify" || out.Subscription.StartDate != "08-2025" || out.User.SubscriptionCount != 2 {
+		t.Errorf("GraphQL data = %+v, want subscription 2 and two subscriptions of the user", out)
+	}
+
+	err = c.GraphQL(ctx, api.GraphQLRequest{Query: `{ subscription(id: 1) { unknownField } }`}, nil)
+	var gqlErr *client.GraphQLErrors
+	if !errors.As(err, &gqlErr) || len(gqlErr.Errors) == 0 {
+		t.Fatalf("GraphQL with an unknown field: got %v, want GraphQL errors", err)
+	}
+}
+
+// Повтор POST-запроса безопасен только с тем же ключом идемпотентности: иначе сервер
+// не узнает в нём уже выполненный запрос.
+func TestRetriesReuseIdempotencyKey(t *testing.T) {
+	sub := api.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: "01-2025"}
+
+	tests := []struct {
+		name string
+		path string
+		call func(c *client.Client, opts ...client.RequestOption) error
+	}{
+		{"Create", "/api/v1/subscriptions/", func(c *client.Client, opts ...client.RequestOption) error {
+			_, err := c.Create(context.Background(), sub, opts...)
+			return err
+		}},
+		{"ImportJSON", "/api/v1/subscriptions/import", func(c *client.Client, opts ...client.RequestOption) error {
+			_, err := c.ImportJSON(context.Background(), []api.CreateSubscriptionRequest{sub}, client.ImportOptions{}, opts...)
+			return err
+		}},
+		{"Batch", "/api/v1/subscriptions:batch", func(c *client.Client, opts ...client.RequestOption) error {
+			op, err := client.NewBatchOperation(api.BatchOpCreate, nil, nil, sub)
+			if err != nil {
+				return err
+			}
+			_, err = c.Batch(context.Background(), api.BatchRequest{Operations: []api.BatchOperation{op}}, opts...)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		for _, explicit := range []string{"", "order-42"} {
+			t.Run(tt.name+"/"+explicit, func(t *testing.T) {
+				c, srv, store := newServer(t)
+				// 429 и 503 от прокси, затем запрос доходит до обработчика.
+				srv.fail(http.MethodPost, tt.path, http.StatusTooManyRequests, http.StatusServiceUnavailable)
+				var opts []client.RequestOption
+				if explicit != "" {
+					opts = append(opts, client.WithIdempotencyKey(explicit))
+				}
+
+				if err := tt.call(c, opts...); err != nil {
+					t.Fatalf("got %v, want success after retries", err)
+				}
+				attempts := srv.take()
+				if len(attempts) != 3 {
+					t.Fatalf("attempts = %d, want 3", len(attempts))
+				}
+				key := attempts[0].key
+				if key == "" || (explicit != "" && key != explicit) {
+					t.Fatalf("Idempotency-Key = %q, want %q or a generated key", key, explicit)
+				}
+				for i, a := range attempts {
+					if a.path != tt.path || a.key != key {
+						t.Errorf("attempt %d: %s with key %q, want %s with key %q", i, a.path, a.key, tt.path, key)
+					}
+				}
+				if saved := len(store.All()); saved != 1 {
+					t.Errorf("saved %d subscriptions, want 1", saved)
+				}
+			})
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	update := api.UpdateSubscriptionRequest{ServiceName: "Netflix", Price: 500, UserID: userID, StartDate: "07-2025"}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		faults   []int
+		call     func(c *client.Client) error
+		attempts int
+		status   int
+	}{
+		{
+			name: "GET retried until the handler answers", method: http.MethodGet, path: "/api/v1/subscriptions/1",
+			faults:   []int{http.StatusTooManyRequests, http.StatusBadGateway},
+			call:     func(c *client.Client) error { _, err := c.Get(context.Background(), 1); return err },
+			attempts: 3,
+		},
+		{
+			name: "retries exhausted", method: http.MethodGet, path: "/api/v1/subscriptions/1",
+			faults:   []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			call:     func(c *client.Client) error { _, err := c.Get(context.Background(), 1); return err },
+			attempts: 4, status: http.StatusBadGateway,
+		},
+		{
+			name: "client error not retried", method: http.MethodGet, path: "/api/v1/subscriptions/1",
+			faults:   []int{http.StatusNotFound},
+			call:     func(c *client.Client) error { _, err := c.Get(context.Background(), 1); return err },
+			attempts: 1, status: http.StatusNotFound,
+		},
+		{
+			name: "PUT retried", method: http.MethodPut, path: "/api/v1/subscriptions/1",
+			faults:   []int{http.StatusServiceUnavailable},
+			call:     func(c *client.Client) error { _, err := c.Update(context.Background(), 1, update); return err },
+			attempts: 2,
+		},
+		{
+			name: "PATCH without If-Match not retried", method: http.MethodPatch, path: "/api/v1/subscriptions/1",
+			faults: []int{http.StatusServiceUnavailable},
+			call: func(c *client.Client) error {
+				_, err := c.MergePatch(context.Background(), 1, map[string]any{"price": 700})
+				return err
+			},
+			attempts: 1, status: http.StatusServiceUnavailable,
+		},
+		{
+			name: "PATCH with If-Match retried", method: http.MethodPatch, path: "/api/v1/subscriptions/1",
+			faults: []int{http.StatusServiceUnavailable},
+			call: func(c *client.Client) error {
+				_, err := c.MergePatch(context.Background(), 1, map[string]any{"price": 700}, client.WithIfMatch(1))
+				return err
+			},
+			attempts: 2,
+		},
+		{
+			name: "DELETE retried", method: http.MethodDelete, path: "/api/v1/subscriptions/1",
+			faults:   []int{http.StatusServiceUnavailable},
+			call:     func(c *client.Client) error { return c.Delete(context.Background(), 1) },
+			attempts: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, srv, _ := newServer(t, model.Subscription{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: month(2025, time.July)})
+			srv.fail(tt.method, tt.path, tt.faults...)
+
+			err := tt.call(c)
+			if status := statusOf(err); status != tt.status || tt.status == 0 && err != nil {
+				t.Fatalf("got %v, want status %d", err, tt.status)
+			}
+			if attempts := srv.take(); len(attempts) != tt.attempts {
+				t.Fatalf("attempts = %d (%+v), want %d", len(attempts), attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+// Повтор создания с тем же ключом возвращает ту же подписку, а не создаёт вторую.
+func TestCreateReplayDoesNotDuplicate(t *testing.T) {
+	c, _, store := newServer(t)
+	ctx := context.Background()
+	req := api.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: "01-2025"}
+
+	first, err := c.Create(ctx, req, client.WithIdempotencyKey("create-1"))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	second, err := c.Create(ctx, req, client.WithIdempotencyKey("create-1"))
+	if err != nil {
+		t.Fatalf("Create replay: %v", err)
+	}
+	if !reflect.DeepEqual(*second, *first) {
+		t.Fatalf("replayed Create = %+v, want %+v", *second, *first)
+	}
+	if saved := len(store.All()); saved != 1 {
+		t.Fatalf("saved %d subscriptions, want 1", saved)
+	}
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+// Members возвращает правило разделения стоимости подписки и доли владельца и участников
+// за текущий месяц.
+func (c *Client) Members(ctx context.Context, id int64) (*api.SharingResponse, error) {
+	var res api.SharingResponse
+	if err := c.do(ctx, newRequest(http.MethodGet, subscriptionPath(id)+"/members", nil), &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// SetMembers заменяет правило разделения и всех участников подписки.
+func (c *Client) SetMembers(ctx context.Context, id int64, members api.SetMembersRequest, opts ...RequestOption) (*api.SharingResponse, error) {
+	req, err := jsonRequest(http.MethodPut, subscriptionPath(id)+"/members", members, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.sharing(ctx, req)
+}
+
+// AddMember добавляет участника по текущему правилу разделения или меняет его условия.
+func (c *Client) AddMember(ctx context.Context, id int64, member api.MemberRequest, opts ...RequestOption) (*api.SharingResponse, error) {
+	req, err := jsonRequest(http.MethodPost, subscriptionPath(id)+"/members", member, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.sharing(ctx, req)
+}
+
+// RemoveMember исключает участника подписки. Если он не участник, IsNotFound(err) == true.
+func (c *Client) RemoveMember(ctx context.Context, id int64, userID uuid.UUID, opts ...RequestOption) (*api.SharingResponse, error) {
+	return c.sharing(ctx, newRequest(http.MethodDelete, subscriptionPath(id)+"/members/"+userID.String(), opts))
+}
+
+func (c *Client) sharing(ctx context.Context, req request) (*api.SharingResponse, error) {
+	var res api.SharingResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Balances возвращает взаиморасчёты по совместным подпискам за месяц month. Нулевой
+// month — текущий месяц, ненулевой userID оставляет только долги пользователя и долги ему.
+func (c *Client) Balances(ctx context.Context, month time.Time, userID uuid.UUID) (*api.BalancesResponse, error) {
+	req := newRequest(http.MethodGet, "/reports/balances", nil)
+	req.query = make(url.Values)
+	if !month.IsZero() {
+		req.query.Set("month", month.Format("01-2006"))
+	}
+	if userID != uuid.Nil {
+		req.query.Set("user_id", userID.String())
+	}
+
+	var res api.BalancesResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
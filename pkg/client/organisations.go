@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+type OrganisationListOptions struct {
+	// UserID оставляет только организации, в которых пользователь состоит.
+	UserID uuid.UUID
+	Limit  int
+	Offset int
+}
+
+func (o OrganisationListOptions) query() url.Values {
+	q := make(url.Values)
+	if o.UserID != uuid.Nil {
+		q.Set("user_id", o.UserID.String())
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		q.Set("offset", strconv.Itoa(o.Offset))
+	}
+	return q
+}
+
+// CreateOrganisation создаёт организацию, владельцем которой становится org.OwnerID.
+func (c *Client) CreateOrganisation(ctx context.Context, org api.CreateOrganisationRequest) (*api.OrganisationResponse, error) {
+	req, err := jsonRequest(http.MethodPost, "/organisations/", org, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.OrganisationResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) GetOrganisation(ctx context.Context, id int64) (*api.OrganisationResponse, error) {
+	var res api.OrganisationResponse
+	if err := c.do(ctx, newRequest(http.MethodGet, organisationPath(id), nil), &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) ListOrganisations(ctx context.Context, opts OrganisationListOptions) ([]api.OrganisationResponse, error) {
+	req := newRequest(http.MethodGet, "/organisations/", nil)
+	req.query = opts.query()
+
+	var res []api.OrganisationResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) RenameOrganisation(ctx context.Context, id int64, name string) (*api.OrganisationResponse, error) {
+	req, err := jsonRequest(http.MethodPut, organisationPath(id), api.OrganisationRequest{Name: name}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.OrganisationResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) DeleteOrganisation(ctx context.Context, id int64) error {
+	return c.do(ctx, newRequest(http.MethodDelete, organisationPath(id), nil), nil)
+}
+
+func (c *Client) Units(ctx context.Context, orgID int64) ([]api.OrgUnitResponse, error) {
+	var res []api.OrgUnitResponse
+	if err := c.do(ctx, newRequest(http.MethodGet, organisationPath(orgID)+"/units", nil), &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) CreateUnit(ctx context.Context, orgID int64, unit api.OrgUnitRequest) (*api.OrgUnitResponse, error) {
+	return c.unit(ctx, http.MethodPost, organisationPath(orgID)+"/units", unit)
+}
+
+func (c *Client) UpdateUnit(ctx context.Context, orgID, id int64, unit api.OrgUnitRequest) (*api.OrgUnitResponse, error) {
+	return c.unit(ctx, http.MethodPut, organisationPath(orgID)+"/units/"+strconv.FormatInt(id, 10), unit)
+}
+
+func (c *Client) DeleteUnit(ctx context.Context, orgID, id int64) error {
+	return c.do(ctx, newRequest(http.MethodDelete, organisationPath(orgID)+"/units/"+strconv.FormatInt(id, 10), nil), nil)
+}
+
+func (c *Client) unit(ctx context.Context, method, path string, unit api.OrgUnitRequest) (*api.OrgUnitResponse, error) {
+	req, err := jsonRequest(method, path, unit, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.OrgUnitResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) CostCentres(ctx context.Context, orgID int64) ([]api.CostCentreResponse, error) {
+	var res []api.CostCentreResponse
+	if err := c.do(ctx, newRequest(http.MethodGet, organisationPath(orgID)+"/cost-centres", nil), &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) CreateCostCentre(ctx context.Context, orgID int64, cc api.CostCentreRequest) (*api.CostCentreResponse, error) {
+	return c.costCentre(ctx, http.MethodPost, organisationPath(orgID)+"/cost-centres", cc)
+}
+
+func (c *Client) UpdateCostCentre(ctx context.Context, orgID, id int64, cc api.CostCentreRequest) (*api.CostCentreResponse, error) {
+	return c.costCentre(ctx, http.MethodPut, organisationPath(orgID)+"/cost-centres/"+strconv.FormatInt(id, 10), cc)
+}
+
+func (c *Client) DeleteCostCentre(ctx context.Context, orgID, id int64) error {
+	return c.do(ctx, newRequest(http.MethodDelete, organisationPath(orgID)+"/cost-centres/"+strconv.FormatInt(id, 10), nil), nil)
+}
+
+func (c *Client) costCentre(ctx context.Context, method, path string, cc api.CostCentreRequest) (*api.CostCentreResponse, error) {
+	req, err := jsonRequest(method, path, cc, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.CostCentreResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) OrgMembers(ctx context.Context, orgID int64) ([]api.OrgMemberResponse, error) {
+	var res []api.OrgMemberResponse
+	if err := c.do(ctx, newRequest(http.MethodGet, organisationPath(orgID)+"/members", nil), &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SetOrgMember добавляет пользователя в организацию или меняет его роль и подразделение.
+func (c *Client) SetOrgMember(ctx context.Context, orgID int64, userID uuid.UUID, member api.OrgMemberRequest) (*api.OrgMemberResponse, error) {
+	req, err := jsonRequest(http.MethodPut, organisationPath(orgID)+"/members/"+userID.String(), member, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.OrgMemberResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// RemoveOrgMember исключает пользователя из организации. Последнего владельца исключить
+// нельзя: сервер отвечает 409.
+func (c *Client) RemoveOrgMember(ctx context.Context, orgID int64, userID uuid.UUID) error {
+	return c.do(ctx, newRequest(http.MethodDelete, organisationPath(orgID)+"/members/"+userID.String(), nil), nil)
+}
+
+// OrgSpending возвращает расходы организации за месяцы с from по to, свёрнутые по центрам
+// затрат, командам и отделам.
+func (c *Client) OrgSpending(ctx context.Context, orgID int64, from, to time.Time) (*api.OrgSpendingResponse, error) {
+	req := newRequest(http.MethodGet, organisationPath(orgID)+"/spending", nil)
+	req.query = url.Values{
+		"from": {from.Format("01-2006")},
+		"to":   {to.Format("01-2006")},
+	}
+
+	var res api.OrgSpendingResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Allocate относит подписку к центру затрат с месяца allocation.From; прошлые месяцы
+// остаются за прежним центром затрат.
+func (c *Client) Allocate(ctx context.Context, id int64, allocation api.AllocateRequest, opts ...RequestOption) (*api.SubscriptionResponse, error) {
+	req, err := jsonRequest(http.MethodPost, subscriptionPath(id)+"/allocations", allocation, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.SubscriptionResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func organisationPath(id int64) string {
+	return "/organisations/" + strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+// SpendingOptions задаёт отчёт о расходах. From и To — первый и последний месяцы периода,
+// GroupBy — одна из констант api.GroupBy*, по умолчанию месяцы.
+type SpendingOptions struct {
+	From        time.Time
+	To          time.Time
+	GroupBy     string
+	UserID      uuid.UUID
+	ServiceID   int64
+	ServiceName string
+}
+
+func (c *Client) Spending(ctx context.Context, opts SpendingOptions) (*api.SpendingReportResponse, error) {
+	req := newRequest(http.MethodGet, "/reports/spending", nil)
+	req.query = url.Values{
+		"from": {opts.From.Format("01-2006")},
+		"to":   {opts.To.Format("01-2006")},
+	}
+	if opts.GroupBy != "" {
+		req.query.Set("group_by", opts.GroupBy)
+	}
+	if opts.UserID != uuid.Nil {
+		req.query.Set("user_id", opts.UserID.String())
+	}
+	if opts.ServiceID > 0 {
+		req.query.Set("service_id", strconv.FormatInt(opts.ServiceID, 10))
+	}
+	if opts.ServiceName != "" {
+		req.query.Set("service_name", opts.ServiceName)
+	}
+
+	var res api.SpendingReportResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ForecastOptions задаёт прогноз расходов. Months == 0 — горизонт сервера по умолчанию.
+type ForecastOptions struct {
+	Months      int
+	UserID      uuid.UUID
+	ServiceID   int64
+	ServiceName string
+	// ExcludePriceChanges считает все месяцы по текущей цене подписок.
+	ExcludePriceChanges bool
+}
+
+func (c *Client) Forecast(ctx context.Context, opts ForecastOptions) (*api.ForecastResponse, error) {
+	req := newRequest(http.MethodGet, "/reports/forecast", nil)
+	req.query = make(url.Values)
+	if opts.Months > 0 {
+		req.query.Set("months", strconv.Itoa(opts.Months))
+	}
+	if opts.UserID != uuid.Nil {
+		req.query.Set("user_id", opts.UserID.String())
+	}
+	if opts.ServiceID > 0 {
+		req.query.Set("service_id", strconv.FormatInt(opts.ServiceID, 10))
+	}
+	if opts.ServiceName != "" {
+		req.query.Set("service_name", opts.ServiceName)
+	}
+	if opts.ExcludePriceChanges {
+		req.query.Set("include_price_changes", "false")
+	}
+
+	var res api.ForecastResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// DuplicatesOptions отбирает пары пересекающихся подписок; пустые поля не фильтруют.
+type DuplicatesOptions struct {
+	UserID      uuid.UUID
+	ServiceID   int64
+	ServiceName string
+}
+
+func (c *Client) Duplicates(ctx context.Context, opts DuplicatesOptions) (*api.DuplicatesReportResponse, error) {
+	req := newRequest(http.MethodGet, "/reports/duplicates", nil)
+	req.query = make(url.Values)
+	if opts.UserID != uuid.Nil {
+		req.query.Set("user_id", opts.UserID.String())
+	}
+	if opts.ServiceID > 0 {
+		req.query.Set("service_id", strconv.FormatInt(opts.ServiceID, 10))
+	}
+	if opts.ServiceName != "" {
+		req.query.Set("service_name", opts.ServiceName)
+	}
+
+	var res api.DuplicatesReportResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// CancellationsOptions задаёт отчёт об отменах. From и To — первый и последний месяцы
+// периода, в которые подписки были отменены.
+type CancellationsOptions struct {
+	From        time.Time
+	To          time.Time
+	UserID      uuid.UUID
+	ServiceID   int64
+	ServiceName string
+}
+
+func (c *Client) Cancellations(ctx context.Context, opts CancellationsOptions) (*api.CancellationReportResponse, error) {
+	req := newRequest(http.MethodGet, "/reports/cancellations", nil)
+	req.query = url.Values{
+		"from": {opts.From.Format("01-2006")},
+		"to":   {opts.To.Format("01-2006")},
+	}
+	if opts.UserID != uuid.Nil {
+		req.query.Set("user_id", opts.UserID.String())
+	}
+	if opts.ServiceID > 0 {
+		req.query.Set("service_id", strconv.FormatInt(opts.ServiceID, 10))
+	}
+	if opts.ServiceName != "" {
+		req.query.Set("service_name", opts.ServiceName)
+	}
+
+	var res api.CancellationReportResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+type ServiceListOptions struct {
+	// Query ищет подстроку в названии и псевдонимах.
+	Query    string
+	Category string
+	Limit    int
+	Offset   int
+}
+
+func (o ServiceListOptions) query() url.Values {
+	q := make(url.Values)
+	if o.Query != "" {
+		q.Set("q", o.Query)
+	}
+	if o.Category != "" {
+		q.Set("category", o.Category)
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		q.Set("offset", strconv.Itoa(o.Offset))
+	}
+	return q
+}
+
+func (c *Client) CreateService(ctx context.Context, svc api.ServiceRequest) (*api.ServiceResponse, error) {
+	req, err := jsonRequest(http.MethodPost, "/services/", svc, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.ServiceResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) GetService(ctx context.Context, id int64) (*api.ServiceResponse, error) {
+	var res api.ServiceResponse
+	if err := c.do(ctx, newRequest(http.MethodGet, servicePath(id), nil), &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) ListServices(ctx context.Context, opts ServiceListOptions) ([]api.ServiceResponse, error) {
+	req := newRequest(http.MethodGet, "/services/", nil)
+	req.query = opts.query()
+
+	var res []api.ServiceResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// UpdateService полностью заменяет запись каталога, включая псевдонимы.
+func (c *Client) UpdateService(ctx context.Context, id int64, svc api.ServiceRequest) (*api.ServiceResponse, error) {
+	req, err := jsonRequest(http.MethodPut, servicePath(id), svc, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.ServiceResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// DeleteService удаляет сервис из каталога. Для сервиса с подписками возвращается ошибка с кодом 409.
+func (c *Client) DeleteService(ctx context.Context, id int64) error {
+	return c.do(ctx, newRequest(http.MethodDelete, servicePath(id), nil), nil)
+}
+
+func servicePath(id int64) string {
+	return "/services/" + strconv.FormatInt(id, 10)
+}
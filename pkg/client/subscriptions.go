@@ -0,0 +1,444 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+const defaultPageSize = 100
+
+type ListOptions struct {
+	UserID      uuid.UUID
+	ServiceID   int64
+	ServiceName string
+	// Category — категория подписки или её сервиса.
+	Category string
+	// Tags отбирает подписки, у которых есть все эти метки.
+	Tags []string
+	// Limit и Offset задают страницу для List. All использует Limit как размер страницы.
+	Limit  int
+	Offset int
+}
+
+func (o ListOptions) query() url.Values {
+	q := make(url.Values)
+	if o.UserID != uuid.Nil {
+		q.Set("user_id", o.UserID.String())
+	}
+	if o.ServiceID > 0 {
+		q.Set("service_id", strconv.FormatInt(o.ServiceID, 10))
+	}
+	if o.ServiceName != "" {
+		q.Set("service_name", o.ServiceName)
+	}
+	if o.Category != "" {
+		q.Set("category", o.Category)
+	}
+	for _, tag := range o.Tags {
+		q.Add("tag", tag)
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		q.Set("offset", strconv.Itoa(o.Offset))
+	}
+	return q
+}
+
+// SearchOptions сужает поиск подписок до пользователя и задаёт страницу результатов.
+type SearchOptions struct {
+	UserID uuid.UUID
+	Limit  int
+	Offset int
+}
+
+type TotalOptions struct {
+	UserID      uuid.UUID
+	ServiceID   int64
+	ServiceName string
+	Category    string
+	Tags        []string
+	From        time.Time
+	To          time.Time
+}
+
+type ImportOptions struct {
+	DryRun bool
+	// Mode — api.ImportModePartial (по умолчанию) или api.ImportModeAllOrNothing.
+	Mode string
+	// Columns — маппинг колонок, например "Сервис:service_name,Цена:price".
+	Columns string
+}
+
+func (o ImportOptions) query() url.Values {
+	q := make(url.Values)
+	if o.DryRun {
+		q.Set("dry_run", "true")
+	}
+	if o.Mode != "" {
+		q.Set("mode", o.Mode)
+	}
+	if o.Columns != "" {
+		q.Set("columns", o.Columns)
+	}
+	return q
+}
+
+// PatchOperation — операция JSON Patch (RFC 6902).
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+func (c *Client) Create(ctx context.Context, sub api.CreateSubscriptionRequest, opts ...RequestOption) (*api.SubscriptionResponse, error) {
+	req, err := jsonRequest(http.MethodPost, "/subscriptions/", sub, withIdempotencyKey(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.SubscriptionResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Get возвращает подписку по ID. Для отсутствующей подписки IsNotFound(err) == true.
+func (c *Client) Get(ctx context.Context, id int64) (*api.SubscriptionResponse, error) {
+	var res api.SubscriptionResponse
+	if err := c.do(ctx, newRequest(http.MethodGet, subscriptionPath(id), nil), &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) List(ctx context.Context, opts ListOptions) ([]api.SubscriptionResponse, error) {
+	req := newRequest(http.MethodGet, "/subscriptions/", nil)
+	req.query = opts.query()
+
+	var res []api.SubscriptionResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Search ищет подписки по названию сервиса, меткам и заметкам и возвращает их
+// по убыванию релевантности.
+func (c *Client) Search(ctx context.Context, q string, opts SearchOptions) ([]api.SearchResult, error) {
+	req := newRequest(http.MethodGet, "/subscriptions/search", nil)
+	req.query = url.Values{"q": {q}}
+	if opts.UserID != uuid.Nil {
+		req.query.Set("user_id", opts.UserID.String())
+	}
+	if opts.Limit > 0 {
+		req.query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		req.query.Set("offset", strconv.Itoa(opts.Offset))
+	}
+
+	var res []api.SearchResult
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// All перебирает все подписки, запрашивая их страницами по opts.Limit
+// (по умолчанию 100), начиная с opts.Offset. Перебор останавливается на первой ошибке.
+func (c *Client) All(ctx context.Context, opts ListOptions) iter.Seq2[api.SubscriptionResponse, error] {
+	return func(yield func(api.SubscriptionResponse, error) bool) {
+		if opts.Limit <= 0 {
+			opts.Limit = defaultPageSize
+		}
+		for {
+			page, err := c.List(ctx, opts)
+			if err != nil {
+				yield(api.SubscriptionResponse{}, err)
+				return
+			}
+			for _, sub := range page {
+				if !yield(sub, nil) {
+					return
+				}
+			}
+			if len(page) < opts.Limit {
+				return
+			}
+			opts.Offset += len(page)
+		}
+	}
+}
+
+// Update полностью заменяет подписку. С WithIfMatch изменение выполняется только
+// для указанной версии, иначе IsPreconditionFailed(err) == true.
+func (c *Client) Update(ctx context.Context, id int64, sub api.UpdateSubscriptionRequest, opts ...RequestOption) (*api.SubscriptionResponse, error) {
+	req, err := jsonRequest(http.MethodPut, subscriptionPath(id), sub, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.SubscriptionResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// SchedulePrice планирует новую цену подписки с месяца change.EffectiveFrom.
+func (c *Client) SchedulePrice(ctx context.Context, id int64, change api.SchedulePriceRequest, opts ...RequestOption) (*api.SubscriptionResponse, error) {
+	req, err := jsonRequest(http.MethodPost, subscriptionPath(id)+"/prices", change, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.SubscriptionResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Pause приостанавливает оплату подписки; пустой запрос приостанавливает её со следующего
+// месяца до возобновления.
+func (c *Client) Pause(ctx context.Context, id int64, pause api.PauseRequest, opts ...RequestOption) (*api.SubscriptionResponse, error) {
+	req, err := jsonRequest(http.MethodPost, subscriptionPath(id)+"/pause", pause, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.SubscriptionResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Resume возобновляет оплату подписки с месяца resume.From, по умолчанию следующего.
+func (c *Client) Resume(ctx context.Context, id int64, resume api.ResumeRequest, opts ...RequestOption) (*api.SubscriptionResponse, error) {
+	req, err := jsonRequest(http.MethodPost, subscriptionPath(id)+"/resume", resume, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.SubscriptionResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Cancel отменяет подписку с причиной cancel.Reason. Режим по умолчанию — end_of_period.
+func (c *Client) Cancel(ctx context.Context, id int64, cancel api.CancelRequest, opts ...RequestOption) (*api.CancellationResponse, error) {
+	req, err := jsonRequest(http.MethodPost, subscriptionPath(id)+"/cancel", cancel, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.CancellationResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// MergePatch частично обновляет подписку документом JSON Merge Patch (RFC 7396):
+// поле со значением nil очищается.
+func (c *Client) MergePatch(ctx context.Context, id int64, patch map[string]any, opts ...RequestOption) (*api.SubscriptionResponse, error) {
+	return c.patch(ctx, id, api.PatchTypeMerge, patch, opts)
+}
+
+// JSONPatch применяет к подписке операции JSON Patch (RFC 6902).
+func (c *Client) JSONPatch(ctx context.Context, id int64, ops []PatchOperation, opts ...RequestOption) (*api.SubscriptionResponse, error) {
+	return c.patch(ctx, id, api.PatchTypeJSON, ops, opts)
+}
+
+func (c *Client) patch(ctx context.Context, id int64, contentType string, patch any, opts []RequestOption) (*api.SubscriptionResponse, error) {
+	req, err := jsonRequest(http.MethodPatch, subscriptionPath(id), patch, opts)
+	if err != nil {
+		return nil, err
+	}
+	req.header.Set("Content-Type", contentType)
+
+	var res api.SubscriptionResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) Delete(ctx context.Context, id int64, opts ...RequestOption) error {
+	return c.do(ctx, newRequest(http.MethodDelete, subscriptionPath(id), opts), nil)
+}
+
+// Total возвращает суммарную стоимость подписок пользователя за период.
+func (c *Client) Total(ctx context.Context, opts TotalOptions) (int, error) {
+	res, err := c.TotalByGroup(ctx, opts, "")
+	if err != nil {
+		return 0, err
+	}
+	return res.Total, nil
+}
+
+// TotalByGroup возвращает суммарную стоимость подписок с разбивкой по groupBy:
+// api.GroupByService, api.GroupByCategory или api.GroupByTag.
+func (c *Client) TotalByGroup(ctx context.Context, opts TotalOptions, groupBy string) (*api.TotalPriceResponse, error) {
+	req := newRequest(http.MethodGet, "/subscriptions/total", nil)
+	req.query = url.Values{"user_id": {opts.UserID.String()}}
+	if groupBy != "" {
+		req.query.Set("group_by", groupBy)
+	}
+	if opts.Category != "" {
+		req.query.Set("category", opts.Category)
+	}
+	for _, tag := range opts.Tags {
+		req.query.Add("tag", tag)
+	}
+	if opts.ServiceID > 0 {
+		req.query.Set("service_id", strconv.FormatInt(opts.ServiceID, 10))
+	}
+	if opts.ServiceName != "" {
+		req.query.Set("service_name", opts.ServiceName)
+	}
+	if !opts.From.IsZero() {
+		req.query.Set("from_date", opts.From.Format("2006-01-02"))
+	}
+	if !opts.To.IsZero() {
+		req.query.Set("to_date", opts.To.Format("2006-01-02"))
+	}
+
+	var res api.TotalPriceResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ImportJSON импортирует подписки. Если в режиме all_or_nothing есть невалидные
+// строки, возвращается и отчёт, и ошибка с кодом 422.
+func (c *Client) ImportJSON(ctx context.Context, subs []api.CreateSubscriptionRequest, opts ImportOptions, reqOpts ...RequestOption) (*api.ImportReport, error) {
+	req, err := jsonRequest(http.MethodPost, "/subscriptions/import", subs, withIdempotencyKey(reqOpts))
+	if err != nil {
+		return nil, err
+	}
+	req.query = opts.query()
+	return c.importRequest(ctx, req)
+}
+
+// ImportCSV импортирует подписки из CSV с заголовком.
+func (c *Client) ImportCSV(ctx context.Context, r io.Reader, opts ImportOptions, reqOpts ...RequestOption) (*api.ImportReport, error) {
+	return c.Import(ctx, r, "text/csv", opts, reqOpts...)
+}
+
+// Import отправляет файл импорта как есть: contentType — "text/csv" или
+// "application/json". Подходит для JSON с нестандартными ключами и маппингом
+// opts.Columns. Данные читаются целиком, чтобы запрос можно было повторить.
+func (c *Client) Import(ctx context.Context, r io.Reader, contentType string, opts ImportOptions, reqOpts ...RequestOption) (*api.ImportReport, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read import data: %w", err)
+	}
+
+	req := newRequest(http.MethodPost, "/subscriptions/import", withIdempotencyKey(reqOpts))
+	req.query = opts.query()
+	req.body = data
+	req.header.Set("Content-Type", contentType)
+	return c.importRequest(ctx, req)
+}
+
+func (c *Client) importRequest(ctx context.Context, req request) (*api.ImportReport, error) {
+	var res api.ImportReport
+	err := c.do(ctx, req, &res)
+	if err != nil && res.Mode == "" {
+		return nil, err
+	}
+	return &res, err
+}
+
+// Batch выполняет пакет операций. Для атомарного пакета с ошибками возвращается
+// и результат по операциям, и ошибка с кодом 422.
+func (c *Client) Batch(ctx context.Context, batch api.BatchRequest, opts ...RequestOption) (*api.BatchResponse, error) {
+	req, err := jsonRequest(http.MethodPost, "/subscriptions:batch", batch, withIdempotencyKey(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.BatchResponse
+	err = c.do(ctx, req, &res)
+	if err != nil && res.Results == nil {
+		return nil, err
+	}
+	return &res, err
+}
+
+// NewBatchOperation собирает операцию пакета; data — api.CreateSubscriptionRequest
+// для create, api.UpdateSubscriptionRequest для update и nil для delete.
+func NewBatchOperation(op string, id *int64, version *int, data any) (api.BatchOperation, error) {
+	res := api.BatchOperation{Op: op, ID: id, Version: version}
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return api.BatchOperation{}, fmt.Errorf("encode batch data: %w", err)
+		}
+		res.Data = raw
+	}
+	return res, nil
+}
+
+// GraphQL выполняет GraphQL-запрос и декодирует поле data в out. Ошибки GraphQL
+// возвращаются как *GraphQLErrors вместе с частично заполненным out.
+func (c *Client) GraphQL(ctx context.Context, query api.GraphQLRequest, out any) error {
+	req, err := jsonRequest(http.MethodPost, "/graphql", query, nil)
+	if err != nil {
+		return err
+	}
+
+	var res api.GraphQLResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return err
+	}
+	if out != nil && len(res.Data) > 0 {
+		if err := json.Unmarshal(res.Data, out); err != nil {
+			return fmt.Errorf("decode graphql data: %w", err)
+		}
+	}
+	if len(res.Errors) > 0 {
+		return &GraphQLErrors{Errors: res.Errors}
+	}
+	return nil
+}
+
+type GraphQLErrors struct {
+	Errors []api.GraphQLError
+}
+
+func (e *GraphQLErrors) Error() string {
+	if len(e.Errors) == 1 {
+		return "graphql: " + e.Errors[0].Message
+	}
+	return fmt.Sprintf("graphql: %s (and %d more errors)", e.Errors[0].Message, len(e.Errors)-1)
+}
+
+func subscriptionPath(id int64) string {
+	return "/subscriptions/" + strconv.FormatInt(id, 10)
+}
+
+// withIdempotencyKey добавляет случайный ключ идемпотентности первым, чтобы
+// явно переданный WithIdempotencyKey его переопределил.
+func withIdempotencyKey(opts []RequestOption) []RequestOption {
+	return append([]RequestOption{WithIdempotencyKey(uuid.NewString())}, opts...)
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+type TagListOptions struct {
+	// Query ищет подстроку в названии метки.
+	Query  string
+	Limit  int
+	Offset int
+}
+
+func (o TagListOptions) query() url.Values {
+	q := make(url.Values)
+	if o.Query != "" {
+		q.Set("q", o.Query)
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		q.Set("offset", strconv.Itoa(o.Offset))
+	}
+	return q
+}
+
+// ListTags возвращает метки с числом подписок по убыванию этого числа.
+func (c *Client) ListTags(ctx context.Context, opts TagListOptions) ([]api.TagResponse, error) {
+	req := newRequest(http.MethodGet, "/tags/", nil)
+	req.query = opts.query()
+
+	var res []api.TagResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) GetTag(ctx context.Context, id int64) (*api.TagResponse, error) {
+	var res api.TagResponse
+	if err := c.do(ctx, newRequest(http.MethodGet, tagPath(id), nil), &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// RenameTag переименовывает метку во всех подписках. Если название занято другой меткой,
+// сервер отвечает 409: такие метки объединяются через MergeTags.
+func (c *Client) RenameTag(ctx context.Context, id int64, name string) (*api.TagResponse, error) {
+	req, err := jsonRequest(http.MethodPut, tagPath(id), api.RenameTagRequest{Name: name}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.TagResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// MergeTags переносит подписки меток sourceIDs на метку targetID и удаляет исходные метки.
+func (c *Client) MergeTags(ctx context.Context, sourceIDs []int64, targetID int64) (*api.TagResponse, error) {
+	req, err := jsonRequest(http.MethodPost, "/tags/merge", api.MergeTagsRequest{SourceIDs: sourceIDs, TargetID: targetID}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.TagResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func tagPath(id int64) string {
+	return "/tags/" + strconv.FormatInt(id, 10)
+}
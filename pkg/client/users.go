@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/shenikar/subscription-service/pkg/api"
+)
+
+type UserListOptions struct {
+	// Query ищет подстроку в email и имени.
+	Query  string
+	Limit  int
+	Offset int
+}
+
+func (o UserListOptions) query() url.Values {
+	q := make(url.Values)
+	if o.Query != "" {
+		q.Set("q", o.Query)
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		q.Set("offset", strconv.Itoa(o.Offset))
+	}
+	return q
+}
+
+func (c *Client) CreateUser(ctx context.Context, user api.UserRequest) (*api.UserResponse, error) {
+	req, err := jsonRequest(http.MethodPost, "/users/", user, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.UserResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) GetUser(ctx context.Context, id uuid.UUID) (*api.UserResponse, error) {
+	var res api.UserResponse
+	if err := c.do(ctx, newRequest(http.MethodGet, userPath(id), nil), &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) ListUsers(ctx context.Context, opts UserListOptions) ([]api.UserResponse, error) {
+	req := newRequest(http.MethodGet, "/users/", nil)
+	req.query = opts.query()
+
+	var res []api.UserResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// UpdateUser полностью заменяет профиль пользователя.
+func (c *Client) UpdateUser(ctx context.Context, id uuid.UUID, user api.UserRequest) (*api.UserResponse, error) {
+	req, err := jsonRequest(http.MethodPut, userPath(id), user, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res api.UserResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// DeleteUser удаляет пользователя. Для пользователя с подписками возвращается ошибка с кодом 409.
+func (c *Client) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, newRequest(http.MethodDelete, userPath(id), nil), nil)
+}
+
+// UserSubscriptions возвращает подписки пользователя. UserID в opts не учитывается.
+func (c *Client) UserSubscriptions(ctx context.Context, id uuid.UUID, opts ListOptions) ([]api.SubscriptionResponse, error) {
+	opts.UserID = uuid.Nil
+	req := newRequest(http.MethodGet, userPath(id)+"/subscriptions", nil)
+	req.query = opts.query()
+
+	var res []api.SubscriptionResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// UserSummary возвращает сводку по пользователю с продлениями в ближайшие withinDays дней.
+// При withinDays == 0 используется горизонт сервера по умолчанию.
+func (c *Client) UserSummary(ctx context.Context, id uuid.UUID, withinDays int) (*api.UserSummaryResponse, error) {
+	req := newRequest(http.MethodGet, userPath(id)+"/summary", nil)
+	if withinDays > 0 {
+		req.query = url.Values{"within_days": {strconv.Itoa(withinDays)}}
+	}
+
+	var res api.UserSummaryResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func userPath(id uuid.UUID) string {
+	return "/users/" + id.String()
+}